@@ -213,6 +213,93 @@ func BenchmarkMemoryUsage(b *testing.B) {
 	}
 }
 
+// newTemplateBenchmark 创建模板渲染性能测试使用的模板引擎和数据：
+// 40个含变量的段落、逐行循环的订单明细与每行3个占位符的表格
+func newTemplateBenchmark(b *testing.B) (*document.TemplateEngine, *document.TemplateData) {
+	doc := document.New()
+	doc.AddParagraph("{{company}} 订单确认函").SetStyle(style.StyleHeading1)
+	for i := 0; i < 40; i++ {
+		doc.AddParagraph(fmt.Sprintf("第%d段：尊敬的{{customer.name}}，您的订单{{orderNo}}金额为{{amount | number:2}}。", i+1))
+	}
+	doc.AddParagraph("{{#each items}}")
+	doc.AddParagraph("{{name}} × {{qty}}：{{price | currency}}")
+	doc.AddParagraph("{{/each}}")
+
+	table := doc.AddTable(&document.TableConfig{Rows: 20, Cols: 3, Width: 8640})
+	for row := 0; row < 20; row++ {
+		table.SetCellText(row, 0, "{{customer.name}}")
+		table.SetCellText(row, 1, "{{orderNo}}")
+		table.SetCellText(row, 2, "{{amount}}")
+	}
+
+	engine := document.NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("order", doc); err != nil {
+		b.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := document.NewTemplateData()
+	data.SetVariable("company", "示例公司")
+	data.SetVariable("customer", map[string]interface{}{"name": "张三"})
+	data.SetVariable("orderNo", "SO-2024-0001")
+	data.SetVariable("amount", 12345.6)
+	items := make([]interface{}, 20)
+	for i := range items {
+		items[i] = map[string]interface{}{"name": fmt.Sprintf("商品%d", i+1), "qty": i + 1, "price": 9.9 * float64(i+1)}
+	}
+	data.SetList("items", items)
+	return engine, data
+}
+
+// BenchmarkTemplateRender 每次调用RenderTemplateToDocument渲染模板
+func BenchmarkTemplateRender(b *testing.B) {
+	engine, data := newTemplateBenchmark(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := engine.RenderTemplateToDocument("order", data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCompiledTemplateRender 使用预编译模板渲染
+func BenchmarkCompiledTemplateRender(b *testing.B) {
+	engine, data := newTemplateBenchmark(b)
+	compiled, err := engine.CompileTemplate("order")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := compiled.Render(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCompiledTemplateRenderParallel 多个goroutine并发使用同一个预编译模板渲染
+func BenchmarkCompiledTemplateRenderParallel(b *testing.B) {
+	engine, data := newTemplateBenchmark(b)
+	compiled, err := engine.CompileTemplate("order")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := compiled.Render(data); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
 // === 新增：固定迭代次数的测试函数，与其他语言保持一致 ===
 
 // TestFixedIterationsPerformance 固定迭代次数的性能测试，与JavaScript和Python保持一致
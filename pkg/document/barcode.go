@@ -0,0 +1,319 @@
+// Package document 提供条形码生成功能
+package document
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultBarcodeModuleWidth 默认的条形码最窄条宽度（像素）
+	DefaultBarcodeModuleWidth = 2
+	// DefaultBarcodeHeight 默认的条形码高度（像素）
+	DefaultBarcodeHeight = 80
+	// DefaultBarcodeQuietZone 默认的条形码静区宽度（模块数），EAN-13左侧至少为11
+	DefaultBarcodeQuietZone = 10
+)
+
+// BarcodeConfig 条形码配置
+type BarcodeConfig struct {
+	// 最窄条的宽度（像素），0表示使用默认值2
+	ModuleWidth int
+	// 条的高度（像素），0表示使用默认值80
+	Height int
+	// 左右静区宽度（模块数），0表示使用默认值，小于0表示不留静区
+	QuietZone int
+	// 前景色（十六进制，如"000000"），为空时为黑色
+	Foreground string
+	// 背景色（十六进制，如"FFFFFF"），为空时为白色
+	Background string
+}
+
+// BarcodeImage 生成的条形码或二维码图片
+type BarcodeImage struct {
+	Data   []byte // PNG图片数据
+	Width  int    // 图片宽度（像素）
+	Height int    // 图片高度（像素）
+}
+
+// code128Patterns Code128符号的条空宽度，依次为条、空交替的模块数
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128CodeA  = 101
+	code128StartA = 103
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// ean13Patterns EAN-13左侧奇、偶校验与右侧字符的模块图案
+var ean13Patterns = [3][10]string{
+	{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"},
+	{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"},
+	{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"},
+}
+
+// ean13Parity 首位数字决定的左侧6位奇偶校验方式（L为奇、G为偶）
+var ean13Parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+
+// GenerateCode128 生成Code128条形码PNG图片
+//
+// 支持ASCII字符（0-127），自动在A、B、C字符集间切换，连续数字使用C字符集压缩；
+// 图片不包含下方的可读文本，需要时可在图片下方添加段落。
+func GenerateCode128(content string, config *BarcodeConfig) (*BarcodeImage, error) {
+	symbols, err := encodeCode128(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []bool
+	for _, symbol := range symbols {
+		for i, width := range code128Patterns[symbol] {
+			for n := 0; n < int(width-'0'); n++ {
+				modules = append(modules, i%2 == 0)
+			}
+		}
+	}
+	return renderBarcode(modules, config, DefaultBarcodeQuietZone)
+}
+
+// encodeCode128 将内容编码为Code128符号值（含起始符、校验符和终止符）
+func encodeCode128(content string) ([]int, error) {
+	if content == "" {
+		return nil, NewValidationError("content", content, "barcode content cannot be empty")
+	}
+	for i := 0; i < len(content); i++ {
+		if content[i] > 127 {
+			return nil, NewValidationError("content", content, "Code128 only supports ASCII characters")
+		}
+	}
+
+	// digitsAt 返回从i开始的连续数字个数
+	digitsAt := func(i int) int {
+		n := 0
+		for i+n < len(content) && content[i+n] >= '0' && content[i+n] <= '9' {
+			n++
+		}
+		return n
+	}
+	// setFor 返回字符适用的A或B字符集：控制字符只在A中，小写字母只在B中
+	setFor := func(i int) int {
+		for ; i < len(content); i++ {
+			if content[i] < 32 {
+				return code128CodeA
+			}
+			if content[i] >= 96 {
+				return code128CodeB
+			}
+		}
+		return code128CodeB
+	}
+
+	var symbols []int
+	var set int
+	if n := digitsAt(0); n >= 4 || (n == len(content) && n%2 == 0) {
+		set = code128CodeC
+		symbols = append(symbols, code128StartC)
+	} else if setFor(0) == code128CodeA {
+		set = code128CodeA
+		symbols = append(symbols, code128StartA)
+	} else {
+		set = code128CodeB
+		symbols = append(symbols, code128StartB)
+	}
+
+	for i := 0; i < len(content); {
+		digits := digitsAt(i)
+		if set == code128CodeC {
+			if digits >= 2 {
+				value, _ := strconv.Atoi(content[i : i+2])
+				symbols = append(symbols, value)
+				i += 2
+				continue
+			}
+			set = setFor(i)
+			symbols = append(symbols, set)
+			continue
+		}
+
+		// 足够长的数字串切换到C字符集，奇数个数字时先在当前字符集中输出一位
+		if digits >= 6 || (digits >= 4 && i+digits == len(content)) {
+			if digits%2 == 1 {
+				symbols = append(symbols, int(content[i])-32)
+				i++
+			}
+			set = code128CodeC
+			symbols = append(symbols, code128CodeC)
+			continue
+		}
+
+		c := content[i]
+		if set == code128CodeA && c >= 96 || set == code128CodeB && c < 32 {
+			set = setFor(i)
+			symbols = append(symbols, set)
+		}
+		if c < 32 {
+			symbols = append(symbols, int(c)+64)
+		} else {
+			symbols = append(symbols, int(c)-32)
+		}
+		i++
+	}
+
+	checksum := symbols[0]
+	for i, symbol := range symbols[1:] {
+		checksum += symbol * (i + 1)
+	}
+	return append(symbols, checksum%103, code128Stop), nil
+}
+
+// GenerateEAN13 生成EAN-13条形码PNG图片
+//
+// code为12位数字时自动计算校验位，为13位时校验最后一位；
+// 图片不包含下方的可读文本，需要时可在图片下方添加段落。
+func GenerateEAN13(code string, config *BarcodeConfig) (*BarcodeImage, error) {
+	code, err := ean13Code(code)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := "101"
+	parity := ean13Parity[code[0]-'0']
+	for i := 1; i <= 6; i++ {
+		set := 0
+		if parity[i-1] == 'G' {
+			set = 1
+		}
+		pattern += ean13Patterns[set][code[i]-'0']
+	}
+	pattern += "01010"
+	for i := 7; i <= 12; i++ {
+		pattern += ean13Patterns[2][code[i]-'0']
+	}
+	pattern += "101"
+
+	modules := make([]bool, len(pattern))
+	for i := range pattern {
+		modules[i] = pattern[i] == '1'
+	}
+	return renderBarcode(modules, config, DefaultBarcodeQuietZone+1)
+}
+
+// ean13Code 校验EAN-13编码并补全校验位
+func ean13Code(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if (len(code) != 12 && len(code) != 13) || strings.Trim(code, "0123456789") != "" {
+		return "", NewValidationError("code", code, "EAN-13 code must be 12 or 13 digits")
+	}
+
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(code[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := byte('0' + (10-sum%10)%10)
+	if len(code) == 13 && code[12] != check {
+		return "", NewValidationError("code", code, "invalid EAN-13 check digit, expected "+string(check))
+	}
+	return code[:12] + string(check), nil
+}
+
+// renderBarcode 按模块序列绘制一维条形码
+func renderBarcode(modules []bool, config *BarcodeConfig, defaultQuietZone int) (*BarcodeImage, error) {
+	if config == nil {
+		config = &BarcodeConfig{}
+	}
+	moduleWidth := config.ModuleWidth
+	if moduleWidth <= 0 {
+		moduleWidth = DefaultBarcodeModuleWidth
+	}
+	height := config.Height
+	if height <= 0 {
+		height = DefaultBarcodeHeight
+	}
+	quietZone := config.QuietZone
+	if quietZone == 0 {
+		quietZone = defaultQuietZone
+	} else if quietZone < 0 {
+		quietZone = 0
+	}
+
+	img, err := newBarcodeCanvas((len(modules)+quietZone*2)*moduleWidth, height, config.Foreground, config.Background)
+	if err != nil {
+		return nil, err
+	}
+	for i, dark := range modules {
+		if dark {
+			fillBarcodeRect(img, (i+quietZone)*moduleWidth, 0, moduleWidth, height)
+		}
+	}
+	return encodeBarcodeImage(img)
+}
+
+// newBarcodeCanvas 创建双色画布，初始为背景色
+func newBarcodeCanvas(width, height int, foreground, background string) (*image.Paletted, error) {
+	fg, err := parseBarcodeColor(foreground, color.RGBA{A: 255})
+	if err != nil {
+		return nil, err
+	}
+	bg, err := parseBarcodeColor(background, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	if err != nil {
+		return nil, err
+	}
+	return image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{bg, fg}), nil
+}
+
+// fillBarcodeRect 以前景色填充矩形
+func fillBarcodeRect(img *image.Paletted, x, y, width, height int) {
+	for row := y; row < y+height; row++ {
+		offset := img.PixOffset(x, row)
+		for i := 0; i < width; i++ {
+			img.Pix[offset+i] = 1
+		}
+	}
+}
+
+// encodeBarcodeImage 将画布编码为PNG
+func encodeBarcodeImage(img *image.Paletted) (*BarcodeImage, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, WrapError("encode_barcode", err)
+	}
+	bounds := img.Bounds()
+	return &BarcodeImage{Data: buf.Bytes(), Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+// parseBarcodeColor 解析十六进制颜色，为空时返回默认颜色
+func parseBarcodeColor(value string, fallback color.RGBA) (color.RGBA, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseUint(value, 16, 32)
+	if err != nil || len(value) != 6 {
+		return fallback, NewValidationError("color", value, "color must be a 6-digit hex value")
+	}
+	return color.RGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 255}, nil
+}
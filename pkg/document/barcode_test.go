@@ -0,0 +1,213 @@
+package document
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestQRCodeEncoding(t *testing.T) {
+	// ISO/IEC 18004 常用示例：HELLO WORLD，版本1-M
+	version, data, err := qrEncodeData("HELLO WORLD", QRCodeLevelM)
+	if err != nil || version != 1 {
+		t.Fatalf("编码失败: version=%d err=%v", version, err)
+	}
+	expected := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("数据码字不正确: %v", data)
+	}
+	ecc := qrReedSolomonRemainder(data, qrReedSolomonDivisor(10))
+	if !bytes.Equal(ecc, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}) {
+		t.Errorf("纠错码字不正确: %v", ecc)
+	}
+
+	if info := qrFormatInfo(QRCodeLevelL, 0); info != 0x77C4 {
+		t.Errorf("格式信息不正确: %#x", info)
+	}
+	if info := qrVersionInfo(7); info != 0x07C94 {
+		t.Errorf("版本信息不正确: %#x", info)
+	}
+	for version, positions := range map[int][]int{
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	} {
+		if got := qrAlignmentPositions(version); !reflect.DeepEqual(got, positions) {
+			t.Errorf("版本%d校正图形位置不正确: %v", version, got)
+		}
+	}
+	if n := qrDataCodewords(40, QRCodeLevelL); n != 2956 {
+		t.Errorf("版本40-L数据码字数应为2956: %d", n)
+	}
+	if n := qrDataCodewords(1, QRCodeLevelH); n != 9 {
+		t.Errorf("版本1-H数据码字数应为9: %d", n)
+	}
+	if _, _, err := qrEncodeData(string(make([]byte, 2954)), QRCodeLevelL); err == nil {
+		t.Error("超出容量时应返回错误")
+	}
+	if version, _, _ := qrEncodeData(string(make([]byte, 2953)), QRCodeLevelL); version != 40 {
+		t.Errorf("2953字节应使用版本40: %d", version)
+	}
+
+	// 从矩阵读回格式信息与码字
+	content := "https://example.com/invoice/1001"
+	code, err := encodeQRCode(content, QRCodeLevelQ)
+	if err != nil {
+		t.Fatalf("生成二维码失败: %v", err)
+	}
+	format := 0
+	for i := 14; i >= 0; i-- {
+		x, y := code.size-1-i, 8
+		if i >= 8 {
+			x, y = 8, code.size-15+i
+		}
+		format <<= 1
+		if code.modules[y][x] {
+			format |= 1
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if qrFormatInfo(QRCodeLevelQ, m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("格式信息与纠错等级不符: %#x", format)
+	}
+
+	_, bits, _ := qrEncodeData(content, QRCodeLevelQ)
+	codewords := newQRCode(code.version, QRCodeLevelQ).addECCAndInterleave(bits)
+	code.applyMask(mask)
+	var read qrBitBuffer
+	for right := code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < code.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = code.size - 1 - vert
+				}
+				if !code.function[y][x] {
+					read = append(read, code.modules[y][x])
+				}
+			}
+		}
+	}
+	if got := read.bytes()[:len(codewords)]; !bytes.Equal(got, codewords) {
+		t.Error("矩阵中的码字与编码结果不一致")
+	}
+}
+
+func TestGenerateQRCode(t *testing.T) {
+	qr, err := GenerateQRCode("https://example.com/invoice/1001", &QRCodeConfig{Size: 200, Level: QRCodeLevelH, QuietZone: 2})
+	if err != nil {
+		t.Fatalf("生成二维码失败: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(qr.Data))
+	if err != nil {
+		t.Fatalf("PNG解码失败: %v", err)
+	}
+	// 版本4为33个模块，加静区共37个模块，每个模块5像素
+	if qr.Width != 185 || qr.Height != 185 || img.Bounds().Dx() != 185 {
+		t.Fatalf("图片尺寸不正确: %dx%d", qr.Width, qr.Height)
+	}
+	if r, _, _, _ := img.At(5, 5).RGBA(); r == 0 {
+		t.Error("静区应为背景色")
+	}
+	if r, _, _, _ := img.At(12, 12).RGBA(); r != 0 {
+		t.Error("定位图形左上角应为前景色")
+	}
+
+	if _, err := GenerateQRCode("x", &QRCodeConfig{Foreground: "red"}); err == nil {
+		t.Error("无效颜色应返回错误")
+	}
+	if _, err := GenerateQRCode("x", &QRCodeConfig{Level: 9}); err == nil {
+		t.Error("无效纠错等级应返回错误")
+	}
+}
+
+func TestGenerateBarcodes(t *testing.T) {
+	for i, pattern := range code128Patterns {
+		sum := 0
+		for _, width := range pattern {
+			sum += int(width - '0')
+		}
+		if (i < code128Stop && sum != 11) || (i == code128Stop && sum != 13) {
+			t.Errorf("Code128符号%d宽度不正确: %s", i, pattern)
+		}
+	}
+
+	cases := map[string][]int{
+		"Wikipedia":  {104, 55, 73, 75, 73, 80, 69, 68, 73, 65, 88, 106},
+		"12345678":   {105, 12, 34, 56, 78, 47, 106},
+		"SO-1234567": {104, 51, 47, 13, 17, 99, 23, 45, 67, 89, 106},
+		"a\tb":       {104, 65, 101, 73, 100, 66, 84, 106},
+	}
+	for content, expected := range cases {
+		symbols, err := encodeCode128(content)
+		if err != nil {
+			t.Fatalf("编码%q失败: %v", content, err)
+		}
+		if !reflect.DeepEqual(symbols, expected) {
+			t.Errorf("编码%q不正确: %v", content, symbols)
+		}
+	}
+	if _, err := encodeCode128("订单"); err == nil {
+		t.Error("非ASCII内容应返回错误")
+	}
+
+	barcode, err := GenerateCode128("SO-1234567", &BarcodeConfig{ModuleWidth: 1, Height: 40})
+	if err != nil {
+		t.Fatalf("生成Code128失败: %v", err)
+	}
+	// 10个符号各11模块、终止符13模块，加左右各10模块静区
+	if barcode.Width != 10*11+13+20 || barcode.Height != 40 {
+		t.Errorf("Code128图片尺寸不正确: %dx%d", barcode.Width, barcode.Height)
+	}
+
+	if code, err := ean13Code("400638133393"); err != nil || code != "4006381333931" {
+		t.Errorf("EAN-13校验位计算不正确: %s %v", code, err)
+	}
+	if _, err := ean13Code("4006381333932"); err == nil {
+		t.Error("错误的校验位应返回错误")
+	}
+	ean, err := GenerateEAN13("4006381333931", &BarcodeConfig{ModuleWidth: 1, QuietZone: -1})
+	if err != nil || ean.Width != 95 {
+		t.Fatalf("EAN-13应为95个模块: %+v %v", ean, err)
+	}
+
+	// 用于模板图片占位符和直接插入文档
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplate("label", "{{#image qrcode}}\n{{#image barcode}}"); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	qr, _ := GenerateQRCode("https://example.com", nil)
+	data := NewTemplateData()
+	data.SetImageFromData("qrcode", qr.Data, &ImageConfig{Size: &ImageSize{Width: 30, Height: 30}})
+	data.SetImageFromData("barcode", barcode.Data, nil)
+	doc, err := engine.RenderTemplateToDocument("label", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	if _, err := doc.AddImageFromData(ean.Data, "ean13.png", ImageFormatPNG, ean.Width, ean.Height, nil); err != nil {
+		t.Fatalf("插入条形码失败: %v", err)
+	}
+	drawings := 0
+	for _, element := range doc.Body.Elements {
+		if para, ok := element.(*Paragraph); ok {
+			for _, run := range para.Runs {
+				if run.Drawing != nil {
+					drawings++
+				}
+			}
+		}
+	}
+	if drawings != 3 {
+		t.Errorf("文档应包含3张图片: %d", drawings)
+	}
+}
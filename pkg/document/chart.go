@@ -0,0 +1,998 @@
+// Package document 提供Word文档原生图表功能
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// chartNamespace DrawingML图表命名空间
+	chartNamespace = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+	// officeRelationshipsNamespace 文档关系命名空间
+	officeRelationshipsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	// chartRelationshipType 图表关系类型
+	chartRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+	// packageRelationshipType 嵌入式工作簿关系类型
+	packageRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/package"
+	// chartContentType 图表部件内容类型
+	chartContentType = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
+	// workbookContentType 嵌入式工作簿内容类型
+	workbookContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	// 图表默认尺寸（毫米）
+	defaultChartWidth  = 150.0
+	defaultChartHeight = 90.0
+
+	// 坐标轴ID
+	chartCategoryAxisID = "500000001"
+	chartValueAxisID    = "500000002"
+)
+
+// defaultChartColors 默认系列配色（Office主题色）
+var defaultChartColors = []string{"4472C4", "ED7D31", "A5A5A5", "FFC000", "5B9BD5", "70AD47"}
+
+// ChartType 图表类型
+type ChartType string
+
+const (
+	// 图表类型选项
+	ChartTypeColumn  ChartType = "column"  // 柱形图
+	ChartTypeBar     ChartType = "bar"     // 条形图
+	ChartTypeLine    ChartType = "line"    // 折线图
+	ChartTypePie     ChartType = "pie"     // 饼图
+	ChartTypeScatter ChartType = "scatter" // 散点图
+	ChartTypeArea    ChartType = "area"    // 面积图
+)
+
+// ChartGrouping 系列分组方式（柱形图、条形图、折线图和面积图）
+type ChartGrouping string
+
+const (
+	// 分组方式选项
+	ChartGroupingStandard       ChartGrouping = "standard"       // 标准（柱形图和条形图为簇状）
+	ChartGroupingStacked        ChartGrouping = "stacked"        // 堆积
+	ChartGroupingPercentStacked ChartGrouping = "percentStacked" // 百分比堆积
+)
+
+// ChartLegendPosition 图例位置
+type ChartLegendPosition string
+
+const (
+	// 图例位置选项
+	ChartLegendRight  ChartLegendPosition = "r"    // 右侧
+	ChartLegendLeft   ChartLegendPosition = "l"    // 左侧
+	ChartLegendTop    ChartLegendPosition = "t"    // 顶部
+	ChartLegendBottom ChartLegendPosition = "b"    // 底部
+	ChartLegendNone   ChartLegendPosition = "none" // 不显示图例
+)
+
+// ChartLabelPosition 数据标签位置
+type ChartLabelPosition string
+
+const (
+	// 数据标签位置选项（不同图表类型支持的位置不同）
+	ChartLabelOutsideEnd ChartLabelPosition = "outEnd"  // 数据点外侧（柱形图、条形图、饼图）
+	ChartLabelInsideEnd  ChartLabelPosition = "inEnd"   // 数据点内侧末端
+	ChartLabelCenter     ChartLabelPosition = "ctr"     // 居中
+	ChartLabelInsideBase ChartLabelPosition = "inBase"  // 数据点内侧底部
+	ChartLabelAbove      ChartLabelPosition = "t"       // 上方（折线图、散点图）
+	ChartLabelBelow      ChartLabelPosition = "b"       // 下方（折线图、散点图）
+	ChartLabelLeft       ChartLabelPosition = "l"       // 左侧（折线图、散点图）
+	ChartLabelRight      ChartLabelPosition = "r"       // 右侧（折线图、散点图）
+	ChartLabelBestFit    ChartLabelPosition = "bestFit" // 最佳位置（饼图）
+)
+
+// ChartSeries 图表数据系列
+type ChartSeries struct {
+	// 系列名称
+	Name string
+	// 系列数值，与 ChartConfig.Categories 一一对应；散点图中为Y值
+	Values []float64
+	// 散点图的X值
+	XValues []float64
+	// 系列颜色（十六进制），为空时使用 ChartConfig.Colors 或默认配色
+	Color string
+	// 系列数据标签，为空时使用 ChartConfig.DataLabels
+	DataLabels *ChartDataLabels
+}
+
+// ChartDataLabels 数据标签配置
+type ChartDataLabels struct {
+	ShowValue        bool               // 显示数值
+	ShowCategoryName bool               // 显示类别名称
+	ShowSeriesName   bool               // 显示系列名称
+	ShowPercent      bool               // 显示百分比（饼图）
+	Position         ChartLabelPosition // 标签位置
+	NumberFormat     string             // 数字格式，如 "0.0%"、"#,##0"
+}
+
+// ChartAxis 坐标轴配置
+type ChartAxis struct {
+	Title        string   // 坐标轴标题
+	Min          *float64 // 最小值（数值轴）
+	Max          *float64 // 最大值（数值轴）
+	MajorUnit    float64  // 主要刻度单位（数值轴），为0时自动
+	NumberFormat string   // 刻度标签数字格式
+	Gridlines    bool     // 显示主要网格线
+	Hidden       bool     // 隐藏坐标轴
+}
+
+// ChartAxes 图表坐标轴配置
+type ChartAxes struct {
+	X *ChartAxis // 类别轴（散点图为X数值轴）
+	Y *ChartAxis // 数值轴
+}
+
+// ChartLegend 图例配置
+type ChartLegend struct {
+	Position ChartLegendPosition // 图例位置，默认底部
+	Overlay  bool                // 图例与绘图区重叠
+}
+
+// ChartConfig 图表配置
+type ChartConfig struct {
+	// 图表类型，默认柱形图
+	Type ChartType
+	// 图表标题，为空时不显示标题
+	Title string
+	// 类别标签（散点图不使用）
+	Categories []string
+	// 数据系列
+	Series []ChartSeries
+	// 坐标轴配置，为空时数值轴显示网格线（饼图无坐标轴）
+	Axes *ChartAxes
+	// 图例配置，为空时在底部显示图例
+	Legend *ChartLegend
+	// 所有系列的数据标签，为空时不显示
+	DataLabels *ChartDataLabels
+	// 系列配色（十六进制），饼图中按数据点依次使用
+	Colors []string
+	// 分组方式（堆积、百分比堆积）
+	Grouping ChartGrouping
+	// 折线图和散点图使用平滑线
+	Smooth bool
+
+	// 宽度（毫米），默认150
+	Width float64
+	// 高度（毫米），默认90
+	Height float64
+	// 位置，默认嵌入式；浮动图表与图片使用相同的定位方式
+	Position ImagePosition
+	// 嵌入式图表的段落对齐方式
+	Alignment AlignmentType
+	// 浮动图表的文字环绕方式
+	WrapText ImageWrapText
+	// 浮动图表相对页边距的水平偏移（毫米）
+	OffsetX float64
+	// 浮动图表相对页边距的垂直偏移（毫米）
+	OffsetY float64
+	// 图表名称
+	Name string
+	// 替代文字
+	AltText string
+}
+
+// Chart 文档中的图表
+type Chart struct {
+	ID         string       // 图表ID（与图片共用docPr编号）
+	RelationID string       // 文档关系ID
+	PartName   string       // 图表部件名称，如 word/charts/chart1.xml
+	Config     *ChartConfig // 图表配置（打开的文档中为解析出的类型、标题和数据）
+}
+
+// ChartReference 绘图中对图表部件的引用
+type ChartReference struct {
+	XMLName xml.Name `xml:"c:chart"`
+	XmlnsC  string   `xml:"xmlns:c,attr"`
+	XmlnsR  string   `xml:"xmlns:r,attr"`
+	ID      string   `xml:"r:id,attr"`
+}
+
+// ChartSpace 图表部件根元素
+type ChartSpace struct {
+	XMLName        xml.Name           `xml:"c:chartSpace"`
+	XmlnsC         string             `xml:"xmlns:c,attr"`
+	XmlnsA         string             `xml:"xmlns:a,attr"`
+	XmlnsR         string             `xml:"xmlns:r,attr"`
+	Date1904       *ChartValue        `xml:"c:date1904"`
+	RoundedCorners *ChartValue        `xml:"c:roundedCorners"`
+	Chart          *ChartElement      `xml:"c:chart"`
+	ExternalData   *ChartExternalData `xml:"c:externalData,omitempty"`
+}
+
+// ChartValue 只包含val属性的图表元素
+type ChartValue struct {
+	Val string `xml:"val,attr"`
+}
+
+// ChartElement 图表内容
+type ChartElement struct {
+	Title            *ChartTitle         `xml:"c:title,omitempty"`
+	AutoTitleDeleted *ChartValue         `xml:"c:autoTitleDeleted"`
+	PlotArea         *PlotArea           `xml:"c:plotArea"`
+	Legend           *ChartLegendElement `xml:"c:legend,omitempty"`
+	PlotVisOnly      *ChartValue         `xml:"c:plotVisOnly"`
+	DispBlanksAs     *ChartValue         `xml:"c:dispBlanksAs"`
+}
+
+// ChartTitle 图表或坐标轴标题
+type ChartTitle struct {
+	Tx      *ChartTitleText `xml:"c:tx"`
+	Overlay *ChartValue     `xml:"c:overlay"`
+}
+
+// ChartTitleText 标题文本
+type ChartTitleText struct {
+	Rich *ChartRichText `xml:"c:rich"`
+}
+
+// ChartRichText 富文本标题
+type ChartRichText struct {
+	BodyPr   *struct{}           `xml:"a:bodyPr"`
+	LstStyle *struct{}           `xml:"a:lstStyle"`
+	P        *ChartTextParagraph `xml:"a:p"`
+}
+
+// ChartTextParagraph 标题段落
+type ChartTextParagraph struct {
+	R *ChartTextRun `xml:"a:r"`
+}
+
+// ChartTextRun 标题文本运行
+type ChartTextRun struct {
+	T string `xml:"a:t"`
+}
+
+// PlotArea 绘图区
+type PlotArea struct {
+	Layout       *struct{}      `xml:"c:layout"`
+	BarChart     *ChartGroup    `xml:"c:barChart,omitempty"`
+	LineChart    *ChartGroup    `xml:"c:lineChart,omitempty"`
+	PieChart     *ChartGroup    `xml:"c:pieChart,omitempty"`
+	ScatterChart *ChartGroup    `xml:"c:scatterChart,omitempty"`
+	AreaChart    *ChartGroup    `xml:"c:areaChart,omitempty"`
+	Axes         []ChartAxisXML `xml:",any"`
+}
+
+// ChartGroup 图表类型分组（c:barChart、c:lineChart等），字段顺序与架构一致
+type ChartGroup struct {
+	BarDir        *ChartValue          `xml:"c:barDir,omitempty"`
+	ScatterStyle  *ChartValue          `xml:"c:scatterStyle,omitempty"`
+	Grouping      *ChartValue          `xml:"c:grouping,omitempty"`
+	VaryColors    *ChartValue          `xml:"c:varyColors"`
+	Series        []ChartSeriesElement `xml:"c:ser"`
+	DLbls         *ChartDataLabelsXML  `xml:"c:dLbls,omitempty"`
+	GapWidth      *ChartValue          `xml:"c:gapWidth,omitempty"`
+	Overlap       *ChartValue          `xml:"c:overlap,omitempty"`
+	Marker        *ChartValue          `xml:"c:marker,omitempty"`
+	FirstSliceAng *ChartValue          `xml:"c:firstSliceAng,omitempty"`
+	AxID          []ChartValue         `xml:"c:axId"`
+}
+
+// ChartSeriesElement 数据系列元素
+type ChartSeriesElement struct {
+	Idx              *ChartValue         `xml:"c:idx"`
+	Order            *ChartValue         `xml:"c:order"`
+	Tx               *ChartSeriesText    `xml:"c:tx,omitempty"`
+	SpPr             *ChartShapeProps    `xml:"c:spPr,omitempty"`
+	InvertIfNegative *ChartValue         `xml:"c:invertIfNegative,omitempty"`
+	Marker           *ChartMarker        `xml:"c:marker,omitempty"`
+	DPt              []ChartDataPoint    `xml:"c:dPt"`
+	DLbls            *ChartDataLabelsXML `xml:"c:dLbls,omitempty"`
+	Cat              *ChartAxisData      `xml:"c:cat,omitempty"`
+	XVal             *ChartAxisData      `xml:"c:xVal,omitempty"`
+	Val              *ChartAxisData      `xml:"c:val,omitempty"`
+	YVal             *ChartAxisData      `xml:"c:yVal,omitempty"`
+	Smooth           *ChartValue         `xml:"c:smooth,omitempty"`
+}
+
+// ChartSeriesText 系列名称
+type ChartSeriesText struct {
+	XMLName xml.Name        `xml:"c:tx"`
+	StrRef  *ChartStringRef `xml:"c:strRef"`
+}
+
+// ChartAxisData 类别或数值数据（c:cat、c:val、c:xVal、c:yVal）
+type ChartAxisData struct {
+	XMLName xml.Name
+	StrRef  *ChartStringRef  `xml:"c:strRef,omitempty"`
+	NumRef  *ChartNumericRef `xml:"c:numRef,omitempty"`
+}
+
+// ChartStringRef 字符串数据引用
+type ChartStringRef struct {
+	F        string          `xml:"c:f"`
+	StrCache *ChartDataCache `xml:"c:strCache"`
+}
+
+// ChartNumericRef 数值数据引用
+type ChartNumericRef struct {
+	F        string          `xml:"c:f"`
+	NumCache *ChartDataCache `xml:"c:numCache"`
+}
+
+// ChartDataCache 数据缓存
+type ChartDataCache struct {
+	FormatCode string           `xml:"c:formatCode,omitempty"`
+	PtCount    *ChartValue      `xml:"c:ptCount"`
+	Pt         []ChartDataValue `xml:"c:pt"`
+}
+
+// ChartDataValue 缓存的数据点
+type ChartDataValue struct {
+	Idx int    `xml:"idx,attr"`
+	V   string `xml:"c:v"`
+}
+
+// ChartShapeProps 图表元素形状属性
+type ChartShapeProps struct {
+	SolidFill *SolidFill `xml:"a:solidFill,omitempty"`
+	Ln        *Ln        `xml:"a:ln,omitempty"`
+}
+
+// ChartMarker 数据标记
+type ChartMarker struct {
+	Symbol *ChartValue      `xml:"c:symbol"`
+	Size   *ChartValue      `xml:"c:size,omitempty"`
+	SpPr   *ChartShapeProps `xml:"c:spPr,omitempty"`
+}
+
+// ChartDataPoint 单个数据点格式（饼图扇区颜色）
+type ChartDataPoint struct {
+	Idx              *ChartValue      `xml:"c:idx"`
+	InvertIfNegative *ChartValue      `xml:"c:invertIfNegative,omitempty"`
+	Bubble3D         *ChartValue      `xml:"c:bubble3D"`
+	SpPr             *ChartShapeProps `xml:"c:spPr"`
+}
+
+// ChartDataLabelsXML 数据标签元素
+type ChartDataLabelsXML struct {
+	NumFmt         *ChartNumFmt `xml:"c:numFmt,omitempty"`
+	DLblPos        *ChartValue  `xml:"c:dLblPos,omitempty"`
+	ShowLegendKey  *ChartValue  `xml:"c:showLegendKey"`
+	ShowVal        *ChartValue  `xml:"c:showVal"`
+	ShowCatName    *ChartValue  `xml:"c:showCatName"`
+	ShowSerName    *ChartValue  `xml:"c:showSerName"`
+	ShowPercent    *ChartValue  `xml:"c:showPercent"`
+	ShowBubbleSize *ChartValue  `xml:"c:showBubbleSize"`
+}
+
+// ChartNumFmt 数字格式
+type ChartNumFmt struct {
+	FormatCode   string `xml:"formatCode,attr"`
+	SourceLinked string `xml:"sourceLinked,attr"`
+}
+
+// ChartAxisXML 坐标轴元素（c:catAx、c:valAx），字段顺序与架构一致
+type ChartAxisXML struct {
+	XMLName        xml.Name
+	AxID           *ChartValue   `xml:"c:axId"`
+	Scaling        *ChartScaling `xml:"c:scaling"`
+	Delete         *ChartValue   `xml:"c:delete"`
+	AxPos          *ChartValue   `xml:"c:axPos"`
+	MajorGridlines *struct{}     `xml:"c:majorGridlines,omitempty"`
+	Title          *ChartTitle   `xml:"c:title,omitempty"`
+	NumFmt         *ChartNumFmt  `xml:"c:numFmt,omitempty"`
+	MajorTickMark  *ChartValue   `xml:"c:majorTickMark"`
+	MinorTickMark  *ChartValue   `xml:"c:minorTickMark"`
+	TickLblPos     *ChartValue   `xml:"c:tickLblPos"`
+	CrossAx        *ChartValue   `xml:"c:crossAx"`
+	Crosses        *ChartValue   `xml:"c:crosses"`
+	Auto           *ChartValue   `xml:"c:auto,omitempty"`
+	LblAlgn        *ChartValue   `xml:"c:lblAlgn,omitempty"`
+	LblOffset      *ChartValue   `xml:"c:lblOffset,omitempty"`
+	CrossBetween   *ChartValue   `xml:"c:crossBetween,omitempty"`
+	MajorUnit      *ChartValue   `xml:"c:majorUnit,omitempty"`
+}
+
+// ChartScaling 坐标轴刻度范围
+type ChartScaling struct {
+	Orientation *ChartValue `xml:"c:orientation"`
+	Max         *ChartValue `xml:"c:max,omitempty"`
+	Min         *ChartValue `xml:"c:min,omitempty"`
+}
+
+// ChartLegendElement 图例元素
+type ChartLegendElement struct {
+	LegendPos *ChartValue `xml:"c:legendPos"`
+	Overlay   *ChartValue `xml:"c:overlay"`
+}
+
+// ChartExternalData 图表数据所在的嵌入式工作簿
+type ChartExternalData struct {
+	ID         string      `xml:"r:id,attr"`
+	AutoUpdate *ChartValue `xml:"c:autoUpdate"`
+}
+
+// AddChart 向文档添加一个原生图表。
+//
+// 图表数据保存在 word/charts/chartN.xml 的缓存中，同时写入一个嵌入式工作簿，
+// 用户可以在Word中通过"编辑数据"修改。图表默认以嵌入方式插入新段落，
+// 将 Position 设为 ImagePositionFloatLeft/FloatRight 或指定偏移可改为浮动图表。
+//
+// 示例:
+//
+//	chart, err := doc.AddChart(&document.ChartConfig{
+//		Type:       document.ChartTypeColumn,
+//		Title:      "季度营收",
+//		Categories: []string{"Q1", "Q2", "Q3", "Q4"},
+//		Series: []document.ChartSeries{
+//			{Name: "2023", Values: []float64{120, 135, 150, 170}},
+//			{Name: "2024", Values: []float64{140, 160, 175, 210}},
+//		},
+//		DataLabels: &document.ChartDataLabels{ShowValue: true},
+//	})
+func (d *Document) AddChart(config *ChartConfig) (*Chart, error) {
+	if config == nil {
+		return nil, fmt.Errorf("图表配置不能为空")
+	}
+
+	cfg := *config
+	if cfg.Type == "" {
+		cfg.Type = ChartTypeColumn
+	}
+	if cfg.Width <= 0 {
+		cfg.Width = defaultChartWidth
+	}
+	if cfg.Height <= 0 {
+		cfg.Height = defaultChartHeight
+	}
+	if err := validateChartData(cfg.Type, cfg.Categories, cfg.Series); err != nil {
+		return nil, err
+	}
+
+	chartXML, err := createChartSpaceXML(&cfg)
+	if err != nil {
+		return nil, WrapError("create_chart", err)
+	}
+	workbook, err := createChartWorkbook(cfg.Type, cfg.Categories, cfg.Series)
+	if err != nil {
+		return nil, WrapError("create_chart_workbook", err)
+	}
+
+	// 图表部件和嵌入式工作簿使用相同的编号
+	number := 1
+	for {
+		if _, exists := d.parts[fmt.Sprintf("word/charts/chart%d.xml", number)]; !exists {
+			break
+		}
+		number++
+	}
+	partName := fmt.Sprintf("word/charts/chart%d.xml", number)
+	workbookName := d.uniqueMediaTarget(fmt.Sprintf("embeddings/Microsoft_Excel_Worksheet%d", number), "xlsx")
+
+	d.parts[partName] = chartXML
+	d.parts[relationshipPartName(workbookName)] = workbook
+	chartRels := &Relationships{
+		Xmlns: "http://schemas.openxmlformats.org/package/2006/relationships",
+		Relationships: []Relationship{
+			{ID: "rId1", Type: packageRelationshipType, Target: "../" + workbookName},
+		},
+	}
+	relsXML, err := xml.Marshal(chartRels)
+	if err != nil {
+		return nil, WrapError("marshal_chart_relationships", err)
+	}
+	d.parts[chartRelationshipsPartName(partName)] = append([]byte(xml.Header), relsXML...)
+
+	if d.contentTypes == nil {
+		d.contentTypes = &ContentTypes{Xmlns: "http://schemas.openxmlformats.org/package/2006/content-types"}
+	}
+	d.addContentType(partName, chartContentType)
+	d.addDefaultContentType("xlsx", workbookContentType)
+
+	relationID := d.nextDocumentRelationshipID()
+	d.documentRelationships.Relationships = append(d.documentRelationships.Relationships, Relationship{
+		ID:     relationID,
+		Type:   chartRelationshipType,
+		Target: strings.TrimPrefix(partName, "word/"),
+	})
+
+	chartID := strconv.Itoa(d.nextImageID)
+	d.nextImageID++
+
+	name := cfg.Name
+	if name == "" {
+		name = fmt.Sprintf("图表 %s", chartID)
+	}
+	graphic := &DrawingGraphic{
+		Xmlns: drawingMLNamespace,
+		GraphicData: &GraphicData{
+			Uri: chartNamespace,
+			Chart: &ChartReference{
+				XmlnsC: chartNamespace,
+				XmlnsR: officeRelationshipsNamespace,
+				ID:     relationID,
+			},
+		},
+	}
+	inline := cfg.Position != ImagePositionFloatLeft && cfg.Position != ImagePositionFloatRight &&
+		cfg.OffsetX == 0 && cfg.OffsetY == 0
+	drawing := d.createFrameDrawing(&DrawingDocPr{ID: chartID, Name: name, Descr: cfg.AltText},
+		mmToEMU(cfg.Width), mmToEMU(cfg.Height), graphic, inline, &ImageConfig{
+			Position: cfg.Position,
+			OffsetX:  cfg.OffsetX,
+			OffsetY:  cfg.OffsetY,
+			WrapText: cfg.WrapText,
+		}, false)
+	if !inline && cfg.WrapText == "" {
+		// 浮动图表默认四周环绕
+		d.setFloatingImageWrap(drawing.Anchor, &ImageConfig{Position: cfg.Position, WrapText: ImageWrapSquare})
+	}
+
+	paragraph := &Paragraph{Runs: []Run{{Drawing: drawing}}}
+	if inline && cfg.Alignment != "" {
+		paragraph.Properties = &ParagraphProperties{
+			Justification: &Justification{Val: string(cfg.Alignment)},
+		}
+	}
+	d.Body.AddElement(paragraph)
+
+	Infof("添加图表成功: %s (%s)", partName, cfg.Type)
+	return &Chart{
+		ID:         chartID,
+		RelationID: relationID,
+		PartName:   partName,
+		Config:     &cfg,
+	}, nil
+}
+
+// ListCharts 列出文档主体（包括表格单元格）中的所有图表，并解析图表类型、标题和缓存数据
+func (d *Document) ListCharts() []*Chart {
+	var charts []*Chart
+
+	d.forEachDrawingRun(func(para *Paragraph, run *Run) bool {
+		ref := drawingChart(run.Drawing)
+		if ref == nil {
+			return true
+		}
+
+		chart := &Chart{RelationID: ref.ID, Config: &ChartConfig{}}
+		if rel := d.findDocumentRelationship(ref.ID); rel != nil {
+			chart.PartName = relationshipPartName(rel.Target)
+			if data, ok := d.parts[chart.PartName]; ok {
+				if parsed, err := parseChartPart(data); err == nil {
+					chart.Config = parsed
+				} else {
+					Debugf("解析图表部件失败: %s: %v", chart.PartName, err)
+				}
+			}
+		}
+
+		if docPr := drawingDocPr(run.Drawing); docPr != nil {
+			chart.ID = docPr.ID
+			chart.Config.Name = docPr.Name
+			chart.Config.AltText = docPr.Descr
+		}
+		cx, cy := drawingExtentEMU(run.Drawing)
+		chart.Config.Width = float64(cx) / 36000
+		chart.Config.Height = float64(cy) / 36000
+		if anchor := run.Drawing.Anchor; anchor != nil {
+			chart.Config.Position = ImagePositionFloatLeft
+			if h := anchor.PositionH; h != nil && h.Align != nil && h.Align.Value == "right" {
+				chart.Config.Position = ImagePositionFloatRight
+			}
+		}
+
+		charts = append(charts, chart)
+		return true
+	})
+
+	return charts
+}
+
+// drawingChart 获取绘图元素中的图表引用，非图表绘图返回nil
+func drawingChart(drawing *DrawingElement) *ChartReference {
+	if drawing == nil {
+		return nil
+	}
+	var graphic *DrawingGraphic
+	if drawing.Inline != nil {
+		graphic = drawing.Inline.Graphic
+	} else if drawing.Anchor != nil {
+		graphic = drawing.Anchor.Graphic
+	}
+	if graphic == nil || graphic.GraphicData == nil {
+		return nil
+	}
+	return graphic.GraphicData.Chart
+}
+
+// chartRelationshipsPartName 返回图表部件的关系文件名称
+func chartRelationshipsPartName(partName string) string {
+	dir, file := partName[:strings.LastIndex(partName, "/")], partName[strings.LastIndex(partName, "/")+1:]
+	return dir + "/_rels/" + file + ".rels"
+}
+
+// addDefaultContentType 按扩展名添加默认内容类型
+func (d *Document) addDefaultContentType(extension, contentType string) {
+	for _, def := range d.contentTypes.Defaults {
+		if def.Extension == extension {
+			return
+		}
+	}
+	d.contentTypes.Defaults = append(d.contentTypes.Defaults, Default{
+		Extension:   extension,
+		ContentType: contentType,
+	})
+}
+
+// validateChartData 校验图表类别与系列数据
+func validateChartData(chartType ChartType, categories []string, series []ChartSeries) error {
+	switch chartType {
+	case ChartTypeColumn, ChartTypeBar, ChartTypeLine, ChartTypePie, ChartTypeScatter, ChartTypeArea:
+	default:
+		return NewValidationError("chart_type", string(chartType), "不支持的图表类型")
+	}
+	if len(series) == 0 {
+		return NewValidationError("series", "0", "图表至少需要一个数据系列")
+	}
+
+	for i, s := range series {
+		if len(s.Values) == 0 {
+			return NewValidationError("series", fmt.Sprintf("%d", i+1), "数据系列不能为空")
+		}
+		if chartType == ChartTypeScatter {
+			if len(s.XValues) != len(s.Values) {
+				return NewValidationError("series", fmt.Sprintf("%d", i+1), "散点图系列的X值与Y值数量必须相同")
+			}
+			continue
+		}
+		if len(s.Values) != len(categories) {
+			return NewValidationError("series", fmt.Sprintf("%d", i+1),
+				fmt.Sprintf("系列数值数量(%d)与类别数量(%d)不一致", len(s.Values), len(categories)))
+		}
+	}
+	return nil
+}
+
+// createChartSpaceXML 根据配置生成图表部件XML
+func createChartSpaceXML(config *ChartConfig) ([]byte, error) {
+	group := &ChartGroup{VaryColors: &ChartValue{Val: "0"}}
+	plotArea := &PlotArea{Layout: &struct{}{}}
+
+	grouping := config.Grouping
+	if grouping == "" {
+		grouping = ChartGroupingStandard
+	}
+
+	switch config.Type {
+	case ChartTypeColumn, ChartTypeBar:
+		barDir := "col"
+		if config.Type == ChartTypeBar {
+			barDir = "bar"
+		}
+		group.BarDir = &ChartValue{Val: barDir}
+		if grouping == ChartGroupingStandard {
+			group.Grouping = &ChartValue{Val: "clustered"}
+			group.GapWidth = &ChartValue{Val: "150"}
+		} else {
+			group.Grouping = &ChartValue{Val: string(grouping)}
+			group.GapWidth = &ChartValue{Val: "150"}
+			group.Overlap = &ChartValue{Val: "100"}
+		}
+		plotArea.BarChart = group
+	case ChartTypeLine:
+		group.Grouping = &ChartValue{Val: string(grouping)}
+		group.Marker = &ChartValue{Val: "1"}
+		plotArea.LineChart = group
+	case ChartTypeArea:
+		group.Grouping = &ChartValue{Val: string(grouping)}
+		plotArea.AreaChart = group
+	case ChartTypePie:
+		group.VaryColors = &ChartValue{Val: "1"}
+		group.FirstSliceAng = &ChartValue{Val: "0"}
+		plotArea.PieChart = group
+	case ChartTypeScatter:
+		group.ScatterStyle = &ChartValue{Val: "lineMarker"}
+		plotArea.ScatterChart = group
+	}
+
+	for i := range config.Series {
+		group.Series = append(group.Series, *createChartSeriesElement(config, i))
+	}
+	if config.DataLabels != nil {
+		group.DLbls = createChartDataLabels(config.DataLabels)
+	}
+
+	if config.Type != ChartTypePie {
+		group.AxID = []ChartValue{{Val: chartCategoryAxisID}, {Val: chartValueAxisID}}
+		plotArea.Axes = createChartAxes(config)
+	}
+
+	chart := &ChartElement{
+		AutoTitleDeleted: &ChartValue{Val: "1"},
+		PlotArea:         plotArea,
+		PlotVisOnly:      &ChartValue{Val: "1"},
+		DispBlanksAs:     &ChartValue{Val: "gap"},
+	}
+	if config.Title != "" {
+		chart.Title = createChartTitle(config.Title)
+		chart.AutoTitleDeleted = &ChartValue{Val: "0"}
+	}
+
+	legendPos := ChartLegendBottom
+	overlay := "0"
+	if config.Legend != nil {
+		if config.Legend.Position != "" {
+			legendPos = config.Legend.Position
+		}
+		if config.Legend.Overlay {
+			overlay = "1"
+		}
+	}
+	if legendPos != ChartLegendNone {
+		chart.Legend = &ChartLegendElement{
+			LegendPos: &ChartValue{Val: string(legendPos)},
+			Overlay:   &ChartValue{Val: overlay},
+		}
+	}
+
+	space := &ChartSpace{
+		XmlnsC:         chartNamespace,
+		XmlnsA:         drawingMLNamespace,
+		XmlnsR:         officeRelationshipsNamespace,
+		Date1904:       &ChartValue{Val: "0"},
+		RoundedCorners: &ChartValue{Val: "0"},
+		Chart:          chart,
+		ExternalData: &ChartExternalData{
+			ID:         "rId1",
+			AutoUpdate: &ChartValue{Val: "0"},
+		},
+	}
+
+	data, err := xml.Marshal(space)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// createChartSeriesElement 创建第 index 个数据系列元素
+func createChartSeriesElement(config *ChartConfig, index int) *ChartSeriesElement {
+	series := config.Series[index]
+	ser := newChartSeriesData(config.Type, index, series, config.Categories)
+
+	color := chartColor(config.Colors, index)
+	if series.Color != "" {
+		color = normalizeHexColor(series.Color)
+	}
+	fill := &SolidFill{SrgbClr: &SrgbClr{Val: color}}
+
+	switch config.Type {
+	case ChartTypeColumn, ChartTypeBar:
+		ser.SpPr = &ChartShapeProps{SolidFill: fill}
+		ser.InvertIfNegative = &ChartValue{Val: "0"}
+	case ChartTypeArea:
+		ser.SpPr = &ChartShapeProps{SolidFill: fill}
+	case ChartTypeLine:
+		ser.SpPr = &ChartShapeProps{Ln: &Ln{W: "28575", SolidFill: fill}}
+		ser.Marker = &ChartMarker{
+			Symbol: &ChartValue{Val: "circle"},
+			Size:   &ChartValue{Val: "5"},
+			SpPr:   &ChartShapeProps{SolidFill: fill},
+		}
+		ser.Smooth = &ChartValue{Val: chartBool(config.Smooth)}
+	case ChartTypeScatter:
+		// 散点图默认只显示数据标记，Smooth 为true时以平滑线连接
+		if config.Smooth {
+			ser.SpPr = &ChartShapeProps{Ln: &Ln{W: "19050", SolidFill: fill}}
+		} else {
+			ser.SpPr = &ChartShapeProps{Ln: &Ln{W: "19050", NoFill: &NoFill{}}}
+		}
+		ser.Marker = &ChartMarker{
+			Symbol: &ChartValue{Val: "circle"},
+			Size:   &ChartValue{Val: "5"},
+			SpPr:   &ChartShapeProps{SolidFill: fill},
+		}
+		ser.Smooth = &ChartValue{Val: chartBool(config.Smooth)}
+	case ChartTypePie:
+		// 饼图按数据点依次着色
+		for i := range series.Values {
+			ser.DPt = append(ser.DPt, ChartDataPoint{
+				Idx:      &ChartValue{Val: strconv.Itoa(i)},
+				Bubble3D: &ChartValue{Val: "0"},
+				SpPr: &ChartShapeProps{
+					SolidFill: &SolidFill{SrgbClr: &SrgbClr{Val: chartColor(config.Colors, i)}},
+					Ln:        &Ln{W: "12700", SolidFill: &SolidFill{SrgbClr: &SrgbClr{Val: "FFFFFF"}}},
+				},
+			})
+		}
+	}
+
+	if series.DataLabels != nil {
+		ser.DLbls = createChartDataLabels(series.DataLabels)
+	}
+	return ser
+}
+
+// newChartSeriesData 创建只包含编号、名称和数据引用的系列元素
+func newChartSeriesData(chartType ChartType, index int, series ChartSeries, categories []string) *ChartSeriesElement {
+	layout := chartSheetLayout(chartType, index, len(series.Values))
+	ser := &ChartSeriesElement{
+		Idx:   &ChartValue{Val: strconv.Itoa(index)},
+		Order: &ChartValue{Val: strconv.Itoa(index)},
+		Tx: &ChartSeriesText{
+			StrRef: &ChartStringRef{
+				F:        layout.nameRef,
+				StrCache: newChartStringCache([]string{series.Name}),
+			},
+		},
+	}
+
+	if chartType == ChartTypeScatter {
+		ser.XVal = &ChartAxisData{
+			XMLName: xml.Name{Local: "c:xVal"},
+			NumRef:  &ChartNumericRef{F: layout.categoryRef, NumCache: newChartNumberCache(series.XValues)},
+		}
+		ser.YVal = &ChartAxisData{
+			XMLName: xml.Name{Local: "c:yVal"},
+			NumRef:  &ChartNumericRef{F: layout.valueRef, NumCache: newChartNumberCache(series.Values)},
+		}
+		return ser
+	}
+
+	ser.Cat = &ChartAxisData{
+		XMLName: xml.Name{Local: "c:cat"},
+		StrRef:  &ChartStringRef{F: layout.categoryRef, StrCache: newChartStringCache(categories)},
+	}
+	ser.Val = &ChartAxisData{
+		XMLName: xml.Name{Local: "c:val"},
+		NumRef:  &ChartNumericRef{F: layout.valueRef, NumCache: newChartNumberCache(series.Values)},
+	}
+	return ser
+}
+
+// newChartStringCache 创建字符串缓存
+func newChartStringCache(values []string) *ChartDataCache {
+	cache := &ChartDataCache{PtCount: &ChartValue{Val: strconv.Itoa(len(values))}}
+	for i, v := range values {
+		cache.Pt = append(cache.Pt, ChartDataValue{Idx: i, V: v})
+	}
+	return cache
+}
+
+// newChartNumberCache 创建数值缓存
+func newChartNumberCache(values []float64) *ChartDataCache {
+	cache := &ChartDataCache{
+		FormatCode: "General",
+		PtCount:    &ChartValue{Val: strconv.Itoa(len(values))},
+	}
+	for i, v := range values {
+		cache.Pt = append(cache.Pt, ChartDataValue{Idx: i, V: strconv.FormatFloat(v, 'f', -1, 64)})
+	}
+	return cache
+}
+
+// createChartDataLabels 创建数据标签元素
+func createChartDataLabels(labels *ChartDataLabels) *ChartDataLabelsXML {
+	dLbls := &ChartDataLabelsXML{
+		ShowLegendKey:  &ChartValue{Val: "0"},
+		ShowVal:        &ChartValue{Val: chartBool(labels.ShowValue)},
+		ShowCatName:    &ChartValue{Val: chartBool(labels.ShowCategoryName)},
+		ShowSerName:    &ChartValue{Val: chartBool(labels.ShowSeriesName)},
+		ShowPercent:    &ChartValue{Val: chartBool(labels.ShowPercent)},
+		ShowBubbleSize: &ChartValue{Val: "0"},
+	}
+	if labels.NumberFormat != "" {
+		dLbls.NumFmt = &ChartNumFmt{FormatCode: labels.NumberFormat, SourceLinked: "0"}
+	}
+	if labels.Position != "" {
+		dLbls.DLblPos = &ChartValue{Val: string(labels.Position)}
+	}
+	return dLbls
+}
+
+// createChartAxes 创建类别轴和数值轴（散点图为两个数值轴）
+func createChartAxes(config *ChartConfig) []ChartAxisXML {
+	xAxis := &ChartAxis{}
+	yAxis := &ChartAxis{Gridlines: true}
+	if config.Axes != nil {
+		if config.Axes.X != nil {
+			xAxis = config.Axes.X
+		}
+		if config.Axes.Y != nil {
+			yAxis = config.Axes.Y
+		}
+	}
+
+	// 条形图的类别轴位于左侧
+	catPos, valPos := "b", "l"
+	if config.Type == ChartTypeBar {
+		catPos, valPos = "l", "b"
+	}
+
+	cat := newChartAxisXML("c:catAx", chartCategoryAxisID, chartValueAxisID, catPos, xAxis)
+	if config.Type == ChartTypeScatter {
+		cat = newChartAxisXML("c:valAx", chartCategoryAxisID, chartValueAxisID, catPos, xAxis)
+		cat.CrossBetween = &ChartValue{Val: "midCat"}
+		applyChartValueScaling(&cat, xAxis)
+	} else {
+		cat.Auto = &ChartValue{Val: "1"}
+		cat.LblAlgn = &ChartValue{Val: "ctr"}
+		cat.LblOffset = &ChartValue{Val: "100"}
+	}
+
+	val := newChartAxisXML("c:valAx", chartValueAxisID, chartCategoryAxisID, valPos, yAxis)
+	val.CrossBetween = &ChartValue{Val: "between"}
+	if config.Type == ChartTypeScatter {
+		val.CrossBetween = &ChartValue{Val: "midCat"}
+	}
+	applyChartValueScaling(&val, yAxis)
+
+	return []ChartAxisXML{cat, val}
+}
+
+// newChartAxisXML 创建坐标轴元素的公共部分
+func newChartAxisXML(name, id, crossID, position string, axis *ChartAxis) ChartAxisXML {
+	element := ChartAxisXML{
+		XMLName:       xml.Name{Local: name},
+		AxID:          &ChartValue{Val: id},
+		Scaling:       &ChartScaling{Orientation: &ChartValue{Val: "minMax"}},
+		Delete:        &ChartValue{Val: chartBool(axis.Hidden)},
+		AxPos:         &ChartValue{Val: position},
+		MajorTickMark: &ChartValue{Val: "out"},
+		MinorTickMark: &ChartValue{Val: "none"},
+		TickLblPos:    &ChartValue{Val: "nextTo"},
+		CrossAx:       &ChartValue{Val: crossID},
+		Crosses:       &ChartValue{Val: "autoZero"},
+	}
+	if axis.Gridlines {
+		element.MajorGridlines = &struct{}{}
+	}
+	if axis.Title != "" {
+		element.Title = createChartTitle(axis.Title)
+	}
+	if axis.NumberFormat != "" {
+		element.NumFmt = &ChartNumFmt{FormatCode: axis.NumberFormat, SourceLinked: "0"}
+	} else {
+		element.NumFmt = &ChartNumFmt{FormatCode: "General", SourceLinked: "1"}
+	}
+	return element
+}
+
+// applyChartValueScaling 设置数值轴的范围和刻度单位
+func applyChartValueScaling(element *ChartAxisXML, axis *ChartAxis) {
+	if axis.Max != nil {
+		element.Scaling.Max = &ChartValue{Val: strconv.FormatFloat(*axis.Max, 'f', -1, 64)}
+	}
+	if axis.Min != nil {
+		element.Scaling.Min = &ChartValue{Val: strconv.FormatFloat(*axis.Min, 'f', -1, 64)}
+	}
+	if axis.MajorUnit > 0 {
+		element.MajorUnit = &ChartValue{Val: strconv.FormatFloat(axis.MajorUnit, 'f', -1, 64)}
+	}
+}
+
+// createChartTitle 创建图表或坐标轴标题
+func createChartTitle(text string) *ChartTitle {
+	return &ChartTitle{
+		Tx: &ChartTitleText{
+			Rich: &ChartRichText{
+				BodyPr:   &struct{}{},
+				LstStyle: &struct{}{},
+				P:        &ChartTextParagraph{R: &ChartTextRun{T: text}},
+			},
+		},
+		Overlay: &ChartValue{Val: "0"},
+	}
+}
+
+// chartColor 返回第 index 个配色
+func chartColor(colors []string, index int) string {
+	if len(colors) > 0 {
+		return normalizeHexColor(colors[index%len(colors)])
+	}
+	return defaultChartColors[index%len(defaultChartColors)]
+}
+
+// chartBool 将布尔值转换为图表XML中的"0"/"1"
+func chartBool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,573 @@
+// Package document 提供图表数据读取、更新和嵌入式工作簿功能
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// chartSheetName 嵌入式工作簿中的数据工作表名称
+	chartSheetName = "Sheet1"
+	// spreadsheetMLNamespace SpreadsheetML命名空间
+	spreadsheetMLNamespace = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+)
+
+// chartSeriesLayout 系列数据在嵌入式工作表中的位置
+type chartSeriesLayout struct {
+	nameRef     string // 系列名称单元格
+	categoryRef string // 类别（散点图为X值）区域
+	valueRef    string // 数值区域
+}
+
+// chartSheetLayout 计算第 index 个系列在工作表中的引用。
+//
+// 普通图表在A列存放类别，从B列开始每列一个系列；
+// 散点图每个系列占用两列，分别存放X值和Y值。
+func chartSheetLayout(chartType ChartType, index, count int) chartSeriesLayout {
+	categoryColumn := "A"
+	valueColumn := spreadsheetColumnName(index + 2)
+	if chartType == ChartTypeScatter {
+		categoryColumn = spreadsheetColumnName(index*2 + 1)
+		valueColumn = spreadsheetColumnName(index*2 + 2)
+	}
+
+	lastRow := count + 1
+	return chartSeriesLayout{
+		nameRef:     fmt.Sprintf("%s!$%s$1", chartSheetName, valueColumn),
+		categoryRef: fmt.Sprintf("%s!$%s$2:$%s$%d", chartSheetName, categoryColumn, categoryColumn, lastRow),
+		valueRef:    fmt.Sprintf("%s!$%s$2:$%s$%d", chartSheetName, valueColumn, valueColumn, lastRow),
+	}
+}
+
+// spreadsheetColumnName 将列号（从1开始）转换为列名，如 1->A、27->AA
+func spreadsheetColumnName(column int) string {
+	name := ""
+	for column > 0 {
+		column--
+		name = string(rune('A'+column%26)) + name
+		column /= 26
+	}
+	return name
+}
+
+// worksheetCell 工作表单元格
+type worksheetCell struct {
+	text   string
+	number *float64
+}
+
+// createChartWorkbook 生成保存图表数据的嵌入式工作簿（xlsx）
+func createChartWorkbook(chartType ChartType, categories []string, series []ChartSeries) ([]byte, error) {
+	grid := map[int]map[int]worksheetCell{}
+	set := func(row, col int, cell worksheetCell) {
+		if grid[row] == nil {
+			grid[row] = map[int]worksheetCell{}
+		}
+		grid[row][col] = cell
+	}
+
+	maxRow := 1
+	if chartType == ChartTypeScatter {
+		for i, s := range series {
+			set(1, i*2+1, worksheetCell{text: "X"})
+			set(1, i*2+2, worksheetCell{text: s.Name})
+			for j := range s.Values {
+				x, y := s.XValues[j], s.Values[j]
+				set(j+2, i*2+1, worksheetCell{number: &x})
+				set(j+2, i*2+2, worksheetCell{number: &y})
+			}
+			if len(s.Values)+1 > maxRow {
+				maxRow = len(s.Values) + 1
+			}
+		}
+	} else {
+		for j, category := range categories {
+			set(j+2, 1, worksheetCell{text: category})
+		}
+		for i, s := range series {
+			set(1, i+2, worksheetCell{text: s.Name})
+			for j := range s.Values {
+				v := s.Values[j]
+				set(j+2, i+2, worksheetCell{number: &v})
+			}
+		}
+		maxRow = len(categories) + 1
+	}
+
+	var sheet bytes.Buffer
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="` + spreadsheetMLNamespace + `"><sheetData>`)
+	for row := 1; row <= maxRow; row++ {
+		cells := grid[row]
+		if len(cells) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sheet, `<row r="%d">`, row)
+		maxCol := 0
+		for col := range cells {
+			if col > maxCol {
+				maxCol = col
+			}
+		}
+		for col := 1; col <= maxCol; col++ {
+			cell, ok := cells[col]
+			if !ok {
+				continue
+			}
+			ref := fmt.Sprintf("%s%d", spreadsheetColumnName(col), row)
+			if cell.number != nil {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(*cell.number, 'f', -1, 64))
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(&sheet, []byte(cell.text))
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="` + spreadsheetMLNamespace + `" xmlns:r="` + officeRelationshipsNamespace + `">` +
+			`<sheets><sheet name="` + chartSheetName + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := writer.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(file.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UpdateChartData 更新图表的类别和系列数据。
+//
+// 适用于 AddChart 创建的图表和打开的模板中已有的图表：图表的样式、坐标轴和格式保持不变，
+// 只替换系列名称、类别和数值缓存，并重新生成嵌入式工作簿。
+// 新数据的系列数多于原图表时，以最后一个系列的格式为模板追加系列；少于原图表时删除多余系列。
+//
+// 示例:
+//
+//	doc, _ := document.Open("report_template.docx")
+//	charts := doc.ListCharts()
+//	err := doc.UpdateChartData(charts[0], []string{"1月", "2月", "3月"}, []document.ChartSeries{
+//		{Name: "销售额", Values: []float64{320, 410, 385}},
+//	})
+func (d *Document) UpdateChartData(chart *Chart, categories []string, series []ChartSeries) error {
+	if chart == nil {
+		return fmt.Errorf("图表不能为空")
+	}
+	data, ok := d.parts[chart.PartName]
+	if !ok {
+		return WrapErrorWithContext("update_chart", fmt.Errorf("图表部件不存在"), chart.PartName)
+	}
+
+	ranges, err := chartSeriesRanges(data)
+	if err != nil {
+		return WrapErrorWithContext("update_chart", err, chart.PartName)
+	}
+	if len(ranges) == 0 {
+		return WrapErrorWithContext("update_chart", fmt.Errorf("图表中没有数据系列"), chart.PartName)
+	}
+
+	// 散点图使用X/Y数值，其他图表使用类别
+	chartType := ChartTypeColumn
+	if ranges[0].group == "scatterChart" || ranges[0].group == "bubbleChart" {
+		chartType = ChartTypeScatter
+	}
+	if err := validateChartData(chartType, categories, series); err != nil {
+		return err
+	}
+
+	// 逐个替换已有系列，系列之间的其他内容（如组合图表中的分组元素）原样保留
+	var out bytes.Buffer
+	previous := int64(0)
+	for i, r := range ranges {
+		out.Write(data[previous:r.start])
+		previous = r.end
+		if i >= len(series) {
+			continue
+		}
+
+		count := 1
+		if i == len(ranges)-1 && len(series) > len(ranges) {
+			// 以最后一个系列为模板追加新系列
+			count = len(series) - i
+		}
+		for j := i; j < i+count; j++ {
+			rewritten, err := rewriteChartSeries(data[r.start:r.end], chartType, j, series[j], categories)
+			if err != nil {
+				return WrapErrorWithContext("update_chart", err, chart.PartName)
+			}
+			out.Write(rewritten)
+		}
+	}
+	out.Write(data[previous:])
+	d.parts[chart.PartName] = out.Bytes()
+
+	// 重新生成嵌入式工作簿
+	if rels, ok := d.parts[chartRelationshipsPartName(chart.PartName)]; ok {
+		var chartRels Relationships
+		if err := xml.Unmarshal(rels, &chartRels); err == nil {
+			for _, rel := range chartRels.Relationships {
+				if rel.Type != packageRelationshipType || !strings.HasSuffix(strings.ToLower(rel.Target), ".xlsx") {
+					continue
+				}
+				workbook, err := createChartWorkbook(chartType, categories, series)
+				if err != nil {
+					return WrapError("create_chart_workbook", err)
+				}
+				d.parts[chartTargetPartName(chart.PartName, rel.Target)] = workbook
+			}
+		}
+	}
+
+	if parsed, err := parseChartPart(d.parts[chart.PartName]); err == nil && chart.Config != nil {
+		chart.Config.Categories = parsed.Categories
+		chart.Config.Series = parsed.Series
+	}
+
+	Infof("更新图表数据成功: %s (%d 个系列)", chart.PartName, len(series))
+	return nil
+}
+
+// chartTargetPartName 解析图表关系中的相对目标路径
+func chartTargetPartName(chartPartName, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	dir := chartPartName[:strings.LastIndex(chartPartName, "/")]
+	for strings.HasPrefix(target, "../") {
+		target = strings.TrimPrefix(target, "../")
+		if idx := strings.LastIndex(dir, "/"); idx >= 0 {
+			dir = dir[:idx]
+		} else {
+			dir = ""
+		}
+	}
+	if dir == "" {
+		return target
+	}
+	return dir + "/" + target
+}
+
+// chartXMLRange 图表XML中一个元素的字节范围
+type chartXMLRange struct {
+	name  string // 元素本地名称
+	group string // 所属图表类型元素（仅系列）
+	start int64  // 起始位置（含）
+	end   int64  // 结束位置（不含）
+}
+
+// chartSeriesRanges 查找绘图区中所有数据系列元素的字节范围
+func chartSeriesRanges(data []byte) ([]chartXMLRange, error) {
+	var ranges []chartXMLRange
+	var stack []string
+	var current *chartXMLRange
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth := len(stack)
+			if t.Name.Local == "ser" && depth >= 2 && stack[depth-2] == "plotArea" && strings.HasSuffix(stack[depth-1], "Chart") {
+				current = &chartXMLRange{name: "ser", group: stack[depth-1], start: offset}
+			}
+			stack = append(stack, t.Name.Local)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+			if current != nil && t.Name.Local == "ser" && len(stack) >= 2 && stack[len(stack)-2] == "plotArea" {
+				current.end = decoder.InputOffset()
+				ranges = append(ranges, *current)
+				current = nil
+			}
+		}
+	}
+
+	return ranges, nil
+}
+
+// rewriteChartSeries 替换系列元素中的编号、名称和数据，保留其余格式
+func rewriteChartSeries(source []byte, chartType ChartType, index int, series ChartSeries, categories []string) ([]byte, error) {
+	// 保留原文档使用的命名空间前缀
+	prefix := ""
+	if end := bytes.IndexAny(source, " />"); end > 1 {
+		if colon := bytes.IndexByte(source[1:end], ':'); colon >= 0 {
+			prefix = string(source[1 : colon+1])
+		}
+	}
+
+	data := newChartSeriesData(chartType, index, series, categories)
+	replacements := map[string]interface{}{
+		"idx":   data.Idx,
+		"order": data.Order,
+		"tx":    data.Tx,
+	}
+	categoryName, valueName := "cat", "val"
+	if chartType == ChartTypeScatter {
+		categoryName, valueName = "xVal", "yVal"
+		replacements["xVal"] = data.XVal
+		replacements["yVal"] = data.YVal
+	} else {
+		replacements["cat"] = data.Cat
+		replacements["val"] = data.Val
+	}
+
+	snippet := func(name string) ([]byte, error) {
+		var buf bytes.Buffer
+		encoder := xml.NewEncoder(&buf)
+		if err := encoder.EncodeElement(replacements[name], xml.StartElement{Name: xml.Name{Local: "c:" + name}}); err != nil {
+			return nil, err
+		}
+		result := buf.Bytes()
+		if prefix != "c" {
+			replacer := strings.NewReplacer("<c:", "<"+prefixWithColon(prefix), "</c:", "</"+prefixWithColon(prefix))
+			result = []byte(replacer.Replace(string(result)))
+		}
+		return result, nil
+	}
+
+	// 收集系列的直接子元素
+	var children []chartXMLRange
+	depth := 0
+	decoder := xml.NewDecoder(bytes.NewReader(source))
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				children = append(children, chartXMLRange{name: t.Name.Local, start: offset})
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				children[len(children)-1].end = decoder.InputOffset()
+			}
+			depth--
+		}
+	}
+
+	present := map[string]bool{}
+	for _, child := range children {
+		present[child.name] = true
+	}
+
+	var out bytes.Buffer
+	position := int64(0)
+	if len(children) > 0 {
+		position = children[0].start
+	} else if end := bytes.IndexByte(source, '>'); end >= 0 {
+		position = int64(end + 1)
+	}
+	out.Write(source[:position])
+
+	for i, child := range children {
+		if i > 0 {
+			out.Write(source[children[i-1].end:child.start])
+		}
+
+		// 缺少的元素按架构顺序插入
+		if child.name == valueName && !present[categoryName] && (chartType == ChartTypeScatter || len(categories) > 0) {
+			s, err := snippet(categoryName)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(s)
+		}
+
+		if _, ok := replacements[child.name]; ok {
+			s, err := snippet(child.name)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(s)
+		} else {
+			out.Write(source[child.start:child.end])
+		}
+
+		if child.name == "order" && !present["tx"] {
+			s, err := snippet("tx")
+			if err != nil {
+				return nil, err
+			}
+			out.Write(s)
+		}
+		position = child.end
+	}
+	out.Write(source[position:])
+
+	return out.Bytes(), nil
+}
+
+// prefixWithColon 返回带冒号的命名空间前缀，默认命名空间返回空字符串
+func prefixWithColon(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	return prefix + ":"
+}
+
+// parseChartPart 解析图表部件中的图表类型、标题、分组方式和缓存的系列数据
+func parseChartPart(data []byte) (*ChartConfig, error) {
+	config := &ChartConfig{}
+	var stack []string
+	var current *ChartSeries
+	section := ""
+	pointIndex := 0
+	seriesCount := 0
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			val := getAttributeValue(t.Attr, "val")
+
+			switch {
+			case parent == "plotArea" && strings.HasSuffix(name, "Chart") && config.Type == "":
+				config.Type = ChartType(strings.TrimSuffix(name, "Chart"))
+			case name == "barDir" && val == "col" && config.Type == ChartTypeBar:
+				config.Type = ChartTypeColumn
+			case name == "grouping" && strings.HasSuffix(parent, "Chart"):
+				if val == "stacked" || val == "percentStacked" {
+					config.Grouping = ChartGrouping(val)
+				}
+			case name == "ser" && strings.HasSuffix(parent, "Chart") && current == nil:
+				config.Series = append(config.Series, ChartSeries{})
+				current = &config.Series[len(config.Series)-1]
+			case current != nil && parent == "ser":
+				switch name {
+				case "tx", "cat", "val", "xVal", "yVal":
+					section = name
+				}
+			case current != nil && section != "" && name == "ptCount":
+				count, _ := strconv.Atoi(val)
+				resizeChartSeriesData(config, current, section, count, seriesCount == 0)
+			case current != nil && section != "" && name == "pt":
+				pointIndex, _ = strconv.Atoi(getAttributeValue(t.Attr, "idx"))
+			}
+			stack = append(stack, name)
+
+		case xml.EndElement:
+			name := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if name == "ser" && current != nil {
+				current = nil
+				seriesCount++
+			} else if name == section && len(stack) > 0 && stack[len(stack)-1] == "ser" {
+				section = ""
+			}
+
+		case xml.CharData:
+			if len(stack) == 0 {
+				continue
+			}
+			name := stack[len(stack)-1]
+			text := string(t)
+
+			// 图表标题（c:chart/c:title）
+			if len(stack) >= 3 && stack[1] == "chart" && stack[2] == "title" && (name == "t" || name == "v") {
+				config.Title += text
+				continue
+			}
+			if current == nil || section == "" || name != "v" {
+				continue
+			}
+
+			switch section {
+			case "tx":
+				current.Name += text
+			case "cat":
+				if seriesCount == 0 {
+					resizeChartSeriesData(config, current, section, pointIndex+1, true)
+					config.Categories[pointIndex] = text
+				}
+			case "xVal":
+				resizeChartSeriesData(config, current, section, pointIndex+1, seriesCount == 0)
+				current.XValues[pointIndex], _ = strconv.ParseFloat(strings.TrimSpace(text), 64)
+			case "val", "yVal":
+				resizeChartSeriesData(config, current, section, pointIndex+1, seriesCount == 0)
+				current.Values[pointIndex], _ = strconv.ParseFloat(strings.TrimSpace(text), 64)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// resizeChartSeriesData 确保类别或系列数据至少包含 count 个元素
+func resizeChartSeriesData(config *ChartConfig, series *ChartSeries, section string, count int, firstSeries bool) {
+	switch section {
+	case "cat":
+		if firstSeries && len(config.Categories) < count {
+			config.Categories = append(config.Categories, make([]string, count-len(config.Categories))...)
+		}
+	case "xVal":
+		if len(series.XValues) < count {
+			series.XValues = append(series.XValues, make([]float64, count-len(series.XValues))...)
+		}
+	case "val", "yVal":
+		if len(series.Values) < count {
+			series.Values = append(series.Values, make([]float64, count-len(series.Values))...)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readChartWorkbookSheet 读取嵌入式工作簿中的工作表XML
+func readChartWorkbookSheet(t *testing.T, data []byte) string {
+	t.Helper()
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("嵌入式工作簿不是有效的zip: %v", err)
+	}
+	for _, file := range reader.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := file.Open()
+			defer rc.Close()
+			content, _ := io.ReadAll(rc)
+			return string(content)
+		}
+	}
+	t.Fatal("嵌入式工作簿缺少工作表")
+	return ""
+}
+
+func TestAddChart(t *testing.T) {
+	doc := New()
+	chart, err := doc.AddChart(&ChartConfig{
+		Type:       ChartTypeColumn,
+		Title:      "季度营收",
+		Categories: []string{"Q1", "Q2", "Q3", "Q4"},
+		Series: []ChartSeries{
+			{Name: "2023", Values: []float64{120, 135, 150, 170}},
+			{Name: "2024", Values: []float64{140, 160, 175, 210.5}, Color: "#C00000"},
+		},
+		DataLabels: &ChartDataLabels{ShowValue: true, Position: ChartLabelOutsideEnd},
+		Legend:     &ChartLegend{Position: ChartLegendRight},
+		Alignment:  AlignCenter,
+	})
+	if err != nil {
+		t.Fatalf("添加图表失败: %v", err)
+	}
+	if chart.PartName != "word/charts/chart1.xml" {
+		t.Errorf("图表部件名称不正确: %s", chart.PartName)
+	}
+
+	chartXML := string(doc.parts[chart.PartName])
+	for _, expected := range []string{`<c:barDir val="col">`, `<c:srgbClr val="C00000">`, `<c:legendPos val="r">`,
+		`<c:dLblPos val="outEnd">`, `<c:f>Sheet1!$C$2:$C$5</c:f>`, `<a:t>季度营收</a:t>`} {
+		if !strings.Contains(strings.ReplaceAll(chartXML, "a:srgbClr", "c:srgbClr"), expected) {
+			t.Errorf("图表XML缺少 %s", expected)
+		}
+	}
+	if err := xml.Unmarshal(doc.parts[chart.PartName], new(struct{})); err != nil {
+		t.Errorf("图表XML格式错误: %v", err)
+	}
+
+	sheet := readChartWorkbookSheet(t, doc.parts["word/embeddings/Microsoft_Excel_Worksheet1.xlsx"])
+	if !strings.Contains(sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">Q1</t>`) ||
+		!strings.Contains(sheet, `<c r="C5"><v>210.5</v></c>`) {
+		t.Errorf("嵌入式工作簿数据不正确: %s", sheet)
+	}
+
+	opened := saveAndReopen(t, doc)
+	charts := opened.ListCharts()
+	if len(charts) != 1 {
+		t.Fatalf("期望1个图表，得到 %d", len(charts))
+	}
+	parsed := charts[0].Config
+	if parsed.Type != ChartTypeColumn || parsed.Title != "季度营收" {
+		t.Errorf("图表类型或标题不正确: %s %q", parsed.Type, parsed.Title)
+	}
+	if strings.Join(parsed.Categories, ",") != "Q1,Q2,Q3,Q4" {
+		t.Errorf("类别不正确: %v", parsed.Categories)
+	}
+	if len(parsed.Series) != 2 || parsed.Series[1].Name != "2024" || parsed.Series[1].Values[3] != 210.5 {
+		t.Errorf("系列数据不正确: %+v", parsed.Series)
+	}
+	if int(parsed.Width+0.5) != 150 || parsed.Position != "" {
+		t.Errorf("图表尺寸或位置不正确: %v %s", parsed.Width, parsed.Position)
+	}
+	if len(opened.ListImages()) != 0 || len(opened.ListShapes()) != 0 {
+		t.Error("图表不应被识别为图片或形状")
+	}
+}
+
+func TestAddChartTypes(t *testing.T) {
+	doc := New()
+	configs := []*ChartConfig{
+		{Type: ChartTypeBar, Categories: []string{"A", "B"}, Series: []ChartSeries{{Name: "s", Values: []float64{1, 2}}}, Grouping: ChartGroupingStacked},
+		{Type: ChartTypeLine, Categories: []string{"A", "B"}, Series: []ChartSeries{{Name: "s", Values: []float64{1, 2}}}, Smooth: true},
+		{Type: ChartTypeArea, Categories: []string{"A", "B"}, Series: []ChartSeries{{Name: "s", Values: []float64{1, 2}}}},
+		{Type: ChartTypePie, Categories: []string{"A", "B", "C"}, Series: []ChartSeries{{Name: "份额", Values: []float64{50, 30, 20}}},
+			Colors: []string{"111111", "222222"}, DataLabels: &ChartDataLabels{ShowPercent: true, NumberFormat: "0%"}},
+		{Type: ChartTypeScatter, Series: []ChartSeries{{Name: "点", XValues: []float64{1.5, 2.5}, Values: []float64{3, 4}}},
+			Position: ImagePositionFloatRight, Axes: &ChartAxes{X: &ChartAxis{Title: "X轴"}, Y: &ChartAxis{Min: new(float64)}}},
+	}
+	for _, config := range configs {
+		if _, err := doc.AddChart(config); err != nil {
+			t.Fatalf("添加%s图表失败: %v", config.Type, err)
+		}
+	}
+
+	barXML := string(doc.parts["word/charts/chart1.xml"])
+	if !strings.Contains(barXML, `<c:barDir val="bar">`) || !strings.Contains(barXML, `<c:overlap val="100">`) {
+		t.Error("堆积条形图XML不正确")
+	}
+	pieXML := string(doc.parts["word/charts/chart4.xml"])
+	if strings.Contains(pieXML, "c:valAx") || strings.Count(pieXML, "<c:dPt>") != 3 || !strings.Contains(pieXML, `val="111111"`) {
+		t.Error("饼图XML不正确")
+	}
+	scatterXML := string(doc.parts["word/charts/chart5.xml"])
+	if !strings.Contains(scatterXML, "<c:xVal>") || strings.Contains(scatterXML, "c:catAx") || !strings.Contains(scatterXML, `<c:min val="0">`) {
+		t.Error("散点图XML不正确")
+	}
+
+	opened := saveAndReopen(t, doc)
+	charts := opened.ListCharts()
+	if len(charts) != 5 {
+		t.Fatalf("期望5个图表，得到 %d", len(charts))
+	}
+	expected := []ChartType{ChartTypeBar, ChartTypeLine, ChartTypeArea, ChartTypePie, ChartTypeScatter}
+	for i, chart := range charts {
+		if chart.Config.Type != expected[i] {
+			t.Errorf("第%d个图表类型不正确: %s", i+1, chart.Config.Type)
+		}
+	}
+	if charts[0].Config.Grouping != ChartGroupingStacked {
+		t.Errorf("分组方式不正确: %s", charts[0].Config.Grouping)
+	}
+	if scatter := charts[4]; scatter.Config.Position != ImagePositionFloatRight || scatter.Config.Series[0].XValues[1] != 2.5 {
+		t.Errorf("散点图解析不正确: %+v", scatter.Config)
+	}
+}
+
+func TestUpdateChartData(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddChart(&ChartConfig{
+		Type:       ChartTypeLine,
+		Categories: []string{"1月", "2月"},
+		Series:     []ChartSeries{{Name: "收入", Values: []float64{1, 2}, Color: "00B050"}},
+	}); err != nil {
+		t.Fatalf("添加图表失败: %v", err)
+	}
+
+	opened := saveAndReopen(t, doc)
+	chart := opened.ListCharts()[0]
+	err := opened.UpdateChartData(chart, []string{"1月", "2月", "3月"}, []ChartSeries{
+		{Name: "收入", Values: []float64{10, 20, 30}},
+		{Name: "支出", Values: []float64{5, 8, 13}},
+	})
+	if err != nil {
+		t.Fatalf("更新图表数据失败: %v", err)
+	}
+	if len(chart.Config.Series) != 2 || chart.Config.Categories[2] != "3月" {
+		t.Errorf("图表配置未同步更新: %+v", chart.Config)
+	}
+
+	// 原系列格式保留，新系列沿用最后一个系列的格式
+	chartXML := string(opened.parts[chart.PartName])
+	if strings.Count(chartXML, `val="00B050"`) != 4 {
+		t.Errorf("系列格式未保留: %s", chartXML)
+	}
+	if !strings.Contains(chartXML, `<c:idx val="1">`) || !strings.Contains(chartXML, `<c:f>Sheet1!$C$2:$C$4</c:f>`) {
+		t.Error("新增系列的编号或引用不正确")
+	}
+	sheet := readChartWorkbookSheet(t, opened.parts["word/embeddings/Microsoft_Excel_Worksheet1.xlsx"])
+	if !strings.Contains(sheet, "支出") || !strings.Contains(sheet, `<c r="C4"><v>13</v></c>`) {
+		t.Errorf("嵌入式工作簿未更新: %s", sheet)
+	}
+
+	reopened := saveAndReopen(t, opened)
+	parsed := reopened.ListCharts()[0].Config
+	if len(parsed.Series) != 2 || parsed.Series[1].Name != "支出" || parsed.Series[1].Values[2] != 13 {
+		t.Errorf("更新后的系列数据不正确: %+v", parsed.Series)
+	}
+
+	// 减少系列
+	if err := reopened.UpdateChartData(reopened.ListCharts()[0], []string{"Q1"}, []ChartSeries{{Name: "合计", Values: []float64{99}}}); err != nil {
+		t.Fatalf("更新图表数据失败: %v", err)
+	}
+	parsed = reopened.ListCharts()[0].Config
+	if len(parsed.Series) != 1 || parsed.Series[0].Values[0] != 99 || len(parsed.Categories) != 1 {
+		t.Errorf("减少系列后数据不正确: %+v", parsed)
+	}
+}
+
+func TestUpdateTemplateChartData(t *testing.T) {
+	doc := New()
+	chart, err := doc.AddChart(&ChartConfig{
+		Categories: []string{"A"},
+		Series:     []ChartSeries{{Name: "s", Values: []float64{1}}},
+	})
+	if err != nil {
+		t.Fatalf("添加图表失败: %v", err)
+	}
+
+	// 模拟Word生成的组合图表：使用其他命名空间前缀、数值类别和扩展元素
+	doc.parts[chart.PartName] = []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cc:chartSpace xmlns:cc="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">
+<cc:chart><cc:title><cc:tx><cc:rich><a:bodyPr/><a:p><a:r><a:t>销售</a:t></a:r><a:r><a:t>趋势</a:t></a:r></a:p></cc:rich></cc:tx></cc:title>
+<cc:plotArea><cc:layout/>
+<cc:barChart><cc:barDir val="col"/><cc:grouping val="clustered"/>
+<cc:ser><cc:idx val="0"/><cc:order val="0"/><cc:spPr><a:solidFill><a:schemeClr val="accent1"/></a:solidFill></cc:spPr>
+<cc:cat><cc:numRef><cc:f>Sheet1!$A$2:$A$3</cc:f><cc:numCache><cc:ptCount val="2"/><cc:pt idx="0"><cc:v>2020</cc:v></cc:pt><cc:pt idx="1"><cc:v>2021</cc:v></cc:pt></cc:numCache></cc:numRef></cc:cat>
+<cc:val><cc:numRef><cc:f>Sheet1!$B$2:$B$3</cc:f><cc:numCache><cc:ptCount val="2"/><cc:pt idx="0"><cc:v>1</cc:v></cc:pt><cc:pt idx="1"><cc:v>2</cc:v></cc:pt></cc:numCache></cc:numRef></cc:val>
+<cc:extLst><cc:ext uri="{test}"/></cc:extLst></cc:ser>
+<cc:axId val="1"/><cc:axId val="2"/></cc:barChart>
+<cc:lineChart><cc:grouping val="standard"/>
+<cc:ser><cc:idx val="1"/><cc:order val="1"/><cc:tx><cc:v>旧</cc:v></cc:tx><cc:val><cc:numLit><cc:ptCount val="1"/><cc:pt idx="0"><cc:v>7</cc:v></cc:pt></cc:numLit></cc:val><cc:smooth val="0"/></cc:ser>
+<cc:axId val="1"/><cc:axId val="2"/></cc:lineChart>
+</cc:plotArea></cc:chart></cc:chartSpace>`)
+
+	charts := doc.ListCharts()
+	if charts[0].Config.Title != "销售趋势" || charts[0].Config.Categories[1] != "2021" {
+		t.Fatalf("模板图表解析不正确: %+v", charts[0].Config)
+	}
+
+	// 只保留一个系列时，折线图分组应保持完整
+	if err := doc.UpdateChartData(charts[0], []string{"2022", "2023"}, []ChartSeries{{Name: "新", Values: []float64{3, 4}}}); err != nil {
+		t.Fatalf("更新模板图表失败: %v", err)
+	}
+	chartXML := string(doc.parts[chart.PartName])
+	if err := xml.Unmarshal([]byte(chartXML), new(struct{})); err != nil {
+		t.Fatalf("更新后的图表XML格式错误: %v", err)
+	}
+	for _, expected := range []string{`<cc:tx><cc:strRef><cc:f>Sheet1!$B$1</cc:f>`, `<a:schemeClr val="accent1"/>`,
+		`<cc:ext uri="{test}"/>`, `<cc:lineChart><cc:grouping val="standard"/>`, `<cc:v>2023</cc:v>`} {
+		if !strings.Contains(chartXML, expected) {
+			t.Errorf("更新后的图表XML缺少 %s", expected)
+		}
+	}
+	if strings.Contains(chartXML, "旧") {
+		t.Error("多余的系列未被删除")
+	}
+
+	// 追加的系列以最后一个系列为模板
+	if err := doc.UpdateChartData(charts[0], []string{"X"}, []ChartSeries{
+		{Name: "一", Values: []float64{1}},
+		{Name: "二", Values: []float64{2}},
+	}); err != nil {
+		t.Fatalf("更新模板图表失败: %v", err)
+	}
+	parsed := doc.ListCharts()[0].Config
+	if len(parsed.Series) != 2 || parsed.Series[1].Name != "二" || parsed.Series[1].Values[0] != 2 {
+		t.Errorf("追加系列不正确: %+v", parsed.Series)
+	}
+}
+
+func TestChartValidation(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddChart(nil); err == nil {
+		t.Error("期望配置为空时返回错误")
+	}
+	if _, err := doc.AddChart(&ChartConfig{Categories: []string{"A"}}); err == nil {
+		t.Error("期望没有系列时返回错误")
+	}
+	if _, err := doc.AddChart(&ChartConfig{Categories: []string{"A", "B"}, Series: []ChartSeries{{Values: []float64{1}}}}); err == nil {
+		t.Error("期望数值与类别数量不一致时返回错误")
+	}
+	if _, err := doc.AddChart(&ChartConfig{Type: ChartTypeScatter, Series: []ChartSeries{{Values: []float64{1}}}}); err == nil {
+		t.Error("期望散点图缺少X值时返回错误")
+	}
+	if _, err := doc.AddChart(&ChartConfig{Type: "radar", Categories: []string{"A"}, Series: []ChartSeries{{Values: []float64{1}}}}); err == nil {
+		t.Error("期望不支持的图表类型返回错误")
+	}
+	if len(doc.ListCharts()) != 0 {
+		t.Error("校验失败时不应添加图表")
+	}
+}
@@ -358,6 +358,16 @@ type Run struct {
 	FieldChar  *FieldChar      `xml:"w:fldChar,omitempty"`
 	InstrText  *InstrText      `xml:"w:instrText,omitempty"`
 	Break      *Break          `xml:"w:br,omitempty"` // 换行
+	Equation   *Equation       `xml:"-"`              // 数学公式，设置时替代文本输出为m:oMath
+}
+
+// MarshalXML 序列化运行，包含公式时输出公式元素
+func (r Run) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if r.Equation != nil {
+		return e.Encode(r.Equation)
+	}
+	type runAlias Run
+	return e.EncodeElement(runAlias(r), start)
 }
 
 // RunProperties 文本属性
@@ -454,9 +464,10 @@ type Relationships struct {
 
 // Relationship 单个关系
 type Relationship struct {
-	ID     string `xml:"Id,attr"`
-	Type   string `xml:"Type,attr"`
-	Target string `xml:"Target,attr"`
+	ID         string `xml:"Id,attr"`
+	Type       string `xml:"Type,attr"`
+	Target     string `xml:"Target,attr"`
+	TargetMode string `xml:"TargetMode,attr,omitempty"` // 外部目标（如超链接）为External
 }
 
 // ContentTypes 内容类型
@@ -1614,6 +1625,29 @@ func (d *Document) parseParagraph(decoder *xml.Decoder, startElement xml.StartEl
 				if run != nil {
 					paragraph.Runs = append(paragraph.Runs, *run)
 				}
+			case "oMath":
+				// 解析行内公式
+				equation, err := d.parseEquation(decoder, false)
+				if err != nil {
+					return nil, err
+				}
+				paragraph.Runs = append(paragraph.Runs, Run{Equation: equation})
+			case "oMathPara":
+				// 解析独立显示公式
+				equations, err := d.parseMathParagraph(decoder)
+				if err != nil {
+					return nil, err
+				}
+				for _, equation := range equations {
+					paragraph.Runs = append(paragraph.Runs, Run{Equation: equation})
+				}
+			case "fldSimple":
+				// 简单域转换为复杂域结构，保留域指令和域结果
+				runs, err := d.parseSimpleField(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				paragraph.Runs = append(paragraph.Runs, runs...)
 			default:
 				// 跳过其他元素
 				if err := d.skipElement(decoder, t.Name.Local); err != nil {
@@ -1680,6 +1714,13 @@ func (d *Document) parseParagraphProperties(decoder *xml.Decoder, paragraph *Par
 				if err := d.skipElement(decoder, t.Name.Local); err != nil {
 					return err
 				}
+			case "numPr":
+				// 编号
+				numPr, err := d.parseNumberingProperties(decoder)
+				if err != nil {
+					return err
+				}
+				paragraph.Properties.NumberingProperties = numPr
 			default:
 				if err := d.skipElement(decoder, t.Name.Local); err != nil {
 					return err
@@ -1693,6 +1734,35 @@ func (d *Document) parseParagraphProperties(decoder *xml.Decoder, paragraph *Par
 	}
 }
 
+// parseNumberingProperties 解析段落编号属性
+func (d *Document) parseNumberingProperties(decoder *xml.Decoder) (*NumberingProperties, error) {
+	numPr := &NumberingProperties{}
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, WrapError("parse_numbering_properties", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "ilvl":
+				numPr.ILevel = &ILevel{Val: getAttributeValue(t.Attr, "val")}
+			case "numId":
+				numPr.NumID = &NumID{Val: getAttributeValue(t.Attr, "val")}
+			}
+			if err := d.skipElement(decoder, t.Name.Local); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if t.Name.Local == "numPr" {
+				return numPr, nil
+			}
+		}
+	}
+}
+
 // parseRun 解析运行
 func (d *Document) parseRun(decoder *xml.Decoder, startElement xml.StartElement) (*Run, error) {
 	run := &Run{
@@ -1724,6 +1794,35 @@ func (d *Document) parseRun(decoder *xml.Decoder, startElement xml.StartElement)
 					return nil, err
 				}
 				run.Text.Content = content
+			case "drawing":
+				// 解析图片和形状绘图
+				drawing, err := d.parseDrawing(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				run.Drawing = drawing
+			case "AlternateContent":
+				// 形状通常包裹在mc:AlternateContent中，取mc:Choice中的绘图，忽略VML后备内容
+				drawing, err := d.parseAlternateContentDrawing(decoder)
+				if err != nil {
+					return nil, err
+				}
+				if drawing != nil {
+					run.Drawing = drawing
+				}
+			case "fldChar":
+				// 解析域字符，保留域结构以便更新域结果
+				run.FieldChar = &FieldChar{FieldCharType: getAttributeValue(t.Attr, "fldCharType")}
+				if err := d.skipElement(decoder, t.Name.Local); err != nil {
+					return nil, err
+				}
+			case "instrText":
+				// 解析域指令
+				content, err := d.readElementText(decoder, "instrText")
+				if err != nil {
+					return nil, err
+				}
+				run.InstrText = &InstrText{Space: getAttributeValue(t.Attr, "space"), Content: content}
 			default:
 				if err := d.skipElement(decoder, t.Name.Local); err != nil {
 					return nil, err
@@ -2113,6 +2212,13 @@ func (d *Document) parseTableCell(decoder *xml.Decoder, startElement xml.StartEl
 				if para != nil {
 					cell.Paragraphs = append(cell.Paragraphs, *para)
 				}
+			case "tbl":
+				// 解析嵌套表格
+				table, err := d.parseTable(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				cell.Tables = append(cell.Tables, NestedTable{Index: len(cell.Paragraphs), Table: table})
 			default:
 				if err := d.skipElement(decoder, t.Name.Local); err != nil {
 					return nil, err
@@ -2265,6 +2371,8 @@ func (d *Document) serializeDocument() error {
 		XmlnsA   string   `xml:"xmlns:a,attr"`
 		XmlnsPic string   `xml:"xmlns:pic,attr"`
 		XmlnsR   string   `xml:"xmlns:r,attr"`
+		XmlnsWPS string   `xml:"xmlns:wps,attr"`
+		XmlnsM   string   `xml:"xmlns:m,attr"`
 		Body     *Body    `xml:"w:body"`
 	}
 	
@@ -2275,6 +2383,8 @@ func (d *Document) serializeDocument() error {
 		XmlnsA:   "http://schemas.openxmlformats.org/drawingml/2006/main",
 		XmlnsPic: "http://schemas.openxmlformats.org/drawingml/2006/picture",
 		XmlnsR:   "http://schemas.openxmlformats.org/officeDocument/2006/relationships",
+		XmlnsWPS: "http://schemas.microsoft.com/office/word/2010/wordprocessingShape",
+		XmlnsM:   mathNamespace,
 		Body:     d.Body,
 	}
 	
@@ -2328,6 +2438,21 @@ func (d *Document) serializeDocumentRelationships() {
 	d.parts["word/_rels/document.xml.rels"] = append([]byte(xml.Header), data...)
 }
 
+// nextDocumentRelationshipID 生成未被占用的文档关系ID（rId1保留给styles.xml）
+func (d *Document) nextDocumentRelationshipID() string {
+	used := make(map[string]bool, len(d.documentRelationships.Relationships))
+	for _, rel := range d.documentRelationships.Relationships {
+		used[rel.ID] = true
+	}
+	
+	for n := len(d.documentRelationships.Relationships) + 2; ; n++ {
+		id := fmt.Sprintf("rId%d", n)
+		if !used[id] {
+			return id
+		}
+	}
+}
+
 // serializeStyles 序列化样式
 func (d *Document) serializeStyles() error {
 	Debugf("开始序列化样式")
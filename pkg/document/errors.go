@@ -22,6 +22,9 @@ var (
 
 	// ErrUnsupportedOperation 不支持的操作
 	ErrUnsupportedOperation = errors.New("unsupported operation")
+
+	// ErrInvalidLaTeX 无效的LaTeX公式
+	ErrInvalidLaTeX = errors.New("invalid latex")
 )
 
 // DocumentError 文档操作错误
@@ -73,6 +73,40 @@ func CreatePageRefField(anchor string) PageRefField {
 	}
 }
 
+// parseSimpleField 解析简单域（w:fldSimple），转换为由域字符和域指令组成的复杂域运行序列
+func (d *Document) parseSimpleField(decoder *xml.Decoder, startElement xml.StartElement) ([]Run, error) {
+	runs := []Run{
+		{FieldChar: &FieldChar{FieldCharType: "begin"}},
+		{InstrText: &InstrText{Space: "preserve", Content: getAttributeValue(startElement.Attr, "instr")}},
+		{FieldChar: &FieldChar{FieldCharType: "separate"}},
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, WrapError("parse_simple_field", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "r" {
+				if err := d.skipElement(decoder, t.Name.Local); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			run, err := d.parseRun(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			runs = append(runs, *run)
+		case xml.EndElement:
+			if t.Name.Local == "fldSimple" {
+				return append(runs, Run{FieldChar: &FieldChar{FieldCharType: "end"}}), nil
+			}
+		}
+	}
+}
+
 type Break struct {
 	XMLName xml.Name `xml:"w:br"`
 	Type    string   `xml:"w:type,attr,omitempty"`
@@ -209,7 +209,7 @@ func (d *Document) AddHeader(headerType HeaderFooterType, text string) error {
 	header.Paragraphs = append(header.Paragraphs, paragraph)
 
 	// 生成关系ID
-	headerID := fmt.Sprintf("rId%d", len(d.documentRelationships.Relationships)+2) // +2因为rId1保留给styles
+	headerID := d.nextDocumentRelationshipID()
 
 	// 序列化页眉
 	headerXML, err := xml.MarshalIndent(header, "", "  ")
@@ -262,7 +262,7 @@ func (d *Document) AddFooter(footerType HeaderFooterType, text string) error {
 	footer.Paragraphs = append(footer.Paragraphs, paragraph)
 
 	// 生成关系ID
-	footerID := fmt.Sprintf("rId%d", len(d.documentRelationships.Relationships)+2) // +2因为rId1保留给styles
+	footerID := d.nextDocumentRelationshipID()
 
 	// 序列化页脚
 	footerXML, err := xml.MarshalIndent(footer, "", "  ")
@@ -341,7 +341,7 @@ func (d *Document) AddHeaderWithPageNumber(headerType HeaderFooterType, text str
 	header.Paragraphs = append(header.Paragraphs, paragraph)
 
 	// 生成关系ID
-	headerID := fmt.Sprintf("rId%d", len(d.documentRelationships.Relationships)+2) // +2因为rId1保留给styles
+	headerID := d.nextDocumentRelationshipID()
 
 	// 序列化页眉
 	headerXML, err := xml.MarshalIndent(header, "", "  ")
@@ -420,7 +420,7 @@ func (d *Document) AddFooterWithPageNumber(footerType HeaderFooterType, text str
 	footer.Paragraphs = append(footer.Paragraphs, paragraph)
 
 	// 生成关系ID
-	footerID := fmt.Sprintf("rId%d", len(d.documentRelationships.Relationships)+2) // +2因为rId1保留给styles
+	footerID := d.nextDocumentRelationshipID()
 
 	// 序列化页脚
 	footerXML, err := xml.MarshalIndent(footer, "", "  ")
@@ -675,7 +675,7 @@ func (d *Document) AddStyleHeader(headerType HeaderFooterType, text, redText str
 	header.Paragraphs = append(header.Paragraphs, paragraph)
 
 	// 生成关系ID
-	headerID := fmt.Sprintf("rId%d", len(d.documentRelationships.Relationships)+2) // +2因为rId1保留给styles
+	headerID := d.nextDocumentRelationshipID()
 
 	// 序列化页眉
 	headerXML, err := xml.MarshalIndent(header, "", "  ")
@@ -71,6 +71,18 @@ type ImageConfig struct {
 	OffsetX float64
 	// 垂直偏移（毫米）
 	OffsetY float64
+	// 图片裁剪
+	Crop *ImageCrop
+	// 旋转角度（度，顺时针）
+	Rotation float64
+	// 水平翻转
+	FlipH bool
+	// 垂直翻转
+	FlipV bool
+	// 图片边框
+	Border *ImageBorder
+	// 图片效果
+	Effects *ImageEffects
 }
 
 // ImageInfo 图片信息
@@ -93,14 +105,16 @@ type DrawingElement struct {
 
 // InlineDrawing 嵌入式绘图
 type InlineDrawing struct {
-	XMLName xml.Name        `xml:"wp:inline"`
-	DistT   string          `xml:"distT,attr,omitempty"`
-	DistB   string          `xml:"distB,attr,omitempty"`
-	DistL   string          `xml:"distL,attr,omitempty"`
-	DistR   string          `xml:"distR,attr,omitempty"`
-	Extent  *DrawingExtent  `xml:"wp:extent"`
-	DocPr   *DrawingDocPr   `xml:"wp:docPr"`
-	Graphic *DrawingGraphic `xml:"a:graphic"`
+	XMLName           xml.Name           `xml:"wp:inline"`
+	DistT             string             `xml:"distT,attr,omitempty"`
+	DistB             string             `xml:"distB,attr,omitempty"`
+	DistL             string             `xml:"distL,attr,omitempty"`
+	DistR             string             `xml:"distR,attr,omitempty"`
+	Extent            *DrawingExtent     `xml:"wp:extent"`
+	EffectExtent      *EffectExtent      `xml:"wp:effectExtent,omitempty"`
+	DocPr             *DrawingDocPr      `xml:"wp:docPr"`
+	CNvGraphicFramePr *CNvGraphicFramePr `xml:"wp:cNvGraphicFramePr,omitempty"`
+	Graphic           *DrawingGraphic    `xml:"a:graphic"`
 }
 
 // AnchorDrawing 浮动绘图
@@ -280,9 +294,11 @@ type DrawingGraphic struct {
 
 // GraphicData 图形数据
 type GraphicData struct {
-	XMLName xml.Name    `xml:"a:graphicData"`
-	Uri     string      `xml:"uri,attr"`
-	Pic     *PicElement `xml:"pic:pic"`
+	XMLName xml.Name             `xml:"a:graphicData"`
+	Uri     string               `xml:"uri,attr"`
+	Pic     *PicElement          `xml:"pic:pic"`
+	Wsp     *WordprocessingShape `xml:"wps:wsp,omitempty"`
+	Chart   *ChartReference      `xml:"c:chart,omitempty"`
 }
 
 // PicElement 图片
@@ -327,13 +343,37 @@ type PicLocks struct {
 type BlipFill struct {
 	XMLName xml.Name `xml:"pic:blipFill"`
 	Blip    *Blip    `xml:"a:blip"`
+	SrcRect *SrcRect `xml:"a:srcRect,omitempty"`
 	Stretch *Stretch `xml:"a:stretch"`
 }
 
 // Blip 二进制图片
 type Blip struct {
-	XMLName xml.Name `xml:"a:blip"`
-	Embed   string   `xml:"r:embed,attr"`
+	XMLName   xml.Name   `xml:"a:blip"`
+	Embed     string     `xml:"r:embed,attr"`
+	Grayscale *Grayscale `xml:"a:grayscl,omitempty"`
+	Lum       *Lum       `xml:"a:lum,omitempty"`
+}
+
+// Grayscale 灰度效果
+type Grayscale struct {
+	XMLName xml.Name `xml:"a:grayscl"`
+}
+
+// Lum 亮度与对比度效果（单位为千分之一百分比）
+type Lum struct {
+	XMLName  xml.Name `xml:"a:lum"`
+	Bright   string   `xml:"bright,attr,omitempty"`
+	Contrast string   `xml:"contrast,attr,omitempty"`
+}
+
+// SrcRect 源图片裁剪矩形（单位为千分之一百分比）
+type SrcRect struct {
+	XMLName xml.Name `xml:"a:srcRect"`
+	L       string   `xml:"l,attr,omitempty"`
+	T       string   `xml:"t,attr,omitempty"`
+	R       string   `xml:"r,attr,omitempty"`
+	B       string   `xml:"b,attr,omitempty"`
 }
 
 // Stretch 拉伸
@@ -352,15 +392,60 @@ type SpPr struct {
 	XMLName  xml.Name  `xml:"pic:spPr"`
 	Xfrm     *Xfrm     `xml:"a:xfrm"`
 	PrstGeom *PrstGeom `xml:"a:prstGeom"`
+	Ln       *Ln       `xml:"a:ln,omitempty"`
 }
 
 // Xfrm 变换
 type Xfrm struct {
 	XMLName xml.Name `xml:"a:xfrm"`
+	Rot     string   `xml:"rot,attr,omitempty"`
+	FlipH   string   `xml:"flipH,attr,omitempty"`
+	FlipV   string   `xml:"flipV,attr,omitempty"`
 	Off     *Off     `xml:"a:off,omitempty"`
 	Ext     *Ext     `xml:"a:ext"`
 }
 
+// Ln 线条（轮廓）
+type Ln struct {
+	XMLName   xml.Name   `xml:"a:ln"`
+	W         string     `xml:"w,attr,omitempty"`
+	NoFill    *NoFill    `xml:"a:noFill,omitempty"`
+	SolidFill *SolidFill `xml:"a:solidFill,omitempty"`
+	PrstDash  *PrstDash  `xml:"a:prstDash,omitempty"`
+	HeadEnd   *LineEnd   `xml:"a:headEnd,omitempty"`
+	TailEnd   *LineEnd   `xml:"a:tailEnd,omitempty"`
+}
+
+// LineEnd 线条端点样式
+type LineEnd struct {
+	Type string `xml:"type,attr,omitempty"`
+	W    string `xml:"w,attr,omitempty"`
+	Len  string `xml:"len,attr,omitempty"`
+}
+
+// NoFill 无填充
+type NoFill struct {
+	XMLName xml.Name `xml:"a:noFill"`
+}
+
+// SolidFill 纯色填充
+type SolidFill struct {
+	XMLName xml.Name `xml:"a:solidFill"`
+	SrgbClr *SrgbClr `xml:"a:srgbClr,omitempty"`
+}
+
+// SrgbClr RGB颜色
+type SrgbClr struct {
+	XMLName xml.Name `xml:"a:srgbClr"`
+	Val     string   `xml:"val,attr"`
+}
+
+// PrstDash 预设线型
+type PrstDash struct {
+	XMLName xml.Name `xml:"a:prstDash"`
+	Val     string   `xml:"val,attr"`
+}
+
 // Off 偏移
 type Off struct {
 	XMLName xml.Name `xml:"a:off"`
@@ -384,7 +469,15 @@ type PrstGeom struct {
 
 // AvLst 调整值列表
 type AvLst struct {
-	XMLName xml.Name `xml:"a:avLst"`
+	XMLName xml.Name     `xml:"a:avLst"`
+	Gd      []ShapeGuide `xml:"a:gd,omitempty"`
+}
+
+// ShapeGuide 形状调整值
+type ShapeGuide struct {
+	XMLName xml.Name `xml:"a:gd"`
+	Name    string   `xml:"name,attr"`
+	Fmla    string   `xml:"fmla,attr"`
 }
 
 // AddImageFromFile 从文件添加图片到文档
@@ -431,7 +524,7 @@ func (d *Document) AddImageFromData(imageData []byte, fileName string, format Im
 	d.nextImageID++ // 递增计数器
 
 	// 生成关系ID，注意：rId1保留给styles.xml，图片从rId2开始
-	relationID := fmt.Sprintf("rId%d", len(d.documentRelationships.Relationships)+2)
+	relationID := d.nextDocumentRelationshipID()
 
 	// 添加图片关系
 	d.documentRelationships.Relationships = append(d.documentRelationships.Relationships, Relationship{
@@ -482,7 +575,7 @@ func (d *Document) AddImageFromDataWithoutElement(imageData []byte, fileName str
 	d.nextImageID++ // 递增计数器
 
 	// 生成关系ID，注意：rId1保留给styles.xml，图片从rId2开始
-	relationID := fmt.Sprintf("rId%d", len(d.documentRelationships.Relationships)+2)
+	relationID := d.nextDocumentRelationshipID()
 
 	// 添加图片关系
 	d.documentRelationships.Relationships = append(d.documentRelationships.Relationships, Relationship{
@@ -780,7 +873,7 @@ func (d *Document) setFloatingImageWrap(anchor *AnchorDrawing, config *ImageConf
 
 // createImageGraphic 创建图片图形元素
 func (d *Document) createImageGraphic(imageInfo *ImageInfo, displayWidth, displayHeight int64, altText, title string) *DrawingGraphic {
-	return &DrawingGraphic{
+	graphic := &DrawingGraphic{
 		Xmlns: "http://schemas.openxmlformats.org/drawingml/2006/main",
 		GraphicData: &GraphicData{
 			Uri: "http://schemas.openxmlformats.org/drawingml/2006/picture",
@@ -826,6 +919,10 @@ func (d *Document) createImageGraphic(imageInfo *ImageInfo, displayWidth, displa
 			},
 		},
 	}
+
+	// 应用裁剪、旋转、边框等外观设置
+	applyImageAppearance(graphic.GraphicData.Pic, imageInfo.Config)
+	return graphic
 }
 
 // calculateDisplaySize 计算图片显示尺寸（EMU单位）
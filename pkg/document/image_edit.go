@@ -0,0 +1,1213 @@
+// Package document 提供Word文档图片的读取与编辑功能
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// drawingMLNamespace DrawingML主命名空间
+	drawingMLNamespace = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	// drawingMLPictureNamespace DrawingML图片命名空间
+	drawingMLPictureNamespace = "http://schemas.openxmlformats.org/drawingml/2006/picture"
+)
+
+// ImageReplaceMode 替换图片数据时的尺寸处理方式
+type ImageReplaceMode string
+
+const (
+	// ImageReplaceKeepSize 保持原有显示尺寸，新图片拉伸填满原有区域（默认）
+	ImageReplaceKeepSize ImageReplaceMode = "keepSize"
+	// ImageReplaceFit 在原有显示区域内按新图片的长宽比缩放
+	ImageReplaceFit ImageReplaceMode = "fit"
+	// ImageReplaceOriginalSize 使用新图片的原始尺寸（96 DPI）
+	ImageReplaceOriginalSize ImageReplaceMode = "originalSize"
+)
+
+// ImageCrop 图片裁剪配置，各边的裁剪量为图片对应尺寸的百分比（0-100）
+type ImageCrop struct {
+	Left   float64 // 左侧裁剪百分比
+	Top    float64 // 顶部裁剪百分比
+	Right  float64 // 右侧裁剪百分比
+	Bottom float64 // 底部裁剪百分比
+}
+
+// ImageBorderStyle 图片边框线型
+type ImageBorderStyle string
+
+const (
+	// 图片边框线型选项
+	ImageBorderSolid      ImageBorderStyle = "solid"         // 实线
+	ImageBorderDot        ImageBorderStyle = "sysDot"        // 点线
+	ImageBorderDash       ImageBorderStyle = "dash"          // 虚线
+	ImageBorderLongDash   ImageBorderStyle = "lgDash"        // 长虚线
+	ImageBorderDashDot    ImageBorderStyle = "dashDot"       // 点划线
+	ImageBorderDashDotDot ImageBorderStyle = "sysDashDotDot" // 双点划线
+)
+
+// ImageBorder 图片边框配置
+type ImageBorder struct {
+	Width float64          // 线宽（磅）
+	Color string           // 颜色（十六进制，如 "000000"）
+	Style ImageBorderStyle // 线型，默认实线
+}
+
+// ImageEffects 图片效果配置
+type ImageEffects struct {
+	Grayscale  bool // 灰度
+	Brightness int  // 亮度调整（-100 到 100）
+	Contrast   int  // 对比度调整（-100 到 100）
+}
+
+// ListImages 列出文档主体（包括表格单元格）中的所有图片。
+//
+// 对于通过 Open 打开的文档，图片信息从 w:drawing 元素中解析得到，
+// 返回的 ImageInfo 可直接传给 ReplaceImageData、SetImageCrop 等编辑方法。
+//
+// 示例:
+//
+//	doc, _ := document.Open("template.docx")
+//	newLogo, _ := os.ReadFile("logo.png")
+//	for _, img := range doc.ListImages() {
+//		if img.Config.AltText == "logo" {
+//			doc.ReplaceImageData(img, newLogo)
+//		}
+//	}
+func (d *Document) ListImages() []*ImageInfo {
+	var images []*ImageInfo
+
+	d.forEachImageRun(func(para *Paragraph, run *Run) bool {
+		if info := d.imageInfoFromDrawing(para, run.Drawing); info != nil {
+			images = append(images, info)
+		}
+		return true
+	})
+
+	return images
+}
+
+// ReplaceImageData 替换图片数据，保持图片原有的显示尺寸。
+//
+// 新图片的格式可以与原图片不同，此时会生成新的媒体文件并更新关系。
+// 注意：引用同一媒体关系的其他图片也会显示新的图片数据。
+func (d *Document) ReplaceImageData(imageInfo *ImageInfo, newData []byte) error {
+	return d.ReplaceImageDataWithMode(imageInfo, newData, ImageReplaceKeepSize)
+}
+
+// ReplaceImageDataWithMode 按指定的尺寸处理方式替换图片数据
+func (d *Document) ReplaceImageDataWithMode(imageInfo *ImageInfo, newData []byte, mode ImageReplaceMode) error {
+	drawing, err := d.findImageDrawing(imageInfo)
+	if err != nil {
+		return err
+	}
+
+	format, err := detectImageFormat(newData)
+	if err != nil {
+		return fmt.Errorf("检测图片格式失败: %v", err)
+	}
+
+	width, height, err := getImageDimensions(newData, format)
+	if err != nil {
+		return fmt.Errorf("获取图片尺寸失败: %v", err)
+	}
+
+	pic := drawingPicture(drawing)
+	rel := d.findDocumentRelationship(pic.BlipFill.Blip.Embed)
+	if rel == nil {
+		return fmt.Errorf("找不到图片关系 %s", pic.BlipFill.Blip.Embed)
+	}
+
+	// 格式变化时使用新的扩展名，避免内容类型与数据不一致
+	target := rel.Target
+	if ext := strings.TrimPrefix(path.Ext(target), "."); !imageExtensionMatches(ext, format) {
+		target = d.uniqueMediaTarget(strings.TrimSuffix(target, path.Ext(target)), imageFormatExtension(format))
+		rel.Target = target
+	}
+
+	if d.parts == nil {
+		d.parts = make(map[string][]byte)
+	}
+	d.parts[relationshipPartName(target)] = newData
+	d.addImageContentType(format)
+
+	// 计算新的显示尺寸
+	cx, cy := drawingExtentEMU(drawing)
+	switch mode {
+	case ImageReplaceFit:
+		if cx > 0 && cy > 0 && width > 0 && height > 0 {
+			ratio := float64(height) / float64(width)
+			if float64(cx)*ratio <= float64(cy) {
+				cy = int64(float64(cx) * ratio)
+			} else {
+				cx = int64(float64(cy) / ratio)
+			}
+		}
+	case ImageReplaceOriginalSize:
+		cx = int64(width) * 9525
+		cy = int64(height) * 9525
+	}
+	setDrawingExtentEMU(drawing, cx, cy)
+
+	imageInfo.Format = format
+	imageInfo.Width = width
+	imageInfo.Height = height
+	imageInfo.Data = newData
+	imageInfo.RelationID = pic.BlipFill.Blip.Embed
+	if imageInfo.Config == nil {
+		imageInfo.Config = &ImageConfig{}
+	}
+	imageInfo.Config.Size = &ImageSize{
+		Width:  float64(cx) / 36000,
+		Height: float64(cy) / 36000,
+	}
+
+	Infof("替换图片 %s 成功 (格式: %s, 尺寸: %dx%d)", imageInfo.ID, format, width, height)
+	return nil
+}
+
+// SetImageCrop 设置图片裁剪，传入nil或全零值表示取消裁剪
+func (d *Document) SetImageCrop(imageInfo *ImageInfo, crop *ImageCrop) error {
+	if crop != nil {
+		for _, v := range []float64{crop.Left, crop.Top, crop.Right, crop.Bottom} {
+			if v < 0 || v >= 100 {
+				return NewValidationError("crop", fmt.Sprintf("%.2f", v), "裁剪百分比必须在0到100之间")
+			}
+		}
+		if crop.Left+crop.Right >= 100 || crop.Top+crop.Bottom >= 100 {
+			return NewValidationError("crop", fmt.Sprintf("%+v", *crop), "裁剪后图片区域不能为空")
+		}
+	}
+
+	pic, err := d.findImagePicture(imageInfo)
+	if err != nil {
+		return err
+	}
+
+	imageInfo.Config.Crop = crop
+	applyImageCrop(pic, crop)
+	return nil
+}
+
+// SetImageRotation 设置图片旋转角度（度，顺时针）
+func (d *Document) SetImageRotation(imageInfo *ImageInfo, degrees float64) error {
+	pic, err := d.findImagePicture(imageInfo)
+	if err != nil {
+		return err
+	}
+
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+
+	imageInfo.Config.Rotation = degrees
+	applyImageTransform(pic, imageInfo.Config)
+	return nil
+}
+
+// SetImageFlip 设置图片水平、垂直翻转
+func (d *Document) SetImageFlip(imageInfo *ImageInfo, horizontal, vertical bool) error {
+	pic, err := d.findImagePicture(imageInfo)
+	if err != nil {
+		return err
+	}
+
+	imageInfo.Config.FlipH = horizontal
+	imageInfo.Config.FlipV = vertical
+	applyImageTransform(pic, imageInfo.Config)
+	return nil
+}
+
+// SetImageBorder 设置图片边框，传入nil表示移除边框
+func (d *Document) SetImageBorder(imageInfo *ImageInfo, border *ImageBorder) error {
+	if border != nil && border.Width < 0 {
+		return NewValidationError("border.width", fmt.Sprintf("%.2f", border.Width), "边框宽度不能为负数")
+	}
+
+	pic, err := d.findImagePicture(imageInfo)
+	if err != nil {
+		return err
+	}
+
+	imageInfo.Config.Border = border
+	applyImageBorder(pic, border)
+	return nil
+}
+
+// SetImageEffects 设置图片灰度、亮度和对比度效果，传入nil表示清除效果
+func (d *Document) SetImageEffects(imageInfo *ImageInfo, effects *ImageEffects) error {
+	if effects != nil {
+		if effects.Brightness < -100 || effects.Brightness > 100 {
+			return NewValidationError("effects.brightness", strconv.Itoa(effects.Brightness), "亮度必须在-100到100之间")
+		}
+		if effects.Contrast < -100 || effects.Contrast > 100 {
+			return NewValidationError("effects.contrast", strconv.Itoa(effects.Contrast), "对比度必须在-100到100之间")
+		}
+	}
+
+	pic, err := d.findImagePicture(imageInfo)
+	if err != nil {
+		return err
+	}
+
+	imageInfo.Config.Effects = effects
+	applyImageEffects(pic, effects)
+	return nil
+}
+
+// applyImageAppearance 将图片配置中的外观设置应用到图片元素
+func applyImageAppearance(pic *PicElement, config *ImageConfig) {
+	if pic == nil || config == nil {
+		return
+	}
+	applyImageCrop(pic, config.Crop)
+	applyImageTransform(pic, config)
+	applyImageBorder(pic, config.Border)
+	applyImageEffects(pic, config.Effects)
+}
+
+// applyImageCrop 应用图片裁剪
+func applyImageCrop(pic *PicElement, crop *ImageCrop) {
+	if pic.BlipFill == nil {
+		pic.BlipFill = &BlipFill{}
+	}
+
+	if crop == nil || (crop.Left == 0 && crop.Top == 0 && crop.Right == 0 && crop.Bottom == 0) {
+		pic.BlipFill.SrcRect = nil
+		return
+	}
+
+	pic.BlipFill.SrcRect = &SrcRect{
+		L: percentToThousandths(crop.Left),
+		T: percentToThousandths(crop.Top),
+		R: percentToThousandths(crop.Right),
+		B: percentToThousandths(crop.Bottom),
+	}
+}
+
+// applyImageTransform 应用图片旋转与翻转
+func applyImageTransform(pic *PicElement, config *ImageConfig) {
+	if pic.SpPr == nil {
+		pic.SpPr = &SpPr{}
+	}
+	if pic.SpPr.Xfrm == nil {
+		pic.SpPr.Xfrm = &Xfrm{}
+	}
+
+	xfrm := pic.SpPr.Xfrm
+	xfrm.Rot = ""
+	if config.Rotation != 0 {
+		// DrawingML角度单位为1/60000度
+		xfrm.Rot = strconv.FormatInt(int64(math.Round(config.Rotation*60000)), 10)
+	}
+
+	xfrm.FlipH = ""
+	if config.FlipH {
+		xfrm.FlipH = "1"
+	}
+	xfrm.FlipV = ""
+	if config.FlipV {
+		xfrm.FlipV = "1"
+	}
+}
+
+// applyImageBorder 应用图片边框
+func applyImageBorder(pic *PicElement, border *ImageBorder) {
+	if pic.SpPr == nil {
+		pic.SpPr = &SpPr{}
+	}
+
+	if border == nil {
+		pic.SpPr.Ln = nil
+		return
+	}
+
+	color := strings.TrimPrefix(border.Color, "#")
+	if color == "" {
+		color = "000000"
+	}
+	style := border.Style
+	if style == "" {
+		style = ImageBorderSolid
+	}
+
+	pic.SpPr.Ln = &Ln{
+		// 磅转EMU：1磅 = 12700 EMU
+		W:         strconv.FormatInt(int64(math.Round(border.Width*12700)), 10),
+		SolidFill: &SolidFill{SrgbClr: &SrgbClr{Val: strings.ToUpper(color)}},
+		PrstDash:  &PrstDash{Val: string(style)},
+	}
+}
+
+// applyImageEffects 应用图片效果
+func applyImageEffects(pic *PicElement, effects *ImageEffects) {
+	if pic.BlipFill == nil || pic.BlipFill.Blip == nil {
+		return
+	}
+
+	blip := pic.BlipFill.Blip
+	blip.Grayscale = nil
+	blip.Lum = nil
+	if effects == nil {
+		return
+	}
+
+	if effects.Grayscale {
+		blip.Grayscale = &Grayscale{}
+	}
+	if effects.Brightness != 0 || effects.Contrast != 0 {
+		blip.Lum = &Lum{}
+		if effects.Brightness != 0 {
+			blip.Lum.Bright = strconv.Itoa(effects.Brightness * 1000)
+		}
+		if effects.Contrast != 0 {
+			blip.Lum.Contrast = strconv.Itoa(effects.Contrast * 1000)
+		}
+	}
+}
+
+// findImagePicture 查找图片对应的pic:pic元素，并确保图片配置已初始化
+func (d *Document) findImagePicture(imageInfo *ImageInfo) (*PicElement, error) {
+	drawing, err := d.findImageDrawing(imageInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if imageInfo.Config == nil {
+		imageInfo.Config = &ImageConfig{}
+	}
+	return drawingPicture(drawing), nil
+}
+
+// findImageDrawing 根据图片ID查找文档中的绘图元素
+func (d *Document) findImageDrawing(imageInfo *ImageInfo) (*DrawingElement, error) {
+	if imageInfo == nil {
+		return nil, fmt.Errorf("图片信息不能为空")
+	}
+
+	var found *DrawingElement
+	d.forEachImageRun(func(para *Paragraph, run *Run) bool {
+		if docPr := drawingDocPr(run.Drawing); docPr != nil && docPr.ID == imageInfo.ID {
+			found = run.Drawing
+			return false
+		}
+		return true
+	})
+
+	if found == nil {
+		return nil, fmt.Errorf("找不到图片ID %s 对应的绘图元素", imageInfo.ID)
+	}
+	return found, nil
+}
+
+// forEachImageRun 遍历文档主体、表格和文本框中包含图片的运行，回调返回false时停止遍历
+func (d *Document) forEachImageRun(fn func(para *Paragraph, run *Run) bool) {
+	d.forEachDrawingRun(func(para *Paragraph, run *Run) bool {
+		if drawingPicture(run.Drawing) == nil {
+			return true
+		}
+		return fn(para, run)
+	})
+}
+
+// forEachDrawingRun 遍历文档主体、表格和文本框中包含绘图元素的运行，回调返回false时停止遍历
+func (d *Document) forEachDrawingRun(fn func(para *Paragraph, run *Run) bool) {
+	if d.Body == nil {
+		return
+	}
+	walkDrawingRuns(d.Body.Elements, fn)
+}
+
+// walkDrawingRuns 遍历段落、表格（包括嵌套表格）和文本框中包含绘图元素的运行，回调返回false时停止遍历。
+// 回调可以替换run.Drawing，随后遍历替换后绘图中的文本框
+func walkDrawingRuns(elements []interface{}, fn func(para *Paragraph, run *Run) bool) bool {
+	var visitParagraph func(para *Paragraph) bool
+	visitParagraph = func(para *Paragraph) bool {
+		for i := range para.Runs {
+			run := &para.Runs[i]
+			if run.Drawing == nil {
+				continue
+			}
+			if !fn(para, run) {
+				return false
+			}
+			// 文本框中的段落和表格可能包含图片或嵌套的文本框
+			if wsp := drawingShape(run.Drawing); wsp != nil && wsp.Txbx != nil && wsp.Txbx.Content != nil {
+				for _, element := range wsp.Txbx.Content.Elements() {
+					switch e := element.(type) {
+					case *Paragraph:
+						if e != nil && !visitParagraph(e) {
+							return false
+						}
+					case *Table:
+						if !walkTableParagraphs(e, visitParagraph) {
+							return false
+						}
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	for _, element := range elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			if !visitParagraph(e) {
+				return false
+			}
+		case *Table:
+			if !walkTableParagraphs(e, visitParagraph) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// imageInfoFromDrawing 根据绘图元素构建图片信息
+func (d *Document) imageInfoFromDrawing(para *Paragraph, drawing *DrawingElement) *ImageInfo {
+	pic := drawingPicture(drawing)
+	docPr := drawingDocPr(drawing)
+	if pic == nil || docPr == nil || pic.BlipFill == nil || pic.BlipFill.Blip == nil {
+		return nil
+	}
+
+	info := &ImageInfo{
+		ID:         docPr.ID,
+		RelationID: pic.BlipFill.Blip.Embed,
+		Config: &ImageConfig{
+			AltText: docPr.Descr,
+			Title:   docPr.Title,
+		},
+	}
+
+	// 读取图片数据及像素尺寸
+	if rel := d.findDocumentRelationship(info.RelationID); rel != nil {
+		info.Data = d.parts[relationshipPartName(rel.Target)]
+		if format, err := detectImageFormat(info.Data); err == nil {
+			info.Format = format
+			if width, height, err := getImageDimensions(info.Data, format); err == nil {
+				info.Width = width
+				info.Height = height
+			}
+		} else {
+			info.Format = ImageFormat(strings.ToLower(strings.TrimPrefix(path.Ext(rel.Target), ".")))
+		}
+	}
+
+	config := info.Config
+	cx, cy := drawingExtentEMU(drawing)
+	if cx > 0 && cy > 0 {
+		config.Size = &ImageSize{
+			Width:  float64(cx) / 36000,
+			Height: float64(cy) / 36000,
+		}
+	}
+
+	// 位置与环绕方式
+	if drawing.Anchor != nil {
+		config.Position = ImagePositionFloatLeft
+		if h := drawing.Anchor.PositionH; h != nil {
+			if h.Align != nil && h.Align.Value == "right" {
+				config.Position = ImagePositionFloatRight
+			}
+			if h.PosOffset != nil {
+				config.OffsetX = float64(parseEMU(h.PosOffset.Value)) / 36000
+			}
+		}
+		if v := drawing.Anchor.PositionV; v != nil && v.PosOffset != nil {
+			config.OffsetY = float64(parseEMU(v.PosOffset.Value)) / 36000
+		}
+		switch {
+		case drawing.Anchor.WrapNone != nil:
+			config.WrapText = ImageWrapNone
+		case drawing.Anchor.WrapTight != nil, drawing.Anchor.WrapThrough != nil:
+			config.WrapText = ImageWrapTight
+		case drawing.Anchor.WrapTopAndBottom != nil:
+			config.WrapText = ImageWrapTopAndBottom
+		default:
+			config.WrapText = ImageWrapSquare
+		}
+	} else {
+		config.Position = ImagePositionInline
+		if para != nil && para.Properties != nil && para.Properties.Justification != nil {
+			config.Alignment = AlignmentType(para.Properties.Justification.Val)
+		}
+	}
+
+	// 裁剪
+	if rect := pic.BlipFill.SrcRect; rect != nil {
+		config.Crop = &ImageCrop{
+			Left:   thousandthsToPercent(rect.L),
+			Top:    thousandthsToPercent(rect.T),
+			Right:  thousandthsToPercent(rect.R),
+			Bottom: thousandthsToPercent(rect.B),
+		}
+	}
+
+	// 旋转与翻转
+	if pic.SpPr != nil && pic.SpPr.Xfrm != nil {
+		xfrm := pic.SpPr.Xfrm
+		if xfrm.Rot != "" {
+			config.Rotation = float64(parseEMU(xfrm.Rot)) / 60000
+		}
+		config.FlipH = xfrm.FlipH == "1" || xfrm.FlipH == "true"
+		config.FlipV = xfrm.FlipV == "1" || xfrm.FlipV == "true"
+	}
+
+	// 边框
+	if pic.SpPr != nil && pic.SpPr.Ln != nil && pic.SpPr.Ln.NoFill == nil {
+		ln := pic.SpPr.Ln
+		border := &ImageBorder{
+			Width: float64(parseEMU(ln.W)) / 12700,
+			Style: ImageBorderSolid,
+		}
+		if ln.SolidFill != nil && ln.SolidFill.SrgbClr != nil {
+			border.Color = ln.SolidFill.SrgbClr.Val
+		}
+		if ln.PrstDash != nil {
+			border.Style = ImageBorderStyle(ln.PrstDash.Val)
+		}
+		config.Border = border
+	}
+
+	// 效果
+	blip := pic.BlipFill.Blip
+	if blip.Grayscale != nil || blip.Lum != nil {
+		effects := &ImageEffects{Grayscale: blip.Grayscale != nil}
+		if blip.Lum != nil {
+			effects.Brightness = int(parseEMU(blip.Lum.Bright) / 1000)
+			effects.Contrast = int(parseEMU(blip.Lum.Contrast) / 1000)
+		}
+		config.Effects = effects
+	}
+
+	return info
+}
+
+// findDocumentRelationship 根据关系ID查找文档关系
+func (d *Document) findDocumentRelationship(relationID string) *Relationship {
+	if d.documentRelationships == nil {
+		return nil
+	}
+	for i := range d.documentRelationships.Relationships {
+		if d.documentRelationships.Relationships[i].ID == relationID {
+			return &d.documentRelationships.Relationships[i]
+		}
+	}
+	return nil
+}
+
+// uniqueMediaTarget 生成不与现有部件冲突的媒体文件路径
+func (d *Document) uniqueMediaTarget(base, ext string) string {
+	target := fmt.Sprintf("%s.%s", base, ext)
+	for i := 1; ; i++ {
+		if _, exists := d.parts[relationshipPartName(target)]; !exists {
+			return target
+		}
+		target = fmt.Sprintf("%s_%d.%s", base, i, ext)
+	}
+}
+
+// relationshipPartName 将文档关系目标转换为包内部件名称
+func relationshipPartName(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Clean(path.Join("word", target))
+}
+
+// imageFormatExtension 返回图片格式对应的文件扩展名
+func imageFormatExtension(format ImageFormat) string {
+	return string(format)
+}
+
+// imageExtensionMatches 判断文件扩展名是否与图片格式一致
+func imageExtensionMatches(ext string, format ImageFormat) bool {
+	ext = strings.ToLower(ext)
+	if format == ImageFormatJPEG {
+		return ext == "jpeg" || ext == "jpg"
+	}
+	return ext == string(format)
+}
+
+// drawingPicture 获取绘图元素中的图片，非图片绘图返回nil
+func drawingPicture(drawing *DrawingElement) *PicElement {
+	if drawing == nil {
+		return nil
+	}
+	var graphic *DrawingGraphic
+	if drawing.Inline != nil {
+		graphic = drawing.Inline.Graphic
+	} else if drawing.Anchor != nil {
+		graphic = drawing.Anchor.Graphic
+	}
+	if graphic == nil || graphic.GraphicData == nil {
+		return nil
+	}
+	return graphic.GraphicData.Pic
+}
+
+// drawingDocPr 获取绘图元素的文档属性
+func drawingDocPr(drawing *DrawingElement) *DrawingDocPr {
+	if drawing == nil {
+		return nil
+	}
+	if drawing.Inline != nil {
+		return drawing.Inline.DocPr
+	}
+	if drawing.Anchor != nil {
+		return drawing.Anchor.DocPr
+	}
+	return nil
+}
+
+// drawingExtentEMU 获取绘图元素的显示尺寸（EMU单位）
+func drawingExtentEMU(drawing *DrawingElement) (int64, int64) {
+	var extent *DrawingExtent
+	if drawing.Inline != nil {
+		extent = drawing.Inline.Extent
+	} else if drawing.Anchor != nil {
+		extent = drawing.Anchor.Extent
+	}
+	if extent == nil {
+		return 0, 0
+	}
+	return parseEMU(extent.Cx), parseEMU(extent.Cy)
+}
+
+// setDrawingExtentEMU 设置绘图元素及其图片变换的显示尺寸（EMU单位）
+func setDrawingExtentEMU(drawing *DrawingElement, cx, cy int64) {
+	extent := &DrawingExtent{
+		Cx: strconv.FormatInt(cx, 10),
+		Cy: strconv.FormatInt(cy, 10),
+	}
+	if drawing.Inline != nil {
+		drawing.Inline.Extent = extent
+	} else if drawing.Anchor != nil {
+		drawing.Anchor.Extent = extent
+	}
+
+	if pic := drawingPicture(drawing); pic != nil {
+		if pic.SpPr == nil {
+			pic.SpPr = &SpPr{}
+		}
+		if pic.SpPr.Xfrm == nil {
+			pic.SpPr.Xfrm = &Xfrm{Off: &Off{X: "0", Y: "0"}}
+		}
+		pic.SpPr.Xfrm.Ext = &Ext{Cx: extent.Cx, Cy: extent.Cy}
+	}
+}
+
+// parseEMU 解析整数形式的EMU等数值，解析失败返回0
+func parseEMU(s string) int64 {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// percentToThousandths 百分比转换为千分之一百分比字符串
+func percentToThousandths(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64(math.Round(v*1000)), 10)
+}
+
+// thousandthsToPercent 千分之一百分比字符串转换为百分比
+func thousandthsToPercent(s string) float64 {
+	return float64(parseEMU(s)) / 1000
+}
+
+// parseDrawing 解析w:drawing元素，仅保留图片和形状绘图，其他绘图返回nil
+func (d *Document) parseDrawing(decoder *xml.Decoder, startElement xml.StartElement) (*DrawingElement, error) {
+	drawing := &DrawingElement{}
+
+	err := d.parseChildElements(decoder, "drawing", func(t xml.StartElement) error {
+		var err error
+		switch t.Name.Local {
+		case "inline":
+			drawing.Inline, err = d.parseInlineDrawing(decoder, t)
+		case "anchor":
+			drawing.Anchor, err = d.parseAnchorDrawing(decoder, t)
+		default:
+			err = d.skipElement(decoder, t.Name.Local)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, WrapError("parse_drawing", err)
+	}
+
+	if drawingPicture(drawing) == nil && drawingShape(drawing) == nil && drawingChart(drawing) == nil {
+		Debugf("跳过不支持的绘图元素")
+		return nil, nil
+	}
+
+	// 保证后续新增图片、形状的ID不与已有绘图冲突
+	if docPr := drawingDocPr(drawing); docPr != nil {
+		if id, err := strconv.Atoi(docPr.ID); err == nil && id >= d.nextImageID {
+			d.nextImageID = id + 1
+		}
+	}
+
+	return drawing, nil
+}
+
+// parseInlineDrawing 解析wp:inline元素
+func (d *Document) parseInlineDrawing(decoder *xml.Decoder, startElement xml.StartElement) (*InlineDrawing, error) {
+	inline := &InlineDrawing{
+		DistT: getAttributeValue(startElement.Attr, "distT"),
+		DistB: getAttributeValue(startElement.Attr, "distB"),
+		DistL: getAttributeValue(startElement.Attr, "distL"),
+		DistR: getAttributeValue(startElement.Attr, "distR"),
+	}
+
+	err := d.parseChildElements(decoder, "inline", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "extent":
+			inline.Extent = parseDrawingExtent(t)
+		case "effectExtent":
+			inline.EffectExtent = parseEffectExtent(t)
+		case "docPr":
+			inline.DocPr = parseDrawingDocPr(t)
+		case "cNvGraphicFramePr":
+			framePr, err := d.parseCNvGraphicFramePr(decoder)
+			inline.CNvGraphicFramePr = framePr
+			return err
+		case "graphic":
+			graphic, err := d.parseDrawingGraphic(decoder)
+			inline.Graphic = graphic
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return inline, err
+}
+
+// parseAnchorDrawing 解析wp:anchor元素
+func (d *Document) parseAnchorDrawing(decoder *xml.Decoder, startElement xml.StartElement) (*AnchorDrawing, error) {
+	attrs := startElement.Attr
+	anchor := &AnchorDrawing{
+		DistT:          getAttributeValue(attrs, "distT"),
+		DistB:          getAttributeValue(attrs, "distB"),
+		DistL:          getAttributeValue(attrs, "distL"),
+		DistR:          getAttributeValue(attrs, "distR"),
+		SimplePos:      getAttributeValue(attrs, "simplePos"),
+		RelativeHeight: getAttributeValue(attrs, "relativeHeight"),
+		BehindDoc:      getAttributeValue(attrs, "behindDoc"),
+		Locked:         getAttributeValue(attrs, "locked"),
+		LayoutInCell:   getAttributeValue(attrs, "layoutInCell"),
+		AllowOverlap:   getAttributeValue(attrs, "allowOverlap"),
+	}
+
+	err := d.parseChildElements(decoder, "anchor", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "simplePos":
+			anchor.SimplePosition = &SimplePosition{
+				X: getAttributeValue(t.Attr, "x"),
+				Y: getAttributeValue(t.Attr, "y"),
+			}
+		case "positionH":
+			align, offset, err := d.parseDrawingPosition(decoder, "positionH")
+			anchor.PositionH = &HorizontalPosition{
+				RelativeFrom: getAttributeValue(t.Attr, "relativeFrom"),
+				Align:        align,
+				PosOffset:    offset,
+			}
+			return err
+		case "positionV":
+			align, offset, err := d.parseDrawingPosition(decoder, "positionV")
+			anchor.PositionV = &VerticalPosition{
+				RelativeFrom: getAttributeValue(t.Attr, "relativeFrom"),
+				Align:        align,
+				PosOffset:    offset,
+			}
+			return err
+		case "extent":
+			anchor.Extent = parseDrawingExtent(t)
+		case "effectExtent":
+			anchor.EffectExtent = parseEffectExtent(t)
+		case "wrapNone":
+			anchor.WrapNone = &WrapNone{}
+		case "wrapSquare":
+			anchor.WrapSquare = &WrapSquare{
+				WrapText: getAttributeValue(t.Attr, "wrapText"),
+				DistT:    getAttributeValue(t.Attr, "distT"),
+				DistB:    getAttributeValue(t.Attr, "distB"),
+				DistL:    getAttributeValue(t.Attr, "distL"),
+				DistR:    getAttributeValue(t.Attr, "distR"),
+			}
+		case "wrapTight":
+			polygon, err := d.parseWrapPolygon(decoder, "wrapTight")
+			anchor.WrapTight = &WrapTight{
+				WrapText:    getAttributeValue(t.Attr, "wrapText"),
+				DistL:       getAttributeValue(t.Attr, "distL"),
+				DistR:       getAttributeValue(t.Attr, "distR"),
+				WrapPolygon: polygon,
+			}
+			return err
+		case "wrapThrough":
+			polygon, err := d.parseWrapPolygon(decoder, "wrapThrough")
+			anchor.WrapThrough = &WrapThrough{
+				WrapText:    getAttributeValue(t.Attr, "wrapText"),
+				DistL:       getAttributeValue(t.Attr, "distL"),
+				DistR:       getAttributeValue(t.Attr, "distR"),
+				WrapPolygon: polygon,
+			}
+			return err
+		case "wrapTopAndBottom":
+			anchor.WrapTopAndBottom = &WrapTopAndBottom{
+				DistT: getAttributeValue(t.Attr, "distT"),
+				DistB: getAttributeValue(t.Attr, "distB"),
+			}
+		case "docPr":
+			anchor.DocPr = parseDrawingDocPr(t)
+		case "cNvGraphicFramePr":
+			framePr, err := d.parseCNvGraphicFramePr(decoder)
+			anchor.CNvGraphicFramePr = framePr
+			return err
+		case "graphic":
+			graphic, err := d.parseDrawingGraphic(decoder)
+			anchor.Graphic = graphic
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return anchor, err
+}
+
+// parseDrawingPosition 解析wp:positionH/wp:positionV的对齐或偏移
+func (d *Document) parseDrawingPosition(decoder *xml.Decoder, elementName string) (*PosAlign, *PosOffset, error) {
+	var align *PosAlign
+	var offset *PosOffset
+
+	err := d.parseChildElements(decoder, elementName, func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "align":
+			value, err := d.readElementText(decoder, "align")
+			align = &PosAlign{Value: strings.TrimSpace(value)}
+			return err
+		case "posOffset":
+			value, err := d.readElementText(decoder, "posOffset")
+			offset = &PosOffset{Value: strings.TrimSpace(value)}
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return align, offset, err
+}
+
+// parseWrapPolygon 解析环绕元素中的wp:wrapPolygon
+func (d *Document) parseWrapPolygon(decoder *xml.Decoder, elementName string) (*WrapPolygon, error) {
+	var polygon *WrapPolygon
+
+	err := d.parseChildElements(decoder, elementName, func(t xml.StartElement) error {
+		if t.Name.Local != "wrapPolygon" {
+			return d.skipElement(decoder, t.Name.Local)
+		}
+		polygon = &WrapPolygon{}
+		return d.parseChildElements(decoder, "wrapPolygon", func(p xml.StartElement) error {
+			x := getAttributeValue(p.Attr, "x")
+			y := getAttributeValue(p.Attr, "y")
+			switch p.Name.Local {
+			case "start":
+				polygon.Start = &PolygonStart{X: x, Y: y}
+			case "lineTo":
+				polygon.LineTo = append(polygon.LineTo, PolygonLineTo{X: x, Y: y})
+			}
+			return d.skipElement(decoder, p.Name.Local)
+		})
+	})
+
+	return polygon, err
+}
+
+// parseCNvGraphicFramePr 解析wp:cNvGraphicFramePr元素
+func (d *Document) parseCNvGraphicFramePr(decoder *xml.Decoder) (*CNvGraphicFramePr, error) {
+	framePr := &CNvGraphicFramePr{}
+
+	err := d.parseChildElements(decoder, "cNvGraphicFramePr", func(t xml.StartElement) error {
+		if t.Name.Local == "graphicFrameLocks" {
+			framePr.GraphicFrameLocks = &GraphicFrameLocks{
+				Xmlns:          drawingMLNamespace,
+				NoChangeAspect: getAttributeValue(t.Attr, "noChangeAspect"),
+				NoCrop:         getAttributeValue(t.Attr, "noCrop"),
+				NoMove:         getAttributeValue(t.Attr, "noMove"),
+				NoResize:       getAttributeValue(t.Attr, "noResize"),
+				NoRot:          getAttributeValue(t.Attr, "noRot"),
+				NoSelect:       getAttributeValue(t.Attr, "noSelect"),
+			}
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return framePr, err
+}
+
+// parseDrawingGraphic 解析a:graphic元素中的图片、形状或图表数据
+func (d *Document) parseDrawingGraphic(decoder *xml.Decoder) (*DrawingGraphic, error) {
+	graphic := &DrawingGraphic{Xmlns: drawingMLNamespace}
+
+	err := d.parseChildElements(decoder, "graphic", func(t xml.StartElement) error {
+		if t.Name.Local != "graphicData" {
+			return d.skipElement(decoder, t.Name.Local)
+		}
+		graphic.GraphicData = &GraphicData{Uri: getAttributeValue(t.Attr, "uri")}
+		return d.parseChildElements(decoder, "graphicData", func(c xml.StartElement) error {
+			switch c.Name.Local {
+			case "pic":
+				pic, err := d.parsePicElement(decoder)
+				graphic.GraphicData.Pic = pic
+				return err
+			case "wsp":
+				wsp, err := d.parseWordprocessingShape(decoder)
+				graphic.GraphicData.Wsp = wsp
+				return err
+			case "chart":
+				graphic.GraphicData.Chart = &ChartReference{
+					XmlnsC: chartNamespace,
+					XmlnsR: officeRelationshipsNamespace,
+					ID:     getAttributeValue(c.Attr, "id"),
+				}
+			}
+			return d.skipElement(decoder, c.Name.Local)
+		})
+	})
+
+	return graphic, err
+}
+
+// parsePicElement 解析pic:pic元素
+func (d *Document) parsePicElement(decoder *xml.Decoder) (*PicElement, error) {
+	pic := &PicElement{Xmlns: drawingMLPictureNamespace}
+
+	err := d.parseChildElements(decoder, "pic", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "nvPicPr":
+			pic.NvPicPr = &NvPicPr{}
+			return d.parseChildElements(decoder, "nvPicPr", func(c xml.StartElement) error {
+				switch c.Name.Local {
+				case "cNvPr":
+					pic.NvPicPr.CNvPr = &CNvPr{
+						ID:    getAttributeValue(c.Attr, "id"),
+						Name:  getAttributeValue(c.Attr, "name"),
+						Descr: getAttributeValue(c.Attr, "descr"),
+						Title: getAttributeValue(c.Attr, "title"),
+					}
+				case "cNvPicPr":
+					pic.NvPicPr.CNvPicPr = &CNvPicPr{}
+					return d.parseChildElements(decoder, "cNvPicPr", func(l xml.StartElement) error {
+						if l.Name.Local == "picLocks" {
+							pic.NvPicPr.CNvPicPr.PicLocks = &PicLocks{
+								NoChangeAspect:     getAttributeValue(l.Attr, "noChangeAspect"),
+								NoChangeArrowheads: getAttributeValue(l.Attr, "noChangeArrowheads"),
+							}
+						}
+						return d.skipElement(decoder, l.Name.Local)
+					})
+				}
+				return d.skipElement(decoder, c.Name.Local)
+			})
+		case "blipFill":
+			blipFill, err := d.parseBlipFill(decoder)
+			pic.BlipFill = blipFill
+			return err
+		case "spPr":
+			spPr, err := d.parsePicShapeProperties(decoder)
+			pic.SpPr = spPr
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return pic, err
+}
+
+// parseBlipFill 解析pic:blipFill元素
+func (d *Document) parseBlipFill(decoder *xml.Decoder) (*BlipFill, error) {
+	blipFill := &BlipFill{}
+
+	err := d.parseChildElements(decoder, "blipFill", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "blip":
+			blip := &Blip{Embed: getAttributeValue(t.Attr, "embed")}
+			blipFill.Blip = blip
+			return d.parseChildElements(decoder, "blip", func(e xml.StartElement) error {
+				switch e.Name.Local {
+				case "grayscl":
+					blip.Grayscale = &Grayscale{}
+				case "lum":
+					blip.Lum = &Lum{
+						Bright:   getAttributeValue(e.Attr, "bright"),
+						Contrast: getAttributeValue(e.Attr, "contrast"),
+					}
+				}
+				return d.skipElement(decoder, e.Name.Local)
+			})
+		case "srcRect":
+			rect := &SrcRect{
+				L: getAttributeValue(t.Attr, "l"),
+				T: getAttributeValue(t.Attr, "t"),
+				R: getAttributeValue(t.Attr, "r"),
+				B: getAttributeValue(t.Attr, "b"),
+			}
+			if rect.L != "" || rect.T != "" || rect.R != "" || rect.B != "" {
+				blipFill.SrcRect = rect
+			}
+		case "stretch":
+			blipFill.Stretch = &Stretch{FillRect: &FillRect{}}
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	if blipFill.Stretch == nil {
+		blipFill.Stretch = &Stretch{FillRect: &FillRect{}}
+	}
+	return blipFill, err
+}
+
+// parsePicShapeProperties 解析pic:spPr元素
+func (d *Document) parsePicShapeProperties(decoder *xml.Decoder) (*SpPr, error) {
+	spPr := &SpPr{}
+
+	err := d.parseChildElements(decoder, "spPr", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "xfrm":
+			xfrm := &Xfrm{
+				Rot:   getAttributeValue(t.Attr, "rot"),
+				FlipH: getAttributeValue(t.Attr, "flipH"),
+				FlipV: getAttributeValue(t.Attr, "flipV"),
+			}
+			spPr.Xfrm = xfrm
+			return d.parseChildElements(decoder, "xfrm", func(c xml.StartElement) error {
+				switch c.Name.Local {
+				case "off":
+					xfrm.Off = &Off{X: getAttributeValue(c.Attr, "x"), Y: getAttributeValue(c.Attr, "y")}
+				case "ext":
+					xfrm.Ext = &Ext{Cx: getAttributeValue(c.Attr, "cx"), Cy: getAttributeValue(c.Attr, "cy")}
+				}
+				return d.skipElement(decoder, c.Name.Local)
+			})
+		case "prstGeom":
+			spPr.PrstGeom = &PrstGeom{Prst: getAttributeValue(t.Attr, "prst"), AvLst: &AvLst{}}
+		case "ln":
+			ln, err := d.parseLn(decoder, t)
+			spPr.Ln = ln
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	if spPr.PrstGeom == nil {
+		spPr.PrstGeom = &PrstGeom{Prst: "rect", AvLst: &AvLst{}}
+	}
+	return spPr, err
+}
+
+// parseLn 解析a:ln线条元素
+func (d *Document) parseLn(decoder *xml.Decoder, startElement xml.StartElement) (*Ln, error) {
+	ln := &Ln{W: getAttributeValue(startElement.Attr, "w")}
+
+	err := d.parseChildElements(decoder, "ln", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "noFill":
+			ln.NoFill = &NoFill{}
+		case "solidFill":
+			fill, err := d.parseSolidFill(decoder)
+			ln.SolidFill = fill
+			return err
+		case "prstDash":
+			ln.PrstDash = &PrstDash{Val: getAttributeValue(t.Attr, "val")}
+		case "headEnd", "tailEnd":
+			end := &LineEnd{
+				Type: getAttributeValue(t.Attr, "type"),
+				W:    getAttributeValue(t.Attr, "w"),
+				Len:  getAttributeValue(t.Attr, "len"),
+			}
+			if t.Name.Local == "headEnd" {
+				ln.HeadEnd = end
+			} else {
+				ln.TailEnd = end
+			}
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return ln, err
+}
+
+// parseSolidFill 解析a:solidFill元素，仅支持RGB颜色
+func (d *Document) parseSolidFill(decoder *xml.Decoder) (*SolidFill, error) {
+	fill := &SolidFill{}
+
+	err := d.parseChildElements(decoder, "solidFill", func(t xml.StartElement) error {
+		if t.Name.Local == "srgbClr" {
+			fill.SrgbClr = &SrgbClr{Val: getAttributeValue(t.Attr, "val")}
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return fill, err
+}
+
+// parseDrawingExtent 解析wp:extent元素
+func parseDrawingExtent(t xml.StartElement) *DrawingExtent {
+	return &DrawingExtent{
+		Cx: getAttributeValue(t.Attr, "cx"),
+		Cy: getAttributeValue(t.Attr, "cy"),
+	}
+}
+
+// parseEffectExtent 解析wp:effectExtent元素
+func parseEffectExtent(t xml.StartElement) *EffectExtent {
+	return &EffectExtent{
+		L: getAttributeValue(t.Attr, "l"),
+		T: getAttributeValue(t.Attr, "t"),
+		R: getAttributeValue(t.Attr, "r"),
+		B: getAttributeValue(t.Attr, "b"),
+	}
+}
+
+// parseDrawingDocPr 解析wp:docPr元素
+func parseDrawingDocPr(t xml.StartElement) *DrawingDocPr {
+	return &DrawingDocPr{
+		ID:    getAttributeValue(t.Attr, "id"),
+		Name:  getAttributeValue(t.Attr, "name"),
+		Descr: getAttributeValue(t.Attr, "descr"),
+		Title: getAttributeValue(t.Attr, "title"),
+	}
+}
+
+// parseChildElements 依次处理当前元素的直接子元素，直到遇到名为elementName的结束标签。
+// handler 负责完整消费传入的子元素（包括其结束标签）。
+func (d *Document) parseChildElements(decoder *xml.Decoder, elementName string, handler func(t xml.StartElement) error) error {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return WrapError("parse_child_elements", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := handler(t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if t.Name.Local == elementName {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package document
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createTestJPEG 创建一个测试用的JPEG图片
+func createTestJPEG(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, nil)
+	return buf.Bytes()
+}
+
+// saveAndReopen 保存文档并重新打开
+func saveAndReopen(t *testing.T, doc *Document) *Document {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "reopen.docx")
+	if err := doc.Save(filename); err != nil {
+		t.Fatalf("保存文档失败: %v", err)
+	}
+
+	reopened, err := Open(filename)
+	if err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	return reopened
+}
+
+func TestListImagesFromOpenedDocument(t *testing.T) {
+	doc := New()
+	doc.AddParagraph("标题")
+	_, err := doc.AddImageFromData(createTestImage(100, 50), "logo.png", ImageFormatPNG, 100, 50, &ImageConfig{
+		Position:  ImagePositionInline,
+		Alignment: AlignCenter,
+		AltText:   "logo",
+		Size:      &ImageSize{Width: 40, Height: 20},
+	})
+	if err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	_, err = doc.AddImageFromData(createTestImage(20, 20), "float.png", ImageFormatPNG, 20, 20, &ImageConfig{
+		Position: ImagePositionFloatRight,
+		WrapText: ImageWrapTopAndBottom,
+	})
+	if err != nil {
+		t.Fatalf("添加浮动图片失败: %v", err)
+	}
+
+	opened := saveAndReopen(t, doc)
+	images := opened.ListImages()
+	if len(images) != 2 {
+		t.Fatalf("期望2张图片，得到 %d", len(images))
+	}
+
+	logo := images[0]
+	if logo.Format != ImageFormatPNG || logo.Width != 100 || logo.Height != 50 {
+		t.Errorf("图片信息不正确: 格式 %s, 尺寸 %dx%d", logo.Format, logo.Width, logo.Height)
+	}
+	if logo.Config.AltText != "logo" || logo.Config.Alignment != AlignCenter {
+		t.Errorf("图片配置不正确: %+v", logo.Config)
+	}
+	if logo.Config.Size == nil || int(logo.Config.Size.Width) != 40 || int(logo.Config.Size.Height) != 20 {
+		t.Errorf("图片显示尺寸不正确: %+v", logo.Config.Size)
+	}
+
+	floating := images[1]
+	if floating.Config.Position != ImagePositionFloatRight || floating.Config.WrapText != ImageWrapTopAndBottom {
+		t.Errorf("浮动图片配置不正确: 位置 %s, 环绕 %s", floating.Config.Position, floating.Config.WrapText)
+	}
+
+	// 新增图片的ID不应与已有图片冲突
+	added, err := opened.AddImageFromData(createTestImage(10, 10), "new.png", ImageFormatPNG, 10, 10, nil)
+	if err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	if added.ID == logo.ID || added.ID == floating.ID {
+		t.Errorf("新图片ID %s 与已有图片冲突", added.ID)
+	}
+}
+
+func TestReplaceImageData(t *testing.T) {
+	doc := New()
+	_, err := doc.AddImageFromData(createTestImage(100, 50), "logo.png", ImageFormatPNG, 100, 50, &ImageConfig{
+		Size: &ImageSize{Width: 40, Height: 20},
+	})
+	if err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	opened := saveAndReopen(t, doc)
+	info := opened.ListImages()[0]
+
+	// 相同格式替换，保持尺寸
+	if err := opened.ReplaceImageData(info, createTestImage(30, 30)); err != nil {
+		t.Fatalf("替换图片失败: %v", err)
+	}
+	if int(info.Config.Size.Width) != 40 || int(info.Config.Size.Height) != 20 {
+		t.Errorf("保持尺寸模式下显示尺寸被改变: %+v", info.Config.Size)
+	}
+
+	// 不同格式替换，按比例适应原区域
+	if err := opened.ReplaceImageDataWithMode(info, createTestJPEG(30, 30), ImageReplaceFit); err != nil {
+		t.Fatalf("替换为JPEG失败: %v", err)
+	}
+	if int(info.Config.Size.Width) != 20 || int(info.Config.Size.Height) != 20 {
+		t.Errorf("适应模式下显示尺寸不正确: %+v", info.Config.Size)
+	}
+
+	reopened := saveAndReopen(t, opened)
+	images := reopened.ListImages()
+	if len(images) != 1 {
+		t.Fatalf("期望1张图片，得到 %d", len(images))
+	}
+	if images[0].Format != ImageFormatJPEG || images[0].Width != 30 {
+		t.Errorf("替换后的图片不正确: 格式 %s, 宽度 %d", images[0].Format, images[0].Width)
+	}
+	rel := reopened.findDocumentRelationship(images[0].RelationID)
+	if rel == nil || !strings.HasSuffix(rel.Target, ".jpeg") {
+		t.Errorf("替换后的关系目标不正确: %+v", rel)
+	}
+}
+
+func TestImageAppearanceEditing(t *testing.T) {
+	doc := New()
+	info, err := doc.AddImageFromData(createTestImage(100, 50), "logo.png", ImageFormatPNG, 100, 50, nil)
+	if err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	if err := doc.SetImageCrop(info, &ImageCrop{Left: 10, Right: 20}); err != nil {
+		t.Fatalf("设置裁剪失败: %v", err)
+	}
+	if err := doc.SetImageRotation(info, -90); err != nil {
+		t.Fatalf("设置旋转失败: %v", err)
+	}
+	if err := doc.SetImageFlip(info, true, false); err != nil {
+		t.Fatalf("设置翻转失败: %v", err)
+	}
+	if err := doc.SetImageBorder(info, &ImageBorder{Width: 1.5, Color: "#ff0000", Style: ImageBorderDash}); err != nil {
+		t.Fatalf("设置边框失败: %v", err)
+	}
+	if err := doc.SetImageEffects(info, &ImageEffects{Grayscale: true, Brightness: 20}); err != nil {
+		t.Fatalf("设置效果失败: %v", err)
+	}
+
+	opened := saveAndReopen(t, doc)
+	config := opened.ListImages()[0].Config
+
+	if config.Crop == nil || config.Crop.Left != 10 || config.Crop.Right != 20 {
+		t.Errorf("裁剪未正确保存: %+v", config.Crop)
+	}
+	if config.Rotation != 270 {
+		t.Errorf("期望旋转270度，得到 %v", config.Rotation)
+	}
+	if !config.FlipH || config.FlipV {
+		t.Errorf("翻转未正确保存: flipH=%v flipV=%v", config.FlipH, config.FlipV)
+	}
+	if config.Border == nil || config.Border.Width != 1.5 || config.Border.Color != "FF0000" || config.Border.Style != ImageBorderDash {
+		t.Errorf("边框未正确保存: %+v", config.Border)
+	}
+	if config.Effects == nil || !config.Effects.Grayscale || config.Effects.Brightness != 20 {
+		t.Errorf("效果未正确保存: %+v", config.Effects)
+	}
+
+	// 清除设置
+	info = opened.ListImages()[0]
+	if err := opened.SetImageCrop(info, nil); err != nil {
+		t.Fatalf("取消裁剪失败: %v", err)
+	}
+	if err := opened.SetImageBorder(info, nil); err != nil {
+		t.Fatalf("移除边框失败: %v", err)
+	}
+	config = opened.ListImages()[0].Config
+	if config.Crop != nil || config.Border != nil {
+		t.Errorf("裁剪或边框未被清除: crop=%+v border=%+v", config.Crop, config.Border)
+	}
+}
+
+func TestImageEditingValidation(t *testing.T) {
+	doc := New()
+	info, err := doc.AddImageFromData(createTestImage(10, 10), "a.png", ImageFormatPNG, 10, 10, nil)
+	if err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	if err := doc.SetImageCrop(info, &ImageCrop{Left: 60, Right: 50}); err == nil {
+		t.Error("期望裁剪范围无效时返回错误")
+	}
+	if err := doc.SetImageEffects(info, &ImageEffects{Contrast: 150}); err == nil {
+		t.Error("期望对比度超出范围时返回错误")
+	}
+	if err := doc.SetImageRotation(&ImageInfo{ID: "999"}, 90); err == nil {
+		t.Error("期望找不到图片时返回错误")
+	}
+	if err := doc.ReplaceImageData(info, []byte("not an image")); err == nil {
+		t.Error("期望无效图片数据时返回错误")
+	}
+}
@@ -0,0 +1,469 @@
+// Package document 提供Word文档图片压缩与降采样功能
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path"
+	"strings"
+)
+
+const (
+	// DefaultImageMaxDPI 默认的图片最大分辨率
+	DefaultImageMaxDPI = 150
+	// DefaultJPEGQuality 默认的JPEG压缩质量
+	DefaultJPEGQuality = 85
+
+	// emuPerInch 每英寸对应的EMU数
+	emuPerInch = 914400
+	// photoColorThreshold 判定为照片的采样颜色数阈值
+	photoColorThreshold = 1024
+
+	// imageRelationshipType 图片关系类型
+	imageRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+)
+
+// ImageOptimizeOptions 图片优化选项
+type ImageOptimizeOptions struct {
+	// 图片最大分辨率（按显示尺寸计算），超出部分会被降采样，0表示使用默认值
+	MaxDPI int
+	// JPEG压缩质量（1-100），0表示使用默认值。
+	// 被页眉页脚等其他部件引用的JPEG图片不会重新压缩
+	JPEGQuality int
+	// 将不透明的PNG照片转换为JPEG
+	ConvertOpaquePNGToJPEG bool
+	// 移除未被引用的媒体文件
+	RemoveUnusedMedia bool
+}
+
+// ImageOptimizeResult 图片优化结果
+type ImageOptimizeResult struct {
+	ImagesProcessed int   // 处理的图片数量
+	ImagesResized   int   // 降采样的图片数量
+	ImagesConverted int   // 由PNG转换为JPEG的图片数量
+	MediaRemoved    int   // 移除的未引用媒体文件数量
+	BytesBefore     int64 // 优化前媒体文件总大小
+	BytesAfter      int64 // 优化后媒体文件总大小
+}
+
+// SaveOptions 保存选项
+type SaveOptions struct {
+	// 保存前优化图片，nil表示不优化
+	ImageOptimization *ImageOptimizeOptions
+}
+
+// SaveWithOptions 按指定选项保存文档。
+//
+// 示例:
+//
+//	err := doc.SaveWithOptions("report.docx", &document.SaveOptions{
+//		ImageOptimization: &document.ImageOptimizeOptions{
+//			MaxDPI:            150,
+//			JPEGQuality:       80,
+//			RemoveUnusedMedia: true,
+//		},
+//	})
+func (d *Document) SaveWithOptions(filename string, options *SaveOptions) error {
+	if options != nil && options.ImageOptimization != nil {
+		result, err := d.OptimizeImagesWithOptions(options.ImageOptimization)
+		if err != nil {
+			return WrapErrorWithContext("optimize_images", err, filename)
+		}
+		Infof("图片优化完成: 处理 %d 张，降采样 %d 张，转换 %d 张，移除 %d 个媒体文件，%d → %d 字节",
+			result.ImagesProcessed, result.ImagesResized, result.ImagesConverted, result.MediaRemoved,
+			result.BytesBefore, result.BytesAfter)
+	}
+	return d.Save(filename)
+}
+
+// OptimizeImages 按最大分辨率和JPEG质量优化文档中的图片，并移除未引用的媒体文件。
+//
+// 图片的像素尺寸超过其显示尺寸在 maxDPI 下所需的像素数时会被降采样，
+// JPEG图片会按 jpegQuality 重新压缩。只有在结果更小时才会替换原数据。
+// 被页眉页脚等其他部件引用的图片无法确定显示尺寸，只对PNG做无损重新压缩。
+func (d *Document) OptimizeImages(maxDPI, jpegQuality int) (*ImageOptimizeResult, error) {
+	return d.OptimizeImagesWithOptions(&ImageOptimizeOptions{
+		MaxDPI:            maxDPI,
+		JPEGQuality:       jpegQuality,
+		RemoveUnusedMedia: true,
+	})
+}
+
+// OptimizeImagesWithOptions 按指定选项优化文档中的图片
+func (d *Document) OptimizeImagesWithOptions(options *ImageOptimizeOptions) (*ImageOptimizeResult, error) {
+	opts := ImageOptimizeOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.MaxDPI <= 0 {
+		opts.MaxDPI = DefaultImageMaxDPI
+	}
+	if opts.JPEGQuality <= 0 {
+		opts.JPEGQuality = DefaultJPEGQuality
+	}
+	if opts.JPEGQuality > 100 {
+		return nil, NewValidationError("jpegQuality", fmt.Sprintf("%d", opts.JPEGQuality), "JPEG质量必须在1到100之间")
+	}
+
+	result := &ImageOptimizeResult{}
+	for name, data := range d.parts {
+		if isMediaPart(name) {
+			result.BytesBefore += int64(len(data))
+		}
+	}
+
+	// 统计每个媒体文件的最大显示尺寸
+	extents := d.collectMediaExtents()
+	externalRefs := d.collectExternalMediaReferences()
+
+	for partName, extent := range extents {
+		data, ok := d.parts[partName]
+		if !ok {
+			continue
+		}
+		format, err := detectImageFormat(data)
+		if err != nil || format == ImageFormatGIF {
+			// 不处理未知格式和可能包含动画的GIF
+			continue
+		}
+
+		// 被其他部件（如页眉页脚）引用的图片无法确定显示尺寸，只做无损重新压缩，
+		// 重新编码JPEG会损失画质，因此保持原数据
+		targetCx, targetCy := extent.cx, extent.cy
+		if externalRefs[partName] {
+			if format == ImageFormatJPEG {
+				continue
+			}
+			targetCx, targetCy = 0, 0
+		}
+
+		result.ImagesProcessed++
+
+		optimized, resized, err := optimizeImageData(data, format, targetCx, targetCy, opts)
+		if err != nil {
+			Debugf("优化图片 %s 失败，保留原数据: %v", partName, err)
+			continue
+		}
+		if resized {
+			result.ImagesResized++
+		}
+
+		// 不透明的PNG照片转换为JPEG
+		if format == ImageFormatPNG && opts.ConvertOpaquePNGToJPEG && !externalRefs[partName] {
+			if converted, ok := convertPNGPhotoToJPEG(optimized, opts.JPEGQuality); ok && len(converted) < len(optimized) {
+				d.moveMediaPart(partName, converted, ImageFormatJPEG)
+				result.ImagesConverted++
+				continue
+			}
+		}
+
+		if len(optimized) < len(data) || resized {
+			d.parts[partName] = optimized
+		}
+	}
+
+	if opts.RemoveUnusedMedia {
+		result.MediaRemoved = d.removeUnusedMedia()
+	}
+
+	for name, data := range d.parts {
+		if isMediaPart(name) {
+			result.BytesAfter += int64(len(data))
+		}
+	}
+
+	return result, nil
+}
+
+// mediaExtent 媒体文件完整图片的显示尺寸（EMU单位），裁剪后的图片按可见比例换算
+type mediaExtent struct {
+	cx, cy int64
+}
+
+// collectMediaExtents 收集文档中每个图片媒体文件的最大显示尺寸
+func (d *Document) collectMediaExtents() map[string]mediaExtent {
+	extents := make(map[string]mediaExtent)
+
+	d.forEachImageRun(func(para *Paragraph, run *Run) bool {
+		pic := drawingPicture(run.Drawing)
+		if pic.BlipFill == nil || pic.BlipFill.Blip == nil {
+			return true
+		}
+		rel := d.findDocumentRelationship(pic.BlipFill.Blip.Embed)
+		if rel == nil {
+			return true
+		}
+
+		partName := relationshipPartName(rel.Target)
+		cx, cy := drawingExtentEMU(run.Drawing)
+		cx, cy = uncroppedExtentEMU(cx, cy, pic.BlipFill.SrcRect)
+		extent := extents[partName]
+		if cx > extent.cx {
+			extent.cx = cx
+		}
+		if cy > extent.cy {
+			extent.cy = cy
+		}
+		extents[partName] = extent
+		return true
+	})
+
+	return extents
+}
+
+// uncroppedExtentEMU 将裁剪后图片的显示尺寸换算为完整图片的显示尺寸，
+// 即除以可见部分的比例（1-左-右，1-上-下）
+func uncroppedExtentEMU(cx, cy int64, rect *SrcRect) (int64, int64) {
+	if rect == nil {
+		return cx, cy
+	}
+	// 裁剪值单位为千分之一百分比
+	visibleW := 1 - float64(parseEMU(rect.L)+parseEMU(rect.R))/100000
+	visibleH := 1 - float64(parseEMU(rect.T)+parseEMU(rect.B))/100000
+	if visibleW > 0 {
+		cx = int64(float64(cx)/visibleW + 0.5)
+	}
+	if visibleH > 0 {
+		cy = int64(float64(cy)/visibleH + 0.5)
+	}
+	return cx, cy
+}
+
+// collectExternalMediaReferences 收集文档主体以外的部件（页眉、页脚等）所引用的媒体文件
+func (d *Document) collectExternalMediaReferences() map[string]bool {
+	refs := make(map[string]bool)
+
+	for name, data := range d.parts {
+		if !strings.HasSuffix(name, ".rels") || name == "word/_rels/document.xml.rels" {
+			continue
+		}
+
+		var rels Relationships
+		if err := xml.Unmarshal(data, &rels); err != nil {
+			continue
+		}
+
+		// 关系目标相对于被描述部件所在目录
+		baseDir := path.Dir(path.Dir(name))
+		for _, rel := range rels.Relationships {
+			if rel.TargetMode == "External" {
+				continue
+			}
+			target := rel.Target
+			if strings.HasPrefix(target, "/") {
+				target = strings.TrimPrefix(target, "/")
+			} else {
+				target = path.Clean(path.Join(baseDir, target))
+			}
+			refs[target] = true
+		}
+	}
+
+	return refs
+}
+
+// removeUnusedMedia 移除未被任何图片引用的图片关系和媒体文件，返回移除的媒体文件数量
+func (d *Document) removeUnusedMedia() int {
+	// 文档主体中实际使用的关系
+	usedRels := make(map[string]bool)
+	d.forEachImageRun(func(para *Paragraph, run *Run) bool {
+		if pic := drawingPicture(run.Drawing); pic.BlipFill != nil && pic.BlipFill.Blip != nil {
+			usedRels[pic.BlipFill.Blip.Embed] = true
+		}
+		return true
+	})
+
+	// 移除未使用的图片关系
+	referenced := d.collectExternalMediaReferences()
+	if d.documentRelationships != nil {
+		kept := d.documentRelationships.Relationships[:0]
+		for _, rel := range d.documentRelationships.Relationships {
+			if rel.Type == imageRelationshipType && !usedRels[rel.ID] {
+				Debugf("移除未使用的图片关系: %s -> %s", rel.ID, rel.Target)
+				continue
+			}
+			kept = append(kept, rel)
+			if rel.TargetMode != "External" {
+				referenced[relationshipPartName(rel.Target)] = true
+			}
+		}
+		d.documentRelationships.Relationships = kept
+	}
+
+	// 移除未被引用的媒体文件
+	removed := 0
+	for name := range d.parts {
+		if isMediaPart(name) && !referenced[name] {
+			Debugf("移除未引用的媒体文件: %s", name)
+			delete(d.parts, name)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// moveMediaPart 将媒体数据以新格式写入新部件，更新引用它的关系并删除旧部件
+func (d *Document) moveMediaPart(partName string, data []byte, format ImageFormat) {
+	base := strings.TrimSuffix(strings.TrimPrefix(partName, "word/"), path.Ext(partName))
+	target := d.uniqueMediaTarget(base, imageFormatExtension(format))
+	d.parts[relationshipPartName(target)] = data
+	d.addImageContentType(format)
+
+	for i := range d.documentRelationships.Relationships {
+		rel := &d.documentRelationships.Relationships[i]
+		if rel.TargetMode != "External" && relationshipPartName(rel.Target) == partName {
+			rel.Target = target
+		}
+	}
+	delete(d.parts, partName)
+}
+
+// optimizeImageData 按显示尺寸降采样并重新编码图片，返回新数据以及是否进行了降采样
+func optimizeImageData(data []byte, format ImageFormat, cx, cy int64, opts ImageOptimizeOptions) ([]byte, bool, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, fmt.Errorf("解码图片失败: %v", err)
+	}
+
+	resized := false
+	bounds := img.Bounds()
+	if cx > 0 && cy > 0 {
+		// 显示尺寸在最大分辨率下需要的像素数
+		needW := int((cx*int64(opts.MaxDPI) + emuPerInch - 1) / emuPerInch)
+		needH := int((cy*int64(opts.MaxDPI) + emuPerInch - 1) / emuPerInch)
+		if needW > 0 && needH > 0 && bounds.Dx() > needW && bounds.Dy() > needH {
+			// 保持原始长宽比
+			scale := float64(needW) / float64(bounds.Dx())
+			if s := float64(needH) / float64(bounds.Dy()); s > scale {
+				scale = s
+			}
+			w := int(float64(bounds.Dx())*scale + 0.5)
+			h := int(float64(bounds.Dy())*scale + 0.5)
+			if w < bounds.Dx() && h < bounds.Dy() {
+				img = downscaleImage(img, w, h)
+				resized = true
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case ImageFormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.JPEGQuality})
+	case ImageFormatPNG:
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err = encoder.Encode(&buf, img)
+	default:
+		return nil, false, fmt.Errorf("不支持的图片格式: %s", format)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("编码图片失败: %v", err)
+	}
+
+	return buf.Bytes(), resized, nil
+}
+
+// convertPNGPhotoToJPEG 将不透明且色彩丰富的PNG图片转换为JPEG
+func convertPNGPhotoToJPEG(data []byte, quality int) ([]byte, bool) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil || !isOpaqueImage(img) || !isPhotographic(img) {
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// isOpaqueImage 判断图片是否完全不透明
+func isOpaqueImage(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isPhotographic 通过采样颜色数判断图片是否为照片（而非图标、线稿等）
+func isPhotographic(img image.Image) bool {
+	bounds := img.Bounds()
+	step := 1
+	for (bounds.Dx()/step)*(bounds.Dy()/step) > 40000 {
+		step++
+	}
+
+	colors := make(map[uint32]struct{})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			colors[(r>>8)<<16|(g>>8)<<8|b>>8] = struct{}{}
+			if len(colors) > photoColorThreshold {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// downscaleImage 使用区域平均法将图片缩小到指定尺寸
+func downscaleImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := bounds.Min.Y + (y+1)*srcH/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := bounds.Min.X + (x+1)*srcW/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+
+			// RGBA()返回预乘的16位分量，直接平均后转换为8位
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return dst
+}
+
+// isMediaPart 判断部件是否为媒体文件
+func isMediaPart(name string) bool {
+	return strings.HasPrefix(name, "word/media/")
+}
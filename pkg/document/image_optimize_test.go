@@ -0,0 +1,254 @@
+package document
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// createNoisyPNG 创建一个色彩丰富且不透明的PNG图片（模拟照片）
+func createNoisyPNG(width, height int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x + rng.Intn(32)), uint8(y + rng.Intn(32)), uint8(rng.Intn(256)), 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestOptimizeImagesDownscale(t *testing.T) {
+	doc := New()
+	info, err := doc.AddImageFromData(createTestJPEG(2000, 1000), "photo.jpeg", ImageFormatJPEG, 2000, 1000, &ImageConfig{
+		Size: &ImageSize{Width: 50, KeepAspectRatio: true},
+	})
+	if err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	result, err := doc.OptimizeImages(150, 80)
+	if err != nil {
+		t.Fatalf("优化图片失败: %v", err)
+	}
+	if result.ImagesProcessed != 1 || result.ImagesResized != 1 {
+		t.Errorf("期望处理并降采样1张图片，得到 %+v", result)
+	}
+	if result.BytesAfter >= result.BytesBefore {
+		t.Errorf("优化后体积未减小: %d -> %d", result.BytesBefore, result.BytesAfter)
+	}
+
+	images := doc.ListImages()
+	if len(images) != 1 {
+		t.Fatalf("期望1张图片，得到 %d", len(images))
+	}
+	// 50毫米在150 DPI下约需296像素
+	if images[0].Width < 295 || images[0].Width > 300 {
+		t.Errorf("降采样后宽度不正确: %d", images[0].Width)
+	}
+	if images[0].Height*2 < images[0].Width-2 || images[0].Height*2 > images[0].Width+2 {
+		t.Errorf("降采样后长宽比不正确: %dx%d", images[0].Width, images[0].Height)
+	}
+	// 显示尺寸保持不变
+	if int(images[0].Config.Size.Width) != int(info.Config.Size.Width) {
+		t.Errorf("显示尺寸被改变: %v", images[0].Config.Size)
+	}
+}
+
+func TestOptimizeImagesSmallImageUnchanged(t *testing.T) {
+	doc := New()
+	data := createTestImage(50, 50)
+	if _, err := doc.AddImageFromData(data, "small.png", ImageFormatPNG, 50, 50, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	result, err := doc.OptimizeImages(150, 80)
+	if err != nil {
+		t.Fatalf("优化图片失败: %v", err)
+	}
+	if result.ImagesResized != 0 {
+		t.Errorf("小图片不应被降采样: %+v", result)
+	}
+	if img := doc.ListImages()[0]; img.Width != 50 || img.Height != 50 {
+		t.Errorf("小图片尺寸被改变: %dx%d", img.Width, img.Height)
+	}
+}
+
+func TestOptimizeImagesConvertAndRemoveUnused(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddImageFromData(createNoisyPNG(200, 200), "photo.png", ImageFormatPNG, 200, 200, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	if _, err := doc.AddImageFromData(createTestImage(40, 40), "icon.png", ImageFormatPNG, 40, 40, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	// 未被引用的媒体文件
+	doc.parts["word/media/orphan.png"] = createTestImage(10, 10)
+
+	result, err := doc.OptimizeImagesWithOptions(&ImageOptimizeOptions{
+		ConvertOpaquePNGToJPEG: true,
+		RemoveUnusedMedia:      true,
+	})
+	if err != nil {
+		t.Fatalf("优化图片失败: %v", err)
+	}
+	if result.ImagesConverted != 1 {
+		t.Errorf("期望转换1张PNG照片，得到 %d", result.ImagesConverted)
+	}
+	if result.MediaRemoved != 1 {
+		t.Errorf("期望移除1个未引用媒体文件，得到 %d", result.MediaRemoved)
+	}
+	if _, ok := doc.parts["word/media/orphan.png"]; ok {
+		t.Error("未引用的媒体文件未被移除")
+	}
+	if _, ok := doc.parts["word/media/photo.png"]; ok {
+		t.Error("转换后的原PNG文件未被移除")
+	}
+
+	images := doc.ListImages()
+	if len(images) != 2 {
+		t.Fatalf("期望2张图片，得到 %d", len(images))
+	}
+	if images[0].Format != ImageFormatJPEG {
+		t.Errorf("照片应转换为JPEG，得到 %s", images[0].Format)
+	}
+	if images[1].Format != ImageFormatPNG {
+		t.Errorf("图标不应被转换，得到 %s", images[1].Format)
+	}
+
+	// 保存后可以重新打开
+	opened := saveAndReopen(t, doc)
+	for _, img := range opened.ListImages() {
+		if len(img.Data) == 0 {
+			t.Errorf("图片 %s 数据丢失", img.ID)
+		}
+	}
+	rel := opened.findDocumentRelationship(opened.ListImages()[0].RelationID)
+	if rel == nil || !strings.HasSuffix(rel.Target, ".jpeg") {
+		t.Errorf("转换后的关系目标不正确: %+v", rel)
+	}
+}
+
+func TestSaveWithOptions(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddImageFromData(createTestJPEG(1200, 600), "photo.jpeg", ImageFormatJPEG, 1200, 600, &ImageConfig{
+		Size: &ImageSize{Width: 30, Height: 15},
+	}); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	filename := t.TempDir() + "/optimized.docx"
+	err := doc.SaveWithOptions(filename, &SaveOptions{
+		ImageOptimization: &ImageOptimizeOptions{MaxDPI: 96, JPEGQuality: 70},
+	})
+	if err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	opened, err := Open(filename)
+	if err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	if img := opened.ListImages()[0]; img.Width >= 1200 {
+		t.Errorf("保存时图片未被降采样: %dx%d", img.Width, img.Height)
+	}
+
+	if _, err := doc.OptimizeImages(96, 150); err == nil {
+		t.Error("期望JPEG质量超出范围时返回错误")
+	}
+}
+
+func TestOptimizeImagesInTextBox(t *testing.T) {
+	doc := New()
+	box, err := doc.AddTextBox(&ShapeConfig{Width: 80, Height: 60})
+	if err != nil {
+		t.Fatalf("添加文本框失败: %v", err)
+	}
+	if _, err := doc.AddImageFromData(createTestJPEG(2000, 1000), "boxed.jpeg", ImageFormatJPEG, 2000, 1000, &ImageConfig{
+		Size: &ImageSize{Width: 50, KeepAspectRatio: true},
+	}); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	if _, err := doc.AddImageFromData(createTestImage(40, 40), "cell.png", ImageFormatPNG, 40, 40, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	// 将图片段落分别移入文本框和文本框中的表格
+	elements := doc.Body.Elements
+	box.appendParagraph(elements[len(elements)-2].(*Paragraph))
+	table := doc.CreateTable(&TableConfig{Rows: 1, Cols: 1, Width: 2000})
+	table.Rows[0].Cells[0].Paragraphs = []Paragraph{*elements[len(elements)-1].(*Paragraph)}
+	box.Element.Txbx.Content.Tables = append(box.Element.Txbx.Content.Tables, NestedTable{Index: 1, Table: table})
+	doc.Body.Elements = elements[:len(elements)-2]
+
+	if images := doc.ListImages(); len(images) != 2 {
+		t.Fatalf("应列出文本框中的图片，得到 %d", len(images))
+	}
+	result, err := doc.OptimizeImagesWithOptions(&ImageOptimizeOptions{MaxDPI: 150, RemoveUnusedMedia: true})
+	if err != nil {
+		t.Fatalf("优化图片失败: %v", err)
+	}
+	if result.MediaRemoved != 0 || result.ImagesResized != 1 {
+		t.Errorf("文本框中的图片应被降采样且不被移除: %+v", result)
+	}
+
+	opened := saveAndReopen(t, doc)
+	images := opened.ListImages()
+	if len(images) != 2 {
+		t.Fatalf("文本框中的图片丢失: %+v", images)
+	}
+	for _, img := range images {
+		if len(img.Data) == 0 || opened.findDocumentRelationship(img.RelationID) == nil {
+			t.Errorf("文本框中图片 %s 的数据或关系被移除", img.ID)
+		}
+	}
+	if shapes := opened.ListShapes(); len(shapes) != 1 || len(shapes[0].Element.Txbx.Content.Tables) != 1 {
+		t.Error("文本框中的表格应被保留")
+	}
+}
+
+func TestOptimizeImagesCropped(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddImageFromData(createTestJPEG(2000, 1000), "cropped.jpeg", ImageFormatJPEG, 2000, 1000, &ImageConfig{
+		Size: &ImageSize{Width: 50, Height: 50},
+		Crop: &ImageCrop{Left: 25, Right: 25},
+	}); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	if _, err := doc.OptimizeImages(150, 80); err != nil {
+		t.Fatalf("优化图片失败: %v", err)
+	}
+	// 可见部分为一半宽度，完整图片需要约592×296像素才能在150 DPI下显示50毫米
+	img := doc.ListImages()[0]
+	if img.Width < 590 || img.Width > 600 || img.Height < 295 || img.Height > 300 {
+		t.Errorf("裁剪图片的降采样尺寸不正确: %dx%d", img.Width, img.Height)
+	}
+}
+
+func TestOptimizeImagesSharedJPEGUnchanged(t *testing.T) {
+	doc := New()
+	data := createTestJPEG(2000, 1000)
+	if _, err := doc.AddImageFromData(data, "logo.jpeg", ImageFormatJPEG, 2000, 1000, &ImageConfig{
+		Size: &ImageSize{Width: 20, KeepAspectRatio: true},
+	}); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	// 页眉也引用了同一张图片
+	doc.parts["word/_rels/header1.xml.rels"] = []byte(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + imageRelationshipType + `" Target="media/logo.jpeg"/></Relationships>`)
+
+	result, err := doc.OptimizeImages(96, 50)
+	if err != nil {
+		t.Fatalf("优化图片失败: %v", err)
+	}
+	if result.ImagesProcessed != 0 || !bytes.Equal(doc.parts["word/media/logo.jpeg"], data) {
+		t.Errorf("被页眉引用的JPEG图片不应重新压缩: %+v", result)
+	}
+}
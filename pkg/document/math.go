@@ -0,0 +1,661 @@
+// Package document 提供Word文档数学公式（Office Math）功能
+package document
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+const (
+	// mathNamespace Office Math命名空间
+	mathNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/math"
+)
+
+// Equation 数学公式（m:oMath）
+//
+// 公式由 MathElement 组成，可以直接构建，也可以通过 ParseLaTeX 从LaTeX解析。
+// Display 为true时公式以独立显示方式（m:oMathPara）输出。
+type Equation struct {
+	Elements []MathElement // 公式内容
+	Display  bool          // 是否为独立显示公式
+}
+
+// MathElement 公式元素
+type MathElement interface {
+	xml.Marshaler
+	isMathElement()
+}
+
+// MathText 公式中的文本（变量、数字、运算符等）
+type MathText struct {
+	Text   string // 文本内容
+	Normal bool   // 使用正体（非斜体），如函数名和普通文本
+}
+
+// MathFraction 分式
+type MathFraction struct {
+	Numerator   []MathElement // 分子
+	Denominator []MathElement // 分母
+	Linear      bool          // 线性分式（a/b）
+}
+
+// MathScript 上下标，Sub 和 Sup 至少有一个非空
+type MathScript struct {
+	Base []MathElement // 基础部分
+	Sub  []MathElement // 下标
+	Sup  []MathElement // 上标
+}
+
+// MathRadical 根式
+type MathRadical struct {
+	Degree []MathElement // 根指数，为空时为平方根
+	Base   []MathElement // 被开方数
+}
+
+// MathNary N元运算符（求和、求积、积分等）
+type MathNary struct {
+	Operator  string        // 运算符字符，如 "∑"、"∏"、"∫"
+	Sub       []MathElement // 下限
+	Sup       []MathElement // 上限
+	Base      []MathElement // 运算对象
+	UnderOver bool          // 上下限位于运算符正上方和正下方
+}
+
+// MathDelimiter 定界符（括号），Items 之间以 Separator 分隔
+type MathDelimiter struct {
+	Begin     string          // 左定界符，为空表示不显示
+	End       string          // 右定界符，为空表示不显示
+	Separator string          // 分隔符
+	Items     [][]MathElement // 括号内的内容
+}
+
+// MathMatrix 矩阵
+type MathMatrix struct {
+	Rows [][][]MathElement // 行、列、单元格内容
+}
+
+// MathFunction 函数（如 sin x）
+type MathFunction struct {
+	Name []MathElement // 函数名
+	Base []MathElement // 函数参数
+}
+
+// MathLimit 极限形式（下方或上方带说明的表达式，如 lim）
+type MathLimit struct {
+	Base  []MathElement // 基础部分
+	Limit []MathElement // 极限部分
+	Upper bool          // 极限位于上方
+}
+
+// MathAccent 重音符号（如 â、x̄、向量箭头）
+type MathAccent struct {
+	Char string        // 重音字符
+	Base []MathElement // 基础部分
+}
+
+func (*MathText) isMathElement()      {}
+func (*MathFraction) isMathElement()  {}
+func (*MathScript) isMathElement()    {}
+func (*MathRadical) isMathElement()   {}
+func (*MathNary) isMathElement()      {}
+func (*MathDelimiter) isMathElement() {}
+func (*MathMatrix) isMathElement()    {}
+func (*MathFunction) isMathElement()  {}
+func (*MathLimit) isMathElement()     {}
+func (*MathAccent) isMathElement()    {}
+
+// AddEquation 在段落末尾添加一个行内公式
+func (p *Paragraph) AddEquation(equation *Equation) {
+	if equation == nil {
+		return
+	}
+	p.Runs = append(p.Runs, Run{Equation: equation})
+}
+
+// AddLaTeXEquation 解析LaTeX并在段落末尾添加一个行内公式
+func (p *Paragraph) AddLaTeXEquation(latex string) (*Equation, error) {
+	equation, err := ParseLaTeX(latex)
+	if err != nil {
+		return nil, err
+	}
+	p.AddEquation(equation)
+	return equation, nil
+}
+
+// Equations 返回段落中的所有公式
+func (p *Paragraph) Equations() []*Equation {
+	var equations []*Equation
+	for _, run := range p.Runs {
+		if run.Equation != nil {
+			equations = append(equations, run.Equation)
+		}
+	}
+	return equations
+}
+
+// AddEquation 向文档添加一个独立显示的公式段落。
+//
+// 公式以 m:oMathPara 形式输出并居中显示。
+//
+// 示例:
+//
+//	equation, _ := document.ParseLaTeX(`x = \frac{-b \pm \sqrt{b^2 - 4ac}}{2a}`)
+//	doc.AddEquation(equation)
+func (d *Document) AddEquation(equation *Equation) *Paragraph {
+	para := &Paragraph{}
+	if equation != nil {
+		equation.Display = true
+		para.Runs = append(para.Runs, Run{Equation: equation})
+	}
+	d.Body.AddElement(para)
+	return para
+}
+
+// AddLaTeXEquation 解析LaTeX并向文档添加一个独立显示的公式段落
+func (d *Document) AddLaTeXEquation(latex string) (*Paragraph, error) {
+	equation, err := ParseLaTeX(latex)
+	if err != nil {
+		return nil, err
+	}
+	return d.AddEquation(equation), nil
+}
+
+// MarshalXML 序列化公式为m:oMath（独立显示公式外层包裹m:oMathPara）
+func (eq *Equation) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if eq.Display {
+		para := xml.StartElement{Name: xml.Name{Local: "m:oMathPara"}}
+		if err := e.EncodeToken(para); err != nil {
+			return err
+		}
+		if err := encodeMathElements(e, "m:oMath", eq.Elements); err != nil {
+			return err
+		}
+		return e.EncodeToken(para.End())
+	}
+	return encodeMathElements(e, "m:oMath", eq.Elements)
+}
+
+// MarshalXML 序列化为m:r
+func (t *MathText) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	run := xml.StartElement{Name: xml.Name{Local: "m:r"}}
+	if err := e.EncodeToken(run); err != nil {
+		return err
+	}
+	if t.Normal {
+		if err := encodeMathProperties(e, "m:rPr", mathProperty{"m:sty", "p"}); err != nil {
+			return err
+		}
+	}
+	text := xml.StartElement{
+		Name: xml.Name{Local: "m:t"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xml:space"}, Value: "preserve"}},
+	}
+	if err := e.EncodeElement(t.Text, text); err != nil {
+		return err
+	}
+	return e.EncodeToken(run.End())
+}
+
+// MarshalXML 序列化为m:f
+func (f *MathFraction) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeMathStructure(e, "m:f", func() error {
+		if f.Linear {
+			if err := encodeMathProperties(e, "m:fPr", mathProperty{"m:type", "lin"}); err != nil {
+				return err
+			}
+		}
+		if err := encodeMathElements(e, "m:num", f.Numerator); err != nil {
+			return err
+		}
+		return encodeMathElements(e, "m:den", f.Denominator)
+	})
+}
+
+// MarshalXML 序列化为m:sSub、m:sSup或m:sSubSup
+func (s *MathScript) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	name := "m:sSubSup"
+	if len(s.Sup) == 0 {
+		name = "m:sSub"
+	} else if len(s.Sub) == 0 {
+		name = "m:sSup"
+	}
+
+	return encodeMathStructure(e, name, func() error {
+		if err := encodeMathElements(e, "m:e", s.Base); err != nil {
+			return err
+		}
+		if name != "m:sSup" {
+			if err := encodeMathElements(e, "m:sub", s.Sub); err != nil {
+				return err
+			}
+		}
+		if name != "m:sSub" {
+			return encodeMathElements(e, "m:sup", s.Sup)
+		}
+		return nil
+	})
+}
+
+// MarshalXML 序列化为m:rad
+func (r *MathRadical) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeMathStructure(e, "m:rad", func() error {
+		if len(r.Degree) == 0 {
+			if err := encodeMathProperties(e, "m:radPr", mathProperty{"m:degHide", "1"}); err != nil {
+				return err
+			}
+		}
+		if err := encodeMathElements(e, "m:deg", r.Degree); err != nil {
+			return err
+		}
+		return encodeMathElements(e, "m:e", r.Base)
+	})
+}
+
+// MarshalXML 序列化为m:nary
+func (n *MathNary) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeMathStructure(e, "m:nary", func() error {
+		limLoc := "subSup"
+		if n.UnderOver {
+			limLoc = "undOvr"
+		}
+		props := []mathProperty{{"m:chr", n.Operator}, {"m:limLoc", limLoc}}
+		if len(n.Sub) == 0 {
+			props = append(props, mathProperty{"m:subHide", "1"})
+		}
+		if len(n.Sup) == 0 {
+			props = append(props, mathProperty{"m:supHide", "1"})
+		}
+		if err := encodeMathProperties(e, "m:naryPr", props...); err != nil {
+			return err
+		}
+		if err := encodeMathElements(e, "m:sub", n.Sub); err != nil {
+			return err
+		}
+		if err := encodeMathElements(e, "m:sup", n.Sup); err != nil {
+			return err
+		}
+		return encodeMathElements(e, "m:e", n.Base)
+	})
+}
+
+// MarshalXML 序列化为m:d
+func (d *MathDelimiter) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeMathStructure(e, "m:d", func() error {
+		props := []mathProperty{{"m:begChr", d.Begin}}
+		if d.Separator != "" {
+			props = append(props, mathProperty{"m:sepChr", d.Separator})
+		}
+		props = append(props, mathProperty{"m:endChr", d.End})
+		if err := encodeMathProperties(e, "m:dPr", props...); err != nil {
+			return err
+		}
+
+		items := d.Items
+		if len(items) == 0 {
+			items = [][]MathElement{nil}
+		}
+		for _, item := range items {
+			if err := encodeMathElements(e, "m:e", item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarshalXML 序列化为m:m
+func (m *MathMatrix) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	columns := 0
+	for _, row := range m.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	return encodeMathStructure(e, "m:m", func() error {
+		// 列属性：列数和居中对齐
+		err := encodeMathStructure(e, "m:mPr", func() error {
+			return encodeMathStructure(e, "m:mcs", func() error {
+				return encodeMathStructure(e, "m:mc", func() error {
+					return encodeMathProperties(e, "m:mcPr",
+						mathProperty{"m:count", strconv.Itoa(columns)},
+						mathProperty{"m:mcJc", "center"})
+				})
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range m.Rows {
+			err := encodeMathStructure(e, "m:mr", func() error {
+				for i := 0; i < columns; i++ {
+					var cell []MathElement
+					if i < len(row) {
+						cell = row[i]
+					}
+					if err := encodeMathElements(e, "m:e", cell); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarshalXML 序列化为m:func
+func (f *MathFunction) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeMathStructure(e, "m:func", func() error {
+		if err := encodeMathElements(e, "m:fName", f.Name); err != nil {
+			return err
+		}
+		return encodeMathElements(e, "m:e", f.Base)
+	})
+}
+
+// MarshalXML 序列化为m:limLow或m:limUpp
+func (l *MathLimit) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	name := "m:limLow"
+	if l.Upper {
+		name = "m:limUpp"
+	}
+	return encodeMathStructure(e, name, func() error {
+		if err := encodeMathElements(e, "m:e", l.Base); err != nil {
+			return err
+		}
+		return encodeMathElements(e, "m:lim", l.Limit)
+	})
+}
+
+// MarshalXML 序列化为m:acc
+func (a *MathAccent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeMathStructure(e, "m:acc", func() error {
+		if err := encodeMathProperties(e, "m:accPr", mathProperty{"m:chr", a.Char}); err != nil {
+			return err
+		}
+		return encodeMathElements(e, "m:e", a.Base)
+	})
+}
+
+// mathProperty 只包含m:val属性的公式属性元素
+type mathProperty struct {
+	name  string
+	value string
+}
+
+// encodeMathStructure 输出一个公式结构元素，内容由 content 写入
+func encodeMathStructure(e *xml.Encoder, name string, content func() error) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := content(); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// encodeMathElements 输出包含公式元素列表的容器元素（如m:e、m:num）
+func encodeMathElements(e *xml.Encoder, name string, elements []MathElement) error {
+	return encodeMathStructure(e, name, func() error {
+		for _, element := range elements {
+			if err := e.Encode(element); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// encodeMathProperties 输出属性容器元素（如m:fPr）及其m:val属性子元素
+func encodeMathProperties(e *xml.Encoder, name string, props ...mathProperty) error {
+	return encodeMathStructure(e, name, func() error {
+		for _, prop := range props {
+			element := xml.StartElement{
+				Name: xml.Name{Local: prop.name},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "m:val"}, Value: prop.value}},
+			}
+			if err := e.EncodeToken(element); err != nil {
+				return err
+			}
+			if err := e.EncodeToken(element.End()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// parseEquation 解析m:oMath元素
+func (d *Document) parseEquation(decoder *xml.Decoder, display bool) (*Equation, error) {
+	elements, err := d.parseMathElements(decoder, "oMath")
+	if err != nil {
+		return nil, WrapError("parse_equation", err)
+	}
+	return &Equation{Elements: elements, Display: display}, nil
+}
+
+// parseMathParagraph 解析m:oMathPara元素，返回其中的独立显示公式
+func (d *Document) parseMathParagraph(decoder *xml.Decoder) ([]*Equation, error) {
+	var equations []*Equation
+	err := d.parseChildElements(decoder, "oMathPara", func(t xml.StartElement) error {
+		if t.Name.Local != "oMath" {
+			return d.skipElement(decoder, t.Name.Local)
+		}
+		equation, err := d.parseEquation(decoder, true)
+		if err == nil {
+			equations = append(equations, equation)
+		}
+		return err
+	})
+	return equations, err
+}
+
+// parseMathElements 解析公式容器元素中的公式元素。
+// 不支持的结构会展开为其内部的公式元素，避免丢失内容。
+func (d *Document) parseMathElements(decoder *xml.Decoder, elementName string) ([]MathElement, error) {
+	var elements []MathElement
+
+	err := d.parseChildElements(decoder, elementName, func(t xml.StartElement) error {
+		name := t.Name.Local
+		var element MathElement
+		var err error
+
+		switch {
+		case name == "r":
+			element, err = d.parseMathText(decoder)
+		case name == "f":
+			element, err = d.parseMathFraction(decoder)
+		case name == "sSub", name == "sSup", name == "sSubSup":
+			script := &MathScript{}
+			element = script
+			err = d.parseMathParts(decoder, name, map[string]*[]MathElement{
+				"e": &script.Base, "sub": &script.Sub, "sup": &script.Sup,
+			}, nil)
+		case name == "rad":
+			radical := &MathRadical{}
+			element = radical
+			err = d.parseMathParts(decoder, name, map[string]*[]MathElement{
+				"deg": &radical.Degree, "e": &radical.Base,
+			}, nil)
+		case name == "nary":
+			nary := &MathNary{Operator: "∫"}
+			element = nary
+			err = d.parseMathParts(decoder, name, map[string]*[]MathElement{
+				"sub": &nary.Sub, "sup": &nary.Sup, "e": &nary.Base,
+			}, func(prop string, value string) {
+				switch prop {
+				case "chr":
+					nary.Operator = value
+				case "limLoc":
+					nary.UnderOver = value == "undOvr"
+				}
+			})
+		case name == "d":
+			element, err = d.parseMathDelimiter(decoder)
+		case name == "m":
+			element, err = d.parseMathMatrix(decoder)
+		case name == "func":
+			function := &MathFunction{}
+			element = function
+			err = d.parseMathParts(decoder, name, map[string]*[]MathElement{
+				"fName": &function.Name, "e": &function.Base,
+			}, nil)
+		case name == "limLow", name == "limUpp":
+			limit := &MathLimit{Upper: name == "limUpp"}
+			element = limit
+			err = d.parseMathParts(decoder, name, map[string]*[]MathElement{
+				"e": &limit.Base, "lim": &limit.Limit,
+			}, nil)
+		case name == "acc":
+			accent := &MathAccent{Char: "̂"}
+			element = accent
+			err = d.parseMathParts(decoder, name, map[string]*[]MathElement{
+				"e": &accent.Base,
+			}, func(prop string, value string) {
+				if prop == "chr" {
+					accent.Char = value
+				}
+			})
+		case strings.HasSuffix(name, "Pr"):
+			return d.skipElement(decoder, name)
+		default:
+			// 其他结构（如m:box、m:bar、m:eqArr）展开为内部元素
+			children, err := d.parseMathElements(decoder, name)
+			elements = append(elements, children...)
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+		if element != nil {
+			elements = append(elements, element)
+		}
+		return nil
+	})
+
+	return elements, err
+}
+
+// parseMathText 解析m:r元素
+func (d *Document) parseMathText(decoder *xml.Decoder) (*MathText, error) {
+	text := &MathText{}
+	err := d.parseChildElements(decoder, "r", func(t xml.StartElement) error {
+		switch {
+		case t.Name.Local == "rPr" && t.Name.Space == mathNamespace:
+			return d.parseChildElements(decoder, "rPr", func(c xml.StartElement) error {
+				if c.Name.Local == "sty" && getAttributeValue(c.Attr, "val") == "p" {
+					text.Normal = true
+				}
+				if c.Name.Local == "nor" && getAttributeValue(c.Attr, "val") != "0" {
+					text.Normal = true
+				}
+				return d.skipElement(decoder, c.Name.Local)
+			})
+		case t.Name.Local == "t":
+			content, err := d.readElementText(decoder, "t")
+			text.Text += content
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+	return text, err
+}
+
+// parseMathFraction 解析m:f元素
+func (d *Document) parseMathFraction(decoder *xml.Decoder) (*MathFraction, error) {
+	fraction := &MathFraction{}
+	err := d.parseMathParts(decoder, "f", map[string]*[]MathElement{
+		"num": &fraction.Numerator, "den": &fraction.Denominator,
+	}, func(prop string, value string) {
+		if prop == "type" && value == "lin" {
+			fraction.Linear = true
+		}
+	})
+	return fraction, err
+}
+
+// parseMathDelimiter 解析m:d元素
+func (d *Document) parseMathDelimiter(decoder *xml.Decoder) (*MathDelimiter, error) {
+	delimiter := &MathDelimiter{Begin: "(", End: ")"}
+	separator := "|"
+	hasSeparator := false
+
+	err := d.parseChildElements(decoder, "d", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "dPr":
+			return d.parseChildElements(decoder, "dPr", func(c xml.StartElement) error {
+				value := getAttributeValue(c.Attr, "val")
+				switch c.Name.Local {
+				case "begChr":
+					delimiter.Begin = value
+				case "endChr":
+					delimiter.End = value
+				case "sepChr":
+					separator = value
+					hasSeparator = true
+				}
+				return d.skipElement(decoder, c.Name.Local)
+			})
+		case "e":
+			item, err := d.parseMathElements(decoder, "e")
+			delimiter.Items = append(delimiter.Items, item)
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	if len(delimiter.Items) > 1 || hasSeparator {
+		delimiter.Separator = separator
+	}
+	return delimiter, err
+}
+
+// parseMathMatrix 解析m:m元素
+func (d *Document) parseMathMatrix(decoder *xml.Decoder) (*MathMatrix, error) {
+	matrix := &MathMatrix{}
+	err := d.parseChildElements(decoder, "m", func(t xml.StartElement) error {
+		if t.Name.Local != "mr" {
+			return d.skipElement(decoder, t.Name.Local)
+		}
+		var row [][]MathElement
+		err := d.parseChildElements(decoder, "mr", func(c xml.StartElement) error {
+			if c.Name.Local != "e" {
+				return d.skipElement(decoder, c.Name.Local)
+			}
+			cell, err := d.parseMathElements(decoder, "e")
+			row = append(row, cell)
+			return err
+		})
+		matrix.Rows = append(matrix.Rows, row)
+		return err
+	})
+	return matrix, err
+}
+
+// parseMathParts 解析由命名参数（如m:e、m:sub）组成的公式结构。
+// parts 将参数名映射到目标字段，onProperty 接收属性元素（*Pr）中的m:val值。
+func (d *Document) parseMathParts(decoder *xml.Decoder, elementName string, parts map[string]*[]MathElement, onProperty func(prop, value string)) error {
+	return d.parseChildElements(decoder, elementName, func(t xml.StartElement) error {
+		name := t.Name.Local
+		if target, ok := parts[name]; ok {
+			elements, err := d.parseMathElements(decoder, name)
+			*target = elements
+			return err
+		}
+		if strings.HasSuffix(name, "Pr") && onProperty != nil {
+			return d.parseChildElements(decoder, name, func(c xml.StartElement) error {
+				onProperty(c.Name.Local, getAttributeValue(c.Attr, "val"))
+				return d.skipElement(decoder, c.Name.Local)
+			})
+		}
+		return d.skipElement(decoder, name)
+	})
+}
@@ -0,0 +1,957 @@
+// Package document LaTeX公式解析与导出
+package document
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// latexSymbol LaTeX命令与对应字符
+type latexSymbol struct {
+	name string
+	text string
+}
+
+// latexSymbols 支持的符号命令，同一字符对应多个命令时导出使用第一个
+var latexSymbols = []latexSymbol{
+	// 希腊字母
+	{"alpha", "α"}, {"beta", "β"}, {"gamma", "γ"}, {"delta", "δ"}, {"epsilon", "ϵ"},
+	{"varepsilon", "ε"}, {"zeta", "ζ"}, {"eta", "η"}, {"theta", "θ"}, {"vartheta", "ϑ"},
+	{"iota", "ι"}, {"kappa", "κ"}, {"lambda", "λ"}, {"mu", "μ"}, {"nu", "ν"},
+	{"xi", "ξ"}, {"pi", "π"}, {"varpi", "ϖ"}, {"rho", "ρ"}, {"varrho", "ϱ"},
+	{"sigma", "σ"}, {"varsigma", "ς"}, {"tau", "τ"}, {"upsilon", "υ"}, {"phi", "ϕ"},
+	{"varphi", "φ"}, {"chi", "χ"}, {"psi", "ψ"}, {"omega", "ω"},
+	{"Gamma", "Γ"}, {"Delta", "Δ"}, {"Theta", "Θ"}, {"Lambda", "Λ"}, {"Xi", "Ξ"},
+	{"Pi", "Π"}, {"Sigma", "Σ"}, {"Upsilon", "Υ"}, {"Phi", "Φ"}, {"Psi", "Ψ"},
+	{"Omega", "Ω"},
+	// 运算符与关系符
+	{"pm", "±"}, {"mp", "∓"}, {"times", "×"}, {"div", "÷"}, {"cdot", "⋅"},
+	{"ast", "∗"}, {"circ", "∘"}, {"bullet", "∙"}, {"oplus", "⊕"}, {"otimes", "⊗"},
+	{"leq", "≤"}, {"le", "≤"}, {"geq", "≥"}, {"ge", "≥"}, {"neq", "≠"}, {"ne", "≠"},
+	{"approx", "≈"}, {"equiv", "≡"}, {"sim", "∼"}, {"simeq", "≃"}, {"cong", "≅"},
+	{"propto", "∝"}, {"ll", "≪"}, {"gg", "≫"},
+	{"in", "∈"}, {"notin", "∉"}, {"ni", "∋"}, {"subset", "⊂"}, {"supset", "⊃"},
+	{"subseteq", "⊆"}, {"supseteq", "⊇"}, {"cup", "∪"}, {"cap", "∩"}, {"setminus", "∖"},
+	{"land", "∧"}, {"wedge", "∧"}, {"lor", "∨"}, {"vee", "∨"}, {"neg", "¬"}, {"lnot", "¬"},
+	{"forall", "∀"}, {"exists", "∃"}, {"nexists", "∄"},
+	// 箭头
+	{"to", "→"}, {"rightarrow", "→"}, {"leftarrow", "←"}, {"gets", "←"},
+	{"leftrightarrow", "↔"}, {"Rightarrow", "⇒"}, {"Leftarrow", "⇐"},
+	{"Leftrightarrow", "⇔"}, {"iff", "⇔"}, {"implies", "⇒"}, {"mapsto", "↦"},
+	{"uparrow", "↑"}, {"downarrow", "↓"},
+	// 其他符号
+	{"infty", "∞"}, {"partial", "∂"}, {"nabla", "∇"}, {"emptyset", "∅"},
+	{"varnothing", "∅"}, {"angle", "∠"}, {"perp", "⊥"}, {"parallel", "∥"},
+	{"prime", "′"}, {"hbar", "ℏ"}, {"ell", "ℓ"}, {"Re", "ℜ"}, {"Im", "ℑ"},
+	{"aleph", "ℵ"}, {"degree", "°"}, {"triangle", "△"},
+	{"cdots", "⋯"}, {"ldots", "…"}, {"dots", "…"}, {"vdots", "⋮"}, {"ddots", "⋱"},
+	{"langle", "⟨"}, {"rangle", "⟩"}, {"lfloor", "⌊"}, {"rfloor", "⌋"},
+	{"lceil", "⌈"}, {"rceil", "⌉"}, {"vert", "|"}, {"lvert", "|"}, {"rvert", "|"},
+	{"Vert", "‖"}, {"lVert", "‖"}, {"rVert", "‖"},
+	// 空白
+	{"quad", "\u2003"}, {"qquad", "\u2003\u2003"},
+}
+
+// latexNaryOperators N元运算符命令
+var latexNaryOperators = []latexSymbol{
+	{"sum", "∑"}, {"prod", "∏"}, {"coprod", "∐"}, {"int", "∫"}, {"iint", "∬"},
+	{"iiint", "∭"}, {"oint", "∮"}, {"bigcup", "⋃"}, {"bigcap", "⋂"},
+	{"bigoplus", "⨁"}, {"bigotimes", "⨂"},
+}
+
+// latexAccents 重音命令与组合字符
+var latexAccents = []latexSymbol{
+	{"hat", "̂"}, {"widehat", "̂"}, {"bar", "̅"}, {"overline", "̅"},
+	{"vec", "⃗"}, {"tilde", "̃"}, {"widetilde", "̃"}, {"dot", "̇"},
+	{"ddot", "̈"}, {"check", "̌"}, {"breve", "̆"},
+}
+
+// latexFunctions 以正体显示的函数名命令
+var latexFunctions = []string{
+	"sin", "cos", "tan", "cot", "sec", "csc", "arcsin", "arccos", "arctan",
+	"sinh", "cosh", "tanh", "coth", "log", "ln", "lg", "exp", "det", "gcd",
+	"deg", "dim", "ker", "arg", "hom",
+	"lim", "liminf", "limsup", "max", "min", "sup", "inf",
+}
+
+// latexLimitFunctions 下标显示在正下方的函数
+var latexLimitFunctions = map[string]bool{
+	"lim": true, "liminf": true, "limsup": true, "max": true, "min": true,
+	"sup": true, "inf": true, "det": true, "gcd": true,
+}
+
+// latexMatrixDelimiters 矩阵环境对应的定界符
+var latexMatrixDelimiters = map[string][2]string{
+	"matrix":  {"", ""},
+	"pmatrix": {"(", ")"},
+	"bmatrix": {"[", "]"},
+	"Bmatrix": {"{", "}"},
+	"vmatrix": {"|", "|"},
+	"Vmatrix": {"‖", "‖"},
+	"cases":   {"{", ""},
+}
+
+// latexEscapes 转义字符命令
+var latexEscapes = map[string]string{
+	"{": "{", "}": "}", "%": "%", "$": "$", "#": "#", "&": "&", "_": "_", "|": "‖",
+	",": "\u2009", ":": "\u205f", ";": "\u2005", "!": "", " ": " ",
+}
+
+var (
+	latexSymbolText   = map[string]string{}
+	latexSymbolName   = map[string]string{}
+	latexNaryText     = map[string]string{}
+	latexNaryName     = map[string]string{}
+	latexAccentText   = map[string]string{}
+	latexAccentName   = map[string]string{}
+	latexFunctionName = map[string]bool{}
+)
+
+func init() {
+	register := func(symbols []latexSymbol, text, name map[string]string) {
+		for _, symbol := range symbols {
+			text[symbol.name] = symbol.text
+			if _, exists := name[symbol.text]; !exists {
+				name[symbol.text] = symbol.name
+			}
+		}
+	}
+	register(latexSymbols, latexSymbolText, latexSymbolName)
+	register(latexNaryOperators, latexNaryText, latexNaryName)
+	register(latexAccents, latexAccentText, latexAccentName)
+	for _, name := range latexFunctions {
+		latexFunctionName[name] = true
+	}
+}
+
+// ParseLaTeX 将LaTeX数学公式解析为公式模型。
+//
+// 支持的语法包括：上下标（^、_）、\frac、\sqrt、\sum/\prod/\int等N元运算符、
+// \left...\right定界符、matrix/pmatrix/bmatrix/vmatrix/cases环境、
+// \sin/\lim等函数、\hat/\vec等重音、\text以及常用希腊字母和运算符号。
+// 输入不应包含外层的$定界符。解析失败时返回的错误包含出错位置并可通过
+// errors.Is(err, ErrInvalidLaTeX) 判断。
+func ParseLaTeX(latex string) (*Equation, error) {
+	parser := &latexParser{src: []rune(latex)}
+	elements, err := parser.parseSequence(false)
+	if err == nil && !parser.eof() {
+		err = parser.errorf("意外的 %q", parser.peekToken())
+	}
+	if err != nil {
+		return nil, WrapErrorWithContext("parse_latex", err, latex)
+	}
+	if len(elements) == 0 {
+		return nil, WrapErrorWithContext("parse_latex", fmt.Errorf("%w: 公式为空", ErrInvalidLaTeX), latex)
+	}
+	return &Equation{Elements: elements}, nil
+}
+
+// latexParser LaTeX递归下降解析器
+type latexParser struct {
+	src []rune
+	pos int
+}
+
+func (p *latexParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: 位置 %d: %s", ErrInvalidLaTeX, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *latexParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *latexParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// peekCommand 返回当前位置的命令名（不消费）
+func (p *latexParser) peekCommand() string {
+	if p.eof() || p.src[p.pos] != '\\' {
+		return ""
+	}
+	end := p.pos + 1
+	for end < len(p.src) && unicode.IsLetter(p.src[end]) && p.src[end] < unicode.MaxASCII {
+		end++
+	}
+	if end == p.pos+1 && end < len(p.src) {
+		end++
+	}
+	return string(p.src[p.pos+1 : end])
+}
+
+// readCommand 读取当前位置的命令名
+func (p *latexParser) readCommand() string {
+	name := p.peekCommand()
+	p.pos += 1 + len([]rune(name))
+	return name
+}
+
+// peekToken 返回当前位置的记号，用于错误信息
+func (p *latexParser) peekToken() string {
+	if name := p.peekCommand(); name != "" {
+		return "\\" + name
+	}
+	if p.eof() {
+		return ""
+	}
+	return string(p.src[p.pos])
+}
+
+// atTerminator 判断当前位置是否为序列结束记号
+func (p *latexParser) atTerminator(stopAtRelation bool) bool {
+	if p.eof() {
+		return true
+	}
+	switch p.src[p.pos] {
+	case '}', '&':
+		return true
+	case '=', '<', '>':
+		return stopAtRelation
+	case '\\':
+		switch name := p.peekCommand(); name {
+		case "\\", "right", "middle", "end":
+			return true
+		default:
+			if stopAtRelation {
+				switch latexSymbolText[name] {
+				case "≤", "≥", "≠", "≈", "≡", "∼", "≃", "≅", "∝", "→", "⇒", "⇔":
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseSequence 解析元素序列直到结束记号。
+// stopAtRelation 为true时在关系运算符处停止（用于N元运算符的运算对象）。
+func (p *latexParser) parseSequence(stopAtRelation bool) ([]MathElement, error) {
+	var elements []MathElement
+	for {
+		p.skipSpace()
+		if p.atTerminator(stopAtRelation) {
+			return mergeMathText(elements), nil
+		}
+
+		var atom []MathElement
+		if c := p.src[p.pos]; c != '^' && c != '_' {
+			var err error
+			if atom, err = p.parseAtom(); err != nil {
+				return nil, err
+			}
+		}
+
+		element, err := p.parseScripts(atom)
+		if err != nil {
+			return nil, err
+		}
+		if element != nil {
+			elements = append(elements, element)
+		} else {
+			elements = append(elements, atom...)
+		}
+	}
+}
+
+// parseScripts 解析紧随基础部分的上下标，没有上下标时返回nil
+func (p *latexParser) parseScripts(base []MathElement) (MathElement, error) {
+	sub, sup, found, err := p.parseLimits()
+	if err != nil || !found {
+		return nil, err
+	}
+	return &MathScript{Base: base, Sub: sub, Sup: sup}, nil
+}
+
+// parseLimits 解析上下标参数
+func (p *latexParser) parseLimits() (sub, sup []MathElement, found bool, err error) {
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return sub, sup, found, nil
+		}
+		var target *[]MathElement
+		switch p.src[p.pos] {
+		case '_':
+			target = &sub
+		case '^':
+			target = &sup
+		default:
+			return sub, sup, found, nil
+		}
+		if *target != nil {
+			return nil, nil, false, p.errorf("重复的上标或下标")
+		}
+		p.pos++
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if arg == nil {
+			arg = []MathElement{}
+		}
+		*target = arg
+		found = true
+	}
+}
+
+// parseArgument 解析命令参数：花括号分组或单个记号
+func (p *latexParser) parseArgument() ([]MathElement, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("缺少参数")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		return p.parseGroup()
+	case c == '\\':
+		return p.parseAtom()
+	case c == '}' || c == '^' || c == '_' || c == '&':
+		return nil, p.errorf("缺少参数")
+	default:
+		p.pos++
+		return []MathElement{&MathText{Text: latexCharText(c)}}, nil
+	}
+}
+
+// parseGroup 解析花括号分组
+func (p *latexParser) parseGroup() ([]MathElement, error) {
+	p.pos++ // {
+	elements, err := p.parseSequence(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.eof() || p.src[p.pos] != '}' {
+		return nil, p.errorf("缺少 }")
+	}
+	p.pos++
+	return elements, nil
+}
+
+// parseAtom 解析单个原子（字符、数字、分组或命令）
+func (p *latexParser) parseAtom() ([]MathElement, error) {
+	c := p.src[p.pos]
+	switch {
+	case c == '{':
+		return p.parseGroup()
+	case c == '\\':
+		return p.parseCommand()
+	case unicode.IsDigit(c):
+		start := p.pos
+		for !p.eof() && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		return []MathElement{&MathText{Text: string(p.src[start:p.pos])}}, nil
+	default:
+		p.pos++
+		return []MathElement{&MathText{Text: latexCharText(c)}}, nil
+	}
+}
+
+// latexCharText 返回普通字符在公式中的文本
+func latexCharText(c rune) string {
+	if c == '\'' {
+		return "′"
+	}
+	return string(c)
+}
+
+// parseCommand 解析命令
+func (p *latexParser) parseCommand() ([]MathElement, error) {
+	start := p.pos
+	name := p.readCommand()
+	if name == "" {
+		return nil, p.errorf("命令不完整")
+	}
+
+	if text, ok := latexEscapes[name]; ok {
+		if text == "" {
+			return nil, nil
+		}
+		return []MathElement{&MathText{Text: text}}, nil
+	}
+	if text, ok := latexSymbolText[name]; ok {
+		return []MathElement{&MathText{Text: text}}, nil
+	}
+	if op, ok := latexNaryText[name]; ok {
+		return p.parseNary(name, op)
+	}
+	if char, ok := latexAccentText[name]; ok {
+		base, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []MathElement{&MathAccent{Char: char, Base: base}}, nil
+	}
+	if latexFunctionName[name] {
+		return p.parseFunction(name)
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		den, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []MathElement{&MathFraction{Numerator: num, Denominator: den}}, nil
+	case "sqrt":
+		radical := &MathRadical{}
+		p.skipSpace()
+		if !p.eof() && p.src[p.pos] == '[' {
+			p.pos++
+			degree, err := p.parseUntil(']')
+			if err != nil {
+				return nil, err
+			}
+			radical.Degree = degree
+		}
+		base, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		radical.Base = base
+		return []MathElement{radical}, nil
+	case "text", "mathrm", "textrm", "operatorname":
+		text, err := p.readRawGroup()
+		if err != nil {
+			return nil, err
+		}
+		return []MathElement{&MathText{Text: text, Normal: true}}, nil
+	case "mathbf", "mathit", "mathsf", "mathcal", "mathbb", "boldsymbol", "displaystyle":
+		// 字体命令仅保留内容
+		if name == "displaystyle" {
+			return nil, nil
+		}
+		return p.parseArgument()
+	case "left":
+		return p.parseDelimiter()
+	case "begin":
+		return p.parseEnvironment()
+	}
+
+	p.pos = start
+	return nil, p.errorf("不支持的命令 \\%s", name)
+}
+
+// parseUntil 解析元素直到指定的结束字符（用于\sqrt[n]）
+func (p *latexParser) parseUntil(end rune) ([]MathElement, error) {
+	var elements []MathElement
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("缺少 %c", end)
+		}
+		if p.src[p.pos] == end {
+			p.pos++
+			return mergeMathText(elements), nil
+		}
+		if p.atTerminator(false) {
+			return nil, p.errorf("意外的 %q", p.peekToken())
+		}
+		atom, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, atom...)
+	}
+}
+
+// readRawGroup 读取花括号中的原始文本（用于\text）
+func (p *latexParser) readRawGroup() (string, error) {
+	p.skipSpace()
+	if p.eof() || p.src[p.pos] != '{' {
+		return "", p.errorf("缺少 {")
+	}
+	p.pos++
+	start, depth := p.pos, 1
+	for ; !p.eof(); p.pos++ {
+		switch p.src[p.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				text := string(p.src[start:p.pos])
+				p.pos++
+				return text, nil
+			}
+		}
+	}
+	return "", p.errorf("缺少 }")
+}
+
+// parseNary 解析N元运算符，运算对象为其后直到关系运算符的内容
+func (p *latexParser) parseNary(name, op string) ([]MathElement, error) {
+	sub, sup, _, err := p.parseLimits()
+	if err != nil {
+		return nil, err
+	}
+	base, err := p.parseSequence(true)
+	if err != nil {
+		return nil, err
+	}
+	nary := &MathNary{
+		Operator:  op,
+		Sub:       sub,
+		Sup:       sup,
+		Base:      base,
+		UnderOver: !strings.Contains(name, "int"),
+	}
+	return []MathElement{nary}, nil
+}
+
+// parseFunction 解析函数命令，如 \sin x、\lim_{x \to 0} f(x)
+func (p *latexParser) parseFunction(name string) ([]MathElement, error) {
+	var fname MathElement = &MathText{Text: name, Normal: true}
+
+	sub, sup, found, err := p.parseLimits()
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		if latexLimitFunctions[name] && sup == nil {
+			fname = &MathLimit{Base: []MathElement{fname}, Limit: sub}
+		} else {
+			fname = &MathScript{Base: []MathElement{fname}, Sub: sub, Sup: sup}
+		}
+	}
+
+	function := &MathFunction{Name: []MathElement{fname}}
+	p.skipSpace()
+	if p.atTerminator(false) {
+		return []MathElement{function}, nil
+	}
+
+	if p.src[p.pos] == '(' {
+		// 括号参数作为整体
+		p.pos++
+		items, err := p.parseUntil(')')
+		if err != nil {
+			return nil, err
+		}
+		function.Base = []MathElement{&MathDelimiter{Begin: "(", End: ")", Items: [][]MathElement{items}}}
+		return []MathElement{function}, nil
+	}
+
+	base, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if script, err := p.parseScripts(base); err != nil {
+		return nil, err
+	} else if script != nil {
+		base = []MathElement{script}
+	}
+	function.Base = base
+	return []MathElement{function}, nil
+}
+
+// readDelimiter 读取\left、\middle、\right后的定界符
+func (p *latexParser) readDelimiter() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", p.errorf("缺少定界符")
+	}
+	c := p.src[p.pos]
+	switch c {
+	case '.':
+		p.pos++
+		return "", nil
+	case '\\':
+		name := p.readCommand()
+		if text, ok := latexEscapes[name]; ok && (name == "{" || name == "}" || name == "|") {
+			return text, nil
+		}
+		if text, ok := latexSymbolText[name]; ok {
+			return text, nil
+		}
+		return "", p.errorf("不支持的定界符 \\%s", name)
+	default:
+		p.pos++
+		return string(c), nil
+	}
+}
+
+// parseDelimiter 解析\left...\middle...\right结构
+func (p *latexParser) parseDelimiter() ([]MathElement, error) {
+	begin, err := p.readDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	delimiter := &MathDelimiter{Begin: begin}
+
+	for {
+		item, err := p.parseSequence(false)
+		if err != nil {
+			return nil, err
+		}
+		delimiter.Items = append(delimiter.Items, item)
+
+		switch p.peekCommand() {
+		case "middle":
+			p.readCommand()
+			separator, err := p.readDelimiter()
+			if err != nil {
+				return nil, err
+			}
+			delimiter.Separator = separator
+		case "right":
+			p.readCommand()
+			end, err := p.readDelimiter()
+			if err != nil {
+				return nil, err
+			}
+			delimiter.End = end
+			return []MathElement{delimiter}, nil
+		default:
+			return nil, p.errorf("缺少 \\right")
+		}
+	}
+}
+
+// parseEnvironment 解析\begin{...}...\end{...}矩阵环境
+func (p *latexParser) parseEnvironment() ([]MathElement, error) {
+	env, err := p.readRawGroup()
+	if err != nil {
+		return nil, err
+	}
+	delimiters, ok := latexMatrixDelimiters[env]
+	if !ok {
+		return nil, p.errorf("不支持的环境 %s", env)
+	}
+
+	matrix := &MathMatrix{}
+	row := [][]MathElement{}
+	for {
+		cell, err := p.parseSequence(false)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, cell)
+
+		if !p.eof() && p.src[p.pos] == '&' {
+			p.pos++
+			continue
+		}
+		switch p.peekCommand() {
+		case "\\":
+			p.readCommand()
+			matrix.Rows = append(matrix.Rows, row)
+			row = [][]MathElement{}
+			continue
+		case "end":
+			p.readCommand()
+			end, err := p.readRawGroup()
+			if err != nil {
+				return nil, err
+			}
+			if end != env {
+				return nil, p.errorf("环境不匹配: \\begin{%s} 与 \\end{%s}", env, end)
+			}
+			// 忽略末尾换行产生的空行
+			if len(row) > 1 || len(row[0]) > 0 {
+				matrix.Rows = append(matrix.Rows, row)
+			}
+		default:
+			return nil, p.errorf("缺少 \\end{%s}", env)
+		}
+		break
+	}
+
+	if delimiters[0] == "" && delimiters[1] == "" {
+		return []MathElement{matrix}, nil
+	}
+	return []MathElement{&MathDelimiter{
+		Begin: delimiters[0],
+		End:   delimiters[1],
+		Items: [][]MathElement{{matrix}},
+	}}, nil
+}
+
+// mergeMathText 合并相邻的同类文本元素
+func mergeMathText(elements []MathElement) []MathElement {
+	merged := make([]MathElement, 0, len(elements))
+	for _, element := range elements {
+		text, ok := element.(*MathText)
+		if ok && len(merged) > 0 {
+			if last, ok := merged[len(merged)-1].(*MathText); ok && last.Normal == text.Normal && !text.Normal {
+				last.Text += text.Text
+				continue
+			}
+		}
+		merged = append(merged, element)
+	}
+	return merged
+}
+
+// LaTeX 将公式导出为LaTeX字符串（不包含外层$定界符）
+func (eq *Equation) LaTeX() string {
+	w := &latexWriter{}
+	w.writeElements(eq.Elements)
+	return strings.TrimSpace(w.String())
+}
+
+// Text 返回公式的纯文本形式
+func (eq *Equation) Text() string {
+	var sb strings.Builder
+	var walk func(elements []MathElement)
+	walk = func(elements []MathElement) {
+		for _, element := range elements {
+			switch el := element.(type) {
+			case *MathText:
+				sb.WriteString(el.Text)
+			case *MathFraction:
+				walk(el.Numerator)
+				sb.WriteString("/")
+				walk(el.Denominator)
+			case *MathScript:
+				walk(el.Base)
+				walk(el.Sub)
+				walk(el.Sup)
+			case *MathRadical:
+				sb.WriteString("√")
+				walk(el.Base)
+			case *MathNary:
+				sb.WriteString(el.Operator)
+				walk(el.Sub)
+				walk(el.Sup)
+				walk(el.Base)
+			case *MathDelimiter:
+				sb.WriteString(el.Begin)
+				for i, item := range el.Items {
+					if i > 0 {
+						sb.WriteString(el.Separator)
+					}
+					walk(item)
+				}
+				sb.WriteString(el.End)
+			case *MathMatrix:
+				for _, row := range el.Rows {
+					for _, cell := range row {
+						walk(cell)
+					}
+				}
+			case *MathFunction:
+				walk(el.Name)
+				walk(el.Base)
+			case *MathLimit:
+				walk(el.Base)
+				walk(el.Limit)
+			case *MathAccent:
+				walk(el.Base)
+			}
+		}
+	}
+	walk(eq.Elements)
+	return sb.String()
+}
+
+// latexWriter LaTeX输出，负责在命令与字母之间插入必要的空格
+type latexWriter struct {
+	strings.Builder
+}
+
+// write 写入片段，前一片段以命令结尾且当前片段以字母开头时插入空格
+func (w *latexWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	current := w.String()
+	first := []rune(s)[0]
+	if unicode.IsLetter(first) && first < unicode.MaxASCII && endsWithCommand(current) {
+		w.WriteByte(' ')
+	}
+	w.WriteString(s)
+}
+
+// endsWithCommand 判断字符串是否以字母命令结尾（如 \alpha）
+func endsWithCommand(s string) bool {
+	i := len(s)
+	for i > 0 && s[i-1] < unicode.MaxASCII && unicode.IsLetter(rune(s[i-1])) {
+		i--
+	}
+	return i < len(s) && i > 0 && s[i-1] == '\\'
+}
+
+// group 渲染元素列表，单个记号时省略花括号
+func (w *latexWriter) group(elements []MathElement) string {
+	inner := &latexWriter{}
+	inner.writeElements(elements)
+	s := inner.String()
+	if len([]rune(s)) == 1 || (len(s) > 1 && s[0] == '\\' && endsWithCommand(s) && strings.Count(s, "\\") == 1) {
+		return s
+	}
+	return "{" + s + "}"
+}
+
+func (w *latexWriter) writeElements(elements []MathElement) {
+	for _, element := range elements {
+		w.writeElement(element)
+	}
+}
+
+func (w *latexWriter) writeScripts(sub, sup []MathElement) {
+	if sub != nil {
+		w.write("_" + w.group(sub))
+	}
+	if sup != nil {
+		w.write("^" + w.group(sup))
+	}
+}
+
+func (w *latexWriter) writeElement(element MathElement) {
+	switch el := element.(type) {
+	case *MathText:
+		w.writeText(el)
+	case *MathFraction:
+		if el.Linear {
+			w.write(w.group(el.Numerator) + "/" + w.group(el.Denominator))
+		} else {
+			w.write("\\frac{" + w.render(el.Numerator) + "}{" + w.render(el.Denominator) + "}")
+		}
+	case *MathScript:
+		w.write(w.group(el.Base))
+		w.writeScripts(el.Sub, el.Sup)
+	case *MathRadical:
+		w.write("\\sqrt")
+		if len(el.Degree) > 0 {
+			w.write("[" + w.render(el.Degree) + "]")
+		}
+		w.write("{" + w.render(el.Base) + "}")
+	case *MathNary:
+		name, ok := latexNaryName[el.Operator]
+		if !ok {
+			name = "int"
+		}
+		w.write("\\" + name)
+		w.writeScripts(el.Sub, el.Sup)
+		if len(el.Base) > 0 {
+			w.write(" ")
+			w.writeElements(el.Base)
+		}
+	case *MathDelimiter:
+		w.writeDelimiter(el)
+	case *MathMatrix:
+		w.writeMatrix("matrix", el)
+	case *MathFunction:
+		w.writeElements(el.Name)
+		if base := w.render(el.Base); base != "" {
+			if strings.HasPrefix(base, "\\left") || len(el.Base) == 1 {
+				w.write(" " + base)
+			} else {
+				w.write("{" + base + "}")
+			}
+		}
+	case *MathLimit:
+		w.writeElements(el.Base)
+		if el.Upper {
+			w.writeScripts(nil, el.Limit)
+		} else {
+			w.writeScripts(el.Limit, nil)
+		}
+	case *MathAccent:
+		name, ok := latexAccentName[el.Char]
+		if !ok {
+			name = "hat"
+		}
+		w.write("\\" + name + "{" + w.render(el.Base) + "}")
+	}
+}
+
+// render 渲染元素列表为字符串
+func (w *latexWriter) render(elements []MathElement) string {
+	inner := &latexWriter{}
+	inner.writeElements(elements)
+	return inner.String()
+}
+
+func (w *latexWriter) writeText(text *MathText) {
+	if text.Normal {
+		if latexFunctionName[text.Text] {
+			w.write("\\" + text.Text)
+		} else {
+			w.write("\\text{" + text.Text + "}")
+		}
+		return
+	}
+	for _, c := range text.Text {
+		s := string(c)
+		switch {
+		case latexSymbolName[s] != "":
+			w.write("\\" + latexSymbolName[s])
+		case strings.ContainsRune("{}%$#&_", c):
+			w.write("\\" + s)
+		case c == '′':
+			w.write("'")
+		case c == '\u2009':
+			w.write("\\,")
+		case c == '\u205f':
+			w.write("\\:")
+		case c == '\u2005':
+			w.write("\\;")
+		default:
+			w.write(s)
+		}
+	}
+}
+
+// latexDelimiter 返回定界符在\left/\right后的写法
+func latexDelimiter(d string) string {
+	switch d {
+	case "":
+		return "."
+	case "{", "}":
+		return "\\" + d
+	case "‖":
+		return "\\|"
+	}
+	if name, ok := latexSymbolName[d]; ok && []rune(d)[0] > unicode.MaxASCII {
+		return "\\" + name
+	}
+	return d
+}
+
+func (w *latexWriter) writeDelimiter(d *MathDelimiter) {
+	// 仅包含矩阵的定界符还原为对应的矩阵环境
+	if len(d.Items) == 1 && len(d.Items[0]) == 1 {
+		if matrix, ok := d.Items[0][0].(*MathMatrix); ok {
+			for env, delimiters := range latexMatrixDelimiters {
+				if env != "matrix" && delimiters[0] == d.Begin && delimiters[1] == d.End {
+					w.writeMatrix(env, matrix)
+					return
+				}
+			}
+		}
+	}
+
+	w.write("\\left" + latexDelimiter(d.Begin))
+	for i, item := range d.Items {
+		if i > 0 {
+			w.write("\\middle" + latexDelimiter(d.Separator))
+		}
+		w.writeElements(item)
+	}
+	w.write("\\right" + latexDelimiter(d.End))
+}
+
+func (w *latexWriter) writeMatrix(env string, m *MathMatrix) {
+	w.write("\\begin{" + env + "}")
+	for i, row := range m.Rows {
+		if i > 0 {
+			w.write(" \\\\ ")
+		}
+		for j, cell := range row {
+			if j > 0 {
+				w.write(" & ")
+			}
+			w.writeElements(cell)
+		}
+	}
+	w.write("\\end{" + env + "}")
+}
@@ -0,0 +1,175 @@
+package document
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLaTeX(t *testing.T) {
+	equation, err := ParseLaTeX(`x = \frac{-b \pm \sqrt{b^2 - 4ac}}{2a}`)
+	if err != nil {
+		t.Fatalf("解析公式失败: %v", err)
+	}
+	if len(equation.Elements) != 2 {
+		t.Fatalf("期望2个元素，得到 %d", len(equation.Elements))
+	}
+	if text, ok := equation.Elements[0].(*MathText); !ok || text.Text != "x=" {
+		t.Errorf("第一个元素不正确: %#v", equation.Elements[0])
+	}
+	fraction, ok := equation.Elements[1].(*MathFraction)
+	if !ok {
+		t.Fatalf("第二个元素应为分式: %#v", equation.Elements[1])
+	}
+	radical, ok := fraction.Numerator[1].(*MathRadical)
+	if !ok || len(radical.Degree) != 0 {
+		t.Fatalf("分子中应包含平方根: %#v", fraction.Numerator)
+	}
+	if script, ok := radical.Base[0].(*MathScript); !ok || script.Sup == nil || script.Sub != nil {
+		t.Errorf("根式内应包含上标: %#v", radical.Base)
+	}
+	if equation.Text() != "x=-b±√b2-4ac/2a" {
+		t.Errorf("公式文本不正确: %q", equation.Text())
+	}
+
+	equation, err = ParseLaTeX(`\sum_{i=1}^{n} i^2 = \frac{n(n+1)(2n+1)}{6}`)
+	if err != nil {
+		t.Fatalf("解析求和公式失败: %v", err)
+	}
+	nary, ok := equation.Elements[0].(*MathNary)
+	if !ok || nary.Operator != "∑" || !nary.UnderOver || len(nary.Base) != 1 {
+		t.Fatalf("求和运算符解析不正确: %#v", equation.Elements[0])
+	}
+
+	equation, err = ParseLaTeX(`A = \begin{pmatrix} 1 & 0 \\ 0 & 1 \end{pmatrix}`)
+	if err != nil {
+		t.Fatalf("解析矩阵失败: %v", err)
+	}
+	delimiter, ok := equation.Elements[1].(*MathDelimiter)
+	if !ok || delimiter.Begin != "(" || delimiter.End != ")" {
+		t.Fatalf("矩阵定界符不正确: %#v", equation.Elements[1])
+	}
+	if matrix := delimiter.Items[0][0].(*MathMatrix); len(matrix.Rows) != 2 || len(matrix.Rows[1]) != 2 {
+		t.Errorf("矩阵大小不正确: %#v", matrix.Rows)
+	}
+}
+
+func TestLaTeXExport(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x^2 + y^2 = z^2`, `x^2+y^2=z^2`},
+		{`\frac{a}{b}`, `\frac{a}{b}`},
+		{`\sqrt[3]{x}`, `\sqrt[3]{x}`},
+		{`\alpha x + \beta`, `\alpha x+\beta`},
+		{`\sum_{i=1}^{n} a_i`, `\sum_{i=1}^n a_i`},
+		{`\int_0^1 f(x) dx`, `\int_0^1 f(x)dx`},
+		{`\lim_{x \to 0} \frac{\sin x}{x} = 1`, `\lim_{x\to0} \frac{\sin x}{x}=1`},
+		{`\left[ a \middle| b \right)`, `\left[a\middle|b\right)`},
+		{`\begin{bmatrix} a & b \\ c & d \end{bmatrix}`, `\begin{bmatrix}a & b \\ c & d\end{bmatrix}`},
+		{`f(x) = \begin{cases} 1 & x > 0 \\ 0 & x \le 0 \end{cases}`, `f(x)=\begin{cases}1 & x>0 \\ 0 & x\leq0\end{cases}`},
+		{`\hat{x} + \vec{v}`, `\hat{x}+\vec{v}`},
+		{`\text{if } x_{ij}`, `\text{if }x_{ij}`},
+		{`\sin^2 x + \cos^2 x`, `\sin^2 x+\cos^2 x`},
+	}
+
+	for _, tt := range tests {
+		equation, err := ParseLaTeX(tt.input)
+		if err != nil {
+			t.Errorf("解析 %q 失败: %v", tt.input, err)
+			continue
+		}
+		latex := equation.LaTeX()
+		if latex != tt.expected {
+			t.Errorf("导出 %q 得到 %q，期望 %q", tt.input, latex, tt.expected)
+		}
+
+		// 导出结果应能再次解析为相同的公式
+		reparsed, err := ParseLaTeX(latex)
+		if err != nil {
+			t.Errorf("重新解析 %q 失败: %v", latex, err)
+		} else if reparsed.LaTeX() != latex {
+			t.Errorf("重新导出不一致: %q -> %q", latex, reparsed.LaTeX())
+		}
+	}
+}
+
+func TestParseLaTeXErrors(t *testing.T) {
+	inputs := []string{
+		``,
+		`\frac{a}`,
+		`{x + 1`,
+		`x + 1}`,
+		`\left( x`,
+		`\unknown{x}`,
+		`x^`,
+		`\begin{pmatrix} 1 \end{bmatrix}`,
+	}
+	for _, input := range inputs {
+		if _, err := ParseLaTeX(input); err == nil {
+			t.Errorf("期望 %q 解析失败", input)
+		} else if !errors.Is(err, ErrInvalidLaTeX) {
+			t.Errorf("错误类型不正确: %v", err)
+		}
+	}
+
+	_, err := ParseLaTeX(`a + \foo`)
+	if err == nil || !strings.Contains(err.Error(), "位置 4") {
+		t.Errorf("错误信息应包含位置: %v", err)
+	}
+}
+
+func TestEquationRoundTrip(t *testing.T) {
+	doc := New()
+	para := doc.AddParagraph("勾股定理：")
+	if _, err := para.AddLaTeXEquation(`a^2 + b^2 = c^2`); err != nil {
+		t.Fatalf("添加行内公式失败: %v", err)
+	}
+	para.AddFormattedText("。", nil)
+
+	display := `x = \frac{-b \pm \sqrt{b^2 - 4ac}}{2a}`
+	if _, err := doc.AddLaTeXEquation(display); err != nil {
+		t.Fatalf("添加独立公式失败: %v", err)
+	}
+	doc.AddEquation(&Equation{Elements: []MathElement{
+		&MathNary{Operator: "∫", Sub: []MathElement{&MathText{Text: "0"}}, Sup: []MathElement{&MathText{Text: "∞"}},
+			Base: []MathElement{&MathScript{Base: []MathElement{&MathText{Text: "e"}}, Sup: []MathElement{&MathText{Text: "-x"}}}, &MathText{Text: "dx"}}},
+		&MathText{Text: "=1"},
+	}})
+
+	if _, err := doc.ToBytes(); err != nil {
+		t.Fatalf("序列化文档失败: %v", err)
+	}
+	documentXML := string(doc.parts["word/document.xml"])
+	for _, expected := range []string{`xmlns:m="http://schemas.openxmlformats.org/officeDocument/2006/math"`, "<m:oMathPara>", "<m:f>", "<m:rad>", `<m:chr m:val="∫">`} {
+		if !strings.Contains(documentXML, expected) {
+			t.Errorf("document.xml缺少 %s", expected)
+		}
+	}
+
+	opened := saveAndReopen(t, doc)
+	paragraphs := opened.Body.GetParagraphs()
+	if len(paragraphs) != 3 {
+		t.Fatalf("期望3个段落，得到 %d", len(paragraphs))
+	}
+
+	inline := paragraphs[0].Equations()
+	if len(inline) != 1 || inline[0].Display || inline[0].LaTeX() != "a^2+b^2=c^2" {
+		t.Fatalf("行内公式不正确: %+v", inline)
+	}
+	if len(paragraphs[0].Runs) != 3 || paragraphs[0].Runs[2].Text.Content != "。" {
+		t.Errorf("公式前后的文本应保持顺序: %+v", paragraphs[0].Runs)
+	}
+
+	equations := paragraphs[1].Equations()
+	if len(equations) != 1 || !equations[0].Display {
+		t.Fatalf("独立公式不正确: %+v", equations)
+	}
+	if latex := equations[0].LaTeX(); latex != `x=\frac{-b\pm\sqrt{b^2-4ac}}{2a}` {
+		t.Errorf("独立公式内容不正确: %s", latex)
+	}
+	if latex := paragraphs[2].Equations()[0].LaTeX(); latex != `\int_0^\infty e^{-x}dx=1` {
+		t.Errorf("积分公式内容不正确: %s", latex)
+	}
+}
@@ -400,6 +400,27 @@ func getPageDimensions(settings *PageSettings) (width, height float64) {
 	return width, height
 }
 
+// contentWidth 页面内容区宽度（Twips）：页面宽度减去左右边距和装订线。
+// 文档尚无节属性时使用默认页面设置，不会向文档添加节属性
+func (d *Document) contentWidth() int {
+	settings := DefaultPageSettings()
+	if d.Body != nil {
+		for _, element := range d.Body.Elements {
+			if _, ok := element.(*SectionProperties); ok {
+				settings = d.GetPageSettings()
+				break
+			}
+		}
+	}
+	return pageContentWidth(settings)
+}
+
+// pageContentWidth 返回页面设置对应的内容区宽度（Twips）
+func pageContentWidth(settings *PageSettings) int {
+	width, _ := getPageDimensions(settings)
+	return int(mmToTwips(width - settings.MarginLeft - settings.MarginRight - settings.GutterWidth))
+}
+
 // identifyPageSize 根据尺寸识别页面类型
 func identifyPageSize(width, height float64) PageSize {
 	// 允许1mm的误差
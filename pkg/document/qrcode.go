@@ -0,0 +1,587 @@
+// Package document 提供二维码生成功能
+package document
+
+import (
+	"strconv"
+	"strings"
+)
+
+// QRCodeLevel 二维码纠错等级
+type QRCodeLevel int
+
+const (
+	// QRCodeLevelM 可恢复约15%的数据（默认）
+	QRCodeLevelM QRCodeLevel = iota
+	// QRCodeLevelL 可恢复约7%的数据
+	QRCodeLevelL
+	// QRCodeLevelQ 可恢复约25%的数据
+	QRCodeLevelQ
+	// QRCodeLevelH 可恢复约30%的数据
+	QRCodeLevelH
+)
+
+// QRCodeConfig 二维码配置
+type QRCodeConfig struct {
+	// 图片边长（像素），按模块数向下取整使每个模块宽度相同，0表示使用默认值256
+	Size int
+	// 纠错等级，默认为QRCodeLevelM
+	Level QRCodeLevel
+	// 静区宽度（模块数），0表示使用默认值4，小于0表示不留静区
+	QuietZone int
+	// 前景色（十六进制，如"000000"），为空时为黑色
+	Foreground string
+	// 背景色（十六进制，如"FFFFFF"），为空时为白色
+	Background string
+}
+
+const (
+	// DefaultQRCodeSize 默认的二维码图片边长（像素）
+	DefaultQRCodeSize = 256
+	// DefaultQRCodeQuietZone 默认的二维码静区宽度（模块数）
+	DefaultQRCodeQuietZone = 4
+)
+
+// qrECCCodewordsPerBlock 每个纠错块的纠错码字数，按纠错等级（L、M、Q、H）和版本索引
+var qrECCCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// qrECCBlocks 纠错块数，按纠错等级（L、M、Q、H）和版本索引
+var qrECCBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// qrAlphanumeric 字母数字模式的字符集
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrCode 二维码模块矩阵
+type qrCode struct {
+	version  int
+	size     int
+	level    QRCodeLevel
+	modules  [][]bool // true为深色模块，按[行][列]索引
+	function [][]bool // 功能图形（定位、校正、时序、格式与版本信息）
+}
+
+// GenerateQRCode 生成二维码PNG图片
+//
+// 内容全部为数字或二维码字母数字字符集（大写字母、数字和空格$%*+-./:）时使用对应的紧凑模式，
+// 其他内容按UTF-8字节编码；自动选择能容纳内容的最小版本（1-40）。
+// 生成的图片可以通过TemplateData.SetImageFromData用于模板图片占位符，或通过AddImageFromData直接插入文档：
+//
+//	qr, err := document.GenerateQRCode("https://example.com/invoice/1001", &document.QRCodeConfig{Level: document.QRCodeLevelQ})
+//	data.SetImageFromData("qrcode", qr.Data, &document.ImageConfig{Size: &document.ImageSize{Width: 30, Height: 30}})
+func GenerateQRCode(content string, config *QRCodeConfig) (*BarcodeImage, error) {
+	if config == nil {
+		config = &QRCodeConfig{}
+	}
+	if config.Level < QRCodeLevelM || config.Level > QRCodeLevelH {
+		return nil, NewValidationError("level", strconv.Itoa(int(config.Level)), "invalid QR code error correction level")
+	}
+	code, err := encodeQRCode(content, config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	quietZone := config.QuietZone
+	if quietZone == 0 {
+		quietZone = DefaultQRCodeQuietZone
+	} else if quietZone < 0 {
+		quietZone = 0
+	}
+	size := config.Size
+	if size <= 0 {
+		size = DefaultQRCodeSize
+	}
+	total := code.size + quietZone*2
+	scale := max(size/total, 1)
+
+	img, err := newBarcodeCanvas(total*scale, total*scale, config.Foreground, config.Background)
+	if err != nil {
+		return nil, err
+	}
+	for y, row := range code.modules {
+		for x, dark := range row {
+			if dark {
+				fillBarcodeRect(img, (x+quietZone)*scale, (y+quietZone)*scale, scale, scale)
+			}
+		}
+	}
+	return encodeBarcodeImage(img)
+}
+
+// encodeQRCode 编码内容并生成模块矩阵
+func encodeQRCode(content string, level QRCodeLevel) (*qrCode, error) {
+	version, bits, err := qrEncodeData(content, level)
+	if err != nil {
+		return nil, err
+	}
+	code := newQRCode(version, level)
+	code.drawFunctionPatterns()
+	code.drawCodewords(code.addECCAndInterleave(bits))
+
+	// 选择惩罚分最低的掩码
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		if penalty := code.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		code.applyMask(mask)
+	}
+	code.applyMask(bestMask)
+	code.drawFormatBits(bestMask)
+	return code, nil
+}
+
+// qrEncodeData 选择编码模式和最小版本，返回填充后的数据码字
+func qrEncodeData(content string, level QRCodeLevel) (int, []byte, error) {
+	mode, modeBits := 2, 0x4 // 字节模式
+	count := len(content)
+	if content != "" && strings.Trim(content, "0123456789") == "" {
+		mode, modeBits = 0, 0x1
+	} else if content != "" && strings.Trim(content, qrAlphanumeric) == "" {
+		mode, modeBits = 1, 0x2
+	}
+	countBits := [3][3]int{{10, 12, 14}, {9, 11, 13}, {8, 16, 16}}[mode]
+
+	var data qrBitBuffer
+	switch mode {
+	case 0:
+		for i := 0; i < len(content); i += 3 {
+			chunk := content[i:min(i+3, len(content))]
+			n, _ := strconv.Atoi(chunk)
+			data.append(n, len(chunk)*3+1)
+		}
+	case 1:
+		for i := 0; i+1 < len(content); i += 2 {
+			data.append(strings.IndexByte(qrAlphanumeric, content[i])*45+strings.IndexByte(qrAlphanumeric, content[i+1]), 11)
+		}
+		if len(content)%2 == 1 {
+			data.append(strings.IndexByte(qrAlphanumeric, content[len(content)-1]), 6)
+		}
+	default:
+		for i := 0; i < len(content); i++ {
+			data.append(int(content[i]), 8)
+		}
+	}
+
+	for version := 1; version <= 40; version++ {
+		group := 0
+		if version >= 27 {
+			group = 2
+		} else if version >= 10 {
+			group = 1
+		}
+		capacity := qrDataCodewords(version, level) * 8
+		if count >= 1<<countBits[group] || 4+countBits[group]+len(data) > capacity {
+			continue
+		}
+
+		var bits qrBitBuffer
+		bits.append(modeBits, 4)
+		bits.append(count, countBits[group])
+		bits = append(bits, data...)
+		// 终止符、补齐字节边界与填充字节
+		bits.append(0, min(4, capacity-len(bits)))
+		bits.append(0, (8-len(bits)%8)%8)
+		for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+			bits.append(pad, 8)
+		}
+		return version, bits.bytes(), nil
+	}
+	return 0, nil, NewValidationError("content", content[:min(len(content), 32)], "content too long for QR code")
+}
+
+// qrBitBuffer 位缓冲区
+type qrBitBuffer []bool
+
+// append 追加value的低n位，高位在前
+func (b *qrBitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+// bytes 按字节输出
+func (b qrBitBuffer) bytes() []byte {
+	result := make([]byte, (len(b)+7)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+// qrRawDataModules 版本中可用于数据和纠错码的模块数
+func qrRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		result -= (25*align-10)*align - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// qrDataCodewords 版本和纠错等级对应的数据码字数
+func qrDataCodewords(version int, level QRCodeLevel) int {
+	index := qrLevelIndex(level)
+	return qrRawDataModules(version)/8 - qrECCCodewordsPerBlock[index][version]*qrECCBlocks[index][version]
+}
+
+// qrLevelIndex 纠错等级在码字表中的索引
+func qrLevelIndex(level QRCodeLevel) int {
+	return [4]int{1, 0, 2, 3}[level]
+}
+
+// qrFormatBits 纠错等级在格式信息中的编码
+func qrFormatBits(level QRCodeLevel) int {
+	return [4]int{0, 1, 3, 2}[level]
+}
+
+func newQRCode(version int, level QRCodeLevel) *qrCode {
+	size := version*4 + 17
+	code := &qrCode{version: version, size: size, level: level}
+	code.modules = make([][]bool, size)
+	code.function = make([][]bool, size)
+	for i := range code.modules {
+		code.modules[i] = make([]bool, size)
+		code.function[i] = make([]bool, size)
+	}
+	return code
+}
+
+// setFunction 设置功能图形模块
+func (c *qrCode) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+// drawFunctionPatterns 绘制定位图形、时序图形、校正图形，并预留格式与版本信息区域
+func (c *qrCode) drawFunctionPatterns() {
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.size-4, 3)
+	c.drawFinder(3, c.size-4)
+
+	positions := qrAlignmentPositions(c.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// 与定位图形重叠的位置不绘制
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.setFunction(x+dx, y+dy, max(qrAbs(dx), qrAbs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinder 绘制以(x, y)为中心的定位图形及其分隔符
+func (c *qrCode) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.size || yy < 0 || yy >= c.size {
+				continue
+			}
+			dist := max(qrAbs(dx), qrAbs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// qrAlignmentPositions 校正图形中心的行列坐标
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	result := make([]int, count)
+	result[0] = 6
+	for i, pos := count-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// qrFormatInfo 纠错等级和掩码的格式信息（含BCH校验位并已异或掩码）
+func qrFormatInfo(level QRCodeLevel, mask int) int {
+	data := qrFormatBits(level)<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// qrVersionInfo 版本信息（含BCH校验位），用于版本7及以上
+func qrVersionInfo(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawFormatBits 绘制两份格式信息
+func (c *qrCode) drawFormatBits(mask int) {
+	bits := qrFormatInfo(c.level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(i))
+	}
+	c.setFunction(8, 7, bit(6))
+	c.setFunction(8, 8, bit(7))
+	c.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.size-15+i, bit(i))
+	}
+	c.setFunction(8, c.size-8, true)
+}
+
+// drawVersion 绘制两份版本信息
+func (c *qrCode) drawVersion() {
+	if c.version < 7 {
+		return
+	}
+	bits := qrVersionInfo(c.version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 != 0
+		a, b := c.size-11+i%3, i/3
+		c.setFunction(a, b, dark)
+		c.setFunction(b, a, dark)
+	}
+}
+
+// addECCAndInterleave 将数据码字分块、计算纠错码并交错排列
+func (c *qrCode) addECCAndInterleave(data []byte) []byte {
+	index := qrLevelIndex(c.level)
+	blocks := qrECCBlocks[index][c.version]
+	eccLen := qrECCCodewordsPerBlock[index][c.version]
+	raw := qrRawDataModules(c.version) / 8
+	shortBlocks := blocks - raw%blocks
+	shortLen := raw / blocks
+
+	divisor := qrReedSolomonDivisor(eccLen)
+	result := make([][]byte, 0, blocks)
+	for i, k := 0, 0; i < blocks; i++ {
+		dataLen := shortLen - eccLen
+		if i >= shortBlocks {
+			dataLen++
+		}
+		block := append([]byte(nil), data[k:k+dataLen]...)
+		k += dataLen
+		ecc := qrReedSolomonRemainder(block, divisor)
+		if i < shortBlocks {
+			// 短块补一个占位码字，交错时跳过
+			block = append(block, 0)
+		}
+		result = append(result, append(block, ecc...))
+	}
+
+	codewords := make([]byte, 0, raw)
+	for i := 0; i <= shortLen; i++ {
+		for j, block := range result {
+			if i != shortLen-eccLen || j >= shortBlocks {
+				codewords = append(codewords, block[i])
+			}
+		}
+	}
+	return codewords
+}
+
+// qrReedSolomonDivisor 计算指定次数的Reed-Solomon生成多项式（不含最高次项系数）
+func qrReedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrGFMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrGFMultiply(root, 0x02)
+	}
+	return result
+}
+
+// qrReedSolomonRemainder 计算数据的Reed-Solomon纠错码
+func qrReedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= qrGFMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// qrGFMultiply GF(2^8)乘法，本原多项式为0x11D
+func qrGFMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// drawCodewords 按之字形顺序放置码字
+func (c *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// 跳过垂直时序图形
+			right = 5
+		}
+		for vert := 0; vert < c.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.size - 1 - vert
+				}
+				if !c.function[y][x] && i < len(data)*8 {
+					c.modules[y][x] = (data[i>>3]>>(7-i&7))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask 对数据模块应用掩码，再次调用可撤销
+func (c *qrCode) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.function[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty 按标准规则计算掩码惩罚分
+func (c *qrCode) penalty() int {
+	result := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return c.modules[x][y]
+		}
+		return c.modules[y][x]
+	}
+
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < c.size; y++ {
+			// 连续同色模块
+			run := 1
+			for x := 1; x <= c.size; x++ {
+				if x < c.size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			// 类似定位图形的1:1:3:1:1图案
+			for x := 0; x+11 <= c.size; x++ {
+				var pattern int
+				for i := 0; i < 11; i++ {
+					pattern <<= 1
+					if at(x+i, y, vertical) {
+						pattern |= 1
+					}
+				}
+				if pattern == 0x5D0 || pattern == 0x05D {
+					result += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			// 2x2同色块
+			if x+1 < c.size && y+1 < c.size {
+				color := c.modules[y][x]
+				if color == c.modules[y][x+1] && color == c.modules[y+1][x] && color == c.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	// 深色模块比例偏离50%
+	total := c.size * c.size
+	result += qrAbs(dark*20-total*10) / total * 10
+	return result
+}
+
+// qrAbs 整数绝对值
+func qrAbs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
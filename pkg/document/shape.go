@@ -0,0 +1,889 @@
+// Package document 提供Word文档文本框与形状功能
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// wordprocessingShapeNamespace Word 2010 形状命名空间
+	wordprocessingShapeNamespace = "http://schemas.microsoft.com/office/word/2010/wordprocessingShape"
+)
+
+// ShapeType 预设形状类型
+type ShapeType string
+
+const (
+	// 基本形状
+	ShapeRectangle      ShapeType = "rect"      // 矩形
+	ShapeRoundRectangle ShapeType = "roundRect" // 圆角矩形
+	ShapeEllipse        ShapeType = "ellipse"   // 椭圆
+	ShapeTriangle       ShapeType = "triangle"  // 三角形
+	ShapeDiamond        ShapeType = "diamond"   // 菱形
+	ShapeLine           ShapeType = "line"      // 直线
+
+	// 箭头
+	ShapeRightArrow ShapeType = "rightArrow" // 右箭头
+	ShapeLeftArrow  ShapeType = "leftArrow"  // 左箭头
+	ShapeUpArrow    ShapeType = "upArrow"    // 上箭头
+	ShapeDownArrow  ShapeType = "downArrow"  // 下箭头
+
+	// 标注
+	ShapeRectCallout      ShapeType = "wedgeRectCallout"      // 矩形标注
+	ShapeRoundRectCallout ShapeType = "wedgeRoundRectCallout" // 圆角矩形标注
+	ShapeEllipseCallout   ShapeType = "wedgeEllipseCallout"   // 椭圆形标注
+	ShapeCloudCallout     ShapeType = "cloudCallout"          // 云形标注
+
+	// 连接符
+	ShapeStraightConnector ShapeType = "straightConnector1" // 直线连接符
+)
+
+// ArrowType 线条端点箭头类型
+type ArrowType string
+
+const (
+	// 箭头类型选项
+	ArrowNone     ArrowType = "none"     // 无箭头
+	ArrowTriangle ArrowType = "triangle" // 三角箭头
+	ArrowStealth  ArrowType = "stealth"  // 燕尾箭头
+	ArrowOpen     ArrowType = "arrow"    // 开放箭头
+	ArrowOval     ArrowType = "oval"     // 圆形
+	ArrowDiamond  ArrowType = "diamond"  // 菱形
+)
+
+// ShapeTextAnchor 形状内文字的垂直对齐方式
+type ShapeTextAnchor string
+
+const (
+	// 文字垂直对齐选项
+	ShapeTextTop    ShapeTextAnchor = "t"   // 顶端对齐
+	ShapeTextCenter ShapeTextAnchor = "ctr" // 居中
+	ShapeTextBottom ShapeTextAnchor = "b"   // 底端对齐
+)
+
+// ShapeConfig 形状（包括文本框）配置
+type ShapeConfig struct {
+	// 预设形状类型，默认矩形
+	Type ShapeType
+	// 宽度（毫米）
+	Width float64
+	// 高度（毫米）
+	Height float64
+	// 位置，默认相对页边距浮动；ImagePositionInline 表示嵌入式
+	Position ImagePosition
+	// 相对页边距的水平偏移（毫米）
+	OffsetX float64
+	// 相对页边距的垂直偏移（毫米）
+	OffsetY float64
+	// 文字环绕方式，默认浮于文字上方（ImageWrapNone）
+	WrapText ImageWrapText
+	// 衬于文字下方
+	BehindText bool
+	// 填充颜色（十六进制），为空表示无填充
+	FillColor string
+	// 轮廓颜色（十六进制），为空时使用黑色
+	LineColor string
+	// 轮廓宽度（磅），为0时使用0.75磅
+	LineWidth float64
+	// 轮廓线型
+	LineStyle ImageBorderStyle
+	// 不显示轮廓
+	NoLine bool
+	// 旋转角度（度，顺时针）
+	Rotation float64
+	// 形状名称
+	Name string
+	// 形状内的文字（可选），也可以通过 Shape.AddParagraph 添加
+	Text string
+	// 文字垂直对齐方式
+	TextAnchor ShapeTextAnchor
+	// 形状调整值，如标注指针位置 {"adj1": -20833, "adj2": 62500}
+	Adjustments map[string]int64
+}
+
+// ConnectorConfig 连接符配置，坐标均为相对页边距的毫米值
+type ConnectorConfig struct {
+	StartX     float64          // 起点水平位置
+	StartY     float64          // 起点垂直位置
+	EndX       float64          // 终点水平位置
+	EndY       float64          // 终点垂直位置
+	LineColor  string           // 线条颜色，默认黑色
+	LineWidth  float64          // 线条宽度（磅），默认0.75磅
+	LineStyle  ImageBorderStyle // 线型
+	StartArrow ArrowType        // 起点箭头
+	EndArrow   ArrowType        // 终点箭头
+	Name       string           // 名称
+}
+
+// Shape 文档中的形状或文本框
+type Shape struct {
+	ID      string               // 形状ID（与图片共用docPr编号）
+	Config  *ShapeConfig         // 形状配置
+	Element *WordprocessingShape // 形状元素
+}
+
+// WordprocessingShape Word形状（wps:wsp）
+type WordprocessingShape struct {
+	XMLName xml.Name         `xml:"wps:wsp"`
+	CNvSpPr *WpsCNvSpPr      `xml:"wps:cNvSpPr,omitempty"`
+	CNvCnPr *WpsCNvCnPr      `xml:"wps:cNvCnPr,omitempty"`
+	SpPr    *WpsShapeProps   `xml:"wps:spPr"`
+	Txbx    *WpsTextBox      `xml:"wps:txbx,omitempty"`
+	BodyPr  *WpsBodyProperty `xml:"wps:bodyPr"`
+}
+
+// WpsCNvSpPr 形状非可视属性
+type WpsCNvSpPr struct {
+	XMLName xml.Name `xml:"wps:cNvSpPr"`
+	TxBox   string   `xml:"txBox,attr,omitempty"`
+}
+
+// WpsCNvCnPr 连接符非可视属性
+type WpsCNvCnPr struct {
+	XMLName xml.Name `xml:"wps:cNvCnPr"`
+}
+
+// WpsShapeProps 形状属性
+type WpsShapeProps struct {
+	XMLName   xml.Name   `xml:"wps:spPr"`
+	Xfrm      *Xfrm      `xml:"a:xfrm,omitempty"`
+	PrstGeom  *PrstGeom  `xml:"a:prstGeom,omitempty"`
+	NoFill    *NoFill    `xml:"a:noFill,omitempty"`
+	SolidFill *SolidFill `xml:"a:solidFill,omitempty"`
+	Ln        *Ln        `xml:"a:ln,omitempty"`
+}
+
+// WpsTextBox 形状文本框
+type WpsTextBox struct {
+	XMLName xml.Name        `xml:"wps:txbx"`
+	Content *TextBoxContent `xml:"w:txbxContent"`
+}
+
+// TextBoxContent 文本框内容
+type TextBoxContent struct {
+	XMLName    xml.Name      `xml:"w:txbxContent"`
+	Paragraphs []*Paragraph  `xml:"w:p"`
+	Tables     []NestedTable `xml:"-"` // 文本框中的表格，与段落的先后顺序由Index确定
+}
+
+// MarshalXML 按段落与表格的先后顺序输出文本框内容，最后一个元素为表格时补充一个空段落
+func (c *TextBoxContent) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "w:txbxContent"}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	elements := c.Elements()
+	for _, element := range elements {
+		if err := e.Encode(element); err != nil {
+			return err
+		}
+	}
+	if len(elements) > 0 {
+		if _, ok := elements[len(elements)-1].(*Table); ok {
+			if err := e.Encode(&Paragraph{}); err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// Elements 按先后顺序返回文本框中的段落（*Paragraph）和表格（*Table）
+func (c *TextBoxContent) Elements() []interface{} {
+	paragraphs := make([]interface{}, 0, len(c.Paragraphs))
+	for _, p := range c.Paragraphs {
+		paragraphs = append(paragraphs, p)
+	}
+	return mergeNestedTables(paragraphs, c.Tables)
+}
+
+// WpsBodyProperty 形状文字区域属性
+type WpsBodyProperty struct {
+	XMLName   xml.Name   `xml:"wps:bodyPr"`
+	Rot       string     `xml:"rot,attr,omitempty"`
+	Vert      string     `xml:"vert,attr,omitempty"`
+	Wrap      string     `xml:"wrap,attr,omitempty"`
+	LIns      string     `xml:"lIns,attr,omitempty"`
+	TIns      string     `xml:"tIns,attr,omitempty"`
+	RIns      string     `xml:"rIns,attr,omitempty"`
+	BIns      string     `xml:"bIns,attr,omitempty"`
+	Anchor    string     `xml:"anchor,attr,omitempty"`
+	NoAutofit *NoAutofit `xml:"a:noAutofit,omitempty"`
+	SpAutoFit *SpAutoFit `xml:"a:spAutoFit,omitempty"`
+}
+
+// NoAutofit 不自动调整大小
+type NoAutofit struct {
+	XMLName xml.Name `xml:"a:noAutofit"`
+}
+
+// SpAutoFit 根据文字调整形状大小
+type SpAutoFit struct {
+	XMLName xml.Name `xml:"a:spAutoFit"`
+}
+
+// AddTextBox 向文档添加一个浮动文本框。
+//
+// 文本框是一个带文字的矩形形状，位置、大小、填充、轮廓和文字环绕由 config 指定，
+// 可以通过返回的 Shape 继续添加段落。
+//
+// 示例:
+//
+//	box, err := doc.AddTextBox(&document.ShapeConfig{
+//		Width:     60,
+//		Height:    30,
+//		OffsetX:   100,
+//		OffsetY:   20,
+//		FillColor: "FFF2CC",
+//		LineColor: "BF9000",
+//		WrapText:  document.ImageWrapSquare,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	box.AddFormattedParagraph("关键指标", &document.TextFormat{Bold: true})
+//	box.AddParagraph("营收同比增长 35%")
+func (d *Document) AddTextBox(config *ShapeConfig) (*Shape, error) {
+	cfg := ShapeConfig{}
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Type == "" {
+		cfg.Type = ShapeRectangle
+	}
+	if cfg.FillColor == "" {
+		cfg.FillColor = "FFFFFF"
+	}
+
+	shape, err := d.addShape(&cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	Infof("添加文本框成功: ID %s", shape.ID)
+	return shape, nil
+}
+
+// AddShape 向文档添加一个预设形状，如矩形、椭圆、箭头或标注。
+//
+// 形状可以包含文字（通过 ShapeConfig.Text 或 Shape.AddParagraph 添加）。
+func (d *Document) AddShape(config *ShapeConfig) (*Shape, error) {
+	if config == nil {
+		return nil, fmt.Errorf("形状配置不能为空")
+	}
+
+	cfg := *config
+	if cfg.Type == "" {
+		cfg.Type = ShapeRectangle
+	}
+
+	shape, err := d.addShape(&cfg, false)
+	if err != nil {
+		return nil, err
+	}
+	Infof("添加形状成功: %s (ID %s)", cfg.Type, shape.ID)
+	return shape, nil
+}
+
+// AddConnector 向文档添加一条连接两个点的直线连接符，可在两端设置箭头
+func (d *Document) AddConnector(config *ConnectorConfig) (*Shape, error) {
+	if config == nil {
+		return nil, fmt.Errorf("连接符配置不能为空")
+	}
+	if config.StartX == config.EndX && config.StartY == config.EndY {
+		return nil, NewValidationError("connector", fmt.Sprintf("(%.1f, %.1f)", config.StartX, config.StartY), "连接符起点和终点不能相同")
+	}
+
+	cfg := &ShapeConfig{
+		Type:      ShapeStraightConnector,
+		Width:     math.Abs(config.EndX - config.StartX),
+		Height:    math.Abs(config.EndY - config.StartY),
+		OffsetX:   math.Min(config.StartX, config.EndX),
+		OffsetY:   math.Min(config.StartY, config.EndY),
+		WrapText:  ImageWrapNone,
+		LineColor: config.LineColor,
+		LineWidth: config.LineWidth,
+		LineStyle: config.LineStyle,
+		Name:      config.Name,
+	}
+
+	shape, err := d.addShape(cfg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// 连接符使用翻转表示方向
+	spPr := shape.Element.SpPr
+	if config.EndX < config.StartX {
+		spPr.Xfrm.FlipH = "1"
+	}
+	if config.EndY < config.StartY {
+		spPr.Xfrm.FlipV = "1"
+	}
+	if config.StartArrow != "" && config.StartArrow != ArrowNone {
+		spPr.Ln.HeadEnd = &LineEnd{Type: string(config.StartArrow)}
+	}
+	if config.EndArrow != "" && config.EndArrow != ArrowNone {
+		spPr.Ln.TailEnd = &LineEnd{Type: string(config.EndArrow)}
+	}
+
+	Infof("添加连接符成功: ID %s", shape.ID)
+	return shape, nil
+}
+
+// ListShapes 列出文档主体（包括表格单元格）中的所有形状和文本框
+func (d *Document) ListShapes() []*Shape {
+	var shapes []*Shape
+
+	d.forEachDrawingRun(func(para *Paragraph, run *Run) bool {
+		if wsp := drawingShape(run.Drawing); wsp != nil {
+			shapes = append(shapes, shapeFromDrawing(run.Drawing, wsp))
+		}
+		return true
+	})
+
+	return shapes
+}
+
+// AddParagraph 向形状中添加一个段落
+func (s *Shape) AddParagraph(text string) *Paragraph {
+	p := &Paragraph{}
+	if text != "" {
+		p.Runs = append(p.Runs, Run{
+			Text: Text{
+				Content: text,
+				Space:   "preserve",
+			},
+		})
+	}
+	s.appendParagraph(p)
+	return p
+}
+
+// AddFormattedParagraph 向形状中添加一个格式化段落
+func (s *Shape) AddFormattedParagraph(text string, format *TextFormat) *Paragraph {
+	p := &Paragraph{}
+	p.AddFormattedText(text, format)
+	s.appendParagraph(p)
+	return p
+}
+
+// Paragraphs 返回形状中的所有段落
+func (s *Shape) Paragraphs() []*Paragraph {
+	if s.Element == nil || s.Element.Txbx == nil || s.Element.Txbx.Content == nil {
+		return nil
+	}
+	return s.Element.Txbx.Content.Paragraphs
+}
+
+// Text 返回形状中所有段落的文本，段落之间以换行分隔
+func (s *Shape) Text() string {
+	var lines []string
+	for _, para := range s.Paragraphs() {
+		var sb strings.Builder
+		for _, run := range para.Runs {
+			sb.WriteString(run.Text.Content)
+		}
+		lines = append(lines, sb.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendParagraph 向形状的文本框内容追加段落
+func (s *Shape) appendParagraph(p *Paragraph) {
+	if s.Element.Txbx == nil {
+		s.Element.Txbx = &WpsTextBox{}
+	}
+	if s.Element.Txbx.Content == nil {
+		s.Element.Txbx.Content = &TextBoxContent{}
+	}
+	s.Element.Txbx.Content.Paragraphs = append(s.Element.Txbx.Content.Paragraphs, p)
+}
+
+// addShape 创建形状绘图并以新段落的形式添加到文档
+func (d *Document) addShape(config *ShapeConfig, textBox bool) (*Shape, error) {
+	if config.Width <= 0 && config.Height <= 0 {
+		return nil, NewValidationError("size", fmt.Sprintf("%.1fx%.1f", config.Width, config.Height), "形状的宽度和高度不能同时为0")
+	}
+	if config.Width < 0 || config.Height < 0 {
+		return nil, NewValidationError("size", fmt.Sprintf("%.1fx%.1f", config.Width, config.Height), "形状尺寸不能为负数")
+	}
+
+	shapeID := strconv.Itoa(d.nextImageID)
+	d.nextImageID++
+
+	wsp := d.createShapeElement(config, textBox)
+	shape := &Shape{
+		ID:      shapeID,
+		Config:  config,
+		Element: wsp,
+	}
+	if config.Text != "" {
+		for _, line := range strings.Split(config.Text, "\n") {
+			shape.AddParagraph(line)
+		}
+	}
+
+	name := config.Name
+	if name == "" {
+		if textBox {
+			name = fmt.Sprintf("文本框 %s", shapeID)
+		} else {
+			name = fmt.Sprintf("形状 %s", shapeID)
+		}
+	}
+
+	d.Body.AddElement(&Paragraph{
+		Runs: []Run{
+			{Drawing: d.createShapeDrawing(shapeID, name, config, wsp)},
+		},
+	})
+
+	return shape, nil
+}
+
+// createShapeElement 创建wps:wsp形状元素
+func (d *Document) createShapeElement(config *ShapeConfig, textBox bool) *WordprocessingShape {
+	cx := mmToEMU(config.Width)
+	cy := mmToEMU(config.Height)
+
+	spPr := &WpsShapeProps{
+		Xfrm: &Xfrm{
+			Off: &Off{X: "0", Y: "0"},
+			Ext: &Ext{Cx: strconv.FormatInt(cx, 10), Cy: strconv.FormatInt(cy, 10)},
+		},
+		PrstGeom: &PrstGeom{Prst: string(config.Type), AvLst: &AvLst{}},
+	}
+	if config.Rotation != 0 {
+		spPr.Xfrm.Rot = strconv.FormatInt(int64(math.Round(config.Rotation*60000)), 10)
+	}
+
+	// 调整值按名称排序，保证输出稳定
+	names := make([]string, 0, len(config.Adjustments))
+	for name := range config.Adjustments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spPr.PrstGeom.AvLst.Gd = append(spPr.PrstGeom.AvLst.Gd, ShapeGuide{
+			Name: name,
+			Fmla: fmt.Sprintf("val %d", config.Adjustments[name]),
+		})
+	}
+
+	// 填充
+	if config.FillColor != "" && !isLineShape(config.Type) {
+		spPr.SolidFill = &SolidFill{SrgbClr: &SrgbClr{Val: normalizeHexColor(config.FillColor)}}
+	} else {
+		spPr.NoFill = &NoFill{}
+	}
+
+	// 轮廓
+	if config.NoLine {
+		spPr.Ln = &Ln{NoFill: &NoFill{}}
+	} else {
+		width := config.LineWidth
+		if width <= 0 {
+			width = 0.75
+		}
+		color := config.LineColor
+		if color == "" {
+			color = "000000"
+		}
+		spPr.Ln = &Ln{
+			W:         strconv.FormatInt(int64(math.Round(width*12700)), 10),
+			SolidFill: &SolidFill{SrgbClr: &SrgbClr{Val: normalizeHexColor(color)}},
+		}
+		if config.LineStyle != "" {
+			spPr.Ln.PrstDash = &PrstDash{Val: string(config.LineStyle)}
+		}
+	}
+
+	wsp := &WordprocessingShape{
+		SpPr: spPr,
+		BodyPr: &WpsBodyProperty{
+			Rot:    "0",
+			Vert:   "horz",
+			Wrap:   "square",
+			Anchor: string(config.TextAnchor),
+		},
+	}
+	if wsp.BodyPr.Anchor == "" {
+		wsp.BodyPr.Anchor = string(ShapeTextTop)
+	}
+
+	if config.Type == ShapeStraightConnector {
+		wsp.CNvCnPr = &WpsCNvCnPr{}
+	} else if textBox {
+		wsp.CNvSpPr = &WpsCNvSpPr{TxBox: "1"}
+		wsp.Txbx = &WpsTextBox{Content: &TextBoxContent{}}
+		wsp.BodyPr.NoAutofit = &NoAutofit{}
+	} else {
+		wsp.CNvSpPr = &WpsCNvSpPr{}
+	}
+
+	return wsp
+}
+
+// createShapeDrawing 创建包含形状的绘图元素
+func (d *Document) createShapeDrawing(id, name string, config *ShapeConfig, wsp *WordprocessingShape) *DrawingElement {
+	graphic := &DrawingGraphic{
+		Xmlns: drawingMLNamespace,
+		GraphicData: &GraphicData{
+			Uri: wordprocessingShapeNamespace,
+			Wsp: wsp,
+		},
+	}
+
+	return d.createFrameDrawing(&DrawingDocPr{ID: id, Name: name}, mmToEMU(config.Width), mmToEMU(config.Height), graphic,
+		config.Position == ImagePositionInline, &ImageConfig{
+			Position: config.Position,
+			OffsetX:  config.OffsetX,
+			OffsetY:  config.OffsetY,
+			WrapText: config.WrapText,
+		}, config.BehindText)
+}
+
+// createFrameDrawing 创建嵌入式或浮动的绘图元素（用于形状、图表等非图片对象）。
+// layout 中的 Position、OffsetX、OffsetY 和 WrapText 决定浮动对象的位置和环绕方式。
+func (d *Document) createFrameDrawing(docPr *DrawingDocPr, cx, cy int64, graphic *DrawingGraphic, inline bool, layout *ImageConfig, behindText bool) *DrawingElement {
+	extent := &DrawingExtent{Cx: strconv.FormatInt(cx, 10), Cy: strconv.FormatInt(cy, 10)}
+
+	if inline {
+		return &DrawingElement{
+			Inline: &InlineDrawing{
+				DistT:             "0",
+				DistB:             "0",
+				DistL:             "0",
+				DistR:             "0",
+				Extent:            extent,
+				EffectExtent:      &EffectExtent{L: "0", T: "0", R: "0", B: "0"},
+				DocPr:             docPr,
+				CNvGraphicFramePr: &CNvGraphicFramePr{},
+				Graphic:           graphic,
+			},
+		}
+	}
+
+	behindDoc := "0"
+	if behindText {
+		behindDoc = "1"
+	}
+
+	anchor := &AnchorDrawing{
+		DistT:             "0",
+		DistB:             "0",
+		DistL:             "114300",
+		DistR:             "114300",
+		SimplePos:         "0",
+		RelativeHeight:    "251659264",
+		BehindDoc:         behindDoc,
+		Locked:            "0",
+		LayoutInCell:      "1",
+		AllowOverlap:      "1",
+		SimplePosition:    &SimplePosition{X: "0", Y: "0"},
+		Extent:            extent,
+		EffectExtent:      &EffectExtent{L: "0", T: "0", R: "0", B: "0"},
+		DocPr:             docPr,
+		CNvGraphicFramePr: &CNvGraphicFramePr{},
+		Graphic:           graphic,
+	}
+
+	// 位置和环绕方式与浮动图片保持一致
+	d.setFloatingImagePosition(anchor, &ImageConfig{
+		Position: layout.Position,
+		OffsetX:  layout.OffsetX,
+		OffsetY:  layout.OffsetY,
+	})
+	if layout.Position != ImagePositionFloatLeft && layout.Position != ImagePositionFloatRight && layout.OffsetX == 0 {
+		// 未指定浮动方向时默认位于页边距左侧
+		anchor.PositionH.Align = &PosAlign{Value: "left"}
+	}
+
+	// 偏移量表示位置，不作为环绕距离
+	wrapText := layout.WrapText
+	if wrapText == "" {
+		wrapText = ImageWrapNone
+	}
+	d.setFloatingImageWrap(anchor, &ImageConfig{
+		Position: layout.Position,
+		WrapText: wrapText,
+	})
+
+	return &DrawingElement{Anchor: anchor}
+}
+
+// drawingShape 获取绘图元素中的形状，非形状绘图返回nil
+func drawingShape(drawing *DrawingElement) *WordprocessingShape {
+	if drawing == nil {
+		return nil
+	}
+	var graphic *DrawingGraphic
+	if drawing.Inline != nil {
+		graphic = drawing.Inline.Graphic
+	} else if drawing.Anchor != nil {
+		graphic = drawing.Anchor.Graphic
+	}
+	if graphic == nil || graphic.GraphicData == nil {
+		return nil
+	}
+	return graphic.GraphicData.Wsp
+}
+
+// shapeFromDrawing 根据绘图元素构建形状信息
+func shapeFromDrawing(drawing *DrawingElement, wsp *WordprocessingShape) *Shape {
+	config := &ShapeConfig{Type: ShapeRectangle}
+	shape := &Shape{Config: config, Element: wsp}
+
+	if docPr := drawingDocPr(drawing); docPr != nil {
+		shape.ID = docPr.ID
+		config.Name = docPr.Name
+	}
+
+	cx, cy := drawingExtentEMU(drawing)
+	config.Width = float64(cx) / 36000
+	config.Height = float64(cy) / 36000
+
+	if drawing.Anchor != nil {
+		anchor := drawing.Anchor
+		config.BehindText = anchor.BehindDoc == "1" || anchor.BehindDoc == "true"
+		if h := anchor.PositionH; h != nil {
+			if h.Align != nil && h.Align.Value == "right" {
+				config.Position = ImagePositionFloatRight
+			} else if h.Align != nil && h.Align.Value == "left" {
+				config.Position = ImagePositionFloatLeft
+			}
+			if h.PosOffset != nil {
+				config.OffsetX = float64(parseEMU(h.PosOffset.Value)) / 36000
+			}
+		}
+		if v := anchor.PositionV; v != nil && v.PosOffset != nil {
+			config.OffsetY = float64(parseEMU(v.PosOffset.Value)) / 36000
+		}
+		switch {
+		case anchor.WrapSquare != nil:
+			config.WrapText = ImageWrapSquare
+		case anchor.WrapTight != nil, anchor.WrapThrough != nil:
+			config.WrapText = ImageWrapTight
+		case anchor.WrapTopAndBottom != nil:
+			config.WrapText = ImageWrapTopAndBottom
+		default:
+			config.WrapText = ImageWrapNone
+		}
+	} else {
+		config.Position = ImagePositionInline
+	}
+
+	if spPr := wsp.SpPr; spPr != nil {
+		if spPr.PrstGeom != nil && spPr.PrstGeom.Prst != "" {
+			config.Type = ShapeType(spPr.PrstGeom.Prst)
+			if spPr.PrstGeom.AvLst != nil {
+				for _, gd := range spPr.PrstGeom.AvLst.Gd {
+					if config.Adjustments == nil {
+						config.Adjustments = make(map[string]int64)
+					}
+					config.Adjustments[gd.Name] = parseEMU(strings.TrimPrefix(gd.Fmla, "val "))
+				}
+			}
+		}
+		if spPr.Xfrm != nil && spPr.Xfrm.Rot != "" {
+			config.Rotation = float64(parseEMU(spPr.Xfrm.Rot)) / 60000
+		}
+		if spPr.SolidFill != nil && spPr.SolidFill.SrgbClr != nil {
+			config.FillColor = spPr.SolidFill.SrgbClr.Val
+		}
+		if ln := spPr.Ln; ln != nil {
+			if ln.NoFill != nil {
+				config.NoLine = true
+			} else {
+				config.LineWidth = float64(parseEMU(ln.W)) / 12700
+				if ln.SolidFill != nil && ln.SolidFill.SrgbClr != nil {
+					config.LineColor = ln.SolidFill.SrgbClr.Val
+				}
+				if ln.PrstDash != nil {
+					config.LineStyle = ImageBorderStyle(ln.PrstDash.Val)
+				}
+			}
+		}
+	}
+	if wsp.BodyPr != nil {
+		config.TextAnchor = ShapeTextAnchor(wsp.BodyPr.Anchor)
+	}
+	config.Text = shape.Text()
+
+	return shape
+}
+
+// isLineShape 判断形状是否为线条类（不支持填充）
+func isLineShape(shapeType ShapeType) bool {
+	return shapeType == ShapeLine || shapeType == ShapeStraightConnector
+}
+
+// mmToEMU 毫米转EMU
+func mmToEMU(mm float64) int64 {
+	return int64(math.Round(mm * 36000))
+}
+
+// normalizeHexColor 规范化十六进制颜色值（去掉#并转为大写）
+func normalizeHexColor(color string) string {
+	return strings.ToUpper(strings.TrimPrefix(color, "#"))
+}
+
+// parseWordprocessingShape 解析wps:wsp元素
+func (d *Document) parseWordprocessingShape(decoder *xml.Decoder) (*WordprocessingShape, error) {
+	wsp := &WordprocessingShape{}
+
+	err := d.parseChildElements(decoder, "wsp", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "cNvSpPr":
+			wsp.CNvSpPr = &WpsCNvSpPr{TxBox: getAttributeValue(t.Attr, "txBox")}
+		case "cNvCnPr":
+			wsp.CNvCnPr = &WpsCNvCnPr{}
+		case "spPr":
+			spPr, err := d.parseWpsShapeProperties(decoder)
+			wsp.SpPr = spPr
+			return err
+		case "txbx":
+			wsp.Txbx = &WpsTextBox{Content: &TextBoxContent{}}
+			return d.parseChildElements(decoder, "txbx", func(c xml.StartElement) error {
+				if c.Name.Local != "txbxContent" {
+					return d.skipElement(decoder, c.Name.Local)
+				}
+				content := wsp.Txbx.Content
+				return d.parseChildElements(decoder, "txbxContent", func(p xml.StartElement) error {
+					switch p.Name.Local {
+					case "p":
+						para, err := d.parseParagraph(decoder, p)
+						if err != nil {
+							return err
+						}
+						content.Paragraphs = append(content.Paragraphs, para)
+					case "tbl":
+						table, err := d.parseTable(decoder, p)
+						if err != nil {
+							return err
+						}
+						content.Tables = append(content.Tables, NestedTable{Index: len(content.Paragraphs), Table: table})
+					default:
+						return d.skipElement(decoder, p.Name.Local)
+					}
+					return nil
+				})
+			})
+		case "bodyPr":
+			bodyPr := &WpsBodyProperty{
+				Rot:    getAttributeValue(t.Attr, "rot"),
+				Vert:   getAttributeValue(t.Attr, "vert"),
+				Wrap:   getAttributeValue(t.Attr, "wrap"),
+				LIns:   getAttributeValue(t.Attr, "lIns"),
+				TIns:   getAttributeValue(t.Attr, "tIns"),
+				RIns:   getAttributeValue(t.Attr, "rIns"),
+				BIns:   getAttributeValue(t.Attr, "bIns"),
+				Anchor: getAttributeValue(t.Attr, "anchor"),
+			}
+			wsp.BodyPr = bodyPr
+			return d.parseChildElements(decoder, "bodyPr", func(c xml.StartElement) error {
+				switch c.Name.Local {
+				case "noAutofit":
+					bodyPr.NoAutofit = &NoAutofit{}
+				case "spAutoFit":
+					bodyPr.SpAutoFit = &SpAutoFit{}
+				}
+				return d.skipElement(decoder, c.Name.Local)
+			})
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	if wsp.SpPr == nil {
+		wsp.SpPr = &WpsShapeProps{}
+	}
+	if wsp.BodyPr == nil {
+		wsp.BodyPr = &WpsBodyProperty{}
+	}
+	return wsp, err
+}
+
+// parseWpsShapeProperties 解析wps:spPr元素
+func (d *Document) parseWpsShapeProperties(decoder *xml.Decoder) (*WpsShapeProps, error) {
+	spPr := &WpsShapeProps{}
+
+	err := d.parseChildElements(decoder, "spPr", func(t xml.StartElement) error {
+		switch t.Name.Local {
+		case "xfrm":
+			xfrm := &Xfrm{
+				Rot:   getAttributeValue(t.Attr, "rot"),
+				FlipH: getAttributeValue(t.Attr, "flipH"),
+				FlipV: getAttributeValue(t.Attr, "flipV"),
+			}
+			spPr.Xfrm = xfrm
+			return d.parseChildElements(decoder, "xfrm", func(c xml.StartElement) error {
+				switch c.Name.Local {
+				case "off":
+					xfrm.Off = &Off{X: getAttributeValue(c.Attr, "x"), Y: getAttributeValue(c.Attr, "y")}
+				case "ext":
+					xfrm.Ext = &Ext{Cx: getAttributeValue(c.Attr, "cx"), Cy: getAttributeValue(c.Attr, "cy")}
+				}
+				return d.skipElement(decoder, c.Name.Local)
+			})
+		case "prstGeom":
+			geom := &PrstGeom{Prst: getAttributeValue(t.Attr, "prst"), AvLst: &AvLst{}}
+			spPr.PrstGeom = geom
+			return d.parseChildElements(decoder, "prstGeom", func(c xml.StartElement) error {
+				if c.Name.Local != "avLst" {
+					return d.skipElement(decoder, c.Name.Local)
+				}
+				return d.parseChildElements(decoder, "avLst", func(g xml.StartElement) error {
+					if g.Name.Local == "gd" {
+						geom.AvLst.Gd = append(geom.AvLst.Gd, ShapeGuide{
+							Name: getAttributeValue(g.Attr, "name"),
+							Fmla: getAttributeValue(g.Attr, "fmla"),
+						})
+					}
+					return d.skipElement(decoder, g.Name.Local)
+				})
+			})
+		case "noFill":
+			spPr.NoFill = &NoFill{}
+		case "solidFill":
+			fill, err := d.parseSolidFill(decoder)
+			spPr.SolidFill = fill
+			return err
+		case "ln":
+			ln, err := d.parseLn(decoder, t)
+			spPr.Ln = ln
+			return err
+		}
+		return d.skipElement(decoder, t.Name.Local)
+	})
+
+	return spPr, err
+}
+
+// parseAlternateContentDrawing 解析mc:AlternateContent，返回mc:Choice中的绘图元素
+func (d *Document) parseAlternateContentDrawing(decoder *xml.Decoder) (*DrawingElement, error) {
+	var drawing *DrawingElement
+
+	err := d.parseChildElements(decoder, "AlternateContent", func(t xml.StartElement) error {
+		if t.Name.Local != "Choice" || drawing != nil {
+			return d.skipElement(decoder, t.Name.Local)
+		}
+		return d.parseChildElements(decoder, "Choice", func(c xml.StartElement) error {
+			if c.Name.Local != "drawing" {
+				return d.skipElement(decoder, c.Name.Local)
+			}
+			parsed, err := d.parseDrawing(decoder, c)
+			if parsed != nil {
+				drawing = parsed
+			}
+			return err
+		})
+	})
+
+	return drawing, err
+}
@@ -0,0 +1,193 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddTextBox(t *testing.T) {
+	doc := New()
+	doc.AddParagraph("正文")
+
+	box, err := doc.AddTextBox(&ShapeConfig{
+		Width:     60,
+		Height:    30,
+		OffsetX:   100,
+		OffsetY:   20,
+		FillColor: "#fff2cc",
+		LineColor: "BF9000",
+		WrapText:  ImageWrapSquare,
+	})
+	if err != nil {
+		t.Fatalf("添加文本框失败: %v", err)
+	}
+	box.AddFormattedParagraph("关键指标", &TextFormat{Bold: true})
+	box.AddParagraph("营收同比增长 35%")
+
+	if box.Text() != "关键指标\n营收同比增长 35%" {
+		t.Errorf("文本框内容不正确: %q", box.Text())
+	}
+
+	anchor := doc.Body.GetParagraphs()[1].Runs[0].Drawing.Anchor
+	if anchor == nil {
+		t.Fatal("文本框应为浮动绘图")
+	}
+	if anchor.PositionH.PosOffset == nil || anchor.PositionH.PosOffset.Value != "3600000" {
+		t.Errorf("水平位置不正确: %+v", anchor.PositionH)
+	}
+	if anchor.WrapSquare == nil || anchor.WrapSquare.DistL != "114300" {
+		t.Errorf("环绕方式不正确: %+v", anchor.WrapSquare)
+	}
+	if box.Element.CNvSpPr == nil || box.Element.CNvSpPr.TxBox != "1" {
+		t.Error("文本框应标记txBox属性")
+	}
+}
+
+func TestShapesRoundTrip(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddTextBox(&ShapeConfig{Width: 50, Height: 20, Text: "第一行\n第二行"}); err != nil {
+		t.Fatalf("添加文本框失败: %v", err)
+	}
+	if _, err := doc.AddShape(&ShapeConfig{
+		Type:        ShapeRectCallout,
+		Width:       40,
+		Height:      25,
+		OffsetX:     10,
+		OffsetY:     80,
+		FillColor:   "DDEBF7",
+		NoLine:      true,
+		Text:        "注意",
+		TextAnchor:  ShapeTextCenter,
+		Adjustments: map[string]int64{"adj1": -20833, "adj2": 62500},
+	}); err != nil {
+		t.Fatalf("添加标注失败: %v", err)
+	}
+	if _, err := doc.AddConnector(&ConnectorConfig{
+		StartX:    60,
+		StartY:    40,
+		EndX:      20,
+		EndY:      10,
+		LineWidth: 1.5,
+		EndArrow:  ArrowTriangle,
+	}); err != nil {
+		t.Fatalf("添加连接符失败: %v", err)
+	}
+	if _, err := doc.AddImageFromData(createTestImage(10, 10), "a.png", ImageFormatPNG, 10, 10, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+
+	opened := saveAndReopen(t, doc)
+	shapes := opened.ListShapes()
+	if len(shapes) != 3 {
+		t.Fatalf("期望3个形状，得到 %d", len(shapes))
+	}
+	if len(opened.ListImages()) != 1 {
+		t.Errorf("图片不应受形状影响，得到 %d 张图片", len(opened.ListImages()))
+	}
+
+	box := shapes[0]
+	if box.Config.Type != ShapeRectangle || box.Text() != "第一行\n第二行" {
+		t.Errorf("文本框解析不正确: 类型 %s, 文本 %q", box.Config.Type, box.Text())
+	}
+	if int(box.Config.Width+0.5) != 50 || box.Config.FillColor != "FFFFFF" {
+		t.Errorf("文本框属性不正确: %+v", box.Config)
+	}
+
+	callout := shapes[1]
+	if callout.Config.Type != ShapeRectCallout || !callout.Config.NoLine || callout.Config.TextAnchor != ShapeTextCenter {
+		t.Errorf("标注解析不正确: %+v", callout.Config)
+	}
+	if callout.Config.Adjustments["adj1"] != -20833 || callout.Config.Adjustments["adj2"] != 62500 {
+		t.Errorf("标注调整值不正确: %v", callout.Config.Adjustments)
+	}
+	if int(callout.Config.OffsetY+0.5) != 80 {
+		t.Errorf("标注位置不正确: %v", callout.Config.OffsetY)
+	}
+
+	connector := shapes[2]
+	ln := connector.Element.SpPr.Ln
+	if connector.Config.Type != ShapeStraightConnector || connector.Element.CNvCnPr == nil {
+		t.Errorf("连接符解析不正确: %+v", connector.Config)
+	}
+	if ln == nil || ln.TailEnd == nil || ln.TailEnd.Type != "triangle" || ln.HeadEnd != nil {
+		t.Errorf("连接符箭头不正确: %+v", ln)
+	}
+	if xfrm := connector.Element.SpPr.Xfrm; xfrm.FlipH != "1" || xfrm.FlipV != "1" {
+		t.Errorf("连接符方向不正确: %+v", xfrm)
+	}
+
+	// 打开的文档中可以继续编辑文本框
+	box.AddParagraph("第三行")
+	reopened := saveAndReopen(t, opened)
+	if text := reopened.ListShapes()[0].Text(); text != "第一行\n第二行\n第三行" {
+		t.Errorf("编辑后的文本框内容不正确: %q", text)
+	}
+}
+
+func TestParseAlternateContentShape(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddShape(&ShapeConfig{Type: ShapeEllipse, Width: 20, Height: 20, Text: "圆"}); err != nil {
+		t.Fatalf("添加形状失败: %v", err)
+	}
+	data, err := doc.ToBytes()
+	if err != nil {
+		t.Fatalf("序列化文档失败: %v", err)
+	}
+
+	// 将绘图包裹在mc:AlternateContent中，模拟Word保存的格式
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("读取文档失败: %v", err)
+	}
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, file := range reader.File {
+		rc, _ := file.Open()
+		var content bytes.Buffer
+		content.ReadFrom(rc)
+		rc.Close()
+
+		part := content.Bytes()
+		if file.Name == "word/document.xml" {
+			xmlText := strings.Replace(string(part), "<w:drawing>",
+				`<mc:AlternateContent xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"><mc:Choice Requires="wps"><w:drawing>`, 1)
+			xmlText = strings.Replace(xmlText, "</w:drawing>",
+				`</w:drawing></mc:Choice><mc:Fallback><w:pict><v:rect xmlns:v="urn:schemas-microsoft-com:vml"/></w:pict></mc:Fallback></mc:AlternateContent>`, 1)
+			part = []byte(xmlText)
+		}
+		w, _ := writer.Create(file.Name)
+		w.Write(part)
+	}
+	writer.Close()
+
+	filename := filepath.Join(t.TempDir(), "alternate.docx")
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("写入文档失败: %v", err)
+	}
+
+	opened, err := Open(filename)
+	if err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	shapes := opened.ListShapes()
+	if len(shapes) != 1 || shapes[0].Config.Type != ShapeEllipse || shapes[0].Text() != "圆" {
+		t.Fatalf("AlternateContent中的形状解析不正确: %d 个形状", len(shapes))
+	}
+}
+
+func TestShapeValidation(t *testing.T) {
+	doc := New()
+	if _, err := doc.AddShape(nil); err == nil {
+		t.Error("期望配置为空时返回错误")
+	}
+	if _, err := doc.AddShape(&ShapeConfig{Type: ShapeEllipse}); err == nil {
+		t.Error("期望尺寸为0时返回错误")
+	}
+	if _, err := doc.AddConnector(&ConnectorConfig{StartX: 5, StartY: 5, EndX: 5, EndY: 5}); err == nil {
+		t.Error("期望连接符起点和终点相同时返回错误")
+	}
+}
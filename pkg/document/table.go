@@ -93,6 +93,7 @@ type TableCell struct {
 	XMLName    xml.Name             `xml:"w:tc"`
 	Properties *TableCellProperties `xml:"w:tcPr,omitempty"`
 	Paragraphs []Paragraph          `xml:"w:p"`
+	Tables     []NestedTable        `xml:"-"` // 嵌套表格，与段落的先后顺序由Index确定
 }
 
 // TableCellProperties 表格单元格属性
@@ -163,6 +164,11 @@ type TableConfig struct {
 
 // CreateTable 创建一个新表格
 func (d *Document) CreateTable(config *TableConfig) *Table {
+	return newTable(config)
+}
+
+// newTable 按配置创建表格，用于文档中的表格和单元格中的嵌套表格
+func newTable(config *TableConfig) *Table {
 	if config.Rows <= 0 || config.Cols <= 0 {
 		Error("表格行数和列数必须大于0")
 		return nil
@@ -644,6 +650,7 @@ func (t *Table) ClearTable() {
 					},
 				},
 			}
+			t.Rows[i].Cells[j].Tables = nil
 		}
 	}
 	Info("表格内容已清空")
@@ -685,6 +692,16 @@ func (t *Table) CopyTable() *Table {
 					}
 				}
 			}
+			
+			// 复制嵌套表格
+			for _, nested := range cell.Tables {
+				if nested.Table != nil {
+					newTable.Rows[i].Cells[j].Tables = append(newTable.Rows[i].Cells[j].Tables, NestedTable{
+						Index: nested.Index,
+						Table: nested.Table.CopyTable(),
+					})
+				}
+			}
 		}
 	}
 	
@@ -1021,6 +1038,7 @@ func (t *Table) MergeCellsVertical(startRow, endRow, col int) error {
 		}
 		// 清空被合并单元格的内容
 		cell.Paragraphs = []Paragraph{{}}
+		cell.Tables = nil
 	}
 	
 	Info(fmt.Sprintf("垂直合并单元格：行%d到%d，列%d", startRow, endRow, col))
@@ -1201,12 +1219,13 @@ func (t *Table) ClearCellContent(row, col int) error {
 		return err
 	}
 	
-	// 保留格式，只清空文本内容
+	// 保留格式，只清空文本内容，嵌套表格一并移除
 	for i := range cell.Paragraphs {
 		for j := range cell.Paragraphs[i].Runs {
 			cell.Paragraphs[i].Runs[j].Text.Content = ""
 		}
 	}
+	cell.Tables = nil
 	
 	Info(fmt.Sprintf("清空单元格(%d,%d)内容成功", row, col))
 	return nil
@@ -2334,6 +2353,8 @@ type CellIterator struct {
 	currentCol int
 	totalRows  int
 	totalCols  int
+	cells      []*CellInfo // 包含嵌套表格时预先展开的单元格
+	index      int
 }
 
 // CellInfo 单元格信息
@@ -2343,6 +2364,8 @@ type CellInfo struct {
 	Cell   *TableCell // 单元格引用
 	Text   string     // 单元格文本
 	IsLast bool       // 是否为最后一个单元格
+	Table  *Table     // 单元格所在的表格
+	Depth  int        // 嵌套层级，顶层表格为0
 }
 
 // NewCellIterator 创建新的单元格迭代器
@@ -2364,6 +2387,9 @@ func (t *Table) NewCellIterator() *CellIterator {
 
 // HasNext 检查是否还有下一个单元格
 func (iter *CellIterator) HasNext() bool {
+	if iter.cells != nil {
+		return iter.index < len(iter.cells)
+	}
 	if iter.totalRows == 0 || iter.totalCols == 0 {
 		return false
 	}
@@ -2378,6 +2404,11 @@ func (iter *CellIterator) Next() (*CellInfo, error) {
 	if !iter.HasNext() {
 		return nil, fmt.Errorf("没有更多单元格")
 	}
+	if iter.cells != nil {
+		cellInfo := iter.cells[iter.index]
+		iter.index++
+		return cellInfo, nil
+	}
 	
 	// 获取当前单元格
 	cell, err := iter.table.GetCell(iter.currentRow, iter.currentCol)
@@ -2390,10 +2421,11 @@ func (iter *CellIterator) Next() (*CellInfo, error) {
 	
 	// 创建单元格信息
 	cellInfo := &CellInfo{
-		Row:  iter.currentRow,
-		Col:  iter.currentCol,
-		Cell: cell,
-		Text: text,
+		Row:   iter.currentRow,
+		Col:   iter.currentCol,
+		Cell:  cell,
+		Text:  text,
+		Table: iter.table,
 	}
 	
 	// 更新位置并检查是否为最后一个
@@ -2413,20 +2445,36 @@ func (iter *CellIterator) Next() (*CellInfo, error) {
 func (iter *CellIterator) Reset() {
 	iter.currentRow = 0
 	iter.currentCol = 0
+	iter.index = 0
 }
 
 // Current 获取当前位置信息（不移动迭代器）
 func (iter *CellIterator) Current() (int, int) {
+	if iter.cells != nil {
+		if iter.index < len(iter.cells) {
+			return iter.cells[iter.index].Row, iter.cells[iter.index].Col
+		}
+		return iter.totalRows, 0
+	}
 	return iter.currentRow, iter.currentCol
 }
 
 // Total 获取总单元格数量
 func (iter *CellIterator) Total() int {
+	if iter.cells != nil {
+		return len(iter.cells)
+	}
 	return iter.totalRows * iter.totalCols
 }
 
 // Progress 获取迭代进度（0.0-1.0）
 func (iter *CellIterator) Progress() float64 {
+	if iter.cells != nil {
+		if len(iter.cells) == 0 {
+			return 1.0
+		}
+		return float64(iter.index) / float64(len(iter.cells))
+	}
 	if iter.totalRows == 0 || iter.totalCols == 0 {
 		return 1.0
 	}
@@ -2529,6 +2577,7 @@ func (t *Table) GetCellRange(startRow, startCol, endRow, endCol int) ([]*CellInf
 				Cell:   cell,
 				Text:   text,
 				IsLast: row == endRow && col == endCol,
+				Table:  t,
 			}
 			
 			cells = append(cells, cellInfo)
@@ -2545,10 +2594,11 @@ func (t *Table) FindCells(predicate func(row, col int, cell *TableCell, text str
 	err := t.ForEach(func(row, col int, cell *TableCell, text string) error {
 		if predicate(row, col, cell, text) {
 			cellInfo := &CellInfo{
-				Row:  row,
-				Col:  col,
-				Cell: cell,
-				Text: text,
+				Row:   row,
+				Col:   col,
+				Cell:  cell,
+				Text:  text,
+				Table: t,
 			}
 			matchedCells = append(matchedCells, cellInfo)
 		}
@@ -2715,7 +2765,7 @@ func createInlineImageDrawingForCell(imageInfo *ImageInfo, displayWidth, display
 
 // createImageGraphicForCell 创建图片图形元素（用于表格单元格）
 func createImageGraphicForCell(imageInfo *ImageInfo, displayWidth, displayHeight int64, altText, title string) *DrawingGraphic {
-	return &DrawingGraphic{
+	graphic := &DrawingGraphic{
 		Xmlns: "http://schemas.openxmlformats.org/drawingml/2006/main",
 		GraphicData: &GraphicData{
 			Uri: "http://schemas.openxmlformats.org/drawingml/2006/picture",
@@ -2761,6 +2811,10 @@ func createImageGraphicForCell(imageInfo *ImageInfo, displayWidth, displayHeight
 			},
 		},
 	}
+
+	// 应用裁剪、旋转、边框等外观设置
+	applyImageAppearance(graphic.GraphicData.Pic, imageInfo.Config)
+	return graphic
 }
 
 // calculateCellImageDisplaySize 计算单元格中图片的显示尺寸（EMU单位）
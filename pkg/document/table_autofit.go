@@ -0,0 +1,435 @@
+// Package document 提供根据内容自动调整表格列宽的功能
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+const (
+	// defaultAutoFitFontSize 未设置字号时按五号字（10.5磅，21半磅）估算文本宽度
+	defaultAutoFitFontSize = 21
+	// defaultAutoFitCellMargin 表格未设置单元格边距时Word使用的左右边距（Twips）
+	defaultAutoFitCellMargin = 108
+)
+
+// TableAutoFitOptions 自动调整列宽选项
+type TableAutoFitOptions struct {
+	PageSettings *PageSettings // 页面设置，用于计算可用宽度（页面内容区宽度），为空时使用默认页面设置
+	Width        int           // 可用宽度（Twips），大于0时代替页面内容区宽度
+	MinWidths    []int         // 各网格列最小宽度（Twips），0表示不限制
+	MaxWidths    []int         // 各网格列最大宽度（Twips），0表示不限制
+}
+
+// autoFitColumn 列宽测量结果：min为不换行时单词或图片所需的最小宽度，pref为内容不换行时的宽度
+type autoFitColumn struct {
+	min, pref int
+}
+
+// AutoFitContents 根据内容调整列宽
+//
+// 按字号估算单元格文本宽度（中文等全角字符按一个字号宽度计算），图片按其显示宽度计算，
+// 内容不换行即可放下时各列使用内容宽度，否则在可用宽度内按需要换行的程度分配，
+// 每列不小于其中最长单词或图片的宽度。结果写入表格网格和单元格宽度。
+func (t *Table) AutoFitContents(opts *TableAutoFitOptions) error {
+	return t.autoFit(opts, false)
+}
+
+// AutoFitWindow 根据内容调整列宽并使表格占满可用宽度
+//
+// 列宽先按 AutoFitContents 计算，剩余宽度按各列内容宽度的比例分配，不超过列的最大宽度
+func (t *Table) AutoFitWindow(opts *TableAutoFitOptions) error {
+	return t.autoFit(opts, true)
+}
+
+// autoFit 计算并设置列宽，window为true时表格占满可用宽度
+func (t *Table) autoFit(opts *TableAutoFitOptions, window bool) error {
+	if opts == nil {
+		opts = &TableAutoFitOptions{}
+	}
+	count := t.gridColumnCount()
+	if count == 0 {
+		return fmt.Errorf("表格没有列，无法自动调整列宽")
+	}
+	for i := 0; i < count; i++ {
+		minWidth, maxWidth := autoFitLimit(opts.MinWidths, i), autoFitLimit(opts.MaxWidths, i)
+		if minWidth < 0 || maxWidth < 0 {
+			return fmt.Errorf("第%d列的宽度限制不能为负数", i)
+		}
+		if maxWidth > 0 && minWidth > maxWidth {
+			return fmt.Errorf("第%d列的最小宽度%d大于最大宽度%d", i, minWidth, maxWidth)
+		}
+	}
+
+	available := opts.Width
+	if available <= 0 {
+		settings := opts.PageSettings
+		if settings == nil {
+			settings = DefaultPageSettings()
+		}
+		available = pageContentWidth(settings)
+	}
+
+	columns := t.measureColumns(count)
+	for i := range columns {
+		minWidth, maxWidth := autoFitLimit(opts.MinWidths, i), autoFitLimit(opts.MaxWidths, i)
+		if maxWidth > 0 {
+			columns[i].min = min(columns[i].min, maxWidth)
+			columns[i].pref = min(columns[i].pref, maxWidth)
+		}
+		columns[i].min = max(columns[i].min, minWidth)
+		columns[i].pref = max(columns[i].pref, columns[i].min)
+	}
+
+	widths := fitColumnWidths(columns, available)
+	if window {
+		expandColumnWidths(widths, columns, opts.MaxWidths, available)
+	}
+	t.applyColumnWidths(widths)
+
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	if t.Properties == nil {
+		t.Properties = &TableProperties{}
+	}
+	if window && total == available {
+		// 百分比宽度使表格在Word中始终占满页面宽度
+		t.Properties.TableW = &TableWidth{W: "5000", Type: "pct"}
+	} else {
+		t.Properties.TableW = &TableWidth{W: strconv.Itoa(total), Type: "dxa"}
+	}
+	t.Properties.TableLayout = &TableLayoutType{Type: "autofit"}
+
+	Info(fmt.Sprintf("自动调整表格列宽：%d列，总宽度%d", count, total))
+	return nil
+}
+
+// measureColumns 测量各网格列的最小宽度和内容宽度，先处理单列单元格，
+// 跨列单元格超出所跨列宽度之和的部分平均分配到各列
+func (t *Table) measureColumns(count int) []autoFitColumn {
+	padding := t.autoFitCellPadding()
+	columns := make([]autoFitColumn, count)
+	for i := range columns {
+		// 空列至少保留一个字的宽度
+		columns[i] = autoFitColumn{min: padding + defaultAutoFitFontSize*10, pref: padding + defaultAutoFitFontSize*10}
+	}
+
+	type spanCell struct {
+		start, span int
+		size        autoFitColumn
+	}
+	var spans []spanCell
+	for i := range t.Rows {
+		start := 0
+		for j := range t.Rows[i].Cells {
+			cell := &t.Rows[i].Cells[j]
+			span := cellGridSpan(cell)
+			if start >= count {
+				break
+			}
+			span = min(span, count-start)
+			// 垂直合并的续接单元格内容为空，不参与测量
+			if !cellIsVMergeContinue(cell) {
+				size := measureTableCell(cell)
+				size.min += padding
+				size.pref += padding
+				if span == 1 {
+					columns[start].min = max(columns[start].min, size.min)
+					columns[start].pref = max(columns[start].pref, size.pref)
+				} else {
+					spans = append(spans, spanCell{start: start, span: span, size: size})
+				}
+			}
+			start += span
+		}
+	}
+
+	for _, cell := range spans {
+		var minSum, prefSum int
+		for c := cell.start; c < cell.start+cell.span; c++ {
+			minSum += columns[c].min
+			prefSum += columns[c].pref
+		}
+		for k, c := 0, cell.start; c < cell.start+cell.span; k, c = k+1, c+1 {
+			if extra := cell.size.min - minSum; extra > 0 {
+				columns[c].min += shareOf(extra, k, cell.span)
+			}
+			if extra := cell.size.pref - prefSum; extra > 0 {
+				columns[c].pref += shareOf(extra, k, cell.span)
+			}
+			columns[c].pref = max(columns[c].pref, columns[c].min)
+		}
+	}
+	return columns
+}
+
+// autoFitCellPadding 单元格左右边距之和（Twips）
+func (t *Table) autoFitCellPadding() int {
+	left, right := defaultAutoFitCellMargin, defaultAutoFitCellMargin
+	if t.Properties != nil && t.Properties.TableCellMar != nil {
+		if space := t.Properties.TableCellMar.Left; space != nil && space.Type == "dxa" {
+			left, _ = strconv.Atoi(space.W)
+		}
+		if space := t.Properties.TableCellMar.Right; space != nil && space.Type == "dxa" {
+			right, _ = strconv.Atoi(space.W)
+		}
+	}
+	return left + right
+}
+
+// applyColumnWidths 将列宽写入表格网格和单元格宽度
+func (t *Table) applyColumnWidths(widths []int) {
+	if t.Grid == nil {
+		t.Grid = &TableGrid{}
+	}
+	cols := make([]TableGridCol, len(widths))
+	for i, width := range widths {
+		cols[i] = TableGridCol{W: strconv.Itoa(width)}
+	}
+	t.Grid.Cols = cols
+
+	for i := range t.Rows {
+		start := 0
+		for j := range t.Rows[i].Cells {
+			cell := &t.Rows[i].Cells[j]
+			span := cellGridSpan(cell)
+			width := 0
+			for c := start; c < start+span && c < len(widths); c++ {
+				width += widths[c]
+			}
+			start += span
+			if cell.Properties == nil {
+				cell.Properties = &TableCellProperties{}
+			}
+			cell.Properties.TableCellW = &TableCellW{W: strconv.Itoa(width), Type: "dxa"}
+		}
+	}
+}
+
+// fitColumnWidths 在可用宽度内分配列宽：内容宽度之和不超过可用宽度时使用内容宽度，
+// 否则最小宽度之外的空间按各列内容宽度与最小宽度之差的比例分配
+func fitColumnWidths(columns []autoFitColumn, available int) []int {
+	widths := make([]int, len(columns))
+	var minSum, prefSum int
+	for _, column := range columns {
+		minSum += column.min
+		prefSum += column.pref
+	}
+	switch {
+	case prefSum <= available:
+		for i, column := range columns {
+			widths[i] = column.pref
+		}
+	case minSum >= available:
+		// 最小宽度已超出可用宽度，表格超出页面而不截断内容
+		for i, column := range columns {
+			widths[i] = column.min
+		}
+	default:
+		space, flexible := available-minSum, prefSum-minSum
+		allocated, flexSum := 0, 0
+		for i, column := range columns {
+			flexSum += column.pref - column.min
+			share := space * flexSum / flexible
+			widths[i] = column.min + share - allocated
+			allocated = share
+		}
+	}
+	return widths
+}
+
+// expandColumnWidths 将剩余宽度按各列内容宽度的比例分配，不超过最大宽度
+func expandColumnWidths(widths []int, columns []autoFitColumn, maxWidths []int, available int) {
+	for {
+		remaining := available
+		weight := 0
+		for i, width := range widths {
+			remaining -= width
+			if maxWidth := autoFitLimit(maxWidths, i); maxWidth == 0 || width < maxWidth {
+				weight += columns[i].pref
+			}
+		}
+		if remaining <= 0 || weight == 0 {
+			return
+		}
+
+		allocated, weightSum, capped := 0, 0, false
+		for i := range widths {
+			maxWidth := autoFitLimit(maxWidths, i)
+			if maxWidth > 0 && widths[i] >= maxWidth {
+				continue
+			}
+			weightSum += columns[i].pref
+			share := remaining * weightSum / weight
+			widths[i] += share - allocated
+			allocated = share
+			if maxWidth > 0 && widths[i] > maxWidth {
+				widths[i] = maxWidth
+				capped = true
+			}
+		}
+		// 有列达到最大宽度时，将多出的宽度继续分配给其他列
+		if !capped {
+			return
+		}
+	}
+}
+
+// measureTableCell 测量单元格内容的最小宽度和内容宽度（不含单元格边距）
+func measureTableCell(cell *TableCell) autoFitColumn {
+	var size autoFitColumn
+	for i := range cell.Paragraphs {
+		para := measureParagraph(&cell.Paragraphs[i])
+		size.min = max(size.min, para.min)
+		size.pref = max(size.pref, para.pref)
+	}
+	for _, nested := range cell.Tables {
+		if nested.Table == nil || nested.Table.Grid == nil {
+			continue
+		}
+		width := 0
+		for _, col := range nested.Table.Grid.Cols {
+			w, _ := strconv.Atoi(col.W)
+			width += w
+		}
+		size.min = max(size.min, width)
+		size.pref = max(size.pref, width)
+	}
+	return size
+}
+
+// measureParagraph 测量段落：pref为最长一行的宽度，min为最长的不可断开片段宽度。
+// 全角字符之间可以换行，西文按空格断词
+func measureParagraph(para *Paragraph) autoFitColumn {
+	var size autoFitColumn
+	line, word := 0, 0
+	endWord := func() {
+		size.min = max(size.min, word)
+		word = 0
+	}
+	endLine := func() {
+		endWord()
+		size.pref = max(size.pref, line)
+		line = 0
+	}
+
+	for _, run := range para.Runs {
+		if run.Drawing != nil {
+			endWord()
+			width := drawingWidthTwips(run.Drawing)
+			size.min = max(size.min, width)
+			line += width
+		}
+		fontSize := runFontSize(run.Properties)
+		for _, r := range run.Text.Content {
+			switch {
+			case r == '\n':
+				endLine()
+			case unicode.IsSpace(r):
+				endWord()
+				line += charWidthTwips(r, fontSize)
+			case isWideRune(r):
+				endWord()
+				word = charWidthTwips(r, fontSize)
+				line += word
+				endWord()
+			default:
+				width := charWidthTwips(r, fontSize)
+				word += width
+				line += width
+			}
+		}
+		if run.Break != nil {
+			endLine()
+		}
+	}
+	endLine()
+
+	if para.Properties != nil && para.Properties.Indentation != nil {
+		indent := 0
+		if left, err := strconv.Atoi(para.Properties.Indentation.Left); err == nil {
+			indent += left
+		}
+		if right, err := strconv.Atoi(para.Properties.Indentation.Right); err == nil {
+			indent += right
+		}
+		size.min += indent
+		size.pref += indent
+	}
+	return size
+}
+
+// runFontSize 运行的字号（半磅），未设置时使用五号字
+func runFontSize(props *RunProperties) int {
+	if props != nil && props.FontSize != nil {
+		if size, err := strconv.Atoi(props.FontSize.Val); err == nil && size > 0 {
+			return size
+		}
+	}
+	return defaultAutoFitFontSize
+}
+
+// charWidthTwips 估算字符宽度（Twips）：全角字符为一个字号宽度，西文字符按常见比例字体估算
+func charWidthTwips(r rune, fontSize int) int {
+	em := fontSize * 10 // 半磅转换为Twips（1磅 = 20 Twips）
+	switch {
+	case isWideRune(r):
+		return em
+	case r == ' ':
+		return em * 28 / 100
+	case unicode.IsUpper(r):
+		return em * 65 / 100
+	case unicode.IsDigit(r):
+		return em * 55 / 100
+	case r == 'i' || r == 'j' || r == 'l' || r == '.' || r == ',' || r == ':' || r == ';' || r == '\'' || r == '|':
+		return em * 28 / 100
+	case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+		return em * 85 / 100
+	}
+	return em * 52 / 100
+}
+
+// isWideRune 是否为全角字符（汉字、假名、谚文、全角标点等）
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK符号和标点
+		(r >= 0xFF01 && r <= 0xFF60) || (r >= 0xFFE0 && r <= 0xFFE6) // 全角字符
+}
+
+// drawingWidthTwips 图片显示宽度（Twips）
+func drawingWidthTwips(drawing *DrawingElement) int {
+	var extent *DrawingExtent
+	switch {
+	case drawing.Inline != nil:
+		extent = drawing.Inline.Extent
+	case drawing.Anchor != nil:
+		extent = drawing.Anchor.Extent
+	}
+	if extent == nil {
+		return 0
+	}
+	emu, err := strconv.Atoi(extent.Cx)
+	if err != nil {
+		return 0
+	}
+	return emu / 635 // 1 Twip = 635 EMU
+}
+
+// autoFitLimit 返回列宽限制，未设置时为0
+func autoFitLimit(limits []int, col int) int {
+	if col < len(limits) {
+		return limits[col]
+	}
+	return 0
+}
+
+// shareOf 将total平均分为n份时第k份的大小，余数分给最后一份
+func shareOf(total, k, n int) int {
+	if k == n-1 {
+		return total - total/n*(n-1)
+	}
+	return total / n
+}
@@ -0,0 +1,167 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func gridWidths(table *Table) []int {
+	widths := make([]int, len(table.Grid.Cols))
+	for i, col := range table.Grid.Cols {
+		widths[i], _ = strconv.Atoi(col.W)
+	}
+	return widths
+}
+
+func sumWidths(widths []int) int {
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	return total
+}
+
+func TestTableAutoFitContents(t *testing.T) {
+	doc := New()
+	table := doc.AddTable(&TableConfig{Rows: 3, Cols: 3, Width: 9000, Data: [][]string{
+		{"序号", "名称", "说明"},
+		{"1", "wordZero", "纯Go实现的Word文档操作库"},
+		{"2", "表格", "自动调整列宽"},
+	}})
+
+	if err := table.AutoFitContents(&TableAutoFitOptions{Width: 20000}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	widths := gridWidths(table)
+	// 两个五号汉字加左右边距
+	if widths[0] != 2*210+216 {
+		t.Errorf("序号列宽度不正确: %v", widths)
+	}
+	if !(widths[2] > widths[1] && widths[1] > widths[0]) {
+		t.Errorf("列宽应随内容长度增加: %v", widths)
+	}
+	if table.Properties.TableW.Type != "dxa" || table.Properties.TableW.W != strconv.Itoa(sumWidths(widths)) {
+		t.Errorf("表格宽度不正确: %+v", table.Properties.TableW)
+	}
+	if cellW := table.Rows[1].Cells[2].Properties.TableCellW; cellW.W != strconv.Itoa(widths[2]) || cellW.Type != "dxa" {
+		t.Errorf("单元格宽度不正确: %+v", cellW)
+	}
+
+	// 内容超出可用宽度时在可用宽度内换行，每列不小于最长的单词
+	if err := table.AutoFitContents(&TableAutoFitOptions{Width: 3000}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	narrow := gridWidths(table)
+	if sumWidths(narrow) != 3000 || narrow[0] < 210+216 || narrow[1] < 216+charWidthTwips('w', 21)*2 {
+		t.Errorf("受限宽度下的列宽不正确: %v", narrow)
+	}
+
+	// 最小、最大宽度限制
+	if err := table.AutoFitContents(&TableAutoFitOptions{Width: 20000, MinWidths: []int{1000}, MaxWidths: []int{0, 0, 2000}}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	if limited := gridWidths(table); limited[0] != 1000 || limited[2] != 2000 {
+		t.Errorf("列宽限制未生效: %v", limited)
+	}
+	if err := table.AutoFitContents(&TableAutoFitOptions{MinWidths: []int{3000}, MaxWidths: []int{2000}}); err == nil {
+		t.Error("最小宽度大于最大宽度时应返回错误")
+	}
+
+	// 默认使用页面内容区宽度
+	long := strings.Repeat("很长的说明文字", 20)
+	table.SetCellText(1, 2, long)
+	if err := table.AutoFitContents(nil); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	if total := sumWidths(gridWidths(table)); total != doc.contentWidth() {
+		t.Errorf("表格宽度应等于页面内容区宽度%d: %d", doc.contentWidth(), total)
+	}
+	landscape := DefaultPageSettings()
+	landscape.Orientation = OrientationLandscape
+	if err := table.AutoFitContents(&TableAutoFitOptions{PageSettings: landscape}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	if total := sumWidths(gridWidths(table)); total != pageContentWidth(landscape) || total <= doc.contentWidth() {
+		t.Errorf("横向页面的表格宽度不正确: %d", total)
+	}
+}
+
+func TestTableAutoFitWindow(t *testing.T) {
+	table := New().AddTable(&TableConfig{Rows: 2, Cols: 3, Width: 3000, Data: [][]string{
+		{"A", "B", "C"},
+		{"短", "稍长一些", "长一些的内容"},
+	}})
+	if err := table.AutoFitWindow(&TableAutoFitOptions{Width: 9000, MaxWidths: []int{800}}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	widths := gridWidths(table)
+	if sumWidths(widths) != 9000 || widths[0] != 800 || widths[2] <= widths[1] {
+		t.Errorf("占满可用宽度的列宽不正确: %v", widths)
+	}
+	if table.Properties.TableW.Type != "pct" || table.Properties.TableW.W != "5000" {
+		t.Errorf("表格宽度应为100%%: %+v", table.Properties.TableW)
+	}
+
+	// 所有列达到最大宽度时表格不占满可用宽度
+	if err := table.AutoFitWindow(&TableAutoFitOptions{Width: 9000, MaxWidths: []int{1000, 1000, 1000}}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	if widths := gridWidths(table); sumWidths(widths) != 3000 || table.Properties.TableW.Type != "dxa" {
+		t.Errorf("达到最大宽度后的列宽不正确: %v %+v", widths, table.Properties.TableW)
+	}
+}
+
+func TestTableAutoFitMeasure(t *testing.T) {
+	table := New().AddTable(&TableConfig{Rows: 3, Cols: 3, Width: 6000})
+	table.SetCellText(0, 0, "标题")
+	table.Rows[0].Cells[0].Paragraphs[0].Runs[0].Properties = &RunProperties{FontSize: &FontSize{Val: "44"}}
+	table.SetCellText(0, 1, "标题")
+	table.Rows[1].Cells[2].Paragraphs[0].Runs = []Run{{Drawing: &DrawingElement{Inline: &InlineDrawing{
+		Extent: &DrawingExtent{Cx: strconv.Itoa(3000 * 635), Cy: "635000"},
+	}}}}
+	table.SetCellText(2, 0, strings.Repeat("宽", 20))
+	if err := table.MergeCellsHorizontal(2, 0, 1); err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+
+	if err := table.AutoFitContents(&TableAutoFitOptions{Width: 20000}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	widths := gridWidths(table)
+	if widths[0] <= widths[1] {
+		t.Errorf("大字号的列应更宽: %v", widths)
+	}
+	if widths[2] != 3000+216 {
+		t.Errorf("图片列宽度不正确: %v", widths)
+	}
+	if widths[0]+widths[1] != 20*210+216 {
+		t.Errorf("跨列单元格的宽度应分配到所跨的列: %v", widths)
+	}
+	if cellW := table.Rows[2].Cells[0].Properties.TableCellW.W; cellW != strconv.Itoa(widths[0]+widths[1]) {
+		t.Errorf("跨列单元格宽度不正确: %s", cellW)
+	}
+
+	para := measureParagraph(&Paragraph{Runs: []Run{{Text: Text{Content: "ab cd中文"}}, {Break: &Break{}}, {Text: Text{Content: "x"}}}})
+	if para.min != 2*charWidthTwips('a', 21) || para.pref != 4*charWidthTwips('a', 21)+charWidthTwips(' ', 21)+2*210 {
+		t.Errorf("段落测量结果不正确: %+v", para)
+	}
+}
+
+func TestTableAutoFitWithoutProperties(t *testing.T) {
+	table := New().AddTable(&TableConfig{Rows: 1, Cols: 2, Width: 4000, Data: [][]string{{"甲", "乙丙"}}})
+	table.Properties = nil
+	if err := table.AutoFitContents(&TableAutoFitOptions{Width: 9000}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	if table.Properties == nil || table.Properties.TableW == nil || table.Properties.TableLayout == nil {
+		t.Fatal("没有属性的表格应创建表格属性")
+	}
+	table.Properties = nil
+	if err := table.AutoFitWindow(&TableAutoFitOptions{Width: 9000}); err != nil {
+		t.Fatalf("自动调整列宽失败: %v", err)
+	}
+	if table.Properties == nil || table.Properties.TableW.Type != "pct" {
+		t.Errorf("占满可用宽度的表格宽度不正确: %+v", table.Properties)
+	}
+}
@@ -0,0 +1,748 @@
+// Package document 提供从CSV、结构体切片和值切片创建表格的功能
+package document
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tableStructTag 结构体字段的表格列标签，如 `table:"金额,order=2,width=1800,align=right,format=#,##0.00,total"`，"-"表示忽略该字段
+const tableStructTag = "table"
+
+// DefaultTotalsLabel 合计行首列的默认文本
+const DefaultTotalsLabel = "合计"
+
+// minDataColumnWidth 自动分配宽度时的最小列宽（磅）
+const minDataColumnWidth = 400
+
+// TableValueFormatter 单元格值格式化函数。
+// 数据行传入原始值（CSV中为字符串），合计行传入int64（整数列）或float64
+type TableValueFormatter func(value interface{}) string
+
+// TableColumn 从数据创建表格时的列定义
+type TableColumn struct {
+	Field     string              // 匹配的结构体字段名或列标题，为空时按位置对应
+	Header    string              // 标题文本，为空时使用数据中的标题、结构体标签或字段名
+	Width     int                 // 列宽（磅），0表示平均分配剩余宽度
+	Align     CellAlignment       // 水平对齐，为空时数值列右对齐
+	Format    string              // 数值格式（如"#,##0.00"、"0.0%"、"¥#,##0"）、fmt格式（如"%.2f"）或时间布局（如"2006-01-02"）
+	Formatter TableValueFormatter // 自定义格式化函数，优先于Format
+	Total     bool                // 在合计行中对该列求和
+}
+
+// TableDataOptions 从数据创建表格的选项
+type TableDataOptions struct {
+	Width        int           // 表格总宽度（磅），0表示页面内容区宽度
+	Columns      []TableColumn // 列定义，覆盖数据标题和结构体标签中的设置
+	NoHeader     bool          // CSV和[][]interface{}的首行为数据而非标题（可通过Columns指定标题）；结构体不输出标题行
+	HeaderFormat *TextFormat   // 标题行文字格式，为空时加粗
+	TotalsRow    bool          // 在末尾添加合计行，没有列指定Total时对所有数值列求和
+	TotalsLabel  string        // 合计行首列文本，为空时为"合计"
+	BorderVal    string        // 表格边框样式，为空时为"single"
+	Comma        rune          // CSV分隔符，0表示逗号
+}
+
+// tableDataColumn 解析后的数据列
+type tableDataColumn struct {
+	TableColumn
+	name       string // 结构体字段名，用于匹配TableColumn.Field
+	fieldIndex []int  // 结构体字段索引
+	order      int    // 结构体标签中的列序号
+	hasOrder   bool
+	numeric    bool // 数值列：右对齐，可参与合计
+	integer    bool // 所有值均为整数
+	parse      bool // 字符串值按数字解析（CSV）
+}
+
+// tableNumberPattern CSV中可识别为数字的文本，允许千分位，不含前导零
+var tableNumberPattern = regexp.MustCompile(`^[-+]?(0|[1-9]\d*|[1-9]\d{0,2}(,\d{3})+)(\.\d+)?$`)
+
+// tableFmtVerb 判断格式是否为fmt格式字符串
+var tableFmtVerb = regexp.MustCompile(`%[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+// AddTableFromCSV 从CSV数据创建表格并添加到文档中
+//
+// 默认首行为标题行；所有非空值都是数字的列识别为数值列，右对齐并可参与合计。
+// 数值列未指定格式时保留CSV中的原始文本，Formatter接收原始字符串。
+func (d *Document) AddTableFromCSV(reader io.Reader, opts *TableDataOptions) (*Table, error) {
+	if opts == nil {
+		opts = &TableDataOptions{}
+	}
+	csvReader := csv.NewReader(reader)
+	if opts.Comma != 0 {
+		csvReader.Comma = opts.Comma
+	}
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, WrapError("read_csv", err)
+	}
+
+	rows := make([][]interface{}, len(records))
+	for i, record := range records {
+		rows[i] = make([]interface{}, len(record))
+		for j, value := range record {
+			if i == 0 && j == 0 {
+				value = strings.TrimPrefix(value, "\ufeff")
+			}
+			rows[i][j] = value
+		}
+	}
+	return d.addValueTable(rows, opts, true)
+}
+
+// AddTableFromValues 从值切片创建表格并添加到文档中
+//
+// 默认首行为标题行；所有非空值都是数值类型的列识别为数值列，右对齐并可参与合计，
+// time.Time按Format中的布局输出，实现了fmt.Stringer的类型使用String()。
+func (d *Document) AddTableFromValues(rows [][]interface{}, opts *TableDataOptions) (*Table, error) {
+	if opts == nil {
+		opts = &TableDataOptions{}
+	}
+	return d.addValueTable(rows, opts, false)
+}
+
+// AddTableFromStructs 从结构体切片创建表格并添加到文档中
+//
+// slice为结构体或结构体指针的切片，每个导出字段为一列，通过table标签设置列：
+//
+//	type Item struct {
+//		Name   string    `table:"名称,width=3000"`
+//		Price  float64   `table:"单价,format=¥#,##0.00"`
+//		Amount float64   `table:"金额,format=#,##0.00,total"`
+//		Date   time.Time `table:"日期,order=1,format=2006-01-02"`
+//		ID     int       `table:"-"`
+//	}
+//
+// 标签首项为标题（为空时使用字段名），选项有order（列序号，未指定的列按声明顺序排在后面）、
+// width（列宽，磅）、align（left、center、right、both）、format（可以包含逗号，应放在total之前或最后）
+// 和total（合计该列）。数值类型的字段为数值列，右对齐。
+func (d *Document) AddTableFromStructs(slice interface{}, opts *TableDataOptions) (*Table, error) {
+	if opts == nil {
+		opts = &TableDataOptions{}
+	}
+	v := reflect.ValueOf(slice)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, NewValidationError("slice", fmt.Sprintf("%T", slice), "value must be a slice of structs")
+	}
+	elemType := v.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, NewValidationError("slice", fmt.Sprintf("%T", slice), "value must be a slice of structs")
+	}
+
+	columns, err := tableStructColumns(elemType)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, v.Len())
+	for i := range rows {
+		rows[i] = make([]interface{}, len(columns))
+		item := v.Index(i)
+		for item.Kind() == reflect.Ptr && !item.IsNil() {
+			item = item.Elem()
+		}
+		if item.Kind() != reflect.Struct {
+			continue
+		}
+		for j, column := range columns {
+			if field, err := item.FieldByIndexErr(column.fieldIndex); err == nil && field.CanInterface() {
+				rows[i][j] = field.Interface()
+			}
+		}
+	}
+
+	if err := applyTableColumns(columns, opts.Columns); err != nil {
+		return nil, err
+	}
+	return d.addDataTable(columns, rows, !opts.NoHeader, opts)
+}
+
+// addValueTable 从值切片创建表格，parse为true时数字文本识别为数值
+func (d *Document) addValueTable(rows [][]interface{}, opts *TableDataOptions, parse bool) (*Table, error) {
+	var headers []interface{}
+	if !opts.NoHeader && len(rows) > 0 {
+		headers, rows = rows[0], rows[1:]
+	}
+	cols := len(headers)
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return nil, NewValidationError("rows", "", "table data cannot be empty")
+	}
+
+	columns := make([]*tableDataColumn, cols)
+	for j := range columns {
+		column := &tableDataColumn{parse: parse}
+		if j < len(headers) {
+			column.Header = formatTableValue(headers[j], "")
+			column.name = column.Header
+		}
+		column.numeric, column.integer = tableColumnNumeric(rows, j, parse)
+		columns[j] = column
+	}
+	if err := applyTableColumns(columns, opts.Columns); err != nil {
+		return nil, err
+	}
+
+	showHeader := false
+	for _, column := range columns {
+		if column.Header != "" {
+			showHeader = true
+		}
+	}
+	return d.addDataTable(columns, rows, showHeader, opts)
+}
+
+// addDataTable 按列定义格式化数据，创建表格并添加到文档中
+func (d *Document) addDataTable(columns []*tableDataColumn, rows [][]interface{}, showHeader bool, opts *TableDataOptions) (*Table, error) {
+	var data [][]string
+	if showHeader {
+		header := make([]string, len(columns))
+		for j, column := range columns {
+			header[j] = column.Header
+		}
+		data = append(data, header)
+	}
+
+	// 合计行：有列指定Total时只合计这些列，否则TotalsRow对所有数值列求和
+	totalColumns := make([]bool, len(columns))
+	hasTotals := false
+	for j, column := range columns {
+		if column.Total {
+			totalColumns[j], hasTotals = true, true
+		}
+	}
+	if !hasTotals && opts.TotalsRow {
+		for j, column := range columns {
+			totalColumns[j] = column.numeric
+		}
+		hasTotals = true
+	}
+
+	sums := make([]float64, len(columns))
+	decimals := make([]int, len(columns))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for j, column := range columns {
+			var value interface{}
+			if j < len(row) {
+				value = row[j]
+			}
+			cells[j] = column.text(value)
+			if number, _, ok := tableNumber(value, column.parse); ok && column.numeric {
+				sums[j] += number
+				if dot := strings.LastIndex(cells[j], "."); dot >= 0 && len(cells[j])-dot-1 > decimals[j] {
+					decimals[j] = len(cells[j]) - dot - 1
+				}
+			}
+		}
+		data = append(data, cells)
+	}
+
+	if hasTotals {
+		label := opts.TotalsLabel
+		if label == "" {
+			label = DefaultTotalsLabel
+		}
+		cells := make([]string, len(columns))
+		for j, column := range columns {
+			if totalColumns[j] {
+				cells[j] = column.totalText(sums[j], decimals[j])
+			}
+		}
+		if !totalColumns[0] {
+			cells[0] = label
+		}
+		data = append(data, cells)
+	}
+	if len(data) == 0 {
+		return nil, NewValidationError("rows", "", "table data cannot be empty")
+	}
+
+	width := opts.Width
+	if width <= 0 {
+		width = d.contentWidth()
+	}
+	colWidths := dataColumnWidths(columns, width)
+	width = 0
+	for _, w := range colWidths {
+		width += w
+	}
+
+	table := newTable(&TableConfig{
+		Rows:      len(data),
+		Cols:      len(columns),
+		Width:     width,
+		ColWidths: colWidths,
+		Data:      data,
+		BorderVal: opts.BorderVal,
+	})
+	if table == nil {
+		return nil, NewValidationError("rows", "", "failed to create table")
+	}
+
+	headerFormat := opts.HeaderFormat
+	if headerFormat == nil {
+		headerFormat = &TextFormat{Bold: true}
+	}
+	for i := range data {
+		var textFormat *TextFormat
+		if showHeader && i == 0 {
+			textFormat = headerFormat
+		} else if hasTotals && i == len(data)-1 {
+			textFormat = &TextFormat{Bold: true}
+		}
+		for j, column := range columns {
+			align := column.Align
+			if align == "" && column.numeric && !(hasTotals && i == len(data)-1 && j == 0 && !totalColumns[0]) {
+				align = CellAlignRight
+			}
+			if align == "" && textFormat == nil {
+				continue
+			}
+			if err := table.SetCellFormat(i, j, &CellFormat{HorizontalAlign: align, TextFormat: textFormat}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if showHeader {
+		if err := table.SetRowAsHeader(0, true); err != nil {
+			return nil, err
+		}
+	}
+
+	d.Body.Elements = append(d.Body.Elements, table)
+	Info(fmt.Sprintf("从数据创建表格：%d行 x %d列", len(data), len(columns)))
+	return table, nil
+}
+
+// text 格式化数据行中的值
+func (c *tableDataColumn) text(value interface{}) string {
+	if c.Formatter != nil {
+		return c.Formatter(value)
+	}
+	if c.parse && c.Format != "" {
+		if number, integer, ok := tableNumber(value, true); ok {
+			if integer {
+				return formatTableValue(int64(number), c.Format)
+			}
+			return formatTableValue(number, c.Format)
+		}
+	}
+	return formatTableValue(value, c.Format)
+}
+
+// totalText 格式化合计值，未指定格式时使用该列数据的最大小数位数
+func (c *tableDataColumn) totalText(sum float64, decimals int) string {
+	var total interface{} = sum
+	if c.integer {
+		total = int64(math.Round(sum))
+	}
+	if c.Formatter != nil {
+		return c.Formatter(total)
+	}
+	if c.Format != "" {
+		return formatTableValue(total, c.Format)
+	}
+	if c.integer {
+		return strconv.FormatInt(total.(int64), 10)
+	}
+	return strconv.FormatFloat(sum, 'f', decimals, 64)
+}
+
+// tableStructColumns 按table标签解析结构体的列
+func tableStructColumns(t reflect.Type) ([]*tableDataColumn, error) {
+	var columns []*tableDataColumn
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		column := &tableDataColumn{name: field.Name, fieldIndex: field.Index}
+		skip, err := parseTableStructTag(field, column)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			continue
+		}
+		column.numeric, column.integer = tableNumericType(field.Type)
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, NewValidationError("slice", t.String(), "struct has no exported fields")
+	}
+
+	sort.SliceStable(columns, func(i, j int) bool {
+		if columns[i].hasOrder != columns[j].hasOrder {
+			return columns[i].hasOrder
+		}
+		return columns[i].hasOrder && columns[i].order < columns[j].order
+	})
+	return columns, nil
+}
+
+// parseTableStructTag 解析字段的table标签，标签为"-"时返回skip
+func parseTableStructTag(field reflect.StructField, column *tableDataColumn) (skip bool, err error) {
+	tag := field.Tag.Get(tableStructTag)
+	if tag == "-" {
+		return true, nil
+	}
+	parts := strings.Split(tag, ",")
+	column.Header = strings.TrimSpace(parts[0])
+	if column.Header == "" {
+		column.Header = field.Name
+	}
+
+	invalid := func(option string) error {
+		return NewValidationError("tag", field.Name+": "+tag, "invalid table tag option "+option)
+	}
+	last := ""
+	for _, part := range parts[1:] {
+		key, value, hasValue := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if !hasValue {
+			switch {
+			case key == "total":
+				column.Total = true
+				last = ""
+			case last == "format":
+				// 格式中的逗号，如 format=#,##0.00
+				column.Format += "," + part
+			default:
+				return false, invalid(part)
+			}
+			continue
+		}
+
+		last = key
+		switch key {
+		case "order":
+			if column.order, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return false, invalid(part)
+			}
+			column.hasOrder = true
+		case "width":
+			if column.Width, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || column.Width < 0 {
+				return false, invalid(part)
+			}
+		case "align":
+			switch align := strings.TrimSpace(value); align {
+			case "left", "center", "right", "both":
+				column.Align = CellAlignment(align)
+			case "justify":
+				column.Align = CellAlignJustify
+			default:
+				return false, invalid(part)
+			}
+		case "format":
+			column.Format = value
+		default:
+			return false, invalid(part)
+		}
+	}
+	return false, nil
+}
+
+// applyTableColumns 将选项中的列定义应用到解析后的列上
+func applyTableColumns(columns []*tableDataColumn, defs []TableColumn) error {
+	for i, def := range defs {
+		var target *tableDataColumn
+		if def.Field == "" {
+			if i >= len(columns) {
+				return NewValidationError("Columns", strconv.Itoa(i), "column index out of range")
+			}
+			target = columns[i]
+		} else {
+			for _, column := range columns {
+				if column.name == def.Field || column.Header == def.Field {
+					target = column
+					break
+				}
+			}
+			if target == nil {
+				return NewValidationError("Columns.Field", def.Field, "column not found")
+			}
+		}
+
+		if def.Header != "" {
+			target.Header = def.Header
+		}
+		if def.Width > 0 {
+			target.Width = def.Width
+		}
+		if def.Align != "" {
+			target.Align = def.Align
+		}
+		if def.Format != "" {
+			target.Format = def.Format
+		}
+		if def.Formatter != nil {
+			target.Formatter = def.Formatter
+		}
+		if def.Total {
+			target.Total = true
+		}
+	}
+	return nil
+}
+
+// dataColumnWidths 计算列宽：指定宽度的列保持不变，其余列平均分配剩余宽度
+func dataColumnWidths(columns []*tableDataColumn, width int) []int {
+	fixed, flexible := 0, 0
+	for _, column := range columns {
+		if column.Width > 0 {
+			fixed += column.Width
+		} else {
+			flexible++
+		}
+	}
+	share := 0
+	if flexible > 0 {
+		share = (width - fixed) / flexible
+		if share < minDataColumnWidth {
+			share = minDataColumnWidth
+		}
+	}
+
+	widths := make([]int, len(columns))
+	for j, column := range columns {
+		widths[j] = column.Width
+		if widths[j] <= 0 {
+			widths[j] = share
+		}
+	}
+	return widths
+}
+
+// tableColumnNumeric 判断第col列的非空值是否全部为数字
+func tableColumnNumeric(rows [][]interface{}, col int, parse bool) (numeric, integer bool) {
+	integer = true
+	for _, row := range rows {
+		if col >= len(row) || tableValueIsEmpty(row[col]) {
+			continue
+		}
+		_, isInteger, ok := tableNumber(row[col], parse)
+		if !ok {
+			return false, false
+		}
+		numeric = true
+		integer = integer && isInteger
+	}
+	return numeric, numeric && integer
+}
+
+// tableValueIsEmpty 值为nil、空指针或空字符串
+func tableValueIsEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.String && strings.TrimSpace(v.String()) == ""
+}
+
+// tableNumericType 判断类型是否为数值类型，实现了fmt.Stringer的类型（如枚举）不作为数值
+func tableNumericType(t reflect.Type) (numeric, integer bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	stringer := reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	if t.Implements(stringer) || reflect.PtrTo(t).Implements(stringer) {
+		return false, false
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true, true
+	case reflect.Float32, reflect.Float64:
+		return true, false
+	}
+	return false, false
+}
+
+// tableNumber 将值转换为数字，parse为true时解析数字文本
+func tableNumber(value interface{}, parse bool) (number float64, integer, ok bool) {
+	if value == nil {
+		return 0, false, false
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String && parse {
+		text := strings.TrimSpace(v.String())
+		if !tableNumberPattern.MatchString(text) {
+			return 0, false, false
+		}
+		number, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), 64)
+		return number, !strings.Contains(text, "."), err == nil
+	}
+
+	numeric, integer := tableNumericType(v.Type())
+	if !numeric {
+		return 0, false, false
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true, true
+	}
+	return v.Float(), integer, true
+}
+
+// formatTableValue 按格式将值转换为单元格文本
+//
+// format可以是数值格式（见formatNumberPattern）、fmt格式字符串或time.Time的布局，
+// 为空时time.Time输出日期（有时间部分时输出日期和时间），nil和空指针输出空文本。
+func formatTableValue(value interface{}, format string) string {
+	if value == nil {
+		return ""
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.CanInterface() {
+		return ""
+	}
+	value = v.Interface()
+
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		layout := format
+		if layout == "" {
+			layout = "2006-01-02"
+			if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+				layout = "2006-01-02 15:04:05"
+			}
+		}
+		return t.Format(layout)
+	}
+	if format != "" && tableFmtVerb.MatchString(format) {
+		return fmt.Sprintf(format, value)
+	}
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if format != "" {
+			return formatNumberPattern(float64(v.Int()), format)
+		}
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if format != "" {
+			return formatNumberPattern(float64(v.Uint()), format)
+		}
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		if format != "" {
+			return formatNumberPattern(v.Float(), format)
+		}
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+	return fmt.Sprint(value)
+}
+
+// formatNumberPattern 按Excel风格的数值格式输出数字
+//
+// 格式由前缀、数字部分和后缀组成，如"¥#,##0.00"、"0.0%"、"#,##0 元"：数字部分含逗号时使用千分位，
+// 小数点后的0为固定小数位、#为可选小数位，后缀含%时数值乘以100。
+func formatNumberPattern(value float64, pattern string) string {
+	return formatNumberPicture(value, pattern, true)
+}
+
+// formatNumberPicture 按数值格式输出数字，percent为false时后缀中的%仅作为文字输出（Word域格式的行为）
+func formatNumberPicture(value float64, pattern string, percent bool) string {
+	start := strings.IndexAny(pattern, "#0")
+	if start < 0 {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	end := start
+	for end < len(pattern) && strings.IndexByte("#0,.", pattern[end]) >= 0 {
+		end++
+	}
+	prefix, body, suffix := pattern[:start], pattern[start:end], pattern[end:]
+	if percent && strings.Contains(suffix, "%") {
+		value *= 100
+	}
+
+	minDecimals, maxDecimals := 0, 0
+	if dot := strings.IndexByte(body, '.'); dot >= 0 {
+		for _, c := range body[dot+1:] {
+			if c == '0' {
+				minDecimals++
+			}
+			if c == '0' || c == '#' {
+				maxDecimals++
+			}
+		}
+	}
+	// 四舍五入（FormatFloat对恰好位于中间的值按银行家舍入）
+	scale := math.Pow(10, float64(maxDecimals))
+	text := strconv.FormatFloat(math.Round(math.Abs(value)*scale)/scale, 'f', maxDecimals, 64)
+	if maxDecimals > minDecimals {
+		text = strings.TrimRight(text, "0")
+		if dot := strings.IndexByte(text, '.'); len(text)-dot-1 < minDecimals {
+			text += strings.Repeat("0", minDecimals-(len(text)-dot-1))
+		}
+		text = strings.TrimSuffix(text, ".")
+	}
+
+	intPart, fraction, _ := strings.Cut(text, ".")
+	if strings.Contains(body, ",") {
+		var grouped strings.Builder
+		for i, c := range intPart {
+			if i > 0 && (len(intPart)-i)%3 == 0 {
+				grouped.WriteByte(',')
+			}
+			grouped.WriteRune(c)
+		}
+		intPart = grouped.String()
+	}
+	if fraction != "" {
+		intPart += "." + fraction
+	}
+
+	sign := ""
+	if value < 0 && strings.Trim(text, "0.") != "" {
+		sign = "-"
+	}
+	return sign + prefix + intPart + suffix
+}
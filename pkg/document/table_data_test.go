@@ -0,0 +1,242 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type tableDataStatus int
+
+func (s tableDataStatus) String() string {
+	if s == 1 {
+		return "已付款"
+	}
+	return "待付款"
+}
+
+type tableDataOrder struct {
+	No      string          `table:"订单号,width=2000"`
+	Qty     int             `table:"数量,total"`
+	Price   float64         `table:"单价,format=¥#,##0.00"`
+	Amount  float64         `table:"金额,format=#,##0.00,total"`
+	Date    time.Time       `table:"日期,order=1,format=2006/01/02"`
+	Status  tableDataStatus `table:"状态"`
+	Note    *string
+	private int
+	ID      int `table:"-"`
+}
+
+func tableRowTexts(table *Table, row int) []string {
+	texts := make([]string, len(table.Rows[row].Cells))
+	for col := range texts {
+		texts[col], _ = table.GetCellText(row, col)
+	}
+	return texts
+}
+
+func cellAlignment(table *Table, row, col int) string {
+	props := table.Rows[row].Cells[col].Paragraphs[0].Properties
+	if props == nil || props.Justification == nil {
+		return ""
+	}
+	return props.Justification.Val
+}
+
+func TestAddTableFromStructs(t *testing.T) {
+	doc := New()
+	note := "加急"
+	orders := []*tableDataOrder{
+		{No: "SO-001", Qty: 2, Price: 1234.5, Amount: 2469, Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Status: 1, Note: &note},
+		{No: "SO-002", Qty: 10, Price: 0.25, Amount: 2.5, Date: time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	table, err := doc.AddTableFromStructs(orders, &TableDataOptions{
+		Width: 9000,
+		Columns: []TableColumn{
+			{Field: "Note", Header: "备注", Formatter: func(value interface{}) string {
+				if s, ok := value.(*string); ok && s != nil {
+					return "【" + *s + "】"
+				}
+				return "-"
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("创建表格失败: %v", err)
+	}
+
+	expected := [][]string{
+		{"日期", "订单号", "数量", "单价", "金额", "状态", "备注"},
+		{"2024/03/05", "SO-001", "2", "¥1,234.50", "2,469.00", "已付款", "【加急】"},
+		{"2024/03/06", "SO-002", "10", "¥0.25", "2.50", "待付款", "-"},
+		{"合计", "", "12", "", "2,471.50", "", ""},
+	}
+	if len(table.Rows) != len(expected) {
+		t.Fatalf("行数应为%d: %d", len(expected), len(table.Rows))
+	}
+	for i, row := range expected {
+		if got := tableRowTexts(table, i); strings.Join(got, "|") != strings.Join(row, "|") {
+			t.Errorf("第%d行内容不正确: %v", i, got)
+		}
+	}
+
+	if isHeader, _ := table.IsRowHeader(0); !isHeader {
+		t.Error("首行应标记为标题行")
+	}
+	if run := table.Rows[0].Cells[0].Paragraphs[0].Runs[0]; run.Properties == nil || run.Properties.Bold == nil {
+		t.Error("标题行应加粗")
+	}
+	if cellAlignment(table, 1, 2) != "right" || cellAlignment(table, 3, 4) != "right" || cellAlignment(table, 1, 1) != "" {
+		t.Error("数值列应右对齐，文本列保持默认对齐")
+	}
+	if cellAlignment(table, 1, 5) != "" {
+		t.Error("实现了Stringer的枚举不应作为数值列")
+	}
+
+	// 指定宽度的列保持不变，其余列平均分配
+	if table.Grid.Cols[1].W != "2000" || table.Grid.Cols[0].W != "1166" || table.Properties.TableW.W != "8996" {
+		t.Errorf("列宽不正确: %v %s", table.Grid.Cols, table.Properties.TableW.W)
+	}
+
+	if _, err := doc.AddTableFromStructs([]int{1}, nil); err == nil {
+		t.Error("非结构体切片应返回错误")
+	}
+	type badTag struct {
+		Name string `table:"名称,size=3"`
+	}
+	if _, err := doc.AddTableFromStructs([]badTag{{}}, nil); err == nil {
+		t.Error("无效标签应返回错误")
+	}
+	if _, err := doc.AddTableFromStructs(orders, &TableDataOptions{Columns: []TableColumn{{Field: "Missing"}}}); err == nil {
+		t.Error("找不到的列应返回错误")
+	}
+
+	// 不输出标题行，未指定宽度时使用页面内容区宽度
+	plain, err := doc.AddTableFromStructs(orders[:1], &TableDataOptions{NoHeader: true})
+	if err != nil {
+		t.Fatalf("创建表格失败: %v", err)
+	}
+	if len(plain.Rows) != 2 || plain.Properties.TableW.W != fmt.Sprint(2000+(doc.contentWidth()-2000)/6*6) {
+		t.Errorf("无标题表格不正确: %d行 宽度%s", len(plain.Rows), plain.Properties.TableW.W)
+	}
+}
+
+func TestAddTableFromCSV(t *testing.T) {
+	doc := New()
+	csvData := "\ufeff部门,人数,预算,编码\n研发,12,\"1,200.50\",007\n市场,3,800,010\n行政,,99.5,100\n"
+	table, err := doc.AddTableFromCSV(strings.NewReader(csvData), &TableDataOptions{
+		TotalsRow:   true,
+		TotalsLabel: "总计",
+	})
+	if err != nil {
+		t.Fatalf("创建表格失败: %v", err)
+	}
+
+	expected := [][]string{
+		{"部门", "人数", "预算", "编码"},
+		{"研发", "12", "1,200.50", "007"},
+		{"市场", "3", "800", "010"},
+		{"行政", "", "99.5", "100"},
+		{"总计", "15", "2100.00", ""},
+	}
+	for i, row := range expected {
+		if got := tableRowTexts(table, i); strings.Join(got, "|") != strings.Join(row, "|") {
+			t.Errorf("第%d行内容不正确: %v", i, got)
+		}
+	}
+	if cellAlignment(table, 1, 1) != "right" || cellAlignment(table, 1, 3) != "" {
+		t.Error("带前导零的编码列不应作为数值列")
+	}
+	if cellAlignment(table, 4, 0) != "" {
+		t.Error("合计标签不应右对齐")
+	}
+
+	// 分隔符、无标题行与按位置指定的列
+	table, err = doc.AddTableFromCSV(strings.NewReader("A;1234.5\nB;0.5\n"), &TableDataOptions{
+		Comma:    ';',
+		NoHeader: true,
+		Columns:  []TableColumn{{Header: "项目"}, {Header: "占比", Format: "0.0%", Total: true}},
+	})
+	if err != nil {
+		t.Fatalf("创建表格失败: %v", err)
+	}
+	if got := tableRowTexts(table, 1); got[1] != "123450.0%" {
+		t.Errorf("百分比格式不正确: %v", got)
+	}
+	if got := tableRowTexts(table, 3); got[0] != "合计" || got[1] != "123500.0%" {
+		t.Errorf("合计行不正确: %v", got)
+	}
+
+	if _, err := doc.AddTableFromCSV(strings.NewReader("a,\"b\n"), nil); err == nil {
+		t.Error("无效CSV应返回错误")
+	}
+	if _, err := doc.AddTableFromCSV(strings.NewReader(""), nil); err == nil {
+		t.Error("空CSV应返回错误")
+	}
+}
+
+func TestAddTableFromValues(t *testing.T) {
+	doc := New()
+	table, err := doc.AddTableFromValues([][]interface{}{
+		{"产品", "销量", "增长"},
+		{"A", 1200, 0.125},
+		{"B", nil, -0.05},
+	}, &TableDataOptions{
+		Columns: []TableColumn{
+			{Field: "增长", Format: "%+.1f%%"},
+			{Field: "销量", Formatter: func(value interface{}) string {
+				if value == nil {
+					return "N/A"
+				}
+				return fmt.Sprintf("%v件", value)
+			}},
+		},
+		TotalsRow: true,
+	})
+	if err != nil {
+		t.Fatalf("创建表格失败: %v", err)
+	}
+	expected := [][]string{
+		{"产品", "销量", "增长"},
+		{"A", "1200件", "+0.1%"},
+		{"B", "N/A", "-0.1%"},
+		{"合计", "1200件", "+0.1%"},
+	}
+	for i, row := range expected {
+		if got := tableRowTexts(table, i); strings.Join(got, "|") != strings.Join(row, "|") {
+			t.Errorf("第%d行内容不正确: %v", i, got)
+		}
+	}
+}
+
+func TestFormatTableValue(t *testing.T) {
+	cases := []struct {
+		value  interface{}
+		format string
+		want   string
+	}{
+		{1234567.891, "#,##0.00", "1,234,567.89"},
+		{-1234.5, "¥#,##0.00", "-¥1,234.50"},
+		{0.1234, "0.0%", "12.3%"},
+		{2.5, "0.##", "2.5"},
+		{2.0, "0.0#", "2.0"},
+		{1234, "#,##0 元", "1,234 元"},
+		{-0.001, "0.00", "0.00"},
+		{3.14159, "%.2f", "3.14"},
+		{int64(42), "%05d", "00042"},
+		{uint8(7), "", "7"},
+		{float32(0.1), "", "0.1"},
+		{true, "", "true"},
+		{time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), "", "2024-01-02"},
+		{time.Date(2024, 1, 2, 8, 30, 0, 0, time.UTC), "", "2024-01-02 08:30:00"},
+		{time.Time{}, "", ""},
+		{(*int)(nil), "", ""},
+		{nil, "", ""},
+	}
+	for _, c := range cases {
+		if got := formatTableValue(c.value, c.format); got != c.want {
+			t.Errorf("formatTableValue(%v, %q) = %q，期望 %q", c.value, c.format, got, c.want)
+		}
+	}
+}
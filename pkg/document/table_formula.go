@@ -0,0 +1,910 @@
+// Package document 提供表格公式域的生成与计算功能
+package document
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 公式计算失败时写入域结果的文本，与Word显示的错误一致
+const (
+	formulaSyntaxError = "!Syntax Error"
+	formulaZeroDivide  = "!Zero Divide"
+)
+
+// formulaCellRefPattern A1形式的单元格引用：列字母加行号
+var formulaCellRefPattern = regexp.MustCompile(`^([A-Z]{1,3})([0-9]+)$`)
+
+// formulaFunction 公式函数定义，list为true时参数可以是区域，所有参数的值合并计算
+type formulaFunction struct {
+	minArgs, maxArgs int // maxArgs为-1表示不限制
+	list             bool
+}
+
+// formulaFunctions 支持的函数，与Word公式域一致
+var formulaFunctions = map[string]formulaFunction{
+	"SUM":     {1, -1, true},
+	"AVERAGE": {1, -1, true},
+	"COUNT":   {1, -1, true},
+	"MAX":     {1, -1, true},
+	"MIN":     {1, -1, true},
+	"PRODUCT": {1, -1, true},
+	"ABS":     {1, 1, false},
+	"INT":     {1, 1, false},
+	"SIGN":    {1, 1, false},
+	"NOT":     {1, 1, false},
+	"MOD":     {2, 2, false},
+	"ROUND":   {2, 2, false},
+	"AND":     {2, 2, false},
+	"OR":      {2, 2, false},
+	"IF":      {3, 3, false},
+}
+
+// formulaError 公式错误，result为写入域结果的Word错误文本
+type formulaError struct {
+	result  string
+	message string
+}
+
+func (e *formulaError) Error() string {
+	return e.message
+}
+
+// formulaSyntaxErrorf 创建语法错误
+func formulaSyntaxErrorf(format string, args ...interface{}) error {
+	return &formulaError{result: formulaSyntaxError, message: fmt.Sprintf(format, args...)}
+}
+
+// formulaNode 公式语法树节点
+type formulaNode interface{}
+
+// formulaNumber 数字常量
+type formulaNumber float64
+
+// formulaRef 单元格引用，行列均从0开始，列为网格列
+type formulaRef struct {
+	row, col int
+}
+
+// formulaRange 单元格区域，如A1:B3
+type formulaRange struct {
+	from, to formulaRef
+}
+
+// formulaDirection 位置参数：ABOVE、BELOW、LEFT、RIGHT
+type formulaDirection string
+
+// formulaUnary 一元运算：负号、正号和百分号
+type formulaUnary struct {
+	op      string
+	operand formulaNode
+}
+
+// formulaBinary 二元运算
+type formulaBinary struct {
+	op          string
+	left, right formulaNode
+}
+
+// formulaCall 函数调用
+type formulaCall struct {
+	name string
+	args []formulaNode
+}
+
+// formulaToken 公式词法单元，kind为'n'（数字）、'i'（标识符）、'o'（运算符）
+type formulaToken struct {
+	kind  byte
+	text  string
+	value float64
+}
+
+// SetCellFormula 将单元格内容设置为Word公式域（FORMULA），并立即计算域结果
+//
+// formula支持"=SUM(ABOVE)"、"=SUM(LEFT)"等位置参数，"=B2*C2"、"=SUM(B2:D2)"等A1形式的单元格引用
+// （列字母对应网格列，行号从1开始），+ - * / ^ % 和比较运算，以及SUM、AVERAGE、COUNT、MAX、MIN、
+// PRODUCT、ABS、INT、SIGN、MOD、ROUND、IF、AND、OR、NOT函数。format为Word数字格式，如"#,##0.00"、
+// "¥#,##0.00;(¥#,##0.00)"，为空时按常规格式输出。在Word中修改数据后选中表格按F9即可更新结果。
+// 公式语法错误时返回错误；计算错误（如除数为零）与Word一致写入错误文本，不返回错误。
+func (t *Table) SetCellFormula(row, col int, formula, format string) error {
+	cell, err := t.GetCell(row, col)
+	if err != nil {
+		return err
+	}
+	expression := strings.TrimPrefix(strings.TrimSpace(formula), "=")
+	if _, err := parseFormula(expression); err != nil {
+		return fmt.Errorf("公式无效：%v", err)
+	}
+	if strings.Contains(format, "\"") {
+		return fmt.Errorf("数字格式不能包含双引号：%s", format)
+	}
+
+	instruction := " =" + expression + " "
+	if format != "" {
+		instruction += `\# "` + format + `" `
+	}
+	result, err := t.evaluateFormula(instruction, row, t.cellGridStart(row, col), cellGridSpan(cell))
+	if err != nil {
+		Warn(fmt.Sprintf("第%d行第%d列的公式计算失败：%v", row, col, err))
+	}
+
+	// 保留原有的段落格式和文字格式
+	paragraph := Paragraph{}
+	var runProps *RunProperties
+	if len(cell.Paragraphs) > 0 {
+		paragraph.Properties = cell.Paragraphs[0].Properties
+		if len(cell.Paragraphs[0].Runs) > 0 {
+			runProps = cell.Paragraphs[0].Runs[0].Properties
+		}
+	}
+	paragraph.Runs = []Run{
+		{FieldChar: &FieldChar{FieldCharType: "begin"}},
+		{InstrText: &InstrText{Space: "preserve", Content: instruction}},
+		{FieldChar: &FieldChar{FieldCharType: "separate"}},
+		{Properties: runProps, Text: Text{Content: result, Space: "preserve"}},
+		{FieldChar: &FieldChar{FieldCharType: "end"}},
+	}
+	cell.Paragraphs = []Paragraph{paragraph}
+
+	Info(fmt.Sprintf("设置第%d行第%d列公式：=%s，结果%s", row, col, expression, result))
+	return nil
+}
+
+// GetCellFormula 获取单元格中第一个公式域的公式（以=开头）和数字格式，单元格没有公式时返回空字符串
+func (t *Table) GetCellFormula(row, col int) (formula, format string, err error) {
+	cell, err := t.GetCell(row, col)
+	if err != nil {
+		return "", "", err
+	}
+	for i := range cell.Paragraphs {
+		if fields := formulaFields(&cell.Paragraphs[i]); len(fields) > 0 {
+			expression, format := parseFormulaInstruction(fields[0].instruction)
+			return "=" + expression, format, nil
+		}
+	}
+	return "", "", nil
+}
+
+// RecalculateFormulas 按从上到下、从左到右的顺序重新计算表格（包括嵌套表格）中所有公式域的结果，
+// 适用于修改了数据的表格和打开的文档。计算失败的公式写入Word的错误文本，并返回第一个错误
+func (t *Table) RecalculateFormulas() error {
+	var firstErr error
+	count := 0
+	for i := range t.Rows {
+		start := 0
+		for j := range t.Rows[i].Cells {
+			cell := &t.Rows[i].Cells[j]
+			span := cellGridSpan(cell)
+			for k := range cell.Paragraphs {
+				paragraph := &cell.Paragraphs[k]
+				fields := formulaFields(paragraph)
+				results := make([]string, len(fields))
+				for f, field := range fields {
+					var err error
+					results[f], err = t.evaluateFormula(field.instruction, i, start, span)
+					if err != nil && firstErr == nil {
+						firstErr = fmt.Errorf("第%d行第%d列的公式计算失败：%v", i, j, err)
+					}
+				}
+				// 从后向前替换，前面的域的运行索引不受影响
+				for f := len(fields) - 1; f >= 0; f-- {
+					paragraph.Runs = replaceFormulaResult(paragraph.Runs, fields[f], results[f])
+				}
+				count += len(fields)
+			}
+			for _, nested := range cell.Tables {
+				if nested.Table == nil {
+					continue
+				}
+				if err := nested.Table.RecalculateFormulas(); err != nil && firstErr == nil {
+					firstErr = err
+				}
+			}
+			start += span
+		}
+	}
+
+	Info(fmt.Sprintf("重新计算表格公式：%d个", count))
+	return firstErr
+}
+
+// cellGridStart 返回行中第col个单元格的起始网格列
+func (t *Table) cellGridStart(row, col int) int {
+	start := 0
+	for j := 0; j < col && j < len(t.Rows[row].Cells); j++ {
+		start += cellGridSpan(&t.Rows[row].Cells[j])
+	}
+	return start
+}
+
+// evaluateFormula 计算公式域指令，返回格式化后的域结果；出错时返回Word的错误文本和错误
+func (t *Table) evaluateFormula(instruction string, row, gridStart, span int) (string, error) {
+	expression, format := parseFormulaInstruction(instruction)
+	node, err := parseFormula(expression)
+	if err == nil {
+		evaluator := &formulaEvaluator{table: t, row: row, start: gridStart, end: gridStart + span}
+		var value float64
+		if value, err = evaluator.scalar(node); err == nil {
+			return formatFormulaResult(value, format), nil
+		}
+	}
+	if formulaErr, ok := err.(*formulaError); ok {
+		return formulaErr.result, err
+	}
+	return formulaSyntaxError, err
+}
+
+// tableFormulaField 段落中的公式域，记录域字符所在的运行索引，没有分隔符时separate为-1
+type tableFormulaField struct {
+	separate, end int
+	instruction   string
+}
+
+// formulaFields 查找段落中的顶层公式域（指令以=开头的域）
+func formulaFields(paragraph *Paragraph) []tableFormulaField {
+	var fields []tableFormulaField
+	var current tableFormulaField
+	var instruction strings.Builder
+	depth := 0
+	for i, run := range paragraph.Runs {
+		switch {
+		case run.FieldChar != nil && run.FieldChar.FieldCharType == "begin":
+			if depth == 0 {
+				current = tableFormulaField{separate: -1}
+				instruction.Reset()
+			}
+			depth++
+		case run.FieldChar != nil && run.FieldChar.FieldCharType == "separate":
+			if depth == 1 {
+				current.separate = i
+			}
+		case run.FieldChar != nil && run.FieldChar.FieldCharType == "end":
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 {
+				current.end = i
+				current.instruction = instruction.String()
+				if strings.HasPrefix(strings.TrimSpace(current.instruction), "=") {
+					fields = append(fields, current)
+				}
+			}
+		case run.InstrText != nil && depth == 1 && current.separate < 0:
+			instruction.WriteString(run.InstrText.Content)
+		}
+	}
+	return fields
+}
+
+// replaceFormulaResult 将域结果替换为result，保留原结果第一个运行的文字格式
+func replaceFormulaResult(runs []Run, field tableFormulaField, result string) []Run {
+	var props *RunProperties
+	resultStart := field.end
+	if field.separate >= 0 {
+		resultStart = field.separate + 1
+		for i := resultStart; i < field.end; i++ {
+			if runs[i].Properties != nil {
+				props = runs[i].Properties
+				break
+			}
+		}
+	}
+
+	replaced := make([]Run, 0, len(runs)+2)
+	replaced = append(replaced, runs[:resultStart]...)
+	if field.separate < 0 {
+		replaced = append(replaced, Run{FieldChar: &FieldChar{FieldCharType: "separate"}})
+	}
+	replaced = append(replaced, Run{Properties: props, Text: Text{Content: result, Space: "preserve"}})
+	return append(replaced, runs[field.end:]...)
+}
+
+// parseFormulaInstruction 拆分域指令中的表达式（不含=）和数字格式开关（\#）
+func parseFormulaInstruction(instruction string) (expression, format string) {
+	instruction = strings.TrimPrefix(strings.TrimSpace(instruction), "=")
+	switches := ""
+	if index := strings.IndexByte(instruction, '\\'); index >= 0 {
+		instruction, switches = instruction[:index], instruction[index:]
+	}
+	if index := strings.Index(switches, `\#`); index >= 0 {
+		value := strings.TrimLeft(switches[index+2:], " ")
+		if strings.HasPrefix(value, `"`) {
+			if end := strings.IndexByte(value[1:], '"'); end >= 0 {
+				format = value[1 : end+1]
+			}
+		} else if fields := strings.Fields(value); len(fields) > 0 {
+			format = fields[0]
+		}
+	}
+	return strings.TrimSpace(instruction), format
+}
+
+// formatFormulaResult 按Word数字格式输出结果。格式可用分号分为正数;负数;零三段，
+// 负数段输出绝对值；未指定格式时按常规格式输出
+func formatFormulaResult(value float64, format string) string {
+	// 消除浮点运算误差，如0.1+0.2
+	value = math.Round(value*1e10) / 1e10
+	if value == 0 {
+		value = 0 // 去掉负零
+	}
+	if format == "" {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	sections := strings.Split(strings.ReplaceAll(format, "'", ""), ";")
+	picture := sections[0]
+	switch {
+	case value < 0 && len(sections) > 1:
+		picture, value = sections[1], -value
+	case value == 0 && len(sections) > 2:
+		picture = sections[2]
+	}
+	if !strings.ContainsAny(picture, "#0") {
+		return picture
+	}
+	return formatNumberPicture(value, picture, false)
+}
+
+// formulaParser 公式语法分析器
+type formulaParser struct {
+	tokens []formulaToken
+	pos    int
+}
+
+// parseFormula 将公式表达式（不含=）解析为语法树
+func parseFormula(expression string) (formulaNode, error) {
+	tokens, err := tokenizeFormula(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, formulaSyntaxErrorf("公式为空")
+	}
+	parser := &formulaParser{tokens: tokens}
+	node, err := parser.comparison()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(tokens) {
+		return nil, formulaSyntaxErrorf("意外的符号：%s", tokens[parser.pos].text)
+	}
+	return node, nil
+}
+
+// tokenizeFormula 词法分析
+func tokenizeFormula(expression string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(expression) && (expression[j] >= '0' && expression[j] <= '9' || expression[j] == '.') {
+				j++
+			}
+			value, err := strconv.ParseFloat(expression[i:j], 64)
+			if err != nil {
+				return nil, formulaSyntaxErrorf("无效的数字：%s", expression[i:j])
+			}
+			tokens = append(tokens, formulaToken{kind: 'n', text: expression[i:j], value: value})
+			i = j
+		case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '_':
+			j := i
+			for j < len(expression) && (expression[j] >= 'A' && expression[j] <= 'Z' || expression[j] >= 'a' && expression[j] <= 'z' ||
+				expression[j] >= '0' && expression[j] <= '9' || expression[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, formulaToken{kind: 'i', text: strings.ToUpper(expression[i:j])})
+			i = j
+		case i+1 < len(expression) && (expression[i:i+2] == "<=" || expression[i:i+2] == ">=" || expression[i:i+2] == "<>"):
+			tokens = append(tokens, formulaToken{kind: 'o', text: expression[i : i+2]})
+			i += 2
+		case strings.IndexByte("+-*/^%(),;:=<>", c) >= 0:
+			tokens = append(tokens, formulaToken{kind: 'o', text: string(c)})
+			i++
+		default:
+			return nil, formulaSyntaxErrorf("无效的字符：%q", expression[i:])
+		}
+	}
+	return tokens, nil
+}
+
+// peek 返回当前运算符，不是运算符时返回空字符串
+func (p *formulaParser) peek() string {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == 'o' {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+// expect 读取指定的运算符
+func (p *formulaParser) expect(op string) error {
+	if p.peek() != op {
+		return formulaSyntaxErrorf("缺少%s", op)
+	}
+	p.pos++
+	return nil
+}
+
+// comparison 比较运算：= < > <= >= <>，结果为1或0
+func (p *formulaParser) comparison() (formulaNode, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch op := p.peek(); op {
+		case "=", "<", ">", "<=", ">=", "<>":
+			p.pos++
+			right, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			left = formulaBinary{op: op, left: left, right: right}
+		default:
+			return left, nil
+		}
+	}
+}
+
+// additive 加减运算
+func (p *formulaParser) additive() (formulaNode, error) {
+	left, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == "+" || op == "-"; op = p.peek() {
+		p.pos++
+		right, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = formulaBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// multiplicative 乘除运算
+func (p *formulaParser) multiplicative() (formulaNode, error) {
+	left, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == "*" || op == "/"; op = p.peek() {
+		p.pos++
+		right, err := p.power()
+		if err != nil {
+			return nil, err
+		}
+		left = formulaBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// power 乘方运算，右结合
+func (p *formulaParser) power() (formulaNode, error) {
+	base, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "^" {
+		return base, nil
+	}
+	p.pos++
+	exponent, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	return formulaBinary{op: "^", left: base, right: exponent}, nil
+}
+
+// unary 正负号与百分号
+func (p *formulaParser) unary() (formulaNode, error) {
+	if op := p.peek(); op == "-" || op == "+" {
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return formulaUnary{op: op, operand: operand}, nil
+	}
+	node, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "%" {
+		p.pos++
+		node = formulaUnary{op: "%", operand: node}
+	}
+	return node, nil
+}
+
+// primary 数字、括号、函数调用、单元格引用和位置参数
+func (p *formulaParser) primary() (formulaNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, formulaSyntaxErrorf("公式不完整")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case 'n':
+		return formulaNumber(token.value), nil
+	case 'o':
+		if token.text != "(" {
+			return nil, formulaSyntaxErrorf("意外的符号：%s", token.text)
+		}
+		node, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		return node, p.expect(")")
+	}
+
+	if p.peek() == "(" {
+		return p.call(token.text)
+	}
+	switch token.text {
+	case "ABOVE", "BELOW", "LEFT", "RIGHT":
+		return formulaDirection(token.text), nil
+	case "TRUE":
+		return formulaNumber(1), nil
+	case "FALSE":
+		return formulaNumber(0), nil
+	}
+	from, err := parseFormulaRef(token.text)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ":" {
+		return from, nil
+	}
+	p.pos++
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != 'i' {
+		return nil, formulaSyntaxErrorf("区域缺少结束单元格")
+	}
+	to, err := parseFormulaRef(p.tokens[p.pos].text)
+	if err != nil {
+		return nil, err
+	}
+	p.pos++
+	return formulaRange{from: from, to: to}, nil
+}
+
+// call 函数调用，参数以逗号（或分号）分隔
+func (p *formulaParser) call(name string) (formulaNode, error) {
+	function, ok := formulaFunctions[name]
+	if !ok {
+		return nil, formulaSyntaxErrorf("不支持的函数：%s", name)
+	}
+	p.pos++ // 左括号
+	var args []formulaNode
+	if p.peek() != ")" {
+		for {
+			arg, err := p.comparison()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if op := p.peek(); op != "," && op != ";" {
+				break
+			}
+			p.pos++
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(args) < function.minArgs || function.maxArgs >= 0 && len(args) > function.maxArgs {
+		return nil, formulaSyntaxErrorf("函数%s的参数个数不正确：%d", name, len(args))
+	}
+	return formulaCall{name: name, args: args}, nil
+}
+
+// parseFormulaRef 解析A1形式的单元格引用
+func parseFormulaRef(text string) (formulaRef, error) {
+	match := formulaCellRefPattern.FindStringSubmatch(text)
+	if match == nil {
+		return formulaRef{}, formulaSyntaxErrorf("无效的单元格引用或不支持的书签：%s", text)
+	}
+	col := 0
+	for _, c := range match[1] {
+		col = col*26 + int(c-'A'+1)
+	}
+	row, _ := strconv.Atoi(match[2])
+	if row < 1 {
+		return formulaRef{}, formulaSyntaxErrorf("无效的单元格引用：%s", text)
+	}
+	return formulaRef{row: row - 1, col: col - 1}, nil
+}
+
+// formulaEvaluator 公式求值器，row和[start, end)为公式所在单元格的行和网格列范围
+type formulaEvaluator struct {
+	table      *Table
+	row        int
+	start, end int
+}
+
+// scalar 计算单个数值
+func (e *formulaEvaluator) scalar(node formulaNode) (float64, error) {
+	switch n := node.(type) {
+	case formulaNumber:
+		return float64(n), nil
+	case formulaRef:
+		return e.cellValue(n)
+	case formulaRange, formulaDirection:
+		return 0, formulaSyntaxErrorf("单元格区域只能用作SUM等函数的参数")
+	case formulaUnary:
+		value, err := e.scalar(n.operand)
+		if err != nil {
+			return 0, err
+		}
+		switch n.op {
+		case "-":
+			return -value, nil
+		case "%":
+			return value / 100, nil
+		}
+		return value, nil
+	case formulaBinary:
+		left, err := e.scalar(n.left)
+		if err != nil {
+			return 0, err
+		}
+		right, err := e.scalar(n.right)
+		if err != nil {
+			return 0, err
+		}
+		return evaluateFormulaBinary(n.op, left, right)
+	case formulaCall:
+		return e.call(n)
+	}
+	return 0, formulaSyntaxErrorf("无效的公式")
+}
+
+// list 计算函数参数的值：区域和位置参数展开为其中的数值
+func (e *formulaEvaluator) list(node formulaNode) ([]float64, error) {
+	switch n := node.(type) {
+	case formulaRange:
+		return e.rangeValues(n)
+	case formulaDirection:
+		return e.directionValues(n), nil
+	}
+	value, err := e.scalar(node)
+	if err != nil {
+		return nil, err
+	}
+	return []float64{value}, nil
+}
+
+// call 计算函数
+func (e *formulaEvaluator) call(n formulaCall) (float64, error) {
+	if formulaFunctions[n.name].list {
+		var values []float64
+		for _, arg := range n.args {
+			argValues, err := e.list(arg)
+			if err != nil {
+				return 0, err
+			}
+			values = append(values, argValues...)
+		}
+		return evaluateFormulaListFunction(n.name, values)
+	}
+
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := e.scalar(arg)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+	switch n.name {
+	case "ABS":
+		return math.Abs(args[0]), nil
+	case "INT":
+		return math.Trunc(args[0]), nil
+	case "SIGN":
+		switch {
+		case args[0] > 0:
+			return 1, nil
+		case args[0] < 0:
+			return -1, nil
+		}
+		return 0, nil
+	case "NOT":
+		return formulaBool(args[0] == 0), nil
+	case "MOD":
+		if args[1] == 0 {
+			return 0, &formulaError{result: formulaZeroDivide, message: "MOD的除数为零"}
+		}
+		return math.Mod(args[0], args[1]), nil
+	case "ROUND":
+		scale := math.Pow(10, math.Trunc(args[1]))
+		return math.Round(args[0]*scale) / scale, nil
+	case "AND":
+		return formulaBool(args[0] != 0 && args[1] != 0), nil
+	case "OR":
+		return formulaBool(args[0] != 0 || args[1] != 0), nil
+	case "IF":
+		if args[0] != 0 {
+			return args[1], nil
+		}
+		return args[2], nil
+	}
+	return 0, formulaSyntaxErrorf("不支持的函数：%s", n.name)
+}
+
+// evaluateFormulaListFunction 计算以数值列表为参数的函数
+func evaluateFormulaListFunction(name string, values []float64) (float64, error) {
+	if name == "COUNT" {
+		return float64(len(values)), nil
+	}
+	if len(values) == 0 {
+		if name == "AVERAGE" {
+			return 0, &formulaError{result: formulaZeroDivide, message: "AVERAGE没有可计算的数值"}
+		}
+		return 0, nil
+	}
+	result := values[0]
+	for _, value := range values[1:] {
+		switch name {
+		case "SUM", "AVERAGE":
+			result += value
+		case "PRODUCT":
+			result *= value
+		case "MAX":
+			result = math.Max(result, value)
+		case "MIN":
+			result = math.Min(result, value)
+		}
+	}
+	if name == "AVERAGE" {
+		result /= float64(len(values))
+	}
+	return result, nil
+}
+
+// evaluateFormulaBinary 计算二元运算
+func evaluateFormulaBinary(op string, left, right float64) (float64, error) {
+	switch op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, &formulaError{result: formulaZeroDivide, message: "除数为零"}
+		}
+		return left / right, nil
+	case "^":
+		return math.Pow(left, right), nil
+	case "=":
+		return formulaBool(left == right), nil
+	case "<>":
+		return formulaBool(left != right), nil
+	case "<":
+		return formulaBool(left < right), nil
+	case ">":
+		return formulaBool(left > right), nil
+	case "<=":
+		return formulaBool(left <= right), nil
+	case ">=":
+		return formulaBool(left >= right), nil
+	}
+	return 0, formulaSyntaxErrorf("不支持的运算符：%s", op)
+}
+
+// formulaBool 逻辑值转换为1或0
+func formulaBool(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// cellValue 单元格的数值，空单元格和非数字文本为0
+func (e *formulaEvaluator) cellValue(ref formulaRef) (float64, error) {
+	cell := e.refCell(ref)
+	if cell == nil {
+		return 0, formulaSyntaxErrorf("单元格%s不在表格中", formulaRefName(ref))
+	}
+	value, _ := parseFormulaNumber(tableCellText(cell))
+	return value, nil
+}
+
+// rangeValues 区域中所有数字单元格的值，合并单元格只计算一次
+func (e *formulaEvaluator) rangeValues(r formulaRange) ([]float64, error) {
+	top, bottom := min(r.from.row, r.to.row), max(r.from.row, r.to.row)
+	left, right := min(r.from.col, r.to.col), max(r.from.col, r.to.col)
+	if e.refCell(formulaRef{row: top, col: left}) == nil {
+		return nil, formulaSyntaxErrorf("单元格%s不在表格中", formulaRefName(formulaRef{row: top, col: left}))
+	}
+	var values []float64
+	seen := make(map[*TableCell]bool)
+	for row := top; row <= bottom && row < len(e.table.Rows); row++ {
+		for col := left; col <= right; col++ {
+			cell := e.table.gridCell(row, col)
+			if cell == nil || seen[cell] {
+				continue
+			}
+			seen[cell] = true
+			if value, ok := parseFormulaNumber(tableCellText(cell)); ok {
+				values = append(values, value)
+			}
+		}
+	}
+	return values, nil
+}
+
+// directionValues 位置参数对应的数值：从公式单元格相邻的单元格开始，
+// 沿指定方向取连续的数字单元格，遇到空单元格或非数字单元格时停止（与Word一致）
+func (e *formulaEvaluator) directionValues(direction formulaDirection) []float64 {
+	var values []float64
+	collect := func(cell *TableCell) bool {
+		if cell == nil {
+			return false
+		}
+		value, ok := parseFormulaNumber(tableCellText(cell))
+		if ok {
+			values = append(values, value)
+		}
+		return ok
+	}
+
+	switch direction {
+	case "ABOVE":
+		for row := e.row - 1; row >= 0 && collect(e.table.gridCell(row, e.start)); row-- {
+		}
+	case "BELOW":
+		for row := e.row + 1; row < len(e.table.Rows) && collect(e.table.gridCell(row, e.start)); row++ {
+		}
+	case "LEFT":
+		for col := e.start - 1; col >= 0; col-- {
+			cell := e.table.gridCell(e.row, col)
+			if !collect(cell) {
+				break
+			}
+			// 跳过水平合并单元格覆盖的其余列
+			col -= cellGridSpan(cell) - 1
+		}
+	case "RIGHT":
+		for col := e.end; ; {
+			cell := e.table.gridCell(e.row, col)
+			if !collect(cell) {
+				break
+			}
+			col += cellGridSpan(cell)
+		}
+	}
+	return values
+}
+
+// refCell 引用的单元格，不在表格中时返回nil
+func (e *formulaEvaluator) refCell(ref formulaRef) *TableCell {
+	if ref.row >= len(e.table.Rows) {
+		return nil
+	}
+	return e.table.gridCell(ref.row, ref.col)
+}
+
+// formulaRefName 单元格引用的A1名称
+func formulaRefName(ref formulaRef) string {
+	name := ""
+	for col := ref.col + 1; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(ref.row+1)
+}
+
+// parseFormulaNumber 解析单元格文本中的数字，允许千分位、货币符号和百分号
+func parseFormulaNumber(text string) (float64, bool) {
+	return parseSortNumber(text)
+}
@@ -0,0 +1,204 @@
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTableFormulas(t *testing.T) {
+	doc := New()
+	table := doc.AddTable(&TableConfig{Rows: 5, Cols: 4, Width: 8000, Data: [][]string{
+		{"项目", "数量", "单价", "金额"},
+		{"服务器", "2", "¥12,000.00", ""},
+		{"交换机", "3", "1500", ""},
+		{"线缆", "10", "25.5", ""},
+		{"合计", "", "", ""},
+	}})
+	for row := 1; row <= 3; row++ {
+		if err := table.SetCellFormula(row, 3, fmt.Sprintf("=B%d*C%d", row+1, row+1), "#,##0.00"); err != nil {
+			t.Fatalf("设置公式失败: %v", err)
+		}
+	}
+	if err := table.SetCellFormula(4, 1, "=SUM(ABOVE)", ""); err != nil {
+		t.Fatalf("设置公式失败: %v", err)
+	}
+	if err := table.SetCellFormula(4, 3, "SUM(ABOVE)", "¥#,##0.00"); err != nil {
+		t.Fatalf("设置公式失败: %v", err)
+	}
+
+	expected := []string{"24,000.00", "4,500.00", "255.00"}
+	for i, want := range expected {
+		if got := tableCellText(&table.Rows[i+1].Cells[3]); got != want {
+			t.Errorf("第%d行金额不正确: %s", i+1, got)
+		}
+	}
+	if got := tableCellText(&table.Rows[4].Cells[1]); got != "15" {
+		t.Errorf("数量合计不正确: %s", got)
+	}
+	if got := tableCellText(&table.Rows[4].Cells[3]); got != "¥28,755.00" {
+		t.Errorf("金额合计不正确: %s", got)
+	}
+
+	runs := table.Rows[4].Cells[3].Paragraphs[0].Runs
+	if len(runs) != 5 || runs[0].FieldChar.FieldCharType != "begin" || runs[1].InstrText.Content != ` =SUM(ABOVE) \# "¥#,##0.00" ` {
+		t.Errorf("公式域结构不正确: %+v", runs)
+	}
+	if formula, format, _ := table.GetCellFormula(4, 3); formula != "=SUM(ABOVE)" || format != "¥#,##0.00" {
+		t.Errorf("读取公式不正确: %s %s", formula, format)
+	}
+	if formula, _, _ := table.GetCellFormula(0, 0); formula != "" {
+		t.Errorf("普通单元格不应返回公式: %s", formula)
+	}
+
+	// 修改数据后重新计算，结果保持原有文字格式
+	table.Rows[4].Cells[3].Paragraphs[0].Runs[3].Properties = &RunProperties{Bold: &Bold{}}
+	table.SetCellText(1, 1, "4")
+	if err := table.RecalculateFormulas(); err != nil {
+		t.Fatalf("重新计算失败: %v", err)
+	}
+	if got := tableCellText(&table.Rows[4].Cells[3]); got != "¥52,755.00" {
+		t.Errorf("重新计算后的合计不正确: %s", got)
+	}
+	if result := table.Rows[4].Cells[3].Paragraphs[0].Runs[3]; result.Properties == nil || result.Properties.Bold == nil {
+		t.Error("重新计算应保留结果的文字格式")
+	}
+
+	// 保存后打开文档重新计算
+	filename := filepath.Join(t.TempDir(), "formula.docx")
+	if err := doc.Save(filename); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	reopened, err := Open(filename)
+	if err != nil {
+		t.Fatalf("打开失败: %v", err)
+	}
+	opened := reopened.Body.GetTables()[0]
+	if formula, _, _ := opened.GetCellFormula(3, 3); formula != "=B4*C4" {
+		t.Fatalf("打开的文档应保留公式域: %q", formula)
+	}
+	opened.SetCellText(3, 1, "20")
+	if err := opened.RecalculateFormulas(); err != nil {
+		t.Fatalf("重新计算失败: %v", err)
+	}
+	if got := tableCellText(&opened.Rows[4].Cells[3]); got != "¥53,010.00" {
+		t.Errorf("打开的文档重新计算结果不正确: %s", got)
+	}
+
+	if err := table.SetCellFormula(0, 0, "=SUM(ABOVE", ""); err == nil {
+		t.Error("语法错误的公式应返回错误")
+	}
+	if err := table.SetCellFormula(0, 0, "=FOO(1)", ""); err == nil {
+		t.Error("不支持的函数应返回错误")
+	}
+}
+
+func TestTableFormulaErrors(t *testing.T) {
+	table := New().AddTable(&TableConfig{Rows: 2, Cols: 3, Width: 6000, Data: [][]string{
+		{"1", "0", ""},
+		{"", "", ""},
+	}})
+	if err := table.SetCellFormula(0, 2, "=A1/B1", ""); err != nil {
+		t.Fatalf("计算错误不应导致设置失败: %v", err)
+	}
+	if got := tableCellText(&table.Rows[0].Cells[2]); got != formulaZeroDivide {
+		t.Errorf("除数为零时结果不正确: %s", got)
+	}
+	if err := table.RecalculateFormulas(); err == nil || !strings.Contains(err.Error(), "除数为零") {
+		t.Errorf("重新计算应返回计算错误: %v", err)
+	}
+	table.SetCellFormula(1, 0, "=Z9", "")
+	if got := tableCellText(&table.Rows[1].Cells[0]); got != formulaSyntaxError {
+		t.Errorf("引用表格外的单元格结果不正确: %s", got)
+	}
+}
+
+func TestEvaluateFormula(t *testing.T) {
+	table := New().AddTable(&TableConfig{Rows: 4, Cols: 4, Width: 8000, Data: [][]string{
+		{"标题", "10", "20", ""},
+		{"1", "2", "3", ""},
+		{"", "4", "x", ""},
+		{"5", "6", "7", ""},
+	}})
+	if err := table.MergeCellsHorizontal(0, 1, 2); err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+
+	cases := []struct {
+		row, col int // 网格位置
+		formula  string
+		want     string
+	}{
+		{3, 3, "SUM(LEFT)", "18"},
+		{1, 3, "SUM(LEFT)", "6"},
+		{2, 3, "SUM(LEFT)", "0"},
+		{3, 1, "SUM(ABOVE)", "16"},
+		{3, 3, "SUM(A2:C4)", "28"},
+		{3, 3, "AVERAGE(A2:B2)", "1.5"},
+		{3, 3, "COUNT(A1:C4)", "8"},
+		{3, 3, "MAX(A2:C4)-MIN(A2:C4)", "6"},
+		{3, 3, "PRODUCT(B2,C2,2)", "12"},
+		{3, 3, "B1", "10"},
+		{3, 3, "C3", "0"},
+		{3, 3, "-2^2+10%", "4.1"},
+		{3, 3, "(1+2)*3/4", "2.25"},
+		{3, 3, "0.1+0.2", "0.3"},
+		{3, 3, "IF(A4>=5,ROUND(2.345,2),0)", "2.35"},
+		{3, 3, "AND(1,0)+OR(1,0)+NOT(0)", "2"},
+		{3, 3, "ABS(-3)+INT(2.7)+SIGN(-5)+MOD(7,3)", "5"},
+		{3, 3, "A4<>5", "0"},
+		{1, 0, "SUM(BELOW)", "0"},
+		{2, 0, "SUM(RIGHT)", "4"},
+	}
+	for _, c := range cases {
+		got, err := table.evaluateFormula(" ="+c.formula+" ", c.row, c.col, 1)
+		if err != nil || got != c.want {
+			t.Errorf("%s = %q (%v)，期望 %q", c.formula, got, err, c.want)
+		}
+	}
+
+	formats := []struct {
+		value  float64
+		format string
+		want   string
+	}{
+		{1234.5, "#,##0.00", "1,234.50"},
+		{-1234.5, "#,##0.00;(#,##0.00)", "(1,234.50)"},
+		{-1234.5, "¥#,##0", "-¥1,235"},
+		{0, "0.00;-0.00;'-'", "-"},
+		{12.5, "0.0%", "12.5%"},
+		{-1e-12, "", "0"},
+	}
+	for _, f := range formats {
+		if got := formatFormulaResult(f.value, f.format); got != f.want {
+			t.Errorf("formatFormulaResult(%v, %q) = %q，期望 %q", f.value, f.format, got, f.want)
+		}
+	}
+
+	if expression, format := parseFormulaInstruction(` =SUM(ABOVE) \# "#,##0.00" \* MERGEFORMAT `); expression != "SUM(ABOVE)" || format != "#,##0.00" {
+		t.Errorf("解析域指令不正确: %q %q", expression, format)
+	}
+	if _, format := parseFormulaInstruction(` = A1 \# 0.0 `); format != "0.0" {
+		t.Errorf("解析无引号的数字格式不正确: %q", format)
+	}
+}
+
+func TestParseSimpleFormulaField(t *testing.T) {
+	source := `<w:p xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:fldSimple w:instr=" =SUM(LEFT) "><w:r><w:rPr><w:b/></w:rPr><w:t>3</w:t></w:r></w:fldSimple></w:p>`
+	decoder := xml.NewDecoder(strings.NewReader(source))
+	token, _ := decoder.Token()
+	paragraph, err := New().parseParagraph(decoder, token.(xml.StartElement))
+	if err != nil {
+		t.Fatalf("解析段落失败: %v", err)
+	}
+	fields := formulaFields(paragraph)
+	if len(fields) != 1 || fields[0].instruction != " =SUM(LEFT) " || len(paragraph.Runs) != 5 {
+		t.Fatalf("简单域应转换为复杂域: %+v", paragraph.Runs)
+	}
+	runs := replaceFormulaResult(paragraph.Runs, fields[0], "4")
+	if runs[3].Text.Content != "4" || runs[3].Properties == nil || runs[3].Properties.Bold == nil {
+		t.Errorf("替换域结果不正确: %+v", runs[3])
+	}
+}
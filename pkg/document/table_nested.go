@@ -0,0 +1,258 @@
+// Package document 提供单元格嵌套表格功能
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// NestedTable 单元格中的嵌套表格
+type NestedTable struct {
+	Index int    // 表格位于单元格第Index个段落之前，大于等于段落数时位于所有段落之后
+	Table *Table // 嵌套表格
+}
+
+// MarshalXML 按段落与嵌套表格的先后顺序输出单元格内容。
+// Word要求单元格以段落结尾，最后一个元素为表格时补充一个空段落
+func (c *TableCell) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "w:tc"}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if c.Properties != nil {
+		if err := e.Encode(c.Properties); err != nil {
+			return err
+		}
+	}
+
+	elements := c.Elements()
+	for _, element := range elements {
+		if err := e.Encode(element); err != nil {
+			return err
+		}
+	}
+	if len(elements) > 0 {
+		if _, ok := elements[len(elements)-1].(*Table); ok {
+			if err := e.Encode(&Paragraph{}); err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// Elements 按先后顺序返回单元格中的段落（*Paragraph）和嵌套表格（*Table），
+// 返回的段落指向单元格中的段落，可以直接修改
+func (c *TableCell) Elements() []interface{} {
+	paragraphs := make([]interface{}, 0, len(c.Paragraphs))
+	for i := range c.Paragraphs {
+		paragraphs = append(paragraphs, &c.Paragraphs[i])
+	}
+	return mergeNestedTables(paragraphs, c.Tables)
+}
+
+// mergeNestedTables 按Index将嵌套表格插入段落序列中
+func mergeNestedTables(paragraphs []interface{}, nestedTables []NestedTable) []interface{} {
+	elements := make([]interface{}, 0, len(paragraphs)+len(nestedTables))
+	tables := make([]NestedTable, 0, len(nestedTables))
+	for _, nested := range nestedTables {
+		if nested.Table != nil {
+			tables = append(tables, nested)
+		}
+	}
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].Index < tables[j].Index })
+
+	next := 0
+	for i, para := range paragraphs {
+		for next < len(tables) && tables[next].Index <= i {
+			elements = append(elements, tables[next].Table)
+			next++
+		}
+		elements = append(elements, para)
+	}
+	for ; next < len(tables); next++ {
+		elements = append(elements, tables[next].Table)
+	}
+	return elements
+}
+
+// SetElements 按顺序设置单元格中的段落（*Paragraph或Paragraph）和嵌套表格（*Table），其他元素被忽略
+func (c *TableCell) SetElements(elements []interface{}) {
+	c.Paragraphs = make([]Paragraph, 0, len(elements))
+	c.Tables = nil
+	for _, element := range elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			if e != nil {
+				c.Paragraphs = append(c.Paragraphs, *e)
+			}
+		case Paragraph:
+			c.Paragraphs = append(c.Paragraphs, e)
+		case *Table:
+			if e != nil {
+				c.Tables = append(c.Tables, NestedTable{Index: len(c.Paragraphs), Table: e})
+			}
+		}
+	}
+}
+
+// AddNestedTable 在指定单元格的内容末尾添加嵌套表格，返回新表格
+//
+// config.Width为0时使用单元格宽度（扣除左右边距）。单元格只有一个空段落时，
+// 表格插入在该段落之前，使单元格以表格开始；Word要求单元格以段落结尾，保存时自动补充。
+func (t *Table) AddNestedTable(row, col int, config *TableConfig) (*Table, error) {
+	cell, err := t.GetCell(row, col)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, NewValidationError("config", "", "table config cannot be nil")
+	}
+	if config.Rows <= 0 || config.Cols <= 0 {
+		return nil, NewValidationError("config", fmt.Sprintf("%dx%d", config.Rows, config.Cols), "rows and columns must be greater than 0")
+	}
+	if len(config.ColWidths) > 0 && len(config.ColWidths) != config.Cols {
+		return nil, NewValidationError("config.ColWidths", fmt.Sprintf("%d", len(config.ColWidths)), "column widths must match column count")
+	}
+
+	nestedConfig := *config
+	if nestedConfig.Width <= 0 {
+		nestedConfig.Width = t.nestedTableWidth(cell, col)
+	}
+	nested := newTable(&nestedConfig)
+	if nested == nil {
+		return nil, NewValidationError("config", "", "failed to create nested table")
+	}
+
+	index := len(cell.Paragraphs)
+	if len(cell.Tables) == 0 && len(cell.Paragraphs) == 1 && paragraphIsEmpty(&cell.Paragraphs[0]) {
+		index = 0
+	}
+	cell.Tables = append(cell.Tables, NestedTable{Index: index, Table: nested})
+
+	Info(fmt.Sprintf("在单元格(%d,%d)中添加%d行x%d列的嵌套表格", row, col, config.Rows, config.Cols))
+	return nested, nil
+}
+
+// GetNestedTables 获取指定单元格中的嵌套表格
+func (t *Table) GetNestedTables(row, col int) ([]*Table, error) {
+	cell, err := t.GetCell(row, col)
+	if err != nil {
+		return nil, err
+	}
+	var tables []*Table
+	for _, element := range cell.Elements() {
+		if table, ok := element.(*Table); ok {
+			tables = append(tables, table)
+		}
+	}
+	return tables, nil
+}
+
+// nestedTableWidth 嵌套表格的默认宽度：单元格宽度扣除表格的左右单元格边距
+func (t *Table) nestedTableWidth(cell *TableCell, col int) int {
+	width := 0
+	if cell.Properties != nil && cell.Properties.TableCellW != nil && cell.Properties.TableCellW.Type == "dxa" {
+		width, _ = strconv.Atoi(cell.Properties.TableCellW.W)
+	}
+	if width <= 0 && t.Grid != nil && col < len(t.Grid.Cols) {
+		width, _ = strconv.Atoi(t.Grid.Cols[col].W)
+	}
+	if width <= 0 {
+		width = 2000
+	}
+
+	margins := 216
+	if t.Properties != nil && t.Properties.TableCellMar != nil {
+		margins = 0
+		if left := t.Properties.TableCellMar.Left; left != nil {
+			n, _ := strconv.Atoi(left.W)
+			margins += n
+		}
+		if right := t.Properties.TableCellMar.Right; right != nil {
+			n, _ := strconv.Atoi(right.W)
+			margins += n
+		}
+	}
+	if width-margins > 0 {
+		width -= margins
+	}
+	return width
+}
+
+// paragraphIsEmpty 检查段落是否没有文本和其他内容
+func paragraphIsEmpty(para *Paragraph) bool {
+	for _, run := range para.Runs {
+		if run.Text.Content != "" || run.Drawing != nil || run.FieldChar != nil || run.InstrText != nil || run.Break != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// NewNestedCellIterator 创建包含嵌套表格的单元格迭代器，
+// 按深度优先顺序遍历：先返回单元格本身，再返回其中嵌套表格的单元格。
+// CellInfo.Table为单元格所在的表格，Depth为嵌套层级，Row与Col为单元格在所在表格中的位置
+func (t *Table) NewNestedCellIterator() *CellIterator {
+	iter := t.NewCellIterator()
+	iter.cells = make([]*CellInfo, 0, iter.Total())
+	collectNestedCells(t, 0, &iter.cells)
+	if len(iter.cells) > 0 {
+		iter.cells[len(iter.cells)-1].IsLast = true
+	}
+	return iter
+}
+
+// collectNestedCells 按深度优先顺序收集表格及其嵌套表格的单元格
+func collectNestedCells(table *Table, depth int, cells *[]*CellInfo) {
+	for i := range table.Rows {
+		for j := range table.Rows[i].Cells {
+			cell := &table.Rows[i].Cells[j]
+			text, _ := table.GetCellText(i, j)
+			*cells = append(*cells, &CellInfo{Row: i, Col: j, Cell: cell, Text: text, Table: table, Depth: depth})
+			for _, element := range cell.Elements() {
+				if nested, ok := element.(*Table); ok {
+					collectNestedCells(nested, depth+1, cells)
+				}
+			}
+		}
+	}
+}
+
+// ForEachNested 遍历表格及其嵌套表格中的所有单元格，顺序见NewNestedCellIterator
+func (t *Table) ForEachNested(fn func(info *CellInfo) error) error {
+	iterator := t.NewNestedCellIterator()
+	for iterator.HasNext() {
+		cellInfo, err := iterator.Next()
+		if err != nil {
+			return fmt.Errorf("迭代失败: %v", err)
+		}
+		if err := fn(cellInfo); err != nil {
+			return fmt.Errorf("回调函数执行失败 (层级:%d, 行:%d, 列:%d): %v", cellInfo.Depth, cellInfo.Row, cellInfo.Col, err)
+		}
+	}
+	return nil
+}
+
+// walkTableParagraphs 按顺序遍历表格单元格中的段落，包括嵌套表格中的段落，fn返回false时停止遍历并返回false
+func walkTableParagraphs(table *Table, fn func(para *Paragraph) bool) bool {
+	for i := range table.Rows {
+		for j := range table.Rows[i].Cells {
+			for _, element := range table.Rows[i].Cells[j].Elements() {
+				switch e := element.(type) {
+				case *Paragraph:
+					if !fn(e) {
+						return false
+					}
+				case *Table:
+					if !walkTableParagraphs(e, fn) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
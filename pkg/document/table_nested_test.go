@@ -0,0 +1,148 @@
+package document
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNestedTable(t *testing.T) {
+	doc := New()
+	table := doc.AddTable(&TableConfig{Rows: 2, Cols: 2, Width: 8000, Data: [][]string{{"申请人", ""}, {"备注", "无"}}})
+	nested, err := table.AddNestedTable(0, 1, &TableConfig{Rows: 2, Cols: 2, Data: [][]string{{"姓名", "张三"}, {"电话", "123"}}})
+	if err != nil {
+		t.Fatalf("添加嵌套表格失败: %v", err)
+	}
+	if w := nested.Properties.TableW.W; w != "3784" {
+		t.Errorf("嵌套表格默认宽度应为单元格宽度减去边距: %s", w)
+	}
+	if _, err := table.AddNestedTable(5, 0, &TableConfig{Rows: 1, Cols: 1}); err == nil {
+		t.Error("无效单元格应返回错误")
+	}
+
+	// 只有空段落的单元格中表格位于段落之前
+	cell, _ := table.GetCell(0, 1)
+	elements := cell.Elements()
+	if len(elements) != 2 || elements[0] != nested {
+		t.Fatalf("单元格内容顺序不正确: %v", elements)
+	}
+	if _, err := table.AddNestedTable(1, 1, &TableConfig{Rows: 1, Cols: 1, Width: 2000}); err != nil {
+		t.Fatalf("添加嵌套表格失败: %v", err)
+	}
+	other, _ := table.GetCell(1, 1)
+	if elements := other.Elements(); len(elements) != 2 || elements[0].(*Paragraph).Runs[0].Text.Content != "无" {
+		t.Errorf("有内容的单元格中表格应位于末尾: %v", elements)
+	}
+
+	// 序列化顺序，以表格结尾时补充空段落
+	output, err := xml.Marshal(other)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	content := string(output)
+	if !strings.HasPrefix(content, "<w:tc><w:tcPr>") || strings.Index(content, "无") > strings.Index(content, "<w:tbl>") ||
+		!strings.HasSuffix(content, "</w:tbl><w:p></w:p></w:tc>") {
+		t.Errorf("单元格序列化不正确: %s", content)
+	}
+
+	// 迭代器
+	var depths []int
+	err = table.ForEachNested(func(info *CellInfo) error {
+		depths = append(depths, info.Depth)
+		if info.Depth == 1 && info.Table == nested && info.Row == 0 && info.Col == 1 && info.Text != "张三" {
+			t.Errorf("嵌套单元格文本不正确: %s", info.Text)
+		}
+		return nil
+	})
+	if err != nil || len(depths) != 9 || depths[2] != 1 || depths[6] != 0 || depths[8] != 1 {
+		t.Errorf("嵌套遍历顺序不正确: %v %v", depths, err)
+	}
+	iterator := table.NewNestedCellIterator()
+	if iterator.Total() != 9 {
+		t.Errorf("嵌套迭代器单元格数应为9: %d", iterator.Total())
+	}
+	if table.NewCellIterator().Total() != 4 {
+		t.Error("普通迭代器不应包含嵌套单元格")
+	}
+
+	// 复制表格
+	copied := table.CopyTable()
+	copiedNested, _ := copied.GetNestedTables(0, 1)
+	if len(copiedNested) != 1 || copiedNested[0] == nested {
+		t.Error("复制表格应深拷贝嵌套表格")
+	}
+
+	// 保存后重新打开
+	filename := filepath.Join(t.TempDir(), "nested.docx")
+	if err := doc.Save(filename); err != nil {
+		t.Fatalf("保存文档失败: %v", err)
+	}
+	reopened, err := Open(filename)
+	if err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	tables := reopened.Body.GetTables()
+	if len(tables) != 1 {
+		t.Fatalf("文档应只有一个顶层表格: %d", len(tables))
+	}
+	parsed, err := tables[0].GetNestedTables(0, 1)
+	if err != nil || len(parsed) != 1 {
+		t.Fatalf("应解析出嵌套表格: %v %v", parsed, err)
+	}
+	if text, _ := parsed[0].GetCellText(1, 1); text != "123" {
+		t.Errorf("嵌套表格内容不正确: %s", text)
+	}
+	if elements := tables[0].Rows[0].Cells[1].Elements(); len(elements) != 2 || elements[0] != parsed[0] {
+		t.Errorf("解析后内容顺序不正确: %v", elements)
+	}
+}
+
+func TestNestedTableTemplate(t *testing.T) {
+	source := New()
+	table := source.AddTable(&TableConfig{Rows: 1, Cols: 2, Width: 8000, Data: [][]string{{"{{title}}", ""}}})
+	nested, _ := table.AddNestedTable(0, 1, &TableConfig{Rows: 2, Cols: 2, Data: [][]string{{"{{#each items}}{{name}}", "{{qty}}{{/each}}"}, {"合计", "{{total}}"}}})
+	if nested == nil {
+		t.Fatal("添加嵌套表格失败")
+	}
+
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("form", source); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	data := NewTemplateData()
+	data.SetVariable("title", "明细")
+	data.SetVariable("total", 3)
+	data.SetList("items", []interface{}{
+		map[string]interface{}{"name": "笔", "qty": 2},
+		map[string]interface{}{"name": "纸", "qty": 1},
+	})
+	doc, err := engine.RenderTemplateToDocument("form", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+
+	rendered := doc.Body.GetTables()
+	if len(rendered) != 1 {
+		t.Fatalf("应输出一个表格: %d", len(rendered))
+	}
+	if text, _ := rendered[0].GetCellText(0, 0); text != "明细" {
+		t.Errorf("外层表格变量未替换: %s", text)
+	}
+	inner, _ := rendered[0].GetNestedTables(0, 1)
+	if len(inner) != 1 || len(inner[0].Rows) != 3 {
+		t.Fatalf("嵌套表格循环行数不正确: %v", inner)
+	}
+	var rows []string
+	for i := range inner[0].Rows {
+		name, _ := inner[0].GetCellText(i, 0)
+		qty, _ := inner[0].GetCellText(i, 1)
+		rows = append(rows, name+"="+qty)
+	}
+	if strings.Join(rows, ",") != "笔=2,纸=1,合计=3" {
+		t.Errorf("嵌套表格内容不正确: %v", rows)
+	}
+	if nested.Rows[0].Cells[0].Paragraphs[0].Runs[0].Text.Content != "{{#each items}}{{name}}" {
+		t.Error("渲染不应修改模板中的嵌套表格")
+	}
+}
@@ -0,0 +1,602 @@
+// Package document 提供表格行排序、筛选和列移动功能
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// TableSortType 排序键类型
+type TableSortType int
+
+const (
+	// SortAuto 自动识别：非空值全部为数字时按数值，全部为日期时按日期，否则按文本
+	SortAuto TableSortType = iota
+	// SortText 按文本排序
+	SortText
+	// SortNumber 按数值排序，支持千分位、货币符号和百分号
+	SortNumber
+	// SortDate 按日期排序
+	SortDate
+)
+
+// TableSortOptions 表格排序选项
+type TableSortOptions struct {
+	Type        TableSortType // 排序键类型，默认自动识别
+	Descending  bool          // 降序
+	Pinyin      bool          // 文本按汉语拼音排序（GB2312一级常用汉字），否则按Unicode码点排序
+	DateLayout  string        // 日期格式，为空时识别常见格式，如"2006-01-02"、"2006/1/2"、"2006年1月2日"
+	HeaderRows  int           // 除标记为标题行的行外，另外保持不动的开头行数
+	FooterRows  int           // 保持不动的末尾行数，如合计行
+	SplitMerged bool          // 拆分涉及排序行的垂直合并单元格（内容复制到每一行），否则返回错误
+}
+
+// sortDateLayouts 自动识别的日期格式
+var sortDateLayouts = []string{
+	"2006-01-02",
+	"2006-1-2",
+	"2006/01/02",
+	"2006/1/2",
+	"2006.01.02",
+	"2006.1.2",
+	"2006年1月2日",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006-01",
+	"2006年1月",
+	time.RFC3339,
+}
+
+// sortKey 排序键，valid为false的值始终排在最后
+type sortKey struct {
+	text   string
+	number float64
+	date   time.Time
+	valid  bool
+}
+
+// tableVMergeGroup 垂直合并的单元格组
+type tableVMergeGroup struct {
+	gridCol    int // 起始网格列
+	start, end int // 起止行
+}
+
+// SortRows 按指定列排序表格行
+//
+// col为网格列索引（与未合并表格的列索引一致），水平合并的单元格按其覆盖的列参与排序。
+// 标记为标题行（IsRowHeader）的行以及开头HeaderRows行、末尾FooterRows行保持不动；
+// 涉及排序行的垂直合并单元格默认返回错误，SplitMerged为true时先拆分为独立单元格。
+// 排序是稳定的，按数字或日期排序时无法解析的值排在最后。
+func (t *Table) SortRows(col int, opts *TableSortOptions) error {
+	if opts == nil {
+		opts = &TableSortOptions{}
+	}
+	if col < 0 || col >= t.gridColumnCount() {
+		return fmt.Errorf("列索引无效：%d，表格共有%d列", col, t.gridColumnCount())
+	}
+
+	rows := t.bodyRows(opts.HeaderRows, opts.FooterRows)
+	if len(rows) < 2 {
+		return nil
+	}
+	if err := t.splitVMergesInRows(rows, opts.SplitMerged); err != nil {
+		return err
+	}
+
+	keys := make([]sortKey, len(rows))
+	for i, row := range rows {
+		keys[i].text = strings.TrimSpace(tableCellText(t.gridCell(row, col)))
+	}
+	sortType := opts.Type
+	if sortType == SortAuto {
+		sortType = detectSortType(keys, opts.DateLayout)
+	}
+	for i := range keys {
+		switch sortType {
+		case SortNumber:
+			keys[i].number, keys[i].valid = parseSortNumber(keys[i].text)
+		case SortDate:
+			keys[i].date, keys[i].valid = parseSortDate(keys[i].text, opts.DateLayout)
+		default:
+			keys[i].valid = true
+		}
+	}
+
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := keys[order[i]], keys[order[j]]
+		if a.valid != b.valid {
+			return a.valid
+		}
+		if !a.valid {
+			return false
+		}
+		var result int
+		switch sortType {
+		case SortNumber:
+			result = compareFloat(a.number, b.number)
+		case SortDate:
+			result = a.date.Compare(b.date)
+		default:
+			if opts.Pinyin {
+				result = comparePinyin(a.text, b.text)
+			} else {
+				result = strings.Compare(a.text, b.text)
+			}
+		}
+		if opts.Descending {
+			return result > 0
+		}
+		return result < 0
+	})
+
+	sorted := make([]TableRow, len(rows))
+	for i, index := range order {
+		sorted[i] = t.Rows[rows[index]]
+	}
+	for i, row := range rows {
+		t.Rows[row] = sorted[i]
+	}
+
+	Info(fmt.Sprintf("按第%d列排序表格，共%d行参与排序", col, len(rows)))
+	return nil
+}
+
+// FilterRows 筛选表格行：保留fn返回true的行，删除其余行，返回删除的行数
+//
+// fn接收行索引和按网格列排列的单元格文本（水平合并的单元格文本位于其起始列，其余列为空）。
+// 标记为标题行的行始终保留且不传给fn。删除垂直合并的起始行时，合并内容移到保留的下一行；
+// 合并范围只剩一行时取消合并。筛选结果不能为空表格。
+func (t *Table) FilterRows(fn func(row int, texts []string) bool) (int, error) {
+	if fn == nil {
+		return 0, fmt.Errorf("筛选函数不能为空")
+	}
+
+	keep := make([]bool, len(t.Rows))
+	kept := 0
+	for i := range t.Rows {
+		if isHeader, _ := t.IsRowHeader(i); isHeader || fn(i, t.rowGridTexts(i)) {
+			keep[i] = true
+			kept++
+		}
+	}
+	if kept == len(t.Rows) {
+		return 0, nil
+	}
+	if kept == 0 {
+		return 0, fmt.Errorf("筛选后表格至少需要保留一行")
+	}
+
+	for _, group := range t.vMergeGroups() {
+		var remaining []int
+		for row := group.start; row <= group.end; row++ {
+			if keep[row] {
+				remaining = append(remaining, row)
+			}
+		}
+		if len(remaining) == 0 {
+			continue
+		}
+		first := t.gridCell(remaining[0], group.gridCol)
+		if remaining[0] != group.start {
+			source := t.gridCell(group.start, group.gridCol)
+			first.Paragraphs, first.Tables = source.Paragraphs, source.Tables
+		}
+		if len(remaining) == 1 {
+			first.Properties.VMerge = nil
+		} else {
+			first.Properties.VMerge = &VMerge{Val: "restart"}
+		}
+	}
+
+	rows := make([]TableRow, 0, kept)
+	for i, row := range t.Rows {
+		if keep[i] {
+			rows = append(rows, row)
+		}
+	}
+	removed := len(t.Rows) - kept
+	t.Rows = rows
+
+	Info(fmt.Sprintf("筛选表格行：删除%d行，保留%d行", removed, kept))
+	return removed, nil
+}
+
+// MoveColumn 将网格列from移动到to位置，其余列依次移动
+//
+// 垂直合并的单元格随列一起移动。某行中水平合并的单元格覆盖from列，
+// 或移动后的位置会落在水平合并的单元格内部时返回错误，表格保持不变。
+func (t *Table) MoveColumn(from, to int) error {
+	count := t.gridColumnCount()
+	if from < 0 || from >= count || to < 0 || to >= count {
+		return fmt.Errorf("列索引无效：从%d移动到%d，表格共有%d列", from, to, count)
+	}
+	if from == to {
+		return nil
+	}
+
+	// 先检查所有行，确认可以移动后再修改
+	positions := make([][2]int, len(t.Rows))
+	for i := range t.Rows {
+		source := -1
+		target := -1
+		start := 0
+		for j := range t.Rows[i].Cells {
+			span := cellGridSpan(&t.Rows[i].Cells[j])
+			if from >= start && from < start+span {
+				if span > 1 {
+					return fmt.Errorf("第%d行的水平合并单元格覆盖第%d列，无法移动该列", i, from)
+				}
+				source = j
+				start += span
+				continue
+			}
+
+			// 移除from列后的网格位置
+			newStart := start
+			if start > from {
+				newStart--
+			}
+			if target < 0 && newStart >= to {
+				target = j
+			}
+			if newStart < to && to < newStart+span {
+				return fmt.Errorf("第%d行的水平合并单元格覆盖目标位置第%d列，无法移动该列", i, to)
+			}
+			start += span
+		}
+		if source < 0 {
+			return fmt.Errorf("第%d行没有第%d列", i, from)
+		}
+		if target < 0 {
+			target = len(t.Rows[i].Cells)
+		}
+		// 目标索引换算为移除源单元格后的索引
+		if target > source {
+			target--
+		}
+		positions[i] = [2]int{source, target}
+	}
+
+	for i := range t.Rows {
+		cells := t.Rows[i].Cells
+		cell := cells[positions[i][0]]
+		cells = append(cells[:positions[i][0]], cells[positions[i][0]+1:]...)
+		cells = append(cells[:positions[i][1]], append([]TableCell{cell}, cells[positions[i][1]:]...)...)
+		t.Rows[i].Cells = cells
+	}
+	if t.Grid != nil && from < len(t.Grid.Cols) && to < len(t.Grid.Cols) {
+		gridCol := t.Grid.Cols[from]
+		t.Grid.Cols = append(t.Grid.Cols[:from], t.Grid.Cols[from+1:]...)
+		t.Grid.Cols = append(t.Grid.Cols[:to], append([]TableGridCol{gridCol}, t.Grid.Cols[to:]...)...)
+	}
+
+	Info(fmt.Sprintf("将第%d列移动到第%d列", from, to))
+	return nil
+}
+
+// bodyRows 返回参与排序的行：排除标题行以及开头headerRows行、末尾footerRows行
+func (t *Table) bodyRows(headerRows, footerRows int) []int {
+	var rows []int
+	for i := range t.Rows {
+		if i < headerRows || i >= len(t.Rows)-footerRows {
+			continue
+		}
+		if isHeader, _ := t.IsRowHeader(i); isHeader {
+			continue
+		}
+		rows = append(rows, i)
+	}
+	return rows
+}
+
+// splitVMergesInRows 检查涉及指定行的垂直合并单元格，split为true时将其拆分，
+// 拆分后的单元格复制合并起始单元格的内容
+func (t *Table) splitVMergesInRows(rows []int, split bool) error {
+	inRows := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		inRows[row] = true
+	}
+	var groups []tableVMergeGroup
+	for _, group := range t.vMergeGroups() {
+		for row := group.start; row <= group.end; row++ {
+			if inRows[row] {
+				groups = append(groups, group)
+				break
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	if !split {
+		group := groups[0]
+		return fmt.Errorf("第%d至%d行第%d列为垂直合并单元格，无法排序，可设置SplitMerged拆分合并单元格", group.start, group.end, group.gridCol)
+	}
+
+	for _, group := range groups {
+		source := t.gridCell(group.start, group.gridCol)
+		source.Properties.VMerge = nil
+		for row := group.start + 1; row <= group.end; row++ {
+			clone, err := cloneCellContent(source)
+			if err != nil {
+				return fmt.Errorf("复制第%d行第%d列单元格失败: %v", group.start, group.gridCol, err)
+			}
+			cell := t.gridCell(row, group.gridCol)
+			cell.Paragraphs, cell.Tables = clone.Paragraphs, clone.Tables
+			cell.Properties.VMerge = nil
+		}
+	}
+	Info(fmt.Sprintf("拆分%d个垂直合并单元格", len(groups)))
+	return nil
+}
+
+// cloneCellContent 序列化后重新解析单元格，得到段落和嵌套表格的深拷贝，拆分后的单元格互不影响
+func cloneCellContent(source *TableCell) (*TableCell, error) {
+	data, err := xml.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return New().parseTableCell(decoder, start)
+		}
+	}
+}
+
+// vMergeGroups 返回表格中所有跨越两行及以上的垂直合并单元格组
+func (t *Table) vMergeGroups() []tableVMergeGroup {
+	var groups []tableVMergeGroup
+	for i := range t.Rows {
+		start := 0
+		for j := range t.Rows[i].Cells {
+			cell := &t.Rows[i].Cells[j]
+			gridCol := start
+			start += cellGridSpan(cell)
+			if cell.Properties == nil || cell.Properties.VMerge == nil {
+				continue
+			}
+			// 合并起始单元格，或没有起始单元格的续接单元格
+			if cellIsVMergeContinue(cell) && i > 0 {
+				if above := t.gridCellAt(i-1, gridCol); above != nil && above.Properties != nil && above.Properties.VMerge != nil {
+					continue
+				}
+			}
+			end := i
+			for end+1 < len(t.Rows) && cellIsVMergeContinue(t.gridCellAt(end+1, gridCol)) {
+				end++
+			}
+			if end > i {
+				groups = append(groups, tableVMergeGroup{gridCol: gridCol, start: i, end: end})
+			}
+		}
+	}
+	return groups
+}
+
+// gridCellAt 返回从指定网格列开始的单元格，不存在时返回nil
+func (t *Table) gridCellAt(row, gridCol int) *TableCell {
+	start := 0
+	for j := range t.Rows[row].Cells {
+		if start == gridCol {
+			return &t.Rows[row].Cells[j]
+		}
+		start += cellGridSpan(&t.Rows[row].Cells[j])
+		if start > gridCol {
+			break
+		}
+	}
+	return nil
+}
+
+// gridCell 返回覆盖指定网格列的单元格，不存在时返回nil
+func (t *Table) gridCell(row, gridCol int) *TableCell {
+	start := 0
+	for j := range t.Rows[row].Cells {
+		start += cellGridSpan(&t.Rows[row].Cells[j])
+		if gridCol < start {
+			return &t.Rows[row].Cells[j]
+		}
+	}
+	return nil
+}
+
+// rowGridTexts 返回按网格列排列的行文本
+func (t *Table) rowGridTexts(row int) []string {
+	texts := make([]string, t.gridColumnCount())
+	start := 0
+	for j := range t.Rows[row].Cells {
+		cell := &t.Rows[row].Cells[j]
+		if start < len(texts) {
+			texts[start] = tableCellText(cell)
+		}
+		start += cellGridSpan(cell)
+	}
+	return texts
+}
+
+// gridColumnCount 表格的网格列数
+func (t *Table) gridColumnCount() int {
+	if t.Grid != nil && len(t.Grid.Cols) > 0 {
+		return len(t.Grid.Cols)
+	}
+	count := 0
+	for i := range t.Rows {
+		columns := 0
+		for j := range t.Rows[i].Cells {
+			columns += cellGridSpan(&t.Rows[i].Cells[j])
+		}
+		if columns > count {
+			count = columns
+		}
+	}
+	return count
+}
+
+// cellGridSpan 单元格占用的网格列数
+func cellGridSpan(cell *TableCell) int {
+	if cell.Properties != nil && cell.Properties.GridSpan != nil {
+		if span, err := strconv.Atoi(cell.Properties.GridSpan.Val); err == nil && span > 1 {
+			return span
+		}
+	}
+	return 1
+}
+
+// cellIsVMergeContinue 单元格是否为垂直合并的续接单元格
+func cellIsVMergeContinue(cell *TableCell) bool {
+	return cell != nil && cell.Properties != nil && cell.Properties.VMerge != nil && cell.Properties.VMerge.Val != "restart"
+}
+
+// tableCellText 返回单元格中所有段落的文本，段落之间以换行分隔
+func tableCellText(cell *TableCell) string {
+	if cell == nil {
+		return ""
+	}
+	var builder strings.Builder
+	for i, para := range cell.Paragraphs {
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+		for _, run := range para.Runs {
+			builder.WriteString(run.Text.Content)
+		}
+	}
+	return builder.String()
+}
+
+// detectSortType 根据非空值识别排序键类型
+func detectSortType(keys []sortKey, layout string) TableSortType {
+	numbers, dates, values := true, true, 0
+	for _, key := range keys {
+		if key.text == "" {
+			continue
+		}
+		values++
+		if _, ok := parseSortNumber(key.text); !ok {
+			numbers = false
+		}
+		if _, ok := parseSortDate(key.text, layout); !ok {
+			dates = false
+		}
+	}
+	switch {
+	case values == 0:
+		return SortText
+	case numbers:
+		return SortNumber
+	case dates:
+		return SortDate
+	}
+	return SortText
+}
+
+// parseSortNumber 解析数字，允许千分位、前置货币符号和后置百分号
+func parseSortNumber(text string) (float64, bool) {
+	text = strings.TrimSpace(text)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+	text = strings.TrimLeft(text, "¥￥$€£ ")
+	text = strings.TrimSuffix(text, "%")
+	text = strings.ReplaceAll(text, ",", "")
+	if text == "" || strings.ContainsAny(text, "+-") && !strings.ContainsAny(text, "eE") {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, false
+	}
+	if negative {
+		number = -number
+	}
+	return number, true
+}
+
+// parseSortDate 按指定格式或常见格式解析日期
+func parseSortDate(text, layout string) (time.Time, bool) {
+	if text == "" {
+		return time.Time{}, false
+	}
+	if layout != "" {
+		date, err := time.Parse(layout, text)
+		return date, err == nil
+	}
+	for _, layout := range sortDateLayouts {
+		if date, err := time.Parse(layout, text); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareFloat 比较两个数字
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+var (
+	pinyinRanksOnce sync.Once
+	pinyinRanks     map[rune]int
+)
+
+// comparePinyin 按汉语拼音比较文本：非汉字字符（忽略大小写）排在汉字之前，
+// 常用汉字按拼音顺序，其他汉字排在常用汉字之后并按码点排序
+func comparePinyin(a, b string) int {
+	pinyinRanksOnce.Do(func() {
+		pinyinRanks = make(map[rune]int, len(pinyinOrder)/3)
+		for i, r := range []rune(pinyinOrder) {
+			pinyinRanks[r] = i
+		}
+	})
+
+	ra, rb := []rune(a), []rune(b)
+	for i := 0; i < len(ra) && i < len(rb); i++ {
+		if ka, kb := pinyinSortKey(ra[i]), pinyinSortKey(rb[i]); ka != kb {
+			if ka < kb {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(ra) != len(rb) {
+		if len(ra) < len(rb) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// pinyinSortKey 单个字符的拼音排序键
+func pinyinSortKey(r rune) int64 {
+	if rank, ok := pinyinRanks[r]; ok {
+		return 1<<32 + int64(rank)
+	}
+	if unicode.Is(unicode.Han, r) {
+		return 2<<32 + int64(r)
+	}
+	return int64(unicode.ToLower(r))
+}
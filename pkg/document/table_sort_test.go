@@ -0,0 +1,201 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func newSortTestTable(data [][]string) *Table {
+	return New().AddTable(&TableConfig{Rows: len(data), Cols: len(data[0]), Width: 6000, Data: data})
+}
+
+func tableColumnTexts(table *Table, col int) string {
+	texts := make([]string, len(table.Rows))
+	for i := range table.Rows {
+		texts[i] = tableCellText(table.gridCell(i, col))
+	}
+	return strings.Join(texts, ",")
+}
+
+func TestTableSortRows(t *testing.T) {
+	table := newSortTestTable([][]string{
+		{"姓名", "金额", "日期"},
+		{"张三", "¥1,200.00", "2024-03-05"},
+		{"李四", "80", "2023/12/31"},
+		{"王五", "-5%", "2024年1月2日"},
+		{"Alice", "", "待定"},
+		{"合计", "1275", ""},
+	})
+	if err := table.SetRowAsHeader(0, true); err != nil {
+		t.Fatalf("设置标题行失败: %v", err)
+	}
+
+	// 自动识别为数字，空值排在最后，合计行保持不动
+	if err := table.SortRows(1, &TableSortOptions{FooterRows: 1}); err != nil {
+		t.Fatalf("排序失败: %v", err)
+	}
+	if got := tableColumnTexts(table, 0); got != "姓名,王五,李四,张三,Alice,合计" {
+		t.Errorf("按金额排序结果不正确: %s", got)
+	}
+
+	if err := table.SortRows(1, &TableSortOptions{FooterRows: 1, Descending: true}); err != nil {
+		t.Fatalf("排序失败: %v", err)
+	}
+	if got := tableColumnTexts(table, 0); got != "姓名,张三,李四,王五,Alice,合计" {
+		t.Errorf("按金额降序排序结果不正确: %s", got)
+	}
+
+	// 指定按日期排序，无法解析的值排在最后
+	if err := table.SortRows(2, &TableSortOptions{Type: SortDate, FooterRows: 1}); err != nil {
+		t.Fatalf("排序失败: %v", err)
+	}
+	if got := tableColumnTexts(table, 0); got != "姓名,李四,王五,张三,Alice,合计" {
+		t.Errorf("按日期排序结果不正确: %s", got)
+	}
+
+	// 拼音排序：非汉字在前，汉字按拼音
+	if err := table.SortRows(0, &TableSortOptions{Pinyin: true, FooterRows: 1}); err != nil {
+		t.Fatalf("排序失败: %v", err)
+	}
+	if got := tableColumnTexts(table, 0); got != "姓名,Alice,李四,王五,张三,合计" {
+		t.Errorf("按拼音排序结果不正确: %s", got)
+	}
+
+	if err := table.SortRows(3, nil); err == nil {
+		t.Error("无效列索引应返回错误")
+	}
+}
+
+func TestTableSortRowsMerged(t *testing.T) {
+	table := newSortTestTable([][]string{
+		{"华东", "上海", "3"},
+		{"", "杭州", "1"},
+		{"华北", "北京", "2"},
+	})
+	if err := table.MergeCellsVertical(0, 1, 0); err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	if err := table.SortRows(2, nil); err == nil {
+		t.Fatal("存在垂直合并单元格时应返回错误")
+	}
+	if got := tableColumnTexts(table, 1); got != "上海,杭州,北京" {
+		t.Errorf("排序失败时表格不应被修改: %s", got)
+	}
+
+	table.Rows[0].Cells[0].Paragraphs[0].Runs[0].Properties = &RunProperties{Bold: &Bold{}}
+	if err := table.SortRows(2, &TableSortOptions{SplitMerged: true}); err != nil {
+		t.Fatalf("拆分合并单元格后排序失败: %v", err)
+	}
+	if got := tableColumnTexts(table, 0) + "|" + tableColumnTexts(table, 1); got != "华东,华北,华东|杭州,北京,上海" {
+		t.Errorf("拆分排序结果不正确: %s", got)
+	}
+	for i := range table.Rows {
+		if table.Rows[i].Cells[0].Properties.VMerge != nil {
+			t.Errorf("第%d行仍为合并单元格", i)
+		}
+	}
+	// 拆分后的单元格保留格式且内容相互独立
+	if props := table.Rows[2].Cells[0].Paragraphs[0].Runs[0].Properties; props == nil || props.Bold == nil {
+		t.Error("拆分后的单元格应保留文字格式")
+	}
+	table.Rows[0].Cells[0].Paragraphs[0].Runs[0].Text.Content = "华南"
+	if tableCellText(&table.Rows[2].Cells[0]) != "华东" {
+		t.Error("拆分后的单元格不应共享内容")
+	}
+}
+
+func TestTableFilterRows(t *testing.T) {
+	table := newSortTestTable([][]string{
+		{"地区", "城市", "销量"},
+		{"华东", "上海", "30"},
+		{"", "杭州", "10"},
+		{"", "南京", "20"},
+		{"华北", "北京", "5"},
+	})
+	table.SetRowAsHeader(0, true)
+	if err := table.MergeCellsVertical(1, 3, 0); err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+
+	removed, err := table.FilterRows(func(row int, texts []string) bool {
+		if row == 0 {
+			t.Error("标题行不应传给筛选函数")
+		}
+		return texts[1] != "上海" && texts[1] != "北京"
+	})
+	if err != nil || removed != 2 {
+		t.Fatalf("筛选结果不正确: %d %v", removed, err)
+	}
+	if got := tableColumnTexts(table, 0) + "|" + tableColumnTexts(table, 1); got != "地区,华东,|城市,杭州,南京" {
+		t.Errorf("筛选后内容不正确: %s", got)
+	}
+	if vm := table.Rows[1].Cells[0].Properties.VMerge; vm == nil || vm.Val != "restart" {
+		t.Error("合并内容应移到保留的第一行")
+	}
+
+	if _, err := table.FilterRows(func(row int, texts []string) bool { return texts[1] == "南京" }); err != nil {
+		t.Fatalf("筛选失败: %v", err)
+	}
+	if len(table.Rows) != 2 || table.Rows[1].Cells[0].Properties.VMerge != nil || tableCellText(&table.Rows[1].Cells[0]) != "华东" {
+		t.Error("只剩一行的合并单元格应取消合并")
+	}
+
+	empty := newSortTestTable([][]string{{"a"}, {"b"}})
+	if _, err := empty.FilterRows(func(int, []string) bool { return false }); err == nil || len(empty.Rows) != 2 {
+		t.Error("筛选后没有行时应返回错误且保持表格不变")
+	}
+}
+
+func TestTableMoveColumn(t *testing.T) {
+	table := newSortTestTable([][]string{
+		{"A", "B", "C", "D"},
+		{"1", "2", "3", "4"},
+	})
+	table.Grid.Cols[0].W = "1000"
+	if err := table.MoveColumn(0, 2); err != nil {
+		t.Fatalf("移动列失败: %v", err)
+	}
+	if got := strings.Join(tableRowTexts(table, 0), ""); got != "BCAD" || table.Grid.Cols[2].W != "1000" {
+		t.Errorf("移动列结果不正确: %s %v", got, table.Grid.Cols)
+	}
+	if err := table.MoveColumn(3, 0); err != nil {
+		t.Fatalf("移动列失败: %v", err)
+	}
+	if got := strings.Join(tableRowTexts(table, 1), ""); got != "4231" {
+		t.Errorf("移动列结果不正确: %s", got)
+	}
+
+	// 水平合并单元格覆盖源列或目标位置时不能移动
+	if err := table.MergeCellsHorizontal(0, 1, 2); err != nil {
+		t.Fatalf("合并失败: %v", err)
+	}
+	if err := table.MoveColumn(1, 3); err == nil {
+		t.Error("源列为水平合并单元格时应返回错误")
+	}
+	if err := table.MoveColumn(0, 1); err == nil {
+		t.Error("目标位置在水平合并单元格内时应返回错误")
+	}
+	if err := table.MoveColumn(3, 1); err != nil {
+		t.Fatalf("移动到合并单元格之前失败: %v", err)
+	}
+	if got := strings.Join(tableRowTexts(table, 1), ""); got != "4123" || len(table.Rows[0].Cells) != 3 {
+		t.Errorf("移动列结果不正确: %s", got)
+	}
+	if err := table.MoveColumn(0, 4); err == nil {
+		t.Error("无效列索引应返回错误")
+	}
+}
+
+func TestComparePinyin(t *testing.T) {
+	words := []string{"重庆", "北京", "阿里", "abc", "Zoo", "𠀀", "安徽"}
+	for i := 0; i < len(words); i++ {
+		for j := i + 1; j < len(words); j++ {
+			if comparePinyin(words[j], words[i]) < 0 {
+				words[i], words[j] = words[j], words[i]
+			}
+		}
+	}
+	if got := strings.Join(words, ","); got != "abc,Zoo,阿里,安徽,北京,重庆,𠀀" {
+		t.Errorf("拼音排序结果不正确: %s", got)
+	}
+}
@@ -0,0 +1,48 @@
+// Package document 提供自定义表格样式注册功能
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/ZeroHawkeye/wordZero/pkg/style"
+)
+
+// AddTableStyle 将表格样式（如 style.TableStyleBuilder 生成的样式）添加到文档中，同ID的样式被替换。
+// 添加后通过 Table.ApplyTableStyle 的 StyleID 引用，FirstRowHeader、BandedRows 等选项决定哪些条件格式生效
+func (d *Document) AddTableStyle(tableStyle *style.Style) error {
+	if tableStyle == nil || tableStyle.StyleID == "" {
+		return NewValidationError("style", "", "style ID cannot be empty")
+	}
+	if tableStyle.Type != string(style.StyleTypeTable) {
+		return NewValidationError("style.Type", tableStyle.Type, "style must be a table style")
+	}
+	d.styleManager.AddStyle(tableStyle)
+	Info(fmt.Sprintf("添加表格样式：%s", tableStyle.StyleID))
+
+	// 打开的文档保存时按原文输出styles.xml，需要同步写入样式定义
+	stylesXML, ok := d.parts["word/styles.xml"]
+	if !ok {
+		return nil
+	}
+	raw, err := xml.Marshal(tableStyle)
+	if err != nil {
+		return WrapError("marshal_style", err)
+	}
+	if existing := templateDocumentStyles(d)[tableStyle.StyleID]; existing != nil {
+		d.parts["word/styles.xml"] = bytes.Replace(stylesXML, existing.raw, raw, 1)
+		return nil
+	}
+
+	end := bytes.LastIndex(stylesXML, []byte("</w:styles>"))
+	if end < 0 {
+		return WrapError("add_table_style", fmt.Errorf("invalid styles.xml"))
+	}
+	var buffer bytes.Buffer
+	buffer.Write(stylesXML[:end])
+	buffer.Write(raw)
+	buffer.Write(stylesXML[end:])
+	d.parts["word/styles.xml"] = buffer.Bytes()
+	return nil
+}
@@ -0,0 +1,58 @@
+package document
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ZeroHawkeye/wordZero/pkg/style"
+)
+
+func TestAddTableStyle(t *testing.T) {
+	doc := New()
+	report := style.NewTableStyleBuilder("ReportTable", "报表").
+		FirstRow(&style.TableConditionFormat{Run: &style.QuickRunConfig{Bold: true, FontColor: "FFFFFF"}, Shading: "1F4E79"}).
+		BandedRows(nil, &style.TableConditionFormat{Shading: "F2F2F2"}).
+		Build()
+	if err := doc.AddTableStyle(report); err != nil {
+		t.Fatalf("添加表格样式失败: %v", err)
+	}
+	if err := doc.AddTableStyle(doc.GetStyleManager().GetStyle("Normal")); err == nil {
+		t.Error("非表格样式应返回错误")
+	}
+
+	table := doc.AddTable(&TableConfig{Rows: 3, Cols: 2, Width: 6000})
+	if err := table.ApplyTableStyle(&TableStyleConfig{StyleID: "ReportTable", FirstRowHeader: true, BandedRows: true}); err != nil {
+		t.Fatalf("应用表格样式失败: %v", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "table_style.docx")
+	if err := doc.Save(filename); err != nil {
+		t.Fatalf("保存文档失败: %v", err)
+	}
+	opened, err := Open(filename)
+	if err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	stylesXML := string(opened.parts["word/styles.xml"])
+	if !strings.Contains(stylesXML, `w:styleId="ReportTable"`) || !strings.Contains(stylesXML, `<w:tblStylePr w:type="firstRow">`) {
+		t.Fatal("styles.xml应包含表格样式及条件格式")
+	}
+	if tables := opened.Body.GetTables(); len(tables) != 1 || tables[0].Properties.TableStyle.Val != "ReportTable" {
+		t.Error("表格应引用自定义样式")
+	}
+
+	// 打开的文档中替换同ID样式并添加新样式
+	updated := style.NewTableStyleBuilder("ReportTable", "报表").LastRow(&style.TableConditionFormat{Run: &style.QuickRunConfig{Bold: true}}).Build()
+	if err := opened.AddTableStyle(updated); err != nil {
+		t.Fatalf("替换表格样式失败: %v", err)
+	}
+	if err := opened.AddTableStyle(style.NewTableStyleBuilder("PlainTable", "简洁").Build()); err != nil {
+		t.Fatalf("添加表格样式失败: %v", err)
+	}
+	stylesXML = string(opened.parts["word/styles.xml"])
+	if strings.Count(stylesXML, `w:styleId="ReportTable"`) != 1 || strings.Contains(stylesXML, `w:type="firstRow"`) ||
+		!strings.Contains(stylesXML, `w:type="lastRow"`) || !strings.Contains(stylesXML, `w:styleId="PlainTable"`) {
+		t.Errorf("打开的文档中样式未正确更新:\n%s", stylesXML)
+	}
+}
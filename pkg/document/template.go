@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,6 +22,9 @@ var (
 	// ErrTemplateRenderError 模板渲染错误
 	ErrTemplateRenderError = NewDocumentError("template_render_error", fmt.Errorf("template render error"), "")
 
+	// ErrTemplateMissingData 严格模式下存在无法解析的占位符
+	ErrTemplateMissingData = NewDocumentError("template_missing_data", fmt.Errorf("template missing data"), "")
+
 	// ErrInvalidTemplateData 无效模板数据
 	ErrInvalidTemplateData = NewDocumentError("invalid_template_data", fmt.Errorf("invalid template data"), "")
 
@@ -35,9 +37,12 @@ var (
 
 // TemplateEngine 模板引擎
 type TemplateEngine struct {
-	cache    map[string]*Template // 模板缓存
-	mutex    sync.RWMutex         // 读写锁
-	basePath string               // 基础路径
+	cache    map[string]*Template      // 模板缓存
+	mutex    sync.RWMutex              // 读写锁
+	basePath string                    // 基础路径
+	filters  map[string]TemplateFilter // 自定义过滤器
+	locale   *TemplateLocale           // 区域格式，为nil时使用默认格式
+	options  TemplateRenderOptions     // 渲染选项
 }
 
 // Template 模板结构
@@ -49,11 +54,14 @@ type Template struct {
 	Blocks        []*TemplateBlock          // 模板块列表
 	Parent        *Template                 // 父模板（用于继承）
 	DefinedBlocks map[string]*TemplateBlock // 定义的块映射
+
+	nodes    []templateNode // 语法树
+	parseErr error          // 语法错误，加载时记录，验证和渲染时返回
 }
 
 // TemplateBlock 模板块
 type TemplateBlock struct {
-	Type           string                 // 块类型：variable, if, each, inherit, block, image
+	Type           string                 // 块类型：variable, if, each, inherit, block, image, partial
 	Name           string                 // 块名称（block类型使用）
 	Content        string                 // 块内容
 	Condition      string                 // 条件（if块使用）
@@ -62,6 +70,8 @@ type TemplateBlock struct {
 	Data           map[string]interface{} // 块数据
 	DefaultContent string                 // 默认内容（用于可选重写）
 	IsOverridden   bool                   // 是否被重写
+
+	body []templateNode // 块内容语法树（用于继承时替换父模板中的同名块）
 }
 
 // TemplateData 模板数据
@@ -84,8 +94,9 @@ type TemplateImageData struct {
 // NewTemplateEngine 创建新的模板引擎
 func NewTemplateEngine() *TemplateEngine {
 	return &TemplateEngine{
-		cache: make(map[string]*Template),
-		mutex: sync.RWMutex{},
+		cache:   make(map[string]*Template),
+		mutex:   sync.RWMutex{},
+		filters: make(map[string]TemplateFilter),
 	}
 }
 
@@ -186,128 +197,132 @@ func (te *TemplateEngine) RemoveTemplate(name string) {
 	delete(te.cache, name)
 }
 
-// parseTemplate 解析模板内容
+// parseTemplate 解析模板内容，生成语法树并收集变量与块信息
+//
+// 语法错误不会导致加载失败，而是记录在模板中，由ValidateTemplate和渲染方法返回
 func (te *TemplateEngine) parseTemplate(template *Template) error {
-	content := template.Content
-
-	// 解析变量: {{变量名}}
-	varPattern := regexp.MustCompile(`\{\{(\w+)\}\}`)
-	varMatches := varPattern.FindAllStringSubmatch(content, -1)
-	for _, match := range varMatches {
-		if len(match) >= 2 {
-			varName := match[1]
-			template.Variables[varName] = ""
-		}
+	var tokens []*templateToken
+	var err error
+	if template.BaseDoc != nil {
+		tokens, err = lexTemplateElements(template.BaseDoc.Body.Elements)
+	} else {
+		para, run := defaultTemplateParagraph()
+		tokens, err = lexTemplateString(template.Content, para, run)
+	}
+	if err == nil {
+		template.nodes, err = parseTemplateTokens(tokens)
+	}
+	if err != nil {
+		template.nodes = nil
+		template.parseErr = err
+		return nil
 	}
 
-	// 解析块定义: {{#block "blockName"}}...{{/block}}
-	blockPattern := regexp.MustCompile(`(?s)\{\{#block\s+"([^"]+)"\}\}(.*?)\{\{/block\}\}`)
-	blockMatches := blockPattern.FindAllStringSubmatch(content, -1)
-	for _, match := range blockMatches {
-		if len(match) >= 3 {
-			blockName := match[1]
-			blockContent := match[2]
+	te.collectTemplateBlocks(template, template.nodes, nil)
+	return nil
+}
 
-			block := &TemplateBlock{
-				Type:           "block",
-				Name:           blockName,
-				Content:        blockContent,
-				DefaultContent: blockContent,
-				Children:       make([]*TemplateBlock, 0),
-			}
+// defaultTemplateParagraph 字符串模板输出使用的默认段落与文本样式
+func defaultTemplateParagraph() (*Paragraph, *Run) {
+	para := &Paragraph{
+		Properties: &ParagraphProperties{
+			ParagraphStyle: &ParagraphStyle{Val: "Normal"},
+		},
+	}
+	run := &Run{
+		Properties: &RunProperties{
+			FontFamily: &FontFamily{
+				ASCII:    "仿宋",
+				HAnsi:    "仿宋",
+				EastAsia: "仿宋",
+			},
+			FontSize: &FontSize{Val: "24"}, // 12pt = 24 half-points
+		},
+	}
+	return para, run
+}
 
-			template.Blocks = append(template.Blocks, block)
-			template.DefinedBlocks[blockName] = block
+// collectTemplateBlocks 遍历语法树，收集变量、块定义和继承关系
+func (te *TemplateEngine) collectTemplateBlocks(template *Template, nodes []templateNode, parent *TemplateBlock) {
+	addBlock := func(block *TemplateBlock) {
+		template.Blocks = append(template.Blocks, block)
+		if parent != nil {
+			parent.Children = append(parent.Children, block)
 		}
 	}
 
-	// 解析条件语句: {{#if 条件}}...{{/if}} (修复：添加 (?s) 标志以匹配换行符)
-	ifPattern := regexp.MustCompile(`(?s)\{\{#if\s+(\w+)\}\}(.*?)\{\{/if\}\}`)
-	ifMatches := ifPattern.FindAllStringSubmatch(content, -1)
-	for _, match := range ifMatches {
-		if len(match) >= 3 {
-			condition := match[1]
-			blockContent := match[2]
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *templateOutputNode:
+			for _, path := range templateExprPaths(n.Tag.Expr) {
+				if root := path.Root(); root != "this" && !strings.HasPrefix(root, "@") {
+					template.Variables[path.Raw] = ""
+				}
+			}
 
+		case *templateIfNode:
 			block := &TemplateBlock{
 				Type:      "if",
-				Condition: condition,
-				Content:   blockContent,
+				Condition: n.Branches[0].Condition,
+				Content:   templateSource(n.Branches[0].Body),
 				Children:  make([]*TemplateBlock, 0),
 			}
+			addBlock(block)
+			for _, branch := range n.Branches {
+				te.collectTemplateBlocks(template, branch.Body, block)
+			}
+			te.collectTemplateBlocks(template, n.Else, block)
 
-			template.Blocks = append(template.Blocks, block)
-		}
-	}
-
-	// 解析循环语句: {{#each 列表}}...{{/each}} (修复：添加 (?s) 标志以匹配换行符)
-	eachPattern := regexp.MustCompile(`(?s)\{\{#each\s+(\w+)\}\}(.*?)\{\{/each\}\}`)
-	eachMatches := eachPattern.FindAllStringSubmatch(content, -1)
-	for _, match := range eachMatches {
-		if len(match) >= 3 {
-			listVar := match[1]
-			blockContent := match[2]
-
+		case *templateEachNode:
 			block := &TemplateBlock{
 				Type:     "each",
-				Variable: listVar,
-				Content:  blockContent,
+				Variable: n.List,
+				Content:  templateSource(n.Body),
 				Children: make([]*TemplateBlock, 0),
 			}
+			addBlock(block)
+			te.collectTemplateBlocks(template, n.Body, block)
+			te.collectTemplateBlocks(template, n.Else, block)
 
-			template.Blocks = append(template.Blocks, block)
-		}
-	}
-
-	// 解析图片占位符: {{#image imageName}}
-	imagePattern := regexp.MustCompile(`\{\{#image\s+(\w+)\}\}`)
-	imageMatches := imagePattern.FindAllStringSubmatch(content, -1)
-	for _, match := range imageMatches {
-		if len(match) >= 2 {
-			imageName := match[1]
-
+		case *templateBlockNode:
+			content := templateSource(n.Body)
 			block := &TemplateBlock{
-				Type:     "image",
-				Name:     imageName,
-				Content:  match[0], // 保存完整的占位符文本
-				Children: make([]*TemplateBlock, 0),
+				Type:           "block",
+				Name:           n.Name,
+				Content:        content,
+				DefaultContent: content,
+				Children:       make([]*TemplateBlock, 0),
+				body:           n.Body,
 			}
+			addBlock(block)
+			template.DefinedBlocks[n.Name] = block
+			te.collectTemplateBlocks(template, n.Body, block)
 
-			template.Blocks = append(template.Blocks, block)
-		}
-	}
-
-	// 解析继承: {{extends "base_template"}}
-	extendsPattern := regexp.MustCompile(`\{\{extends\s+"([^"]+)"\}\}`)
-	extendsMatches := extendsPattern.FindStringSubmatch(content)
-	if len(extendsMatches) >= 2 {
-		baseName := extendsMatches[1]
-		baseTemplate, err := te.getTemplateInternal(baseName)
-		if err == nil {
-			template.Parent = baseTemplate
-			// 处理块重写
-			te.processBlockOverrides(template, baseTemplate)
-		}
-	}
+		case *templateImageNode:
+			addBlock(&TemplateBlock{
+				Type:     "image",
+				Name:     n.Tag.Arg,
+				Content:  n.Tag.Raw, // 保存完整的占位符文本
+				Children: make([]*TemplateBlock, 0),
+			})
 
-	return nil
-}
+		case *templatePartialNode:
+			addBlock(&TemplateBlock{
+				Type:     "partial",
+				Name:     n.Tag.Arg,
+				Content:  n.Tag.Raw,
+				Children: make([]*TemplateBlock, 0),
+			})
 
-// processBlockOverrides 处理块重写
-func (te *TemplateEngine) processBlockOverrides(childTemplate, parentTemplate *Template) {
-	// 遍历子模板的块定义，检查是否重写父模板的块
-	for blockName, childBlock := range childTemplate.DefinedBlocks {
-		if parentBlock, exists := parentTemplate.DefinedBlocks[blockName]; exists {
-			// 标记父模板块被重写
-			parentBlock.IsOverridden = true
-			parentBlock.Content = childBlock.Content
+		case *templateExtendsNode:
+			// 解析继承: {{extends "base_template"}}
+			if template.Parent == nil {
+				if baseTemplate, err := te.getTemplateInternal(n.Tag.Arg); err == nil {
+					template.Parent = baseTemplate
+				}
+			}
 		}
 	}
-
-	// 递归处理父模板的父模板
-	if parentTemplate.Parent != nil {
-		te.processBlockOverrides(childTemplate, parentTemplate.Parent)
-	}
 }
 
 // RenderToDocument 渲染模板到新文档
@@ -317,263 +332,66 @@ func (te *TemplateEngine) RenderToDocument(templateName string, data *TemplateDa
 		return nil, WrapErrorWithContext("render_to_document", err, templateName)
 	}
 
-	// 创建新文档
-	var doc *Document
-	if template.BaseDoc != nil {
-		// 基于基础文档创建
-		doc = te.cloneDocument(template.BaseDoc)
-	} else {
-		// 创建新文档
-		doc = New()
-	}
-
-	// 渲染模板内容
-	renderedContent, err := te.renderTemplate(template, data)
+	doc, _, err := te.renderTemplateDocument(template, data)
 	if err != nil {
 		return nil, WrapErrorWithContext("render_to_document", err, templateName)
 	}
-
-	// 将渲染内容应用到文档
-	if err := te.applyRenderedContentToDocument(doc, renderedContent); err != nil {
-		return nil, WrapErrorWithContext("render_to_document", err, templateName)
-	}
-
-	// 处理图片占位符
-	if err := te.processImagePlaceholders(doc, data); err != nil {
-		return nil, WrapErrorWithContext("render_to_document", err, templateName)
-	}
-
 	return doc, nil
 }
 
-// renderTemplate 渲染模板
-func (te *TemplateEngine) renderTemplate(template *Template, data *TemplateData) (string, error) {
-	var content string
-
-	// 处理继承：如果有父模板，使用父模板作为基础
-	if template.Parent != nil {
-		// 渲染父模板作为基础内容
-		parentContent, err := te.renderTemplate(template.Parent, data)
-		if err != nil {
-			return "", err
-		}
-		content = parentContent
+// renderTemplateDocument 渲染模板语法树生成新文档并返回渲染报告
+//
+// 严格模式下存在无法解析的占位符时返回渲染报告和*TemplateMissingDataError
+func (te *TemplateEngine) renderTemplateDocument(template *Template, data *TemplateData) (*Document, *RenderReport, error) {
+	root, overrides, err := templateInheritance(template)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// 应用子模板的块重写到父模板内容中
-		content = te.applyBlockOverrides(content, template)
+	var doc *Document
+	if root.BaseDoc != nil {
+		// 基于基础文档创建，保留样式等信息
+		doc = te.cloneDocument(root.BaseDoc)
 	} else {
-		// 没有父模板，直接使用当前模板内容
-		content = template.Content
+		doc = New()
 	}
 
-	// 渲染块定义
-	content = te.renderBlocks(content, template, data)
-
-	// 渲染变量
-	content = te.renderVariables(content, data.Variables)
-
-	// 渲染循环语句（先处理循环，循环内部会处理条件语句）
-	content = te.renderLoops(content, data.Lists)
-
-	// 渲染条件语句（处理非循环内的条件语句）
-	content = te.renderConditionals(content, data.Conditions)
-
-	// 渲染图片占位符
-	content = te.renderImages(content, data.Images)
-
-	return content, nil
+	return te.newTemplateRenderer(doc, data, overrides).renderDocument(root.nodes)
 }
 
-// applyBlockOverrides 将子模板的块重写应用到父模板内容中
-func (te *TemplateEngine) applyBlockOverrides(content string, template *Template) string {
-	// 将子模板的块内容替换父模板中对应的块占位符
-	blockPattern := regexp.MustCompile(`(?s)\{\{#block\s+"([^"]+)"\}\}.*?\{\{/block\}\}`)
-
-	return blockPattern.ReplaceAllStringFunc(content, func(match string) string {
-		matches := blockPattern.FindStringSubmatch(match)
-		if len(matches) >= 2 {
-			blockName := matches[1]
-			// 如果子模板中定义了这个块，使用子模板的内容
-			if childBlock, exists := template.DefinedBlocks[blockName]; exists {
-				return childBlock.Content
-			}
-		}
-		return match // 保持原样
-	})
-}
-
-// renderBlocks 渲染块定义
-func (te *TemplateEngine) renderBlocks(content string, template *Template, data *TemplateData) string {
-	blockPattern := regexp.MustCompile(`(?s)\{\{#block\s+"([^"]+)"\}\}(.*?)\{\{/block\}\}`)
-
-	return blockPattern.ReplaceAllStringFunc(content, func(match string) string {
-		matches := blockPattern.FindStringSubmatch(match)
-		if len(matches) >= 3 {
-			blockName := matches[1]
-			blockContent := matches[2]
-
-			// 检查是否有定义的块
-			if block, exists := template.DefinedBlocks[blockName]; exists {
-				// 如果块被重写，使用重写的内容，否则使用默认内容
-				if block.IsOverridden {
-					return block.Content
-				}
-				return block.DefaultContent
-			}
-
-			// 如果没有定义块，使用原始内容
-			return blockContent
-		}
-		return match
-	})
-}
-
-// renderVariables 渲染变量
-func (te *TemplateEngine) renderVariables(content string, variables map[string]interface{}) string {
-	varPattern := regexp.MustCompile(`\{\{(\w+)\}\}`)
-
-	return varPattern.ReplaceAllStringFunc(content, func(match string) string {
-		varName := varPattern.FindStringSubmatch(match)[1]
-		if value, exists := variables[varName]; exists {
-			return te.interfaceToString(value)
-		}
-		return match // 保持原样
-	})
+// renderDocument 渲染语法树并将生成的元素写入输出文档，返回渲染报告
+func (r *templateRenderer) renderDocument(nodes []templateNode) (*Document, *RenderReport, error) {
+	elements, err := r.render(nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := r.finishReport()
+	if r.options.Strict && len(report.Missing) > 0 {
+		return nil, report, &TemplateMissingDataError{Missing: report.Missing}
+	}
+	r.doc.Body.Elements = elements
+	return r.doc, report, nil
 }
 
-// renderConditionals 渲染条件语句（支持if-else语法）
-func (te *TemplateEngine) renderConditionals(content string, conditions map[string]bool) string {
-	ifElsePattern := regexp.MustCompile(`(?s)\{\{#if\s+(\w+)\}\}(.*?)\{\{/if\}\}`)
-
-	return ifElsePattern.ReplaceAllStringFunc(content, func(match string) string {
-		matches := ifElsePattern.FindStringSubmatch(match)
-		if len(matches) >= 3 {
-			condition := matches[1]
-			blockContent := matches[2]
-
-			// 检查是否有else部分
-			elsePattern := regexp.MustCompile(`(?s)(.*?)\{\{else\}\}(.*?)`)
-			elseMatches := elsePattern.FindStringSubmatch(blockContent)
-
-			if len(elseMatches) >= 3 {
-				// 有else部分
-				ifContent := elseMatches[1]
-				elseContent := elseMatches[2]
-
-				if condValue, exists := conditions[condition]; exists && condValue {
-					return ifContent
-				} else {
-					return elseContent
-				}
-			} else {
-				// 没有else部分，按原逻辑处理
-				if condValue, exists := conditions[condition]; exists && condValue {
-					return blockContent
-				}
-			}
+// templateInheritance 解析继承关系，返回需要渲染的最顶层父模板，
+// 以及子模板（越靠近子模板优先级越高）中定义的、用于替换同名块的块内容
+func templateInheritance(template *Template) (*Template, map[string][]templateNode, error) {
+	overrides := make(map[string][]templateNode)
+	root := template
+	for current := template; current != nil; current = current.Parent {
+		if current.parseErr != nil {
+			return nil, nil, current.parseErr
 		}
-		return "" // 条件不满足，返回空字符串
-	})
-}
-
-// renderLoops 渲染循环语句
-func (te *TemplateEngine) renderLoops(content string, lists map[string][]interface{}) string {
-	eachPattern := regexp.MustCompile(`(?s)\{\{#each\s+(\w+)\}\}(.*?)\{\{/each\}\}`)
-
-	return eachPattern.ReplaceAllStringFunc(content, func(match string) string {
-		matches := eachPattern.FindStringSubmatch(match)
-		if len(matches) >= 3 {
-			listVar := matches[1]
-			blockContent := matches[2]
-
-			if listData, exists := lists[listVar]; exists {
-				var result strings.Builder
-				for i, item := range listData {
-					// 创建循环上下文变量
-					loopContent := strings.ReplaceAll(blockContent, "{{this}}", te.interfaceToString(item))
-					loopContent = strings.ReplaceAll(loopContent, "{{@index}}", strconv.Itoa(i))
-					loopContent = strings.ReplaceAll(loopContent, "{{@first}}", strconv.FormatBool(i == 0))
-					loopContent = strings.ReplaceAll(loopContent, "{{@last}}", strconv.FormatBool(i == len(listData)-1))
-
-					// 如果item是map，处理属性访问
-					if itemMap, ok := item.(map[string]interface{}); ok {
-						for key, value := range itemMap {
-							placeholder := fmt.Sprintf("{{%s}}", key)
-							loopContent = strings.ReplaceAll(loopContent, placeholder, te.interfaceToString(value))
-						}
-
-						// 处理循环内部的条件语句
-						loopContent = te.renderLoopConditionals(loopContent, itemMap)
-					}
-
-					result.WriteString(loopContent)
+		if current.Parent != nil {
+			for name, block := range current.DefinedBlocks {
+				if _, exists := overrides[name]; !exists {
+					overrides[name] = block.body
 				}
-				return result.String()
 			}
 		}
-		return match // 保持原样
-	})
-}
-
-// renderLoopConditionals 渲染循环内部的条件语句（支持if-else语法）
-func (te *TemplateEngine) renderLoopConditionals(content string, itemData map[string]interface{}) string {
-	ifElsePattern := regexp.MustCompile(`(?s)\{\{#if\s+(\w+)\}\}(.*?)\{\{/if\}\}`)
-
-	return ifElsePattern.ReplaceAllStringFunc(content, func(match string) string {
-		matches := ifElsePattern.FindStringSubmatch(match)
-		if len(matches) >= 3 {
-			condition := matches[1]
-			blockContent := matches[2]
-
-			// 检查是否有else部分
-			elsePattern := regexp.MustCompile(`(?s)(.*?)\{\{else\}\}(.*?)`)
-			elseMatches := elsePattern.FindStringSubmatch(blockContent)
-
-			var ifContent, elseContent string
-			hasElse := false
-
-			if len(elseMatches) >= 3 {
-				// 有else部分
-				ifContent = elseMatches[1]
-				elseContent = elseMatches[2]
-				hasElse = true
-			} else {
-				// 没有else部分
-				ifContent = blockContent
-			}
-
-			// 检查条件是否在当前循环项的数据中
-			if condValue, exists := itemData[condition]; exists {
-				// 转换为布尔值
-				conditionMet := false
-				switch v := condValue.(type) {
-				case bool:
-					conditionMet = v
-				case string:
-					conditionMet = v == "true" || v == "1" || v == "yes" || v != ""
-				case int:
-					conditionMet = v != 0
-				case int64:
-					conditionMet = v != 0
-				case float64:
-					conditionMet = v != 0.0
-				default:
-					// 对于其他类型，如果不为nil就认为是true
-					conditionMet = v != nil
-				}
-
-				if conditionMet {
-					return ifContent
-				} else if hasElse {
-					return elseContent
-				}
-			} else if hasElse {
-				// 条件不存在，返回else部分
-				return elseContent
-			}
-		}
-		return "" // 条件不满足且没有else，返回空字符串
-	})
+		root = current
+	}
+	return root, overrides, nil
 }
 
 // interfaceToString 将interface{}转换为字符串
@@ -598,88 +416,14 @@ func (te *TemplateEngine) interfaceToString(value interface{}) string {
 	}
 }
 
-// ValidateTemplate 验证模板语法
+// ValidateTemplate 验证模板语法，语法错误以*TemplateSyntaxError返回（包含行号与列号）
 func (te *TemplateEngine) ValidateTemplate(template *Template) error {
-	content := template.Content
-
-	// 检查括号配对
-	if err := te.validateBrackets(content); err != nil {
-		return WrapErrorWithContext("validate_template", err, template.Name)
-	}
-
-	// 检查块语句配对
-	if err := te.validateBlockStatements(content); err != nil {
-		return WrapErrorWithContext("validate_template", err, template.Name)
-	}
-
-	// 检查if语句配对
-	if err := te.validateIfStatements(content); err != nil {
-		return WrapErrorWithContext("validate_template", err, template.Name)
-	}
-
-	// 检查each语句配对
-	if err := te.validateEachStatements(content); err != nil {
-		return WrapErrorWithContext("validate_template", err, template.Name)
-	}
-
-	return nil
-}
-
-// validateBrackets 验证括号配对
-func (te *TemplateEngine) validateBrackets(content string) error {
-	openCount := strings.Count(content, "{{")
-	closeCount := strings.Count(content, "}}")
-
-	if openCount != closeCount {
-		return NewValidationError("brackets", content, "mismatched template brackets")
-	}
-
-	return nil
-}
-
-// validateBlockStatements 验证块语句配对
-func (te *TemplateEngine) validateBlockStatements(content string) error {
-	blockCount := len(regexp.MustCompile(`\{\{#block\s+"[^"]+"\}\}`).FindAllString(content, -1))
-	endblockCount := len(regexp.MustCompile(`\{\{/block\}\}`).FindAllString(content, -1))
-
-	if blockCount != endblockCount {
-		return NewValidationError("block_statements", content, "mismatched block/endblock statements")
+	if template.parseErr != nil {
+		return WrapErrorWithContext("validate_template", template.parseErr, template.Name)
 	}
-
 	return nil
 }
 
-// validateIfStatements 验证if语句配对
-func (te *TemplateEngine) validateIfStatements(content string) error {
-	ifCount := len(regexp.MustCompile(`\{\{#if\s+\w+\}\}`).FindAllString(content, -1))
-	endifCount := len(regexp.MustCompile(`\{\{/if\}\}`).FindAllString(content, -1))
-
-	if ifCount != endifCount {
-		return NewValidationError("if_statements", content, "mismatched if/endif statements")
-	}
-
-	return nil
-}
-
-// validateEachStatements 验证each语句配对
-func (te *TemplateEngine) validateEachStatements(content string) error {
-	eachCount := len(regexp.MustCompile(`\{\{#each\s+\w+\}\}`).FindAllString(content, -1))
-	endeachCount := len(regexp.MustCompile(`\{\{/each\}\}`).FindAllString(content, -1))
-
-	if eachCount != endeachCount {
-		return NewValidationError("each_statements", content, "mismatched each/endeach statements")
-	}
-
-	return nil
-}
-
-// documentToTemplateString 将文档转换为模板字符串
-func (te *TemplateEngine) documentToTemplateString(doc *Document) (string, error) {
-	// 这里不再转换为纯字符串，而是保持原始文档结构
-	// 实际上我们应该直接在原文档上进行变量替换
-	return "", nil // 将在新的方法中处理
-}
-
 // extractTemplateContentFromDocument 从文档中提取模板内容
 func (te *TemplateEngine) extractTemplateContentFromDocument(doc *Document) (string, error) {
 	var contentBuilder strings.Builder
@@ -741,6 +485,33 @@ func (te *TemplateEngine) cloneDocument(source *Document) *Document {
 		doc.parts["word/styles.xml"] = data
 	}
 
+	// 复制图片、图表、页眉页脚等部件及其关系，使模板中引用的资源在输出文档中保持有效
+	for name, data := range source.parts {
+		switch name {
+		case "word/document.xml", "[Content_Types].xml", "_rels/.rels", "word/_rels/document.xml.rels":
+			continue
+		}
+		if _, exists := doc.parts[name]; !exists {
+			doc.parts[name] = data
+		}
+	}
+	if source.documentRelationships != nil {
+		doc.documentRelationships = &Relationships{
+			Xmlns:         source.documentRelationships.Xmlns,
+			Relationships: append([]Relationship(nil), source.documentRelationships.Relationships...),
+		}
+	}
+	if source.contentTypes != nil {
+		doc.contentTypes = &ContentTypes{
+			Xmlns:     source.contentTypes.Xmlns,
+			Defaults:  append([]Default(nil), source.contentTypes.Defaults...),
+			Overrides: append([]Override(nil), source.contentTypes.Overrides...),
+		}
+	}
+	if source.nextImageID > doc.nextImageID {
+		doc.nextImageID = source.nextImageID
+	}
+
 	return doc
 }
 
@@ -824,6 +595,18 @@ func (te *TemplateEngine) cloneParagraphProperties(source *ParagraphProperties)
 		}
 	}
 
+	// 复制段落边框
+	if source.ParagraphBorder != nil {
+		border := *source.ParagraphBorder
+		props.ParagraphBorder = &border
+	}
+
+	// 复制段前分页和分节符
+	if source.PageBreak != nil {
+		props.PageBreak = &PageBreak{}
+	}
+	props.SectionProperties = source.SectionProperties
+
 	return props
 }
 
@@ -850,6 +633,14 @@ func (te *TemplateEngine) cloneRun(source *Run) Run {
 		newRun.InstrText = source.InstrText
 	}
 
+	// 复制换行和公式（如果有）
+	if source.Break != nil {
+		newRun.Break = source.Break
+	}
+	if source.Equation != nil {
+		newRun.Equation = source.Equation
+	}
+
 	return newRun
 }
 
@@ -1348,6 +1139,11 @@ func (te *TemplateEngine) cloneTableCell(source *TableCell) TableCell {
 	for i, para := range source.Paragraphs {
 		newCell.Paragraphs[i] = *te.cloneParagraph(&para)
 	}
+	for _, nested := range source.Tables {
+		if nested.Table != nil {
+			newCell.Tables = append(newCell.Tables, NestedTable{Index: nested.Index, Table: te.cloneTable(nested.Table)})
+		}
+	}
 
 	return newCell
 }
@@ -1433,771 +1229,20 @@ func (te *TemplateEngine) cloneTableCellProperties(source *TableCellProperties)
 	return props
 }
 
-// applyRenderedContentToDocument 将渲染内容应用到文档
-func (te *TemplateEngine) applyRenderedContentToDocument(doc *Document, content string) error {
-	// 如果内容为空，直接返回
-	if strings.TrimSpace(content) == "" {
-		return nil
-	}
-
-	// 将内容按行分割并创建段落
-	lines := strings.Split(content, "\n")
-
-	for _, line := range lines {
-		// 创建新段落，即使是空行也要创建（保持格式）
-		para := &Paragraph{
-			Properties: &ParagraphProperties{
-				ParagraphStyle: &ParagraphStyle{Val: "Normal"},
-			},
-			Runs: []Run{},
-		}
-
-		// 如果行不为空，添加文本内容
-		if strings.TrimSpace(line) != "" {
-			run := Run{
-				Text: Text{Content: line},
-				Properties: &RunProperties{
-					FontFamily: &FontFamily{
-						ASCII:    "仿宋",
-						HAnsi:    "仿宋",
-						EastAsia: "仿宋",
-					},
-					FontSize: &FontSize{Val: "24"}, // 12pt = 24 half-points
-				},
-			}
-			para.Runs = append(para.Runs, run)
-		} else {
-			// 空行也需要一个空的Run来保持段落结构
-			run := Run{
-				Text: Text{Content: ""},
-				Properties: &RunProperties{
-					FontFamily: &FontFamily{
-						ASCII:    "仿宋",
-						HAnsi:    "仿宋",
-						EastAsia: "仿宋",
-					},
-					FontSize: &FontSize{Val: "24"},
-				},
-			}
-			para.Runs = append(para.Runs, run)
-		}
-
-		// 将段落添加到文档
-		doc.Body.Elements = append(doc.Body.Elements, para)
-	}
-
-	return nil
-}
-
 // RenderTemplateToDocument 渲染模板到新文档（新的主要方法）
+//
+// 从文档加载的模板会保留原文档中段落、文本和表格的样式
 func (te *TemplateEngine) RenderTemplateToDocument(templateName string, data *TemplateData) (*Document, error) {
 	template, err := te.GetTemplate(templateName)
 	if err != nil {
 		return nil, WrapErrorWithContext("render_template_to_document", err, templateName)
 	}
 
-	// 如果有基础文档，克隆它并在其上进行变量替换
-	if template.BaseDoc != nil {
-		doc := te.cloneDocument(template.BaseDoc)
-
-		// 在文档结构中直接进行变量替换
-		err := te.replaceVariablesInDocument(doc, data)
-		if err != nil {
-			return nil, WrapErrorWithContext("render_template_to_document", err, templateName)
-		}
-
-		return doc, nil
-	}
-
-	// 如果没有基础文档，使用原有的方式
-	return te.RenderToDocument(templateName, data)
-}
-
-// replaceVariablesInDocument 在文档结构中直接替换变量
-func (te *TemplateEngine) replaceVariablesInDocument(doc *Document, data *TemplateData) error {
-	// 首先处理文档级别的循环（跨段落）
-	err := te.processDocumentLevelLoops(doc, data)
-	if err != nil {
-		return err
-	}
-
-	for _, element := range doc.Body.Elements {
-		switch elem := element.(type) {
-		case *Paragraph:
-			// 处理段落中的变量替换
-			err := te.replaceVariablesInParagraph(elem, data)
-			if err != nil {
-				return err
-			}
-
-		case *Table:
-			// 处理表格中的变量替换和模板语法
-			err := te.replaceVariablesInTable(elem, data)
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	// 处理图片占位符
-	err = te.processImagePlaceholders(doc, data)
+	doc, _, err := te.renderTemplateDocument(template, data)
 	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// processDocumentLevelLoops 处理文档级别的循环（跨段落）
-func (te *TemplateEngine) processDocumentLevelLoops(doc *Document, data *TemplateData) error {
-	elements := doc.Body.Elements
-	newElements := make([]interface{}, 0)
-
-	i := 0
-	for i < len(elements) {
-		element := elements[i]
-
-		// 检查当前元素是否包含循环开始标记
-		if para, ok := element.(*Paragraph); ok {
-			// 获取段落的完整文本
-			fullText := ""
-			for _, run := range para.Runs {
-				fullText += run.Text.Content
-			}
-
-			// 检查是否包含循环开始标记
-			eachPattern := regexp.MustCompile(`\{\{#each\s+(\w+)\}\}`)
-			matches := eachPattern.FindStringSubmatch(fullText)
-
-			if len(matches) > 1 {
-				listVarName := matches[1]
-
-				// 找到循环结束位置
-				loopEndIndex := -1
-				templateElements := make([]interface{}, 0)
-
-				// 收集循环模板元素（从当前位置到结束标记）
-				for j := i; j < len(elements); j++ {
-					templateElements = append(templateElements, elements[j])
-
-					if nextPara, ok := elements[j].(*Paragraph); ok {
-						nextText := ""
-						for _, run := range nextPara.Runs {
-							nextText += run.Text.Content
-						}
-
-						if strings.Contains(nextText, "{{/each}}") {
-							loopEndIndex = j
-							break
-						}
-					}
-				}
-
-				if loopEndIndex >= 0 {
-					// 处理循环
-					if listData, exists := data.Lists[listVarName]; exists {
-						// 为每个数据项生成元素
-						for _, item := range listData {
-							if itemMap, ok := item.(map[string]interface{}); ok {
-								// 复制模板元素并替换变量
-								for _, templateElement := range templateElements {
-									if templatePara, ok := templateElement.(*Paragraph); ok {
-										newPara := te.cloneParagraph(templatePara)
-
-										// 处理段落文本
-										fullText := ""
-										for _, run := range newPara.Runs {
-											fullText += run.Text.Content
-										}
-
-										// 移除循环标记
-										content := fullText
-										content = regexp.MustCompile(`\{\{#each\s+\w+\}\}`).ReplaceAllString(content, "")
-										content = regexp.MustCompile(`\{\{/each\}\}`).ReplaceAllString(content, "")
-
-										// 替换变量
-										for key, value := range itemMap {
-											placeholder := fmt.Sprintf("{{%s}}", key)
-											content = strings.ReplaceAll(content, placeholder, te.interfaceToString(value))
-										}
-
-										// 如果内容不为空，创建新段落
-										if strings.TrimSpace(content) != "" {
-											newPara.Runs = []Run{{
-												Text: Text{Content: content},
-												Properties: &RunProperties{
-													FontFamily: &FontFamily{
-														ASCII:    "仿宋",
-														HAnsi:    "仿宋",
-														EastAsia: "仿宋",
-													},
-													Bold: &Bold{},
-												},
-											}}
-											newElements = append(newElements, newPara)
-										}
-									}
-								}
-							}
-						}
-					}
-
-					// 跳过循环模板元素
-					i = loopEndIndex + 1
-					continue
-				}
-			}
-		}
-
-		// 不是循环元素，直接添加
-		newElements = append(newElements, element)
-		i++
-	}
-
-	// 更新文档元素
-	doc.Body.Elements = newElements
-	return nil
-}
-
-// replaceVariablesInParagraph 在段落中替换变量（改进版本，更好地保持样式）
-func (te *TemplateEngine) replaceVariablesInParagraph(para *Paragraph, data *TemplateData) error {
-	// 首先识别所有变量占位符的位置
-	fullText := ""
-	runInfos := make([]struct {
-		startIndex int
-		endIndex   int
-		run        *Run
-	}, 0)
-
-	currentIndex := 0
-	for i := range para.Runs {
-		runText := para.Runs[i].Text.Content
-		if runText != "" {
-			runInfos = append(runInfos, struct {
-				startIndex int
-				endIndex   int
-				run        *Run
-			}{
-				startIndex: currentIndex,
-				endIndex:   currentIndex + len(runText),
-				run:        &para.Runs[i],
-			})
-			fullText += runText
-			currentIndex += len(runText)
-		}
-	}
-
-	// 如果没有文本内容，直接返回
-	if fullText == "" {
-		return nil
-	}
-
-	// 先处理循环语句（包括非表格循环）
-	processedText, hasLoopChanges := te.processNonTableLoops(fullText, data)
-	if hasLoopChanges {
-		// 重新构建段落
-		para.Runs = []Run{{
-			Text: Text{Content: processedText},
-			Properties: &RunProperties{
-				FontFamily: &FontFamily{
-					ASCII:    "仿宋",
-					HAnsi:    "仿宋",
-					EastAsia: "仿宋",
-				},
-				Bold: &Bold{},
-			},
-		}}
-		fullText = processedText
-	}
-
-	// 使用新的逐个变量替换方法
-	newRuns, hasVarChanges := te.replaceVariablesSequentially(runInfos, fullText, data)
-
-	// 如果有变化，更新段落的Run
-	if hasVarChanges || hasLoopChanges {
-		para.Runs = newRuns
-	}
-
-	return nil
-}
-
-// processNonTableLoops 处理非表格循环
-func (te *TemplateEngine) processNonTableLoops(content string, data *TemplateData) (string, bool) {
-	eachPattern := regexp.MustCompile(`(?s)\{\{#each\s+(\w+)\}\}(.*?)\{\{/each\}\}`)
-	matches := eachPattern.FindAllStringSubmatchIndex(content, -1)
-
-	if len(matches) == 0 {
-		return content, false
-	}
-
-	var result strings.Builder
-	lastEnd := 0
-	hasChanges := false
-
-	for _, match := range matches {
-		// 找到变量名和块内容
-		fullMatch := content[match[0]:match[1]]
-		submatch := eachPattern.FindStringSubmatch(fullMatch)
-		if len(submatch) >= 3 {
-			listVar := submatch[1]
-			blockContent := submatch[2]
-
-			// 添加循环前的内容
-			result.WriteString(content[lastEnd:match[0]])
-
-			// 处理循环
-			if listData, exists := data.Lists[listVar]; exists {
-				for _, item := range listData {
-					if itemMap, ok := item.(map[string]interface{}); ok {
-						loopContent := blockContent
-						for key, value := range itemMap {
-							placeholder := fmt.Sprintf("{{%s}}", key)
-							loopContent = strings.ReplaceAll(loopContent, placeholder, te.interfaceToString(value))
-						}
-						result.WriteString(loopContent)
-					}
-				}
-			}
-
-			lastEnd = match[1]
-			hasChanges = true
-		}
-	}
-
-	// 添加剩余内容
-	if lastEnd < len(content) {
-		result.WriteString(content[lastEnd:])
-	}
-
-	return result.String(), hasChanges
-}
-
-// replaceVariablesSequentially 逐个替换变量，保持样式
-func (te *TemplateEngine) replaceVariablesSequentially(originalRunInfos []struct {
-	startIndex int
-	endIndex   int
-	run        *Run
-}, originalText string, data *TemplateData) ([]Run, bool) {
-
-	// 找到所有变量位置
-	varPattern := regexp.MustCompile(`\{\{(\w+)\}\}`)
-	varMatches := varPattern.FindAllStringSubmatchIndex(originalText, -1)
-
-	if len(varMatches) == 0 {
-		// 没有变量，检查条件语句
-		return te.processConditionals(originalRunInfos, originalText, data)
-	}
-
-	newRuns := make([]Run, 0)
-	currentPos := 0
-	hasChanges := false
-
-	for _, varMatch := range varMatches {
-		varStart := varMatch[0]
-		varEnd := varMatch[1]
-		varNameStart := varMatch[2]
-		varNameEnd := varMatch[3]
-
-		// 添加变量前的文本（保持原样式）
-		if varStart > currentPos {
-			beforeText := originalText[currentPos:varStart]
-			beforeRuns := te.extractRunsForSegment(originalRunInfos, currentPos, varStart, beforeText)
-			newRuns = append(newRuns, beforeRuns...)
-		}
-
-		// 处理变量替换
-		varName := originalText[varNameStart:varNameEnd]
-		if value, exists := data.Variables[varName]; exists {
-			replacementText := te.interfaceToString(value)
-
-			// 为变量选择合适的样式（使用覆盖变量位置的Run样式）
-			varRun := te.findRunForPosition(originalRunInfos, varStart)
-			if varRun != nil {
-				newRun := te.cloneRun(varRun)
-				newRun.Text.Content = replacementText
-				newRuns = append(newRuns, newRun)
-				hasChanges = true
-			}
-		} else {
-			// 变量不存在，保持原始占位符
-			varText := originalText[varStart:varEnd]
-			varRun := te.findRunForPosition(originalRunInfos, varStart)
-			if varRun != nil {
-				newRun := te.cloneRun(varRun)
-				newRun.Text.Content = varText
-				newRuns = append(newRuns, newRun)
-			}
-		}
-
-		currentPos = varEnd
-	}
-
-	// 添加最后剩余的文本
-	if currentPos < len(originalText) {
-		afterText := originalText[currentPos:]
-		afterRuns := te.extractRunsForSegment(originalRunInfos, currentPos, len(originalText), afterText)
-		newRuns = append(newRuns, afterRuns...)
-	}
-
-	// 如果没有找到任何变量但文本发生了变化，处理条件语句
-	if !hasChanges {
-		return te.processConditionals(originalRunInfos, originalText, data)
-	}
-
-	// 对结果处理条件语句（但要保持每个Run的独立性）
-	if hasChanges {
-		finalRuns := te.processConditionalsPreservingRuns(newRuns, data)
-		return finalRuns, true
-	}
-
-	return newRuns, hasChanges
-}
-
-// processConditionalsPreservingRuns 处理条件语句但保持Run的独立性
-func (te *TemplateEngine) processConditionalsPreservingRuns(runs []Run, data *TemplateData) []Run {
-	finalRuns := make([]Run, 0)
-
-	for _, run := range runs {
-		originalContent := run.Text.Content
-		processedContent := te.renderConditionals(originalContent, data.Conditions)
-
-		// 如果内容发生变化，更新这个Run
-		if processedContent != originalContent {
-			newRun := run // 复制Run结构
-			newRun.Text.Content = processedContent
-			finalRuns = append(finalRuns, newRun)
-		} else {
-			// 内容没有变化，保持原样
-			finalRuns = append(finalRuns, run)
-		}
-	}
-
-	return finalRuns
-}
-
-// processConditionals 处理条件语句
-func (te *TemplateEngine) processConditionals(originalRunInfos []struct {
-	startIndex int
-	endIndex   int
-	run        *Run
-}, originalText string, data *TemplateData) ([]Run, bool) {
-
-	processedText := te.renderConditionals(originalText, data.Conditions)
-
-	if processedText == originalText {
-		// 没有变化，返回原始Runs
-		newRuns := make([]Run, len(originalRunInfos))
-		for i, runInfo := range originalRunInfos {
-			newRuns[i] = te.cloneRun(runInfo.run)
-		}
-		return newRuns, false
-	}
-
-	// 有条件语句被处理，简化处理
-	if len(originalRunInfos) == 1 {
-		newRun := te.cloneRun(originalRunInfos[0].run)
-		newRun.Text.Content = processedText
-		return []Run{newRun}, true
-	}
-
-	// 多个Run的情况，使用第一个Run的样式
-	newRun := te.cloneRun(originalRunInfos[0].run)
-	newRun.Text.Content = processedText
-	return []Run{newRun}, true
-}
-
-// extractRunsForSegment 为文本片段提取相应的Run（改进版本）
-func (te *TemplateEngine) extractRunsForSegment(originalRunInfos []struct {
-	startIndex int
-	endIndex   int
-	run        *Run
-}, segmentStart, segmentEnd int, segmentText string) []Run {
-	runs := make([]Run, 0)
-
-	for _, runInfo := range originalRunInfos {
-		// 检查Run是否与文本段有重叠
-		if runInfo.endIndex > segmentStart && runInfo.startIndex < segmentEnd {
-			overlapStart := max(runInfo.startIndex, segmentStart)
-			overlapEnd := min(runInfo.endIndex, segmentEnd)
-
-			if overlapEnd > overlapStart {
-				newRun := te.cloneRun(runInfo.run)
-				// 计算在分段文本中的相对位置
-				relativeStart := overlapStart - segmentStart
-				relativeEnd := overlapEnd - segmentStart
-
-				// 确保索引在有效范围内
-				if relativeStart >= 0 && relativeEnd <= len(segmentText) && relativeStart < relativeEnd {
-					newRun.Text.Content = segmentText[relativeStart:relativeEnd]
-					if newRun.Text.Content != "" {
-						runs = append(runs, newRun)
-					}
-				}
-			}
-		}
-	}
-
-	return runs
-}
-
-// findRunForPosition 找到覆盖指定位置的Run
-func (te *TemplateEngine) findRunForPosition(originalRunInfos []struct {
-	startIndex int
-	endIndex   int
-	run        *Run
-}, position int) *Run {
-	for _, runInfo := range originalRunInfos {
-		if position >= runInfo.startIndex && position < runInfo.endIndex {
-			return runInfo.run
-		}
-	}
-	// 如果没找到，返回第一个Run
-	if len(originalRunInfos) > 0 {
-		return originalRunInfos[0].run
-	}
-	return nil
-}
-
-// max 返回两个整数中的较大值
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-// min 返回两个整数中的较小值
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-// replaceVariablesInTable 在表格中替换变量和处理表格模板
-func (te *TemplateEngine) replaceVariablesInTable(table *Table, data *TemplateData) error {
-	// 检查是否有表格循环模板
-	if len(table.Rows) > 0 && te.isTableTemplate(table) {
-		return te.renderTableTemplate(table, data)
-	}
-
-	// 普通表格变量替换
-	for i := range table.Rows {
-		for j := range table.Rows[i].Cells {
-			for k := range table.Rows[i].Cells[j].Paragraphs {
-				err := te.replaceVariablesInParagraph(&table.Rows[i].Cells[j].Paragraphs[k], data)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	}
-
-	return nil
-}
-
-// isTableTemplate 检查表格是否包含模板语法（支持跨Run检测）
-func (te *TemplateEngine) isTableTemplate(table *Table) bool {
-	if len(table.Rows) == 0 {
-		return false
-	}
-
-	// 检查所有行是否包含循环语法，支持跨Run检测
-	for _, row := range table.Rows {
-		for _, cell := range row.Cells {
-			for _, para := range cell.Paragraphs {
-				// 使用新的跨Run检测方法
-				if te.containsTemplateLoopInRuns(para.Runs) {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
-}
-
-// containsTemplateLoop 检查文本是否包含循环模板语法（支持跨Run检测）
-func (te *TemplateEngine) containsTemplateLoop(text string) bool {
-	eachPattern := regexp.MustCompile(`\{\{#each\s+\w+\}\}`)
-	return eachPattern.MatchString(text)
-}
-
-// containsTemplateLoopInRuns 检查Run列表中是否包含循环模板语法（跨Run检测）
-func (te *TemplateEngine) containsTemplateLoopInRuns(runs []Run) bool {
-	// 合并所有Run的文本
-	fullText := ""
-	for _, run := range runs {
-		fullText += run.Text.Content
-	}
-
-	return te.containsTemplateLoop(fullText)
-}
-
-// renderTableTemplate 渲染表格模板
-func (te *TemplateEngine) renderTableTemplate(table *Table, data *TemplateData) error {
-	if len(table.Rows) == 0 {
-		return nil
-	}
-
-	// 找到模板行（包含循环语法的行）
-	templateRowIndex := -1
-	var listVarName string
-
-	for i, row := range table.Rows {
-		found := false
-		// 检查整行的所有单元格，合并文本来解决跨Run的变量问题
-		for _, cell := range row.Cells {
-			for _, para := range cell.Paragraphs {
-				// 合并所有Run的文本
-				fullText := ""
-				for _, run := range para.Runs {
-					fullText += run.Text.Content
-				}
-
-				// 检查合并后的文本中是否包含循环语法
-				eachPattern := regexp.MustCompile(`\{\{#each\s+(\w+)\}\}`)
-				matches := eachPattern.FindStringSubmatch(fullText)
-				if len(matches) > 1 {
-					templateRowIndex = i
-					listVarName = matches[1]
-					found = true
-					break
-				}
-			}
-			if found {
-				break
-			}
-		}
-		if found {
-			break
-		}
-	}
-
-	if templateRowIndex < 0 || listVarName == "" {
-		return nil
-	}
-
-	// 获取列表数据
-	listData, exists := data.Lists[listVarName]
-	if !exists || len(listData) == 0 {
-		// 删除模板行
-		table.Rows = append(table.Rows[:templateRowIndex], table.Rows[templateRowIndex+1:]...)
-		return nil
-	}
-
-	// 保存模板行
-	templateRow := table.Rows[templateRowIndex]
-	newRows := make([]TableRow, 0)
-
-	// 保留模板行之前的行（深度克隆以保持样式）
-	for _, row := range table.Rows[:templateRowIndex] {
-		clonedRow := te.cloneTableRow(&row)
-		newRows = append(newRows, *clonedRow)
-	}
-
-	// 为每个数据项生成新行
-	for _, item := range listData {
-		newRow := te.cloneTableRow(&templateRow)
-
-		// 在新行中替换变量
-		if itemMap, ok := item.(map[string]interface{}); ok {
-			for i := range newRow.Cells {
-				for j := range newRow.Cells[i].Paragraphs {
-					// 合并所有Run的文本
-					fullText := ""
-					originalRuns := newRow.Cells[i].Paragraphs[j].Runs
-					for _, run := range originalRuns {
-						fullText += run.Text.Content
-					}
-
-					// 移除模板语法标记
-					content := fullText
-					content = regexp.MustCompile(`\{\{#each\s+\w+\}\}`).ReplaceAllString(content, "")
-					content = regexp.MustCompile(`\{\{/each\}\}`).ReplaceAllString(content, "")
-
-					// 替换变量
-					for key, value := range itemMap {
-						placeholder := fmt.Sprintf("{{%s}}", key)
-						content = strings.ReplaceAll(content, placeholder, te.interfaceToString(value))
-					}
-
-					// 处理条件语句
-					content = te.renderLoopConditionals(content, itemMap)
-
-					// 重建Run结构，更好地保持样式继承
-					if len(originalRuns) > 0 {
-						// 寻找第一个有实际内容或样式的Run作为样式模板
-						var templateRun *Run
-						for k := range originalRuns {
-							if originalRuns[k].Properties != nil || originalRuns[k].Text.Content != "" {
-								templateRun = &originalRuns[k]
-								break
-							}
-						}
-
-						if templateRun != nil {
-							newRun := te.cloneRun(templateRun)
-							newRun.Text.Content = content
-							newRow.Cells[i].Paragraphs[j].Runs = []Run{newRun}
-						} else {
-							// 使用第一个Run但确保基本样式
-							newRun := te.cloneRun(&originalRuns[0])
-							newRun.Text.Content = content
-							// 确保基本的字体设置
-							if newRun.Properties == nil {
-								newRun.Properties = &RunProperties{}
-							}
-							if newRun.Properties.FontFamily == nil {
-								newRun.Properties.FontFamily = &FontFamily{
-									ASCII:    "仿宋",
-									HAnsi:    "仿宋",
-									EastAsia: "仿宋",
-								}
-							}
-							newRow.Cells[i].Paragraphs[j].Runs = []Run{newRun}
-						}
-					} else {
-						// 如果没有原始Run，创建新的但尝试继承段落样式
-						newRun := Run{
-							Text: Text{Content: content},
-							Properties: &RunProperties{
-								FontFamily: &FontFamily{
-									ASCII:    "仿宋",
-									HAnsi:    "仿宋",
-									EastAsia: "仿宋",
-								},
-								Bold: &Bold{},
-							},
-						}
-
-						// 如果段落有默认的Run属性，尝试继承
-						if len(templateRow.Cells) > i && len(templateRow.Cells[i].Paragraphs) > j {
-							templatePara := &templateRow.Cells[i].Paragraphs[j]
-							if len(templatePara.Runs) > 0 && templatePara.Runs[0].Properties != nil {
-								newRun.Properties = te.cloneRunProperties(templatePara.Runs[0].Properties)
-							}
-						}
-
-						newRow.Cells[i].Paragraphs[j].Runs = []Run{newRun}
-					}
-				}
-			}
-		}
-
-		newRows = append(newRows, *newRow)
-	}
-
-	// 保留模板行之后的行（深度克隆以保持样式）
-	for _, row := range table.Rows[templateRowIndex+1:] {
-		clonedRow := te.cloneTableRow(&row)
-		newRows = append(newRows, *clonedRow)
+		return nil, WrapErrorWithContext("render_template_to_document", err, templateName)
 	}
-
-	// 更新表格行
-	table.Rows = newRows
-
-	return nil
+	return doc, nil
 }
 
 // NewTemplateData 创建新的模板数据
@@ -2288,9 +1333,16 @@ func (td *TemplateData) Clear() {
 }
 
 // FromStruct 从结构体生成模板数据
+//
+// 每个导出字段以小写字段名（或`wordzero:"name"`标签指定的名称）保存为变量，
+// 嵌套结构体递归转换为map，切片转换为[]interface{}并同时注册为列表，
+// 模板中可以通过 {{customer.address.city}}、{{#each order.lines}} 等路径访问
 func (td *TemplateData) FromStruct(data interface{}) error {
 	value := reflect.ValueOf(data)
-	if value.Kind() == reflect.Ptr {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return NewValidationError("data_type", "nil", "expected struct type")
+		}
 		value = value.Elem()
 	}
 
@@ -2298,18 +1350,15 @@ func (td *TemplateData) FromStruct(data interface{}) error {
 		return NewValidationError("data_type", "struct", "expected struct type")
 	}
 
-	typ := value.Type()
-	for i := 0; i < value.NumField(); i++ {
-		field := typ.Field(i)
-		fieldValue := value.Field(i)
-
-		// 跳过不可导出的字段
-		if !fieldValue.CanInterface() {
-			continue
+	converted, ok := templateValue(value, 0).(map[string]interface{})
+	if !ok {
+		return NewValidationError("data_type", value.Type().String(), "struct cannot be converted to template data")
+	}
+	for name, fieldValue := range converted {
+		td.Variables[name] = fieldValue
+		if list, ok := fieldValue.([]interface{}); ok {
+			td.Lists[name] = list
 		}
-
-		fieldName := strings.ToLower(field.Name)
-		td.Variables[fieldName] = fieldValue.Interface()
 	}
 
 	return nil
@@ -2345,133 +1394,6 @@ func (td *TemplateData) SetImageWithDetails(name, filePath string, data []byte,
 	td.Images[name] = imageData
 }
 
-// renderImages 渲染图片占位符
-func (te *TemplateEngine) renderImages(content string, images map[string]*TemplateImageData) string {
-	// 图片占位符模式: {{#image imageName}}
-	imagePattern := regexp.MustCompile(`\{\{#image\s+(\w+)\}\}`)
-
-	return imagePattern.ReplaceAllStringFunc(content, func(match string) string {
-		matches := imagePattern.FindStringSubmatch(match)
-		if len(matches) >= 2 {
-			imageName := matches[1]
-
-			// 查找图片数据
-			if _, exists := images[imageName]; exists {
-				// 在传统的字符串模板中，我们返回图片占位符标记
-				// 实际的图片处理将在RenderTemplateToDocument方法中完成
-				return fmt.Sprintf("[IMAGE:%s]", imageName)
-			}
-		}
-		// 如果找不到图片数据，保持原样或返回错误信息
-		return fmt.Sprintf("[IMAGE_NOT_FOUND:%s]", matches[1])
-	})
-}
-
-// processImagePlaceholders 处理文档中的图片占位符
-func (te *TemplateEngine) processImagePlaceholders(doc *Document, data *TemplateData) error {
-	// 遍历文档元素，查找并替换图片占位符
-	for i, element := range doc.Body.Elements {
-		switch elem := element.(type) {
-		case *Paragraph:
-			// 检查段落是否包含图片占位符
-			newElements, err := te.processImagePlaceholdersInParagraph(elem, data, doc)
-			if err != nil {
-				return err
-			}
-
-			// 如果有图片替换，更新文档元素
-			if len(newElements) > 1 || (len(newElements) == 1 && newElements[0] != elem) {
-				// 移除原段落，插入新元素（可能包含图片段落）
-				doc.Body.Elements = append(doc.Body.Elements[:i], append(newElements, doc.Body.Elements[i+1:]...)...)
-			}
-		}
-	}
-	return nil
-}
-
-// processImagePlaceholdersInParagraph 处理段落中的图片占位符
-func (te *TemplateEngine) processImagePlaceholdersInParagraph(para *Paragraph, data *TemplateData, doc *Document) ([]interface{}, error) {
-	// 获取段落的完整文本
-	fullText := ""
-	for _, run := range para.Runs {
-		fullText += run.Text.Content
-	}
-
-	// 检查是否包含图片占位符（支持两种格式）
-	// 1. 原始模板格式：{{#image imageName}}
-	// 2. 渲染后格式：[IMAGE:imageName]
-	originalImagePattern := regexp.MustCompile(`\{\{#image\s+(\w+)\}\}`)
-	renderedImagePattern := regexp.MustCompile(`\[IMAGE:(\w+)\]`)
-
-	originalMatches := originalImagePattern.FindAllStringSubmatch(fullText, -1)
-	renderedMatches := renderedImagePattern.FindAllStringSubmatch(fullText, -1)
-
-	// 合并两种格式的匹配结果
-	allMatches := make([][2]string, 0)
-	for _, match := range originalMatches {
-		allMatches = append(allMatches, [2]string{match[0], match[1]})
-	}
-	for _, match := range renderedMatches {
-		allMatches = append(allMatches, [2]string{match[0], match[1]})
-	}
-
-	if len(allMatches) == 0 {
-		// 没有图片占位符，返回原段落
-		return []interface{}{para}, nil
-	}
-
-	result := make([]interface{}, 0)
-	lastEnd := 0
-
-	// 处理每个图片占位符
-	for _, match := range allMatches {
-		imageName := match[1]
-		matchStart := strings.Index(fullText[lastEnd:], match[0]) + lastEnd
-		matchEnd := matchStart + len(match[0])
-
-		// 添加图片占位符前的文本（如果有）
-		if matchStart > lastEnd {
-			beforeText := fullText[lastEnd:matchStart]
-			if strings.TrimSpace(beforeText) != "" {
-				beforePara := te.createTextParagraph(beforeText, para)
-				result = append(result, beforePara)
-			}
-		}
-
-		// 创建图片段落
-		if imageData, exists := data.Images[imageName]; exists {
-			imagePara, err := te.createImageParagraph(imageData, doc)
-			if err != nil {
-				return nil, fmt.Errorf("创建图片段落失败: %v", err)
-			}
-			result = append(result, imagePara)
-		} else {
-			// 图片数据不存在，创建错误文本段落
-			errorPara := te.createTextParagraph(fmt.Sprintf("[图片未找到: %s]", imageName), para)
-			result = append(result, errorPara)
-		}
-
-		lastEnd = matchEnd
-	}
-
-	// 添加最后剩余的文本（如果有）
-	if lastEnd < len(fullText) {
-		afterText := fullText[lastEnd:]
-		if strings.TrimSpace(afterText) != "" {
-			afterPara := te.createTextParagraph(afterText, para)
-			result = append(result, afterPara)
-		}
-	}
-
-	// 如果没有任何内容，返回空段落
-	if len(result) == 0 {
-		emptyPara := te.createTextParagraph("", para)
-		result = append(result, emptyPara)
-	}
-
-	return result, nil
-}
-
 // createTextParagraph 创建文本段落（保持原段落样式）
 func (te *TemplateEngine) createTextParagraph(text string, originalPara *Paragraph) *Paragraph {
 	newPara := te.cloneParagraph(originalPara)
@@ -0,0 +1,206 @@
+// Package document 预编译模板
+package document
+
+import (
+	"fmt"
+)
+
+// CompiledTemplate 预编译的不可变模板
+//
+// 编译时解析继承关系、加载引用的片段、准备输出文档的样式与部件，并固定引擎当前的过滤器、
+// 区域格式和渲染选项；跨文本片段的占位符已在加载模板时定位为语法树，渲染只根据数据生成内容。
+//
+// 编译后的模板可以在多个goroutine中并发调用Render和RenderWithReport：渲染不访问模板引擎的缓存和锁，
+// 之后对引擎的修改（重新加载模板、注册过滤器、设置区域格式或渲染选项）不影响已编译的模板。
+// 编译后不应再修改模板的基础文档；自定义过滤器需要自行保证并发安全，同一份数据不应在渲染期间被修改。
+type CompiledTemplate struct {
+	name      string
+	te        *TemplateEngine
+	nodes     []templateNode            // 最顶层父模板的语法树
+	overrides map[string][]templateNode // 子模板重写的块
+	partials  map[string]*Template      // 引用的片段模板（包括片段中引用的片段）
+	shell     *Document                 // 不含正文的输出文档原型
+	filters   map[string]TemplateFilter // 编译时注册的自定义过滤器
+	locale    *TemplateLocale           // 区域格式
+	localized bool                      // 是否通过SetLocale设置了区域格式
+	options   TemplateRenderOptions     // 渲染选项
+}
+
+// Compile 将模板编译为不可变的预编译模板，模板存在语法错误或引用的片段无法加载时返回错误
+func (te *TemplateEngine) Compile(template *Template) (*CompiledTemplate, error) {
+	if template == nil {
+		return nil, NewValidationError("template", "", "template cannot be nil")
+	}
+	root, overrides, err := templateInheritance(template)
+	if err != nil {
+		return nil, WrapErrorWithContext("compile_template", err, template.Name)
+	}
+
+	compiled := &CompiledTemplate{
+		name:      template.Name,
+		te:        te,
+		nodes:     root.nodes,
+		overrides: overrides,
+		partials:  make(map[string]*Template),
+		locale:    te.GetLocale(),
+		localized: te.currentLocale() != nil,
+		options:   te.GetRenderOptions(),
+	}
+	if err := compiled.loadPartials(root.nodes, overrides); err != nil {
+		return nil, WrapErrorWithContext("compile_template", err, template.Name)
+	}
+
+	te.mutex.RLock()
+	compiled.filters = make(map[string]TemplateFilter, len(te.filters))
+	for name, filter := range te.filters {
+		compiled.filters[name] = filter
+	}
+	te.mutex.RUnlock()
+
+	if root.BaseDoc != nil {
+		compiled.shell = te.cloneDocument(root.BaseDoc)
+	} else {
+		compiled.shell = New()
+	}
+	compiled.shell.Body.Elements = nil
+	return compiled, nil
+}
+
+// CompileTemplate 编译已加载的模板，见Compile
+func (te *TemplateEngine) CompileTemplate(templateName string) (*CompiledTemplate, error) {
+	template, err := te.GetTemplate(templateName)
+	if err != nil {
+		return nil, WrapErrorWithContext("compile_template", err, templateName)
+	}
+	return te.Compile(template)
+}
+
+// Name 返回模板名称
+func (c *CompiledTemplate) Name() string {
+	return c.name
+}
+
+// Render 使用数据渲染预编译模板，生成新文档
+func (c *CompiledTemplate) Render(data *TemplateData) (*Document, error) {
+	doc, _, err := c.render(data)
+	if err != nil {
+		return nil, WrapErrorWithContext("render_compiled_template", err, c.name)
+	}
+	return doc, nil
+}
+
+// RenderWithReport 使用数据渲染预编译模板并返回渲染报告，见TemplateEngine.RenderTemplateWithReport
+func (c *CompiledTemplate) RenderWithReport(data *TemplateData) (*Document, *RenderReport, error) {
+	doc, report, err := c.render(data)
+	if err != nil {
+		return nil, report, WrapErrorWithContext("render_compiled_template", err, c.name)
+	}
+	return doc, report, nil
+}
+
+func (c *CompiledTemplate) render(data *TemplateData) (*Document, *RenderReport, error) {
+	if data == nil {
+		data = NewTemplateData()
+	}
+	doc := c.newDocument()
+	renderer := &templateRenderer{
+		te:        c.te,
+		data:      data,
+		doc:       doc,
+		overrides: c.overrides,
+		locale:    c.locale,
+		localized: c.localized,
+		builder:   newTemplateBuilder(c.te, doc),
+		options:   c.options,
+		report:    &RenderReport{},
+		missing:   make(map[templateMissingKey]bool),
+		used:      make(map[string]bool),
+		compiled:  c,
+	}
+	return renderer.renderDocument(c.nodes)
+}
+
+// newDocument 由输出文档原型创建新文档，渲染时可能修改的部件、关系和样式各自复制一份
+func (c *CompiledTemplate) newDocument() *Document {
+	shell := c.shell
+	doc := &Document{
+		Body:        &Body{Elements: make([]interface{}, 0)},
+		parts:       make(map[string][]byte, len(shell.parts)),
+		nextImageID: shell.nextImageID,
+	}
+	for name, data := range shell.parts {
+		doc.parts[name] = data
+	}
+	doc.relationships = copyTemplateRelationships(shell.relationships)
+	doc.documentRelationships = copyTemplateRelationships(shell.documentRelationships)
+	if shell.contentTypes != nil {
+		doc.contentTypes = &ContentTypes{
+			Xmlns:     shell.contentTypes.Xmlns,
+			Defaults:  append([]Default(nil), shell.contentTypes.Defaults...),
+			Overrides: append([]Override(nil), shell.contentTypes.Overrides...),
+		}
+	}
+	if shell.styleManager != nil {
+		doc.styleManager = shell.styleManager.Clone()
+	}
+	return doc
+}
+
+// copyTemplateRelationships 复制关系列表
+func copyTemplateRelationships(rels *Relationships) *Relationships {
+	if rels == nil {
+		return nil
+	}
+	return &Relationships{
+		Xmlns:         rels.Xmlns,
+		Relationships: append([]Relationship(nil), rels.Relationships...),
+	}
+}
+
+// loadPartials 加载语法树中引用的片段模板，包括片段中引用的片段
+func (c *CompiledTemplate) loadPartials(nodes []templateNode, overrides map[string][]templateNode) error {
+	var walk func(nodes []templateNode, overrides map[string][]templateNode) error
+	walk = func(nodes []templateNode, overrides map[string][]templateNode) error {
+		for _, node := range nodes {
+			var err error
+			switch n := node.(type) {
+			case *templateIfNode:
+				for _, branch := range n.Branches {
+					if err = walk(branch.Body, overrides); err != nil {
+						return err
+					}
+				}
+				err = walk(n.Else, overrides)
+			case *templateEachNode:
+				if err = walk(n.Body, overrides); err == nil {
+					err = walk(n.Else, overrides)
+				}
+			case *templateBlockNode:
+				body, exists := overrides[n.Name]
+				if !exists {
+					body = n.Body
+				}
+				err = walk(body, overrides)
+			case *templatePartialNode:
+				if _, loaded := c.partials[n.Tag.Arg]; loaded {
+					continue
+				}
+				template, loadErr := c.te.loadPartial(n.Tag.Arg)
+				if loadErr != nil {
+					return fmt.Errorf("partial %q: %w", n.Tag.Arg, loadErr)
+				}
+				c.partials[n.Tag.Arg] = template
+				root, partialOverrides, inheritErr := templateInheritance(template)
+				if inheritErr != nil {
+					return fmt.Errorf("partial %q: %w", n.Tag.Arg, inheritErr)
+				}
+				err = walk(root.nodes, partialOverrides)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(nodes, overrides)
+}
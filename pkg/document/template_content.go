@@ -0,0 +1,529 @@
+// Package document 模板富内容变量
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RichTextRun 富文本片段
+type RichTextRun struct {
+	Text      string      // 文本内容
+	Format    *TextFormat // 片段格式，为nil时沿用占位符的格式
+	Underline bool        // 是否加下划线
+	Link      string      // 超链接地址，非空时片段输出为超链接
+}
+
+// RichText 富文本变量值：一组格式化片段，在占位符所在位置插入段落中
+//
+// 片段在占位符的文本格式基础上应用自身格式，未设置的格式项保持占位符的格式。
+type RichText struct {
+	Runs []RichTextRun
+}
+
+// NewRichText 创建空的富文本
+func NewRichText() *RichText {
+	return &RichText{}
+}
+
+// NewTemplateLink 创建只包含一个超链接的富文本
+func NewTemplateLink(text, url string) *RichText {
+	return NewRichText().AddLink(text, url, nil)
+}
+
+// AddText 追加格式化文本片段
+func (rt *RichText) AddText(text string, format *TextFormat) *RichText {
+	rt.Runs = append(rt.Runs, RichTextRun{Text: text, Format: format})
+	return rt
+}
+
+// AddLink 追加超链接片段，format为nil时使用蓝色下划线的超链接格式
+func (rt *RichText) AddLink(text, url string, format *TextFormat) *RichText {
+	rt.Runs = append(rt.Runs, RichTextRun{Text: text, Format: format, Link: url})
+	return rt
+}
+
+// String 返回富文本的纯文本内容，用于过滤器和比较
+func (rt *RichText) String() string {
+	var builder strings.Builder
+	for _, run := range rt.Runs {
+		builder.WriteString(run.Text)
+	}
+	return builder.String()
+}
+
+// HTMLContent HTML片段变量值
+//
+// 支持的标签：p、div、h1-h6、ul、ol、li、blockquote（段落），b、strong、i、em、u、s、del、
+// span、font（文本格式，span支持style中的color、font-weight、font-style、text-decoration、font-size），
+// a（超链接）和br（换行）；其他标签只输出其中的文本。只包含行内标签的片段插入占位符所在段落，
+// 包含段落标签时在占位符位置插入段落，段落未指定样式时沿用占位符所在段落的格式。
+type HTMLContent string
+
+// isTemplateContent 检查值是否为模板引擎展开的富内容变量值
+func isTemplateContent(value interface{}) bool {
+	switch value.(type) {
+	case *RichText, HTMLContent, *Paragraph, []*Paragraph, *Table, *Document:
+		return true
+	}
+	return false
+}
+
+// renderContent 在占位符位置展开富内容变量值，返回值是否为富内容。
+// *RichText与只含行内标签的HTMLContent插入当前段落；*Paragraph、[]*Paragraph、*Table、
+// *Document（正文，如Markdown转换结果）和含段落的HTMLContent作为独立元素插入
+func (r *templateRenderer) renderContent(value interface{}, run *Run, para *Paragraph) bool {
+	var base *RunProperties
+	if run != nil {
+		base = run.Properties
+	}
+
+	switch v := value.(type) {
+	case *RichText:
+		if v == nil {
+			return false
+		}
+		var runs []Run
+		for _, item := range v.Runs {
+			props := r.te.cloneRunProperties(base)
+			if item.Link != "" && item.Format == nil {
+				props = templateLinkProperties(props)
+			}
+			props = applyTextFormat(props, item.Format)
+			if item.Underline {
+				props = ensureRunProperties(props)
+				props.Underline = &Underline{Val: "single"}
+			}
+			content := Run{Properties: props, Text: Text{Content: item.Text, Space: "preserve"}}
+			if item.Link != "" {
+				runs = append(runs, templateLinkRuns(item.Link, []Run{content})...)
+			} else {
+				runs = append(runs, content)
+			}
+		}
+		r.builder.addRuns(runs, para)
+
+	case HTMLContent:
+		elements, inline := parseTemplateHTML(string(v), r.te, base)
+		if inline {
+			if len(elements) > 0 {
+				r.builder.addRuns(elements[0].(*Paragraph).Runs, para)
+			}
+			return true
+		}
+		for _, element := range elements {
+			r.inheritParagraph(element.(*Paragraph), para, nil)
+		}
+		r.builder.addElements(elements)
+
+	case *Paragraph:
+		if v == nil {
+			return false
+		}
+		clone := r.te.cloneParagraph(v)
+		r.inheritParagraph(clone, para, base)
+		r.builder.addElements([]interface{}{clone})
+
+	case []*Paragraph:
+		elements := make([]interface{}, 0, len(v))
+		for _, p := range v {
+			if p == nil {
+				continue
+			}
+			clone := r.te.cloneParagraph(p)
+			r.inheritParagraph(clone, para, base)
+			elements = append(elements, clone)
+		}
+		r.builder.addElements(elements)
+
+	case *Table:
+		if v == nil {
+			return false
+		}
+		r.builder.addElements([]interface{}{r.te.cloneTable(v)})
+
+	case *Document:
+		if v == nil || v.Body == nil {
+			return false
+		}
+		r.builder.addElements(r.documentElements(v))
+
+	default:
+		return false
+	}
+	return true
+}
+
+// inheritParagraph 段落未指定样式时沿用占位符所在段落的格式，未设置格式的文本沿用占位符的文本格式
+func (r *templateRenderer) inheritParagraph(target, placeholder *Paragraph, base *RunProperties) {
+	if placeholder != nil && placeholder.Properties != nil {
+		if target.Properties == nil {
+			target.Properties = r.builder.resources.paragraphProperties(r.te.cloneParagraphProperties(placeholder.Properties))
+			target.Properties.SectionProperties = nil
+		} else if target.Properties.ParagraphStyle == nil && placeholder.Properties.ParagraphStyle != nil {
+			target.Properties.ParagraphStyle = &ParagraphStyle{Val: placeholder.Properties.ParagraphStyle.Val}
+		}
+	}
+	if base == nil {
+		return
+	}
+	for i := range target.Runs {
+		if target.Runs[i].Properties == nil && target.Runs[i].FieldChar == nil && target.Runs[i].InstrText == nil {
+			target.Runs[i].Properties = r.te.cloneRunProperties(base)
+		}
+	}
+}
+
+// documentElements 复制文档正文中的段落和表格，样式、编号和图片一并导入输出文档
+func (r *templateRenderer) documentElements(source *Document) []interface{} {
+	if r.imported == nil {
+		r.imported = make(map[*Document]*templatePartialResources)
+	}
+	resources := r.imported[source]
+	if resources == nil {
+		resources = importTemplateResources(r.doc, source)
+		r.imported[source] = resources
+	}
+
+	elements := make([]interface{}, 0, len(source.Body.Elements))
+	for _, element := range source.Body.Elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			clone := r.te.cloneParagraph(e)
+			clone.Properties = resources.paragraphProperties(clone.Properties)
+			for i := range clone.Runs {
+				clone.Runs[i].Drawing = mapDrawingTree(clone.Runs[i].Drawing, func(drawing *DrawingElement) *DrawingElement {
+					return r.builder.uniqueDrawing(resources.drawing(drawing))
+				})
+			}
+			elements = append(elements, clone)
+		case *Table:
+			clone := r.te.cloneTable(e)
+			walkTableParagraphs(clone, func(para *Paragraph) bool {
+				para.Properties = resources.paragraphProperties(para.Properties)
+				for i := range para.Runs {
+					para.Runs[i].Drawing = mapDrawingTree(para.Runs[i].Drawing, func(drawing *DrawingElement) *DrawingElement {
+						return r.builder.uniqueDrawing(resources.drawing(drawing))
+					})
+				}
+				return true
+			})
+			elements = append(elements, clone)
+		}
+	}
+	return elements
+}
+
+// addRuns 在当前段落中追加Run
+func (b *templateBuilder) addRuns(runs []Run, para *Paragraph) {
+	if len(runs) == 0 {
+		return
+	}
+	frame := b.ensureParagraph(para)
+	frame.para.Runs = append(frame.para.Runs, runs...)
+	frame.lastRun = nil
+}
+
+// addElements 在当前位置插入块级元素：结束当前段落（尚无内容时丢弃），占位符之后的文本另起段落
+func (b *templateBuilder) addElements(elements []interface{}) {
+	frame := b.top()
+	if frame.para != nil && len(frame.para.Runs) == 0 {
+		frame.para = nil
+	}
+	frame.flush()
+	frame.elements = append(frame.elements, elements...)
+}
+
+// ensureRunProperties 返回非nil的Run属性
+func ensureRunProperties(props *RunProperties) *RunProperties {
+	if props == nil {
+		return &RunProperties{}
+	}
+	return props
+}
+
+// applyTextFormat 在Run属性上应用文本格式，未设置的格式项保持不变
+func applyTextFormat(props *RunProperties, format *TextFormat) *RunProperties {
+	if format == nil {
+		return props
+	}
+	props = ensureRunProperties(props)
+	if format.Bold {
+		props.Bold = &Bold{}
+		props.BoldCs = &BoldCs{}
+	}
+	if format.Italic {
+		props.Italic = &Italic{}
+		props.ItalicCs = &ItalicCs{}
+	}
+	if format.FontSize > 0 {
+		size := strconv.Itoa(format.FontSize * 2)
+		props.FontSize = &FontSize{Val: size}
+		props.FontSizeCs = &FontSizeCs{Val: size}
+	}
+	if format.FontColor != "" {
+		props.Color = &Color{Val: strings.TrimPrefix(format.FontColor, "#")}
+	}
+	if format.FontFamily != "" {
+		props.FontFamily = &FontFamily{ASCII: format.FontFamily, HAnsi: format.FontFamily, EastAsia: format.FontFamily}
+	}
+	return props
+}
+
+// templateLinkProperties 超链接文本格式：蓝色单下划线
+func templateLinkProperties(props *RunProperties) *RunProperties {
+	props = ensureRunProperties(props)
+	props.Color = &Color{Val: "0563C1"}
+	props.Underline = &Underline{Val: "single"}
+	return props
+}
+
+// templateLinkRuns 以HYPERLINK域包裹显示文本
+func templateLinkRuns(url string, content []Run) []Run {
+	runs := make([]Run, 0, len(content)+4)
+	runs = append(runs,
+		Run{FieldChar: &FieldChar{FieldCharType: "begin"}},
+		Run{InstrText: &InstrText{Space: "preserve", Content: fmt.Sprintf(" HYPERLINK \"%s\" ", strings.ReplaceAll(url, "\"", "%22"))}},
+		Run{FieldChar: &FieldChar{FieldCharType: "separate"}},
+	)
+	runs = append(runs, content...)
+	return append(runs, Run{FieldChar: &FieldChar{FieldCharType: "end"}})
+}
+
+// templateHTMLSpace 连续空白
+var templateHTMLSpace = regexp.MustCompile(`\s+`)
+
+// templateHTMLState HTML标签打开时的文本格式
+type templateHTMLState struct {
+	tag   string
+	props *RunProperties
+	link  bool // 标签为超链接
+}
+
+// templateHTMLList 正在解析的列表
+type templateHTMLList struct {
+	ordered bool
+	count   int
+}
+
+// parseTemplateHTML 将HTML片段转换为段落，base为占位符的文本格式。
+// 片段中没有段落标签时返回只含一个段落的列表且inline为true
+func parseTemplateHTML(source string, te *TemplateEngine, base *RunProperties) (elements []interface{}, inline bool) {
+	decoder := xml.NewDecoder(strings.NewReader("<html>" + source + "</html>"))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	inline = true
+	var para *Paragraph
+	var lists []*templateHTMLList
+	states := []templateHTMLState{{props: te.cloneRunProperties(base)}}
+
+	flush := func() {
+		// 段落结束时关闭未闭合的超链接域，域不能跨段落
+		for i := range states {
+			if states[i].link && para != nil {
+				para.Runs = append(para.Runs, Run{FieldChar: &FieldChar{FieldCharType: "end"}})
+				states[i].link = false
+			}
+		}
+		if para != nil && len(para.Runs) > 0 {
+			elements = append(elements, para)
+		}
+		para = nil
+	}
+	current := func() *Paragraph {
+		if para == nil {
+			para = &Paragraph{}
+		}
+		return para
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 无法解析的HTML丢弃已转换的内容，整体按纯文本输出，避免内容重复和残缺的域
+			text := strings.TrimSpace(templateHTMLSpace.ReplaceAllString(source, " "))
+			return []interface{}{&Paragraph{Runs: []Run{{
+				Properties: te.cloneRunProperties(base),
+				Text:       Text{Content: text, Space: "preserve"},
+			}}}}, true
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			tag := strings.ToLower(t.Name.Local)
+			state := templateHTMLState{tag: tag, props: te.cloneRunProperties(states[len(states)-1].props)}
+			switch tag {
+			case "p", "div", "blockquote", "h1", "h2", "h3", "h4", "h5", "h6", "li":
+				inline = false
+				flush()
+				para = &Paragraph{}
+				if len(tag) == 2 && tag[0] == 'h' {
+					para.Properties = &ParagraphProperties{ParagraphStyle: &ParagraphStyle{Val: "Heading" + tag[1:]}}
+				}
+				if tag == "li" && len(lists) > 0 {
+					list := lists[len(lists)-1]
+					list.count++
+					prefix := strings.Repeat("  ", len(lists)-1) + "• "
+					if list.ordered {
+						prefix = strings.Repeat("  ", len(lists)-1) + strconv.Itoa(list.count) + ". "
+					}
+					para.Runs = append(para.Runs, Run{Properties: te.cloneRunProperties(state.props), Text: Text{Content: prefix, Space: "preserve"}})
+				}
+			case "ul", "ol":
+				inline = false
+				flush()
+				lists = append(lists, &templateHTMLList{ordered: tag == "ol"})
+			case "b", "strong":
+				state.props = ensureRunProperties(state.props)
+				state.props.Bold, state.props.BoldCs = &Bold{}, &BoldCs{}
+			case "i", "em":
+				state.props = ensureRunProperties(state.props)
+				state.props.Italic, state.props.ItalicCs = &Italic{}, &ItalicCs{}
+			case "u", "ins":
+				state.props = ensureRunProperties(state.props)
+				state.props.Underline = &Underline{Val: "single"}
+			case "s", "del", "strike":
+				state.props = ensureRunProperties(state.props)
+				state.props.Strike = &Strike{}
+			case "a":
+				if href := templateHTMLAttr(t, "href"); href != "" {
+					state.link = true
+					state.props = templateLinkProperties(state.props)
+					runs := templateLinkRuns(href, nil)
+					current().Runs = append(current().Runs, runs[:len(runs)-1]...)
+				}
+			case "br":
+				current().Runs = append(current().Runs, Run{Break: &Break{}})
+			case "font":
+				if color := templateHTMLAttr(t, "color"); strings.HasPrefix(color, "#") {
+					state.props = ensureRunProperties(state.props)
+					state.props.Color = &Color{Val: strings.ToUpper(strings.TrimPrefix(color, "#"))}
+				}
+			}
+			if style := templateHTMLAttr(t, "style"); style != "" {
+				state.props = applyTemplateHTMLStyle(state.props, style)
+			}
+			states = append(states, state)
+
+		case xml.EndElement:
+			tag := strings.ToLower(t.Name.Local)
+			for i := len(states) - 1; i > 0; i-- {
+				if states[i].tag != tag {
+					continue
+				}
+				for _, state := range states[i:] {
+					if state.link {
+						current().Runs = append(current().Runs, Run{FieldChar: &FieldChar{FieldCharType: "end"}})
+					}
+				}
+				states = states[:i]
+				break
+			}
+			switch tag {
+			case "p", "div", "blockquote", "h1", "h2", "h3", "h4", "h5", "h6", "li":
+				flush()
+			case "ul", "ol":
+				flush()
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+			}
+
+		case xml.CharData:
+			text := templateHTMLSpace.ReplaceAllString(string(t), " ")
+			if para == nil || len(para.Runs) == 0 {
+				text = strings.TrimLeft(text, " ")
+			}
+			if text == "" || (strings.TrimSpace(text) == "" && !inline && para == nil) {
+				continue
+			}
+			current().Runs = append(current().Runs, Run{
+				Properties: te.cloneRunProperties(states[len(states)-1].props),
+				Text:       Text{Content: text, Space: "preserve"},
+			})
+		}
+	}
+	flush()
+
+	// 去除段落末尾的空白
+	for _, element := range elements {
+		runs := element.(*Paragraph).Runs
+		for i := len(runs) - 1; i >= 0; i-- {
+			if runs[i].Text.Content == "" {
+				continue
+			}
+			runs[i].Text.Content = strings.TrimRight(runs[i].Text.Content, " ")
+			break
+		}
+	}
+	return elements, inline
+}
+
+// templateHTMLAttr 获取HTML标签属性
+func templateHTMLAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+// applyTemplateHTMLStyle 应用style属性中的文本格式
+func applyTemplateHTMLStyle(props *RunProperties, style string) *RunProperties {
+	for _, declaration := range strings.Split(style, ";") {
+		name, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.ToLower(strings.TrimSpace(value))
+		props = ensureRunProperties(props)
+		switch name {
+		case "color":
+			if strings.HasPrefix(value, "#") && (len(value) == 7 || len(value) == 4) {
+				color := strings.ToUpper(value[1:])
+				if len(color) == 3 {
+					color = string([]byte{color[0], color[0], color[1], color[1], color[2], color[2]})
+				}
+				props.Color = &Color{Val: color}
+			}
+		case "font-weight":
+			if value == "bold" || value == "bolder" || value >= "600" && len(value) == 3 {
+				props.Bold, props.BoldCs = &Bold{}, &BoldCs{}
+			}
+		case "font-style":
+			if value == "italic" || value == "oblique" {
+				props.Italic, props.ItalicCs = &Italic{}, &ItalicCs{}
+			}
+		case "text-decoration", "text-decoration-line":
+			if strings.Contains(value, "underline") {
+				props.Underline = &Underline{Val: "single"}
+			}
+			if strings.Contains(value, "line-through") {
+				props.Strike = &Strike{}
+			}
+		case "font-size":
+			var points float64
+			if n, err := strconv.ParseFloat(strings.TrimSuffix(value, "pt"), 64); err == nil && strings.HasSuffix(value, "pt") {
+				points = n
+			} else if n, err := strconv.ParseFloat(strings.TrimSuffix(value, "px"), 64); err == nil && strings.HasSuffix(value, "px") {
+				points = n * 0.75
+			}
+			if points > 0 {
+				size := strconv.Itoa(int(points*2 + 0.5))
+				props.FontSize, props.FontSizeCs = &FontSize{Val: size}, &FontSizeCs{Val: size}
+			}
+		}
+	}
+	return props
+}
@@ -0,0 +1,188 @@
+// Package document 从JSON与map生成模板数据
+package document
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TemplateDataFromJSON 从JSON对象生成模板数据，规则见FromMap
+func TemplateDataFromJSON(data []byte) (*TemplateData, error) {
+	td := NewTemplateData()
+	if err := td.FromJSON(data); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// TemplateDataFromMap 从map生成模板数据，规则见FromMap
+func TemplateDataFromMap(values map[string]interface{}) (*TemplateData, error) {
+	td := NewTemplateData()
+	if err := td.FromMap(values); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// FromJSON 从JSON对象导入模板数据，规则见FromMap
+//
+// 整数保持为int，避免大数字以科学计数法输出。
+func (td *TemplateData) FromJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return WrapError("parse_template_json", err)
+	}
+	if values == nil {
+		return NewValidationError("data", "null", "expected JSON object")
+	}
+	return td.FromMap(values)
+}
+
+// FromMap 从map导入模板数据
+//
+// 顶层键按值的类型归类：布尔值为条件，数组同时注册为列表和变量，
+// 图片数据为图片，其余值（包括嵌套对象）为变量，模板中可以通过 {{customer.address.city}}、
+// {{#each order.lines}} 等路径访问。嵌套的map、切片和结构体统一转换为
+// map[string]interface{}与[]interface{}。
+//
+// 图片数据可以是data URI字符串（data:image/png;base64,...），或type为"image"的对象：
+//
+//	{"type": "image", "data": "<Base64或data URI>", "width": 40, "height": 20, "alt": "印章", "title": "公司印章", "align": "center"}
+//	{"type": "image", "path": "images/logo.png"}
+//
+// width、height单位为毫米，只设置一个时保持长宽比。
+func (td *TemplateData) FromMap(values map[string]interface{}) error {
+	for name, value := range values {
+		converted := templateJSONValue(templateValue(reflect.ValueOf(value), 0))
+
+		image, isImage, err := templateImageEntry(converted)
+		if err != nil {
+			return WrapErrorWithContext("template_data_from_map", err, name)
+		}
+		if isImage {
+			td.Images[name] = image
+			continue
+		}
+
+		switch v := converted.(type) {
+		case bool:
+			td.Conditions[name] = v
+		case []interface{}:
+			td.Lists[name] = v
+			td.Variables[name] = v
+		default:
+			td.Variables[name] = v
+		}
+	}
+	return nil
+}
+
+// templateJSONValue 将json.Number转换为int或float64
+func templateJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil && int64(int(n)) == n {
+			return int(n)
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = templateJSONValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = templateJSONValue(item)
+		}
+	}
+	return value
+}
+
+// templateImageEntry 识别图片数据，返回是否为图片
+func templateImageEntry(value interface{}) (*TemplateImageData, bool, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, "data:image/") {
+			return nil, false, nil
+		}
+		data, err := decodeTemplateImage(v)
+		if err != nil {
+			return nil, true, err
+		}
+		return &TemplateImageData{Data: data}, true, nil
+
+	case map[string]interface{}:
+		if kind, _ := v["type"].(string); kind != "image" {
+			return nil, false, nil
+		}
+		image := &TemplateImageData{}
+		if encoded, ok := v["data"].(string); ok {
+			data, err := decodeTemplateImage(encoded)
+			if err != nil {
+				return nil, true, err
+			}
+			image.Data = data
+		} else if encoded, ok := v["base64"].(string); ok {
+			data, err := decodeTemplateImage(encoded)
+			if err != nil {
+				return nil, true, err
+			}
+			image.Data = data
+		}
+		image.FilePath, _ = v["path"].(string)
+		if len(image.Data) == 0 && image.FilePath == "" {
+			return nil, true, NewValidationError("image", "", "image entry requires data or path")
+		}
+
+		image.AltText, _ = v["alt"].(string)
+		image.Title, _ = v["title"].(string)
+		width, hasWidth := templateNumber(v["width"])
+		height, hasHeight := templateNumber(v["height"])
+		align, _ := v["align"].(string)
+		if hasWidth || hasHeight || align != "" || image.AltText != "" || image.Title != "" {
+			image.Config = &ImageConfig{AltText: image.AltText, Title: image.Title}
+			if hasWidth || hasHeight {
+				image.Config.Size = &ImageSize{Width: width, Height: height, KeepAspectRatio: !hasWidth || !hasHeight}
+			}
+			switch align {
+			case "":
+			case "left", "center", "right":
+				image.Config.Alignment = AlignmentType(align)
+			default:
+				return nil, true, NewValidationError("align", align, "image alignment must be left, center or right")
+			}
+		}
+		return image, true, nil
+	}
+	return nil, false, nil
+}
+
+// decodeTemplateImage 解码Base64图片数据，支持data URI
+func decodeTemplateImage(encoded string) ([]byte, error) {
+	if strings.HasPrefix(encoded, "data:") {
+		comma := strings.Index(encoded, ",")
+		if comma < 0 || !strings.HasSuffix(encoded[:comma], ";base64") {
+			return nil, NewValidationError("image", encoded[:min(len(encoded), 32)], "data URI must be base64 encoded")
+		}
+		encoded = encoded[comma+1:]
+	}
+
+	encoded = strings.TrimSpace(encoded)
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		if data, rawErr := base64.RawStdEncoding.DecodeString(encoded); rawErr == nil {
+			return data, nil
+		}
+		return nil, fmt.Errorf("invalid base64 image data: %w", err)
+	}
+	return data, nil
+}
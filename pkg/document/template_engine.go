@@ -82,6 +82,35 @@ func (tr *TemplateRenderer) RenderTemplate(templateName string, data *TemplateDa
 	return doc, nil
 }
 
+// SetRenderOptions 设置渲染选项（严格模式、缺失值占位文本）
+func (tr *TemplateRenderer) SetRenderOptions(options TemplateRenderOptions) {
+	tr.engine.SetRenderOptions(options)
+}
+
+// RenderTemplateWithReport 渲染模板并返回渲染报告，报告中的警告同时写入日志
+func (tr *TemplateRenderer) RenderTemplateWithReport(templateName string, data *TemplateData) (*Document, *RenderReport, error) {
+	tr.logInfo("开始渲染模板: %s", templateName)
+
+	if err := tr.validateTemplateData(data); err != nil {
+		tr.logError("模板数据验证失败: %v", err)
+		return nil, nil, err
+	}
+
+	doc, report, err := tr.engine.RenderTemplateWithReport(templateName, data)
+	if report != nil {
+		for _, warning := range report.Warnings {
+			tr.logInfo("警告: %s", warning)
+		}
+	}
+	if err != nil {
+		tr.logError("模板渲染失败: %v", err)
+		return nil, report, err
+	}
+
+	tr.logInfo("模板渲染完成: %s", templateName)
+	return doc, report, nil
+}
+
 // validateTemplateData 验证模板数据
 func (tr *TemplateRenderer) validateTemplateData(data *TemplateData) error {
 	if data == nil {
@@ -144,6 +173,13 @@ func (tr *TemplateRenderer) AnalyzeTemplate(templateName string) (*TemplateAnaly
 		tr.analyzeDocument(template.BaseDoc, analysis)
 	}
 
+	schema, err := tr.engine.ExtractSchema(templateName)
+	if err != nil {
+		tr.logError("无法提取模板数据结构 %s: %v", templateName, err)
+		return nil, err
+	}
+	analysis.Schema = schema
+
 	tr.logInfo("模板分析完成: %s", templateName)
 	tr.logInfo("- 变量: %d", len(analysis.Variables))
 	tr.logInfo("- 列表: %d", len(analysis.Lists))
@@ -265,6 +301,7 @@ type TemplateAnalysis struct {
 	Lists        map[string]bool  // 列表变量
 	Conditions   map[string]bool  // 条件变量
 	Tables       []*TableAnalysis // 表格分析
+	Schema       *TemplateSchema  // 模板数据结构（含嵌套对象、循环项字段和类型）
 }
 
 // TableAnalysis 表格分析结果
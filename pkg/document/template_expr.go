@@ -0,0 +1,719 @@
+// Package document 模板表达式解析与求值
+package document
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// templateExpr 模板表达式：*templateLiteralExpr、*templatePathExpr、*templateNotExpr、
+// *templateBinaryExpr、*templatePipelineExpr、*templateCallExpr
+type templateExpr interface{}
+
+// templateLiteralExpr 字面量（字符串、数字、布尔值、null）
+type templateLiteralExpr struct {
+	Value interface{}
+}
+
+// templatePathExpr 变量路径
+type templatePathExpr struct {
+	Path *templatePath
+}
+
+// templateNotExpr 逻辑非
+type templateNotExpr struct {
+	X templateExpr
+}
+
+// templateBinaryExpr 二元运算：and、or、==、!=、>、>=、<、<=
+type templateBinaryExpr struct {
+	Op          string
+	Left, Right templateExpr
+}
+
+// templatePipelineExpr 管道过滤器，如 price | currency:"CNY"
+type templatePipelineExpr struct {
+	X       templateExpr
+	Filters []templateFilterCall
+}
+
+// templateCallExpr 聚合函数调用，如 sum lines.amount
+type templateCallExpr struct {
+	Name string
+	Args []templateExpr
+}
+
+// templateFilterCall 过滤器调用
+type templateFilterCall struct {
+	Name string
+	Args []templateExpr
+}
+
+// templateExprToken 表达式词法单元
+type templateExprToken struct {
+	kind  string // ident、string、number、op、eof
+	text  string
+	value interface{}
+	pos   int
+}
+
+// lexTemplateExpression 对表达式进行词法分析
+func lexTemplateExpression(source string) ([]templateExprToken, error) {
+	runes := []rune(source)
+	tokens := make([]templateExprToken, 0)
+	pos := 0
+	for pos < len(runes) {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+
+		case r == '"' || r == '\'':
+			start := pos
+			var builder strings.Builder
+			pos++
+			for pos < len(runes) && runes[pos] != r {
+				if runes[pos] == '\\' && pos+1 < len(runes) {
+					pos++
+					switch runes[pos] {
+					case 'n':
+						builder.WriteRune('\n')
+					case 't':
+						builder.WriteRune('\t')
+					default:
+						builder.WriteRune(runes[pos])
+					}
+				} else {
+					builder.WriteRune(runes[pos])
+				}
+				pos++
+			}
+			if pos >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in %q", source)
+			}
+			pos++
+			tokens = append(tokens, templateExprToken{kind: "string", text: string(runes[start:pos]), value: builder.String(), pos: start})
+
+		case unicode.IsDigit(r) || (r == '-' && pos+1 < len(runes) && unicode.IsDigit(runes[pos+1]) && expectsOperand(tokens)):
+			start := pos
+			pos++
+			for pos < len(runes) && (unicode.IsDigit(runes[pos]) || runes[pos] == '.') {
+				pos++
+			}
+			text := string(runes[start:pos])
+			var value interface{}
+			if strings.Contains(text, ".") {
+				f, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid number %q", text)
+				}
+				value = f
+			} else {
+				n, err := strconv.ParseInt(text, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid number %q", text)
+				}
+				value = int(n)
+			}
+			tokens = append(tokens, templateExprToken{kind: "number", text: text, value: value, pos: start})
+
+		case r == '_' || r == '@' || unicode.IsLetter(r):
+			start := pos
+			for pos < len(runes) {
+				c := runes[pos]
+				if c == '[' {
+					for pos < len(runes) && runes[pos] != ']' {
+						pos++
+					}
+					if pos < len(runes) {
+						pos++
+					}
+					continue
+				}
+				if c != '_' && c != '@' && c != '.' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+					break
+				}
+				pos++
+			}
+			tokens = append(tokens, templateExprToken{kind: "ident", text: string(runes[start:pos]), pos: start})
+
+		default:
+			start := pos
+			two := ""
+			if pos+1 < len(runes) {
+				two = string(runes[pos : pos+2])
+			}
+			switch two {
+			case "==", "!=", ">=", "<=", "&&", "||":
+				pos += 2
+				tokens = append(tokens, templateExprToken{kind: "op", text: two, pos: start})
+				continue
+			}
+			switch r {
+			case '>', '<', '!', '(', ')', '|', ':', ',':
+				pos++
+				tokens = append(tokens, templateExprToken{kind: "op", text: string(r), pos: start})
+			default:
+				return nil, fmt.Errorf("unexpected character %q in %q", r, source)
+			}
+		}
+	}
+	return append(tokens, templateExprToken{kind: "eof", pos: len(runes)}), nil
+}
+
+// expectsOperand 判断下一个词法单元是否应为操作数（用于识别负数）
+func expectsOperand(tokens []templateExprToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == "op" && last.text != ")"
+}
+
+// templateExprParser 表达式语法分析器
+type templateExprParser struct {
+	source string
+	tokens []templateExprToken
+	pos    int
+}
+
+// parseTemplateExpression 解析表达式，支持比较、逻辑运算、括号和管道过滤器
+//
+//	pipeline := or ("|" filter)*
+//	filter   := name (":" primary ("," primary)*)?
+//	or       := and (("or" | "||") and)*
+//	and      := not (("and" | "&&") not)*
+//	not      := ("not" | "!") not | compare
+//	compare  := primary (("==" | "!=" | ">" | ">=" | "<" | "<=") primary)?
+//	primary  := string | number | true | false | null | path | call | "(" pipeline ")"
+//	call     := ("sum" | "count" | "avg" | "min" | "max") primary+
+func parseTemplateExpression(source string) (templateExpr, error) {
+	tokens, err := lexTemplateExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	parser := &templateExprParser{source: source, tokens: tokens}
+	expr, err := parser.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token.kind != "eof" {
+		return nil, fmt.Errorf("unexpected %q in %q", token.text, source)
+	}
+	return expr, nil
+}
+
+func (p *templateExprParser) peek() templateExprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *templateExprParser) next() templateExprToken {
+	token := p.tokens[p.pos]
+	if token.kind != "eof" {
+		p.pos++
+	}
+	return token
+}
+
+// accept 如果下一个词法单元为指定的运算符或关键字则消费它
+func (p *templateExprParser) accept(texts ...string) (string, bool) {
+	token := p.peek()
+	if token.kind != "op" && token.kind != "ident" {
+		return "", false
+	}
+	for _, text := range texts {
+		if token.text == text {
+			p.pos++
+			return text, true
+		}
+	}
+	return "", false
+}
+
+func (p *templateExprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s in %q", fmt.Sprintf(format, args...), p.source)
+}
+
+func (p *templateExprParser) parsePipeline() (templateExpr, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().text != "|" || p.peek().kind != "op" {
+		return expr, nil
+	}
+
+	pipeline := &templatePipelineExpr{X: expr}
+	for {
+		if _, ok := p.accept("|"); !ok {
+			break
+		}
+		name := p.next()
+		if name.kind != "ident" || strings.ContainsAny(name.text, ".[@") {
+			return nil, p.errorf("missing filter name")
+		}
+		call := templateFilterCall{Name: name.text}
+		if _, ok := p.accept(":"); ok {
+			for {
+				arg, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				call.Args = append(call.Args, arg)
+				if _, ok := p.accept(","); !ok {
+					break
+				}
+			}
+		}
+		pipeline.Filters = append(pipeline.Filters, call)
+	}
+	return pipeline, nil
+}
+
+func (p *templateExprParser) parseOr() (templateExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("or", "||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &templateBinaryExpr{Op: "or", Left: left, Right: right}
+	}
+}
+
+func (p *templateExprParser) parseAnd() (templateExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("and", "&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &templateBinaryExpr{Op: "and", Left: left, Right: right}
+	}
+}
+
+func (p *templateExprParser) parseNot() (templateExpr, error) {
+	if _, ok := p.accept("not", "!"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &templateNotExpr{X: x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *templateExprParser) parseCompare() (templateExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", ">=", "<=", ">", "<")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return &templateBinaryExpr{Op: op, Left: left, Right: right}, nil
+}
+
+func (p *templateExprParser) parsePrimary() (templateExpr, error) {
+	token := p.next()
+	switch token.kind {
+	case "string", "number":
+		return &templateLiteralExpr{Value: token.value}, nil
+	case "ident":
+		switch token.text {
+		case "true":
+			return &templateLiteralExpr{Value: true}, nil
+		case "false":
+			return &templateLiteralExpr{Value: false}, nil
+		case "null", "nil":
+			return &templateLiteralExpr{Value: nil}, nil
+		case "and", "or", "not":
+			return nil, p.errorf("unexpected %q", token.text)
+		}
+		if _, ok := templateAggregates[token.text]; ok && p.atOperand() {
+			call := &templateCallExpr{Name: token.text}
+			for p.atOperand() {
+				arg, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				call.Args = append(call.Args, arg)
+			}
+			return call, nil
+		}
+		path, err := parseTemplatePath(token.text)
+		if err != nil {
+			return nil, err
+		}
+		return &templatePathExpr{Path: path}, nil
+	case "op":
+		if token.text == "(" {
+			expr, err := p.parsePipeline()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, p.errorf("missing )")
+			}
+			return expr, nil
+		}
+		return nil, p.errorf("unexpected %q", token.text)
+	}
+	return nil, p.errorf("unexpected end of expression")
+}
+
+// atOperand 检查下一个词法单元是否为操作数的开始
+func (p *templateExprParser) atOperand() bool {
+	token := p.peek()
+	switch token.kind {
+	case "string", "number":
+		return true
+	case "ident":
+		return token.text != "and" && token.text != "or"
+	case "op":
+		return token.text == "("
+	}
+	return false
+}
+
+// templateExprPaths 返回表达式中引用的所有变量路径
+func templateExprPaths(expr templateExpr) []*templatePath {
+	var paths []*templatePath
+	var walk func(templateExpr)
+	walk = func(expr templateExpr) {
+		switch e := expr.(type) {
+		case *templatePathExpr:
+			paths = append(paths, e.Path)
+		case *templateNotExpr:
+			walk(e.X)
+		case *templateBinaryExpr:
+			walk(e.Left)
+			walk(e.Right)
+		case *templateCallExpr:
+			for _, arg := range e.Args {
+				walk(arg)
+			}
+		case *templatePipelineExpr:
+			walk(e.X)
+			for _, filter := range e.Filters {
+				for _, arg := range filter.Args {
+					walk(arg)
+				}
+			}
+		}
+	}
+	walk(expr)
+	return paths
+}
+
+// eval 计算表达式的值，不存在的变量计算为nil
+func (r *templateRenderer) eval(expr templateExpr) (interface{}, error) {
+	switch e := expr.(type) {
+	case *templateLiteralExpr:
+		return e.Value, nil
+
+	case *templatePathExpr:
+		value, _ := r.resolvePath(e.Path)
+		return value, nil
+
+	case *templateNotExpr:
+		value, err := r.eval(e.X)
+		if err != nil {
+			return nil, err
+		}
+		return !isTemplateTruthy(value), nil
+
+	case *templateBinaryExpr:
+		left, err := r.eval(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case "and":
+			if !isTemplateTruthy(left) {
+				return false, nil
+			}
+			right, err := r.eval(e.Right)
+			return isTemplateTruthy(right), err
+		case "or":
+			if isTemplateTruthy(left) {
+				return true, nil
+			}
+			right, err := r.eval(e.Right)
+			return isTemplateTruthy(right), err
+		}
+		right, err := r.eval(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return compareTemplateValues(e.Op, left, right), nil
+
+	case *templateCallExpr:
+		var values []interface{}
+		for _, arg := range e.Args {
+			if pathExpr, ok := arg.(*templatePathExpr); ok {
+				values = append(values, r.collect(pathExpr.Path)...)
+				continue
+			}
+			value, err := r.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			if list := templateSlice(value); list != nil {
+				values = append(values, list...)
+			} else if value != nil {
+				values = append(values, value)
+			}
+		}
+		result, err := templateAggregates[e.Name](values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		return result, nil
+
+	case *templatePipelineExpr:
+		value, err := r.eval(e.X)
+		if err != nil {
+			return nil, err
+		}
+		for _, call := range e.Filters {
+			filter, ok := r.lookupFilter(call.Name)
+			if !ok {
+				return nil, fmt.Errorf("unknown filter %q", call.Name)
+			}
+			args := make([]interface{}, len(call.Args))
+			for i, arg := range call.Args {
+				if args[i], err = r.eval(arg); err != nil {
+					return nil, err
+				}
+			}
+			if value, err = filter(value, args...); err != nil {
+				return nil, fmt.Errorf("filter %q: %w", call.Name, err)
+			}
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported expression %T", expr)
+}
+
+// compareTemplateValues 比较两个值：均为数字时按数值比较，均为时间时按时间比较，否则按字符串比较
+func compareTemplateValues(op string, left, right interface{}) bool {
+	var cmp int
+	leftNumber, leftOK := templateNumber(left)
+	rightNumber, rightOK := templateNumber(right)
+	leftTime, leftIsTime := templateTime(left)
+	rightTime, rightIsTime := templateTime(right)
+
+	switch {
+	case leftOK && rightOK:
+		switch {
+		case leftNumber < rightNumber:
+			cmp = -1
+		case leftNumber > rightNumber:
+			cmp = 1
+		}
+	case leftIsTime && rightIsTime:
+		cmp = leftTime.Compare(rightTime)
+	case left == nil || right == nil:
+		if op == "==" {
+			return left == nil && right == nil
+		}
+		if op == "!=" {
+			return !(left == nil && right == nil)
+		}
+		return false
+	default:
+		if leftBool, ok := left.(bool); ok {
+			if rightBool, ok := right.(bool); ok && (op == "==" || op == "!=") {
+				return (leftBool == rightBool) == (op == "==")
+			}
+		}
+		cmp = strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// templateNumber 将数值或数字字符串转换为float64
+func templateNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case nil, bool:
+		return 0, false
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// templateTimeLayouts 字符串转换为时间时尝试的格式
+var templateTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02", "2006/01/02"}
+
+// templateTime 将time.Time、*time.Time或日期字符串转换为时间
+func templateTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	case string:
+		for _, layout := range templateTimeLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// collect 收集路径对应的所有值：路径经过列表时对每一项继续取值，如 groups.lines.amount
+// 返回所有分组中所有明细的金额。末端值为列表时展开，nil值被忽略
+func (r *templateRenderer) collect(path *templatePath) []interface{} {
+	root, ok := r.resolvePath(&templatePath{Raw: path.Root(), Segments: path.Segments[:1]})
+	if !ok {
+		return nil
+	}
+
+	values := []interface{}{root}
+	for _, segment := range path.Segments[1:] {
+		var next []interface{}
+		for _, value := range values {
+			if member, ok := templateMember(value, segment); ok {
+				next = append(next, member)
+				continue
+			}
+			if segment.IsIndex {
+				continue
+			}
+			for _, item := range templateSlice(value) {
+				if member, ok := templateMember(item, segment); ok {
+					next = append(next, member)
+				}
+			}
+		}
+		values = next
+	}
+
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if list := templateSlice(value); list != nil {
+			result = append(result, list...)
+		} else if value != nil {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// templateAggregates 聚合函数
+var templateAggregates = map[string]func(values []interface{}) (interface{}, error){
+	"count": func(values []interface{}) (interface{}, error) {
+		return len(values), nil
+	},
+	"sum": func(values []interface{}) (interface{}, error) {
+		numbers, err := templateNumbers(values)
+		if err != nil {
+			return nil, err
+		}
+		total := 0.0
+		for _, n := range numbers {
+			total += n
+		}
+		return total, nil
+	},
+	"avg": func(values []interface{}) (interface{}, error) {
+		numbers, err := templateNumbers(values)
+		if err != nil || len(numbers) == 0 {
+			return nil, err
+		}
+		total := 0.0
+		for _, n := range numbers {
+			total += n
+		}
+		return total / float64(len(numbers)), nil
+	},
+	"min": func(values []interface{}) (interface{}, error) {
+		return templateExtreme(values, -1)
+	},
+	"max": func(values []interface{}) (interface{}, error) {
+		return templateExtreme(values, 1)
+	},
+}
+
+// templateNumbers 将值转换为数字，非数字值返回错误
+func templateNumbers(values []interface{}) ([]float64, error) {
+	numbers := make([]float64, 0, len(values))
+	for _, value := range values {
+		n, ok := templateNumber(value)
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", value)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// templateExtreme 返回最小值（sign为-1）或最大值（sign为1），列表为空时返回nil
+func templateExtreme(values []interface{}, sign int) (interface{}, error) {
+	numbers, err := templateNumbers(values)
+	if err != nil || len(numbers) == 0 {
+		return nil, err
+	}
+	result := numbers[0]
+	for _, n := range numbers[1:] {
+		if (sign > 0 && n > result) || (sign < 0 && n < result) {
+			result = n
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,311 @@
+// Package document 模板输出过滤器
+package document
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TemplateFilter 模板过滤器函数
+//
+// value为管道左侧的值（变量不存在时为nil），args为过滤器参数，
+// 如 {{price | currency:"CNY"}} 中value为price的值，args为["CNY"]。
+// 返回的错误会中止渲染并以*TemplateRenderError返回。
+type TemplateFilter func(value interface{}, args ...interface{}) (interface{}, error)
+
+// templateBuiltinFilter 内置过滤器，locale为渲染时的区域格式
+type templateBuiltinFilter func(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error)
+
+// builtinTemplateFilters 内置过滤器
+var builtinTemplateFilters = map[string]templateBuiltinFilter{
+	"upper":    filterUpper,
+	"lower":    filterLower,
+	"trim":     filterTrim,
+	"default":  filterDefault,
+	"format":   filterFormat,
+	"number":   filterNumber,
+	"currency": filterCurrency,
+	"date":     filterDate,
+	"rmb":      filterRMB,
+	"join":     filterJoin,
+}
+
+// RegisterFilter 注册自定义过滤器，同名时覆盖内置过滤器
+//
+// 过滤器名称只能包含字母、数字和下划线，且不能以数字开头。
+func (te *TemplateEngine) RegisterFilter(name string, filter TemplateFilter) error {
+	if !isTemplateFilterName(name) {
+		return NewValidationError("filter_name", name, "过滤器名称只能包含字母、数字和下划线，且不能以数字开头")
+	}
+	if filter == nil {
+		return NewValidationError("filter", name, "过滤器函数不能为空")
+	}
+
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+	te.filters[name] = filter
+	return nil
+}
+
+// lookupFilter 查找过滤器，自定义过滤器优先，内置过滤器使用locale格式化
+func (te *TemplateEngine) lookupFilter(name string, locale *TemplateLocale) (TemplateFilter, bool) {
+	te.mutex.RLock()
+	filter, ok := te.filters[name]
+	te.mutex.RUnlock()
+	if ok {
+		return filter, true
+	}
+	builtin, ok := builtinTemplateFilters[name]
+	if !ok {
+		return nil, false
+	}
+	return func(value interface{}, args ...interface{}) (interface{}, error) {
+		return builtin(locale, value, args...)
+	}, true
+}
+
+// lookupFilter 查找渲染使用的过滤器，预编译模板使用编译时的过滤器
+func (r *templateRenderer) lookupFilter(name string) (TemplateFilter, bool) {
+	if r.compiled == nil {
+		return r.te.lookupFilter(name, r.locale)
+	}
+	if filter, ok := r.compiled.filters[name]; ok {
+		return filter, true
+	}
+	builtin, ok := builtinTemplateFilters[name]
+	if !ok {
+		return nil, false
+	}
+	return func(value interface{}, args ...interface{}) (interface{}, error) {
+		return builtin(r.locale, value, args...)
+	}, true
+}
+
+// isTemplateFilterName 检查过滤器名称是否合法
+func isTemplateFilterName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !(i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterString 将值转换为字符串，nil转换为空字符串
+func filterString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// filterArg 获取第index个参数，不存在时返回def
+func filterArg(args []interface{}, index int, def string) string {
+	if index < len(args) && args[index] != nil {
+		return filterString(args[index])
+	}
+	return def
+}
+
+// filterIntArg 获取第index个整数参数，不存在时返回def
+func filterIntArg(args []interface{}, index int, def int) (int, error) {
+	if index >= len(args) {
+		return def, nil
+	}
+	n, ok := templateNumber(args[index])
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return 0, fmt.Errorf("argument %d must be a non-negative integer, got %v", index+1, args[index])
+	}
+	return int(n), nil
+}
+
+// filterUpper 转换为大写：{{name | upper}}
+func filterUpper(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	return strings.ToUpper(filterString(value)), nil
+}
+
+// filterLower 转换为小写：{{name | lower}}
+func filterLower(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	return strings.ToLower(filterString(value)), nil
+}
+
+// filterTrim 去除首尾空白：{{name | trim}}
+func filterTrim(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	return strings.TrimSpace(filterString(value)), nil
+}
+
+// filterDefault 值为空（nil、空字符串、空列表）时使用默认值：{{notes | default:"-"}}
+func filterDefault(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing default value")
+	}
+	if value == nil {
+		return args[0], nil
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return args[0], nil
+	}
+	if list := templateSlice(value); list != nil && len(list) == 0 {
+		return args[0], nil
+	}
+	return value, nil
+}
+
+// filterFormat 格式化时间或数字：{{date | format:"2006-01-02"}}、{{ratio | format:"%.1f%%"}}
+//
+// 时间值及可解析为时间的字符串使用Go时间格式，其他值使用fmt格式。
+func filterFormat(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing format")
+	}
+	layout := filterString(args[0])
+	if value == nil {
+		return "", nil
+	}
+	if t, ok := templateTime(value); ok {
+		return locale.formatDate(t, layout), nil
+	}
+	if strings.Contains(layout, "%") {
+		return fmt.Sprintf(layout, value), nil
+	}
+	return nil, fmt.Errorf("cannot format %v with %q", value, layout)
+}
+
+// filterNumber 按区域的千分位和小数点格式化数字，参数为小数位数（默认为区域小数位数）和区域：
+// {{amount | number:0}}、{{amount | number:2,"de-DE"}}
+func filterNumber(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	if value == nil {
+		return "", nil
+	}
+	n, ok := templateNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", value)
+	}
+	locale, err := filterLocaleArg(locale, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := filterIntArg(args, 0, locale.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	return locale.formatNumber(n, decimals), nil
+}
+
+// templateCurrencies 货币代码对应的符号与小数位数
+var templateCurrencies = map[string]struct {
+	symbol   string
+	decimals int
+}{
+	"CNY": {"¥", 2},
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"JPY": {"¥", 0},
+	"HKD": {"HK$", 2},
+}
+
+// filterCurrency 按区域格式化金额，参数为货币代码（默认为区域货币）和区域：
+// {{price | currency:"USD"}} 输出 $1,234.50，{{price | currency:"EUR","de-DE"}} 输出 1.234,50 €
+func filterCurrency(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	if value == nil {
+		return "", nil
+	}
+	n, ok := templateNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", value)
+	}
+	locale, err := filterLocaleArg(locale, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return locale.formatCurrency(n, filterArg(args, 0, "")), nil
+}
+
+// filterDate 按区域日期格式输出时间，参数为样式（date、datetime、long或Go时间格式）和区域：
+// {{signed | date}}、{{signed | date:"long"}}、{{signed | date:"long","en-US"}}
+func filterDate(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	if value == nil {
+		return "", nil
+	}
+	t, ok := templateTime(value)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a date", value)
+	}
+	locale, err := filterLocaleArg(locale, args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return locale.formatDate(t, filterArg(args, 0, "")), nil
+}
+
+// filterRMB 输出中文大写金额：{{total | rmb}} 输出 壹仟贰佰叁拾肆元伍角整
+func filterRMB(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	if value == nil {
+		return "", nil
+	}
+	n, ok := templateNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a number", value)
+	}
+	return FormatChineseAmount(n), nil
+}
+
+// filterLocaleArg 获取第index个参数指定的区域，不存在时返回locale
+func filterLocaleArg(locale *TemplateLocale, args []interface{}, index int) (*TemplateLocale, error) {
+	if index >= len(args) {
+		return locale, nil
+	}
+	name := filterString(args[index])
+	if override, ok := templateLocales[name]; ok {
+		return override, nil
+	}
+	return nil, fmt.Errorf("unsupported locale %q", name)
+}
+
+// filterJoin 使用分隔符连接列表（默认", "）：{{tags | join:"、"}}
+func filterJoin(locale *TemplateLocale, value interface{}, args ...interface{}) (interface{}, error) {
+	list := templateSlice(value)
+	if list == nil {
+		return filterString(value), nil
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = filterString(item)
+	}
+	return strings.Join(parts, filterArg(args, 0, ", ")), nil
+}
+
+// formatTemplateNumber 格式化数字，整数部分按三位分组
+func formatTemplateNumber(n float64, decimals int, groupSep, decimalSep string) string {
+	text := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(text, ".")
+
+	var builder strings.Builder
+	if n < 0 && strings.Trim(text, "0.") != "" {
+		builder.WriteString("-")
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			builder.WriteString(groupSep)
+		}
+		builder.WriteRune(digit)
+	}
+	if fraction != "" {
+		builder.WriteString(decimalSep)
+		builder.WriteString(fraction)
+	}
+	return builder.String()
+}
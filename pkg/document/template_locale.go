@@ -0,0 +1,279 @@
+// Package document 模板本地化格式
+package document
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TemplateLocale 模板输出的本地化格式：千分位、小数位、货币符号和日期格式
+type TemplateLocale struct {
+	Name             string   // 区域名称，如 zh-CN
+	GroupSeparator   string   // 千分位分隔符
+	DecimalSeparator string   // 小数点
+	Decimals         int      // number过滤器默认小数位数
+	CurrencyCode     string   // 默认货币代码，如 CNY
+	SymbolAfter      bool     // 货币符号是否位于金额之后（以空格分隔）
+	DateLayout       string   // 日期格式（Go时间格式）
+	DateTimeLayout   string   // 日期时间格式，时间值包含时分秒时使用
+	LongDateLayout   string   // 长日期格式，用于 date:"long"
+	MonthNames       []string // 长日期中的月份名称（1月至12月），为空时使用英文月份名
+}
+
+// templateLocales 内置区域格式
+var templateLocales = map[string]*TemplateLocale{
+	"zh-CN": {
+		Name:             "zh-CN",
+		GroupSeparator:   ",",
+		DecimalSeparator: ".",
+		Decimals:         2,
+		CurrencyCode:     "CNY",
+		DateLayout:       "2006-01-02",
+		DateTimeLayout:   "2006-01-02 15:04:05",
+		LongDateLayout:   "2006年1月2日",
+	},
+	"en-US": {
+		Name:             "en-US",
+		GroupSeparator:   ",",
+		DecimalSeparator: ".",
+		Decimals:         2,
+		CurrencyCode:     "USD",
+		DateLayout:       "01/02/2006",
+		DateTimeLayout:   "01/02/2006 3:04 PM",
+		LongDateLayout:   "January 2, 2006",
+	},
+	"de-DE": {
+		Name:             "de-DE",
+		GroupSeparator:   ".",
+		DecimalSeparator: ",",
+		Decimals:         2,
+		CurrencyCode:     "EUR",
+		SymbolAfter:      true,
+		DateLayout:       "02.01.2006",
+		DateTimeLayout:   "02.01.2006 15:04",
+		LongDateLayout:   "2. January 2006",
+		MonthNames: []string{"Januar", "Februar", "März", "April", "Mai", "Juni",
+			"Juli", "August", "September", "Oktober", "November", "Dezember"},
+	},
+}
+
+// defaultTemplateLocale 未设置区域时使用的格式
+const defaultTemplateLocale = "zh-CN"
+
+// GetTemplateLocale 获取内置区域格式（zh-CN、en-US、de-DE）的副本
+func GetTemplateLocale(name string) (*TemplateLocale, bool) {
+	locale, ok := templateLocales[name]
+	if !ok {
+		return nil, false
+	}
+	clone := *locale
+	clone.MonthNames = append([]string(nil), locale.MonthNames...)
+	return &clone, true
+}
+
+// SetLocale 设置模板输出使用的内置区域格式（zh-CN、en-US、de-DE）
+//
+// 设置后，浮点数变量按区域的千分位与小数点输出，过滤器number、currency、date使用区域默认值。
+// 时间值无论是否设置区域都按日期格式输出，未设置时使用zh-CN。
+func (te *TemplateEngine) SetLocale(name string) error {
+	locale, ok := GetTemplateLocale(name)
+	if !ok {
+		return NewValidationError("locale", name, "不支持的区域格式")
+	}
+	return te.SetLocaleConfig(locale)
+}
+
+// SetLocaleConfig 设置自定义区域格式
+func (te *TemplateEngine) SetLocaleConfig(locale *TemplateLocale) error {
+	if locale == nil {
+		return NewValidationError("locale", "", "区域格式不能为空")
+	}
+	if locale.DecimalSeparator == "" || locale.DecimalSeparator == locale.GroupSeparator {
+		return NewValidationError("decimal_separator", locale.DecimalSeparator, "小数点不能为空且不能与千分位分隔符相同")
+	}
+	if locale.Decimals < 0 {
+		return NewValidationError("decimals", strconv.Itoa(locale.Decimals), "小数位数不能为负数")
+	}
+	if len(locale.MonthNames) != 0 && len(locale.MonthNames) != 12 {
+		return NewValidationError("month_names", strconv.Itoa(len(locale.MonthNames)), "月份名称必须为12个")
+	}
+
+	clone := *locale
+	clone.MonthNames = append([]string(nil), locale.MonthNames...)
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+	te.locale = &clone
+	return nil
+}
+
+// GetLocale 获取当前区域格式，未设置时返回zh-CN格式
+func (te *TemplateEngine) GetLocale() *TemplateLocale {
+	if locale := te.currentLocale(); locale != nil {
+		return locale
+	}
+	locale, _ := GetTemplateLocale(defaultTemplateLocale)
+	return locale
+}
+
+// currentLocale 返回通过SetLocale设置的区域格式，未设置时返回nil
+func (te *TemplateEngine) currentLocale() *TemplateLocale {
+	te.mutex.RLock()
+	defer te.mutex.RUnlock()
+	return te.locale
+}
+
+// FormatNumber 按当前区域格式化数字，decimals为小数位数，小于0时使用最短表示
+func (te *TemplateEngine) FormatNumber(value interface{}, decimals int) (string, error) {
+	n, ok := templateNumber(value)
+	if !ok {
+		return "", NewValidationError("value", fmt.Sprint(value), "不是有效的数字")
+	}
+	return te.GetLocale().formatNumber(n, decimals), nil
+}
+
+// FormatCurrency 按当前区域格式化金额，code为空时使用区域默认货币
+func (te *TemplateEngine) FormatCurrency(value interface{}, code string) (string, error) {
+	n, ok := templateNumber(value)
+	if !ok {
+		return "", NewValidationError("value", fmt.Sprint(value), "不是有效的数字")
+	}
+	return te.GetLocale().formatCurrency(n, code), nil
+}
+
+// FormatDate 按当前区域的日期格式输出时间，style可以为""（日期）、"datetime"、"long"或Go时间格式
+func (te *TemplateEngine) FormatDate(t time.Time, style string) string {
+	return te.GetLocale().formatDate(t, style)
+}
+
+// formatNumber 按区域格式化数字
+func (l *TemplateLocale) formatNumber(n float64, decimals int) string {
+	return formatTemplateNumber(n, decimals, l.GroupSeparator, l.DecimalSeparator)
+}
+
+// formatCurrency 按区域格式化金额
+func (l *TemplateLocale) formatCurrency(n float64, code string) string {
+	if code == "" {
+		code = l.CurrencyCode
+	}
+	code = strings.ToUpper(code)
+	symbol, decimals := code, 2
+	if currency, ok := templateCurrencies[code]; ok {
+		symbol, decimals = currency.symbol, currency.decimals
+	}
+
+	amount := l.formatNumber(math.Abs(n), decimals)
+	sign := ""
+	if n < 0 && strings.Trim(amount, "0"+l.GroupSeparator+l.DecimalSeparator) != "" {
+		sign = "-"
+	}
+	if l.SymbolAfter {
+		return sign + amount + " " + symbol
+	}
+	if _, ok := templateCurrencies[code]; !ok {
+		symbol += " "
+	}
+	return sign + symbol + amount
+}
+
+// formatDate 按区域格式输出时间
+func (l *TemplateLocale) formatDate(t time.Time, style string) string {
+	switch style {
+	case "":
+		if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+			return t.Format(l.DateTimeLayout)
+		}
+		return t.Format(l.DateLayout)
+	case "date":
+		return t.Format(l.DateLayout)
+	case "datetime":
+		return t.Format(l.DateTimeLayout)
+	case "long":
+		text := t.Format(l.LongDateLayout)
+		if len(l.MonthNames) == 12 {
+			text = strings.Replace(text, t.Month().String(), l.MonthNames[t.Month()-1], 1)
+		}
+		return text
+	}
+	return t.Format(style)
+}
+
+// chineseDigits 大写数字
+var chineseDigits = []string{"零", "壹", "贰", "叁", "肆", "伍", "陆", "柒", "捌", "玖"}
+
+// FormatChineseAmount 将金额转换为中文大写金额，如 1234.5 转换为“壹仟贰佰叁拾肆元伍角整”
+//
+// 金额四舍五入到分；到元或角为止时以“整”结尾，有分时不写“整”，负数以“负”开头。
+func FormatChineseAmount(amount float64) string {
+	cents := int64(math.Round(math.Abs(amount) * 100))
+	integer, jiao, fen := cents/100, cents/10%10, cents%10
+
+	var builder strings.Builder
+	if amount < 0 && cents > 0 {
+		builder.WriteString("负")
+	}
+	if integer > 0 {
+		builder.WriteString(chineseAmountInteger(integer))
+		builder.WriteString("元")
+	}
+
+	switch {
+	case jiao == 0 && fen == 0:
+		if integer == 0 {
+			builder.WriteString("零元")
+		}
+		builder.WriteString("整")
+	case fen == 0:
+		builder.WriteString(chineseDigits[jiao] + "角整")
+	default:
+		if jiao > 0 {
+			builder.WriteString(chineseDigits[jiao] + "角")
+		} else if integer > 0 {
+			builder.WriteString("零")
+		}
+		builder.WriteString(chineseDigits[fen] + "分")
+	}
+	return builder.String()
+}
+
+// chineseAmountInteger 转换金额的整数部分，按万、亿分节，节内及节间的连续零只读一个“零”
+func chineseAmountInteger(n int64) string {
+	sections := []string{"", "万", "亿", "万亿", "亿亿"}
+	result := ""
+	lower := int64(-1) // 低一节的数值
+	for i := 0; n > 0; i++ {
+		section := n % 10000
+		n /= 10000
+		if section != 0 {
+			text := chineseAmountSection(section) + sections[i]
+			if result != "" && lower < 1000 {
+				text += "零"
+			}
+			result = text + result
+		}
+		lower = section
+	}
+	return result
+}
+
+// chineseAmountSection 转换1至9999之间的数
+func chineseAmountSection(n int64) string {
+	units := []string{"仟", "佰", "拾", ""}
+	var builder strings.Builder
+	zero := false
+	for i, divisor := 0, int64(1000); divisor > 0; i, divisor = i+1, divisor/10 {
+		digit := n / divisor % 10
+		if digit == 0 {
+			zero = builder.Len() > 0
+			continue
+		}
+		if zero {
+			builder.WriteString("零")
+			zero = false
+		}
+		builder.WriteString(chineseDigits[digit] + units[i])
+	}
+	return builder.String()
+}
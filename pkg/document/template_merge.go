@@ -0,0 +1,593 @@
+// Package document 模板邮件合并
+package document
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MailMergeSource 邮件合并的记录来源
+type MailMergeSource interface {
+	// Next 返回下一条记录，没有更多记录时返回io.EOF
+	Next() (*TemplateData, error)
+}
+
+// MailMergeFunc 以函数作为记录来源，函数返回io.EOF表示没有更多记录
+type MailMergeFunc func() (*TemplateData, error)
+
+// Next 实现MailMergeSource接口
+func (f MailMergeFunc) Next() (*TemplateData, error) {
+	return f()
+}
+
+// NewSliceMergeSource 以模板数据切片作为记录来源
+func NewSliceMergeSource(records []*TemplateData) MailMergeSource {
+	index := 0
+	return MailMergeFunc(func() (*TemplateData, error) {
+		if index >= len(records) {
+			return nil, io.EOF
+		}
+		index++
+		return records[index-1], nil
+	})
+}
+
+// csvMergeSource CSV记录来源
+type csvMergeSource struct {
+	reader  *csv.Reader
+	headers []string
+}
+
+// NewCSVMergeSource 以CSV作为记录来源，首行为列名
+//
+// 每行生成一条记录：列名为变量名，含"."的列名生成嵌套对象（如 address.city），
+// 值为true/false（不区分大小写）的单元格作为条件，其余单元格为字符串变量。
+// 列数少于列名的行缺少后面的变量，首个列名前的UTF-8 BOM会被忽略。
+func NewCSVMergeSource(r io.Reader) MailMergeSource {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	return &csvMergeSource{reader: reader}
+}
+
+// Next 实现MailMergeSource接口
+func (s *csvMergeSource) Next() (*TemplateData, error) {
+	if s.headers == nil {
+		headers, err := s.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		if len(headers) > 0 {
+			headers[0] = strings.TrimPrefix(headers[0], "\ufeff")
+		}
+		s.headers = headers
+	}
+
+	row, err := s.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(row))
+	for i, cell := range row {
+		if i >= len(s.headers) || s.headers[i] == "" {
+			continue
+		}
+		var value interface{} = cell
+		if strings.EqualFold(cell, "true") {
+			value = true
+		} else if strings.EqualFold(cell, "false") {
+			value = false
+		}
+
+		names := strings.Split(s.headers[i], ".")
+		target := values
+		for _, name := range names[:len(names)-1] {
+			child, ok := target[name].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				target[name] = child
+			}
+			target = child
+		}
+		target[names[len(names)-1]] = value
+	}
+	return TemplateDataFromMap(values)
+}
+
+// jsonLinesMergeSource JSON Lines记录来源
+type jsonLinesMergeSource struct {
+	decoder *json.Decoder
+}
+
+// NewJSONLinesMergeSource 以JSON Lines（每行一个JSON对象）作为记录来源，
+// 每个对象按TemplateData.FromMap的规则生成一条记录
+func NewJSONLinesMergeSource(r io.Reader) MailMergeSource {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return &jsonLinesMergeSource{decoder: decoder}
+}
+
+// Next 实现MailMergeSource接口
+func (s *jsonLinesMergeSource) Next() (*TemplateData, error) {
+	var values map[string]interface{}
+	if err := s.decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return nil, NewValidationError("record", "null", "expected JSON object")
+	}
+	return TemplateDataFromMap(values)
+}
+
+// MailMergeSeparator 合并到单个文档时记录之间的分隔方式
+type MailMergeSeparator int
+
+const (
+	// MailMergePageBreak 分页符（默认）
+	MailMergePageBreak MailMergeSeparator = iota
+	// MailMergeSectionBreak 分节符（下一页），每条记录为独立的节
+	MailMergeSectionBreak
+)
+
+// MailMergeOptions 邮件合并选项
+type MailMergeOptions struct {
+	// Workers 并发渲染的记录数，小于等于0时为CPU核数
+	Workers int
+	// OutputDir 输出目录（MergeToFiles使用），不存在时自动创建
+	OutputDir string
+	// FileNamePattern 文件名模板（MergeToFiles使用），可使用模板表达式，如 "offer_{{name}}_{{@number}}.docx"；
+	// @index为记录序号（从0开始），@number为记录编号（从1开始）；缺少.docx扩展名时自动添加，
+	// 路径分隔符等非法字符替换为"_"。默认为 "record_{{@number}}.docx"
+	FileNamePattern string
+	// Separator 记录之间的分隔方式（MergeToDocument使用）
+	Separator MailMergeSeparator
+	// RestartPageNumbering 每条记录从第1页开始编号（仅在使用分节符时有效）
+	RestartPageNumbering bool
+	// StopOnError 首条记录出错后停止读取后续记录；默认继续处理并在结果中收集错误
+	StopOnError bool
+	// Progress 每条记录处理完成后调用，在调用方所在的goroutine中按完成顺序执行
+	Progress func(progress MailMergeProgress)
+}
+
+// MailMergeProgress 邮件合并进度
+type MailMergeProgress struct {
+	Index     int    // 刚完成的记录序号（从0开始）
+	Completed int    // 已完成的记录数（包括失败的记录）
+	Failed    int    // 失败的记录数
+	File      string // 输出文件路径（MergeToFiles）
+	Err       error  // 该记录的错误
+}
+
+// MailMergeResult 邮件合并结果
+type MailMergeResult struct {
+	Total     int               // 读取的记录数
+	Succeeded int               // 成功的记录数
+	Files     []string          // 按记录顺序排列的输出文件路径（MergeToFiles），失败的记录为空字符串
+	Errors    []*MailMergeError // 按记录顺序排列的记录错误
+}
+
+// MailMergeError 单条记录的合并错误
+type MailMergeError struct {
+	Index int   // 记录序号（从0开始）
+	Err   error // 原始错误
+}
+
+// Error 实现error接口
+func (e *MailMergeError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Index, e.Err)
+}
+
+// Unwrap 返回原始错误
+func (e *MailMergeError) Unwrap() error {
+	return e.Err
+}
+
+// mailMergeJob 待渲染的记录
+type mailMergeJob struct {
+	index int
+	data  *TemplateData
+}
+
+// mailMergeOutcome 记录的渲染结果
+type mailMergeOutcome struct {
+	index int
+	doc   *Document
+	file  string
+	err   error
+}
+
+// MergeToFiles 使用模板逐条渲染记录，每条记录保存为一个.docx文件
+//
+// 模板预编译一次，记录按options.Workers并发渲染和保存。单条记录的错误收集在结果中，不影响其他记录；
+// 返回的错误只表示模板不存在、文件名模板无效或读取记录失败。
+func (te *TemplateEngine) MergeToFiles(templateName string, source MailMergeSource, options *MailMergeOptions) (*MailMergeResult, error) {
+	if options == nil {
+		options = &MailMergeOptions{}
+	}
+	compiled, err := te.CompileTemplate(templateName)
+	if err != nil {
+		return nil, WrapErrorWithContext("mail_merge", err, templateName)
+	}
+
+	pattern := options.FileNamePattern
+	if pattern == "" {
+		pattern = "record_{{@number}}.docx"
+	}
+	fileName, err := te.compileMergeFileName(pattern)
+	if err != nil {
+		return nil, WrapErrorWithContext("mail_merge", err, pattern)
+	}
+
+	var namesMutex sync.Mutex
+	names := make(map[string]int)
+	result := &MailMergeResult{}
+	work := func(job mailMergeJob) mailMergeOutcome {
+		name, err := fileName(job.index, job.data)
+		if err != nil {
+			return mailMergeOutcome{index: job.index, err: err}
+		}
+		file := filepath.Join(options.OutputDir, name)
+
+		namesMutex.Lock()
+		previous, duplicate := names[file]
+		if !duplicate {
+			names[file] = job.index
+		}
+		namesMutex.Unlock()
+		if duplicate {
+			return mailMergeOutcome{index: job.index, err: fmt.Errorf("output file %q is already used by record %d", file, previous)}
+		}
+
+		doc, _, err := compiled.render(job.data)
+		if err != nil {
+			return mailMergeOutcome{index: job.index, err: err}
+		}
+		if err := doc.Save(file); err != nil {
+			return mailMergeOutcome{index: job.index, err: err}
+		}
+		return mailMergeOutcome{index: job.index, file: file}
+	}
+	handle := func(outcome mailMergeOutcome) {
+		for len(result.Files) <= outcome.index {
+			result.Files = append(result.Files, "")
+		}
+		result.Files[outcome.index] = outcome.file
+	}
+
+	if options.OutputDir != "" {
+		if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
+			return nil, WrapErrorWithContext("mail_merge", err, options.OutputDir)
+		}
+	}
+	err = runMailMerge(source, options, result, work, handle)
+	return result, err
+}
+
+// MergeToDocument 使用模板逐条渲染记录，并按记录顺序合并为一个文档，记录之间以分页符或分节符分隔
+//
+// 记录按options.Workers并发渲染，合并按记录顺序进行；各记录中的图片复制到合并文档，
+// 节属性以第一条成功记录为准。失败的记录不出现在合并文档中，其错误收集在结果中。
+// 没有成功的记录时返回的文档为nil。
+func (te *TemplateEngine) MergeToDocument(templateName string, source MailMergeSource, options *MailMergeOptions) (*Document, *MailMergeResult, error) {
+	if options == nil {
+		options = &MailMergeOptions{}
+	}
+	compiled, err := te.CompileTemplate(templateName)
+	if err != nil {
+		return nil, nil, WrapErrorWithContext("mail_merge", err, templateName)
+	}
+
+	result := &MailMergeResult{}
+	var target *templateMergeTarget
+	pending := make(map[int]*Document)
+	next := 0
+	work := func(job mailMergeJob) mailMergeOutcome {
+		doc, _, err := compiled.render(job.data)
+		return mailMergeOutcome{index: job.index, doc: doc, err: err}
+	}
+	handle := func(outcome mailMergeOutcome) {
+		pending[outcome.index] = outcome.doc
+		for {
+			doc, ok := pending[next]
+			if !ok {
+				return
+			}
+			delete(pending, next)
+			next++
+			if doc == nil {
+				continue
+			}
+			if target == nil {
+				target = newTemplateMergeTarget(doc, options)
+			} else {
+				target.append(doc)
+			}
+		}
+	}
+
+	err = runMailMerge(source, options, result, work, handle)
+	if target == nil {
+		return nil, result, err
+	}
+	return target.finish(), result, err
+}
+
+// runMailMerge 读取记录并分发给并发的渲染任务，按完成顺序处理结果、记录错误并报告进度
+func runMailMerge(source MailMergeSource, options *MailMergeOptions, result *MailMergeResult,
+	work func(mailMergeJob) mailMergeOutcome, handle func(mailMergeOutcome)) error {
+	if source == nil {
+		return NewValidationError("source", "", "mail merge source cannot be nil")
+	}
+	workers := options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan mailMergeJob)
+	outcomes := make(chan mailMergeOutcome)
+	stop := make(chan struct{})
+	var sourceErr error
+	total := 0
+
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			data, err := source.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				sourceErr = WrapErrorWithContext("mail_merge_source", err, fmt.Sprintf("record %d", index))
+				return
+			}
+			if data == nil {
+				data = NewTemplateData()
+			}
+			select {
+			case jobs <- mailMergeJob{index: index, data: data}:
+				total++
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				outcomes <- work(job)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var firstErr error
+	progress := MailMergeProgress{}
+	for outcome := range outcomes {
+		progress.Index = outcome.index
+		progress.Completed++
+		progress.File = outcome.file
+		progress.Err = outcome.err
+		if outcome.err != nil {
+			mergeErr := &MailMergeError{Index: outcome.index, Err: outcome.err}
+			result.Errors = append(result.Errors, mergeErr)
+			progress.Failed++
+			if options.StopOnError && firstErr == nil {
+				firstErr = mergeErr
+				close(stop)
+			}
+		} else {
+			result.Succeeded++
+		}
+		handle(outcome)
+		if options.Progress != nil {
+			options.Progress(progress)
+		}
+	}
+
+	result.Total = total
+	sort.Slice(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Index < result.Errors[j].Index
+	})
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return firstErr
+}
+
+// templateMergeExpr 文件名模板中的表达式标签
+var templateMergeExpr = regexp.MustCompile(`\{\{(.+?)\}\}`)
+
+// templateInvalidFileChars 文件名中不允许的字符
+var templateInvalidFileChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+
+// compileMergeFileName 解析文件名模板，返回按记录生成文件名的函数
+func (te *TemplateEngine) compileMergeFileName(pattern string) (func(index int, data *TemplateData) (string, error), error) {
+	type part struct {
+		text string
+		expr templateExpr
+	}
+	var parts []part
+	last := 0
+	for _, match := range templateMergeExpr.FindAllStringSubmatchIndex(pattern, -1) {
+		expr, err := parseTemplateExpression(pattern[match[2]:match[3]])
+		if err != nil {
+			return nil, fmt.Errorf("invalid file name pattern %q: %w", pattern, err)
+		}
+		parts = append(parts, part{text: pattern[last:match[0]]}, part{expr: expr})
+		last = match[1]
+	}
+	parts = append(parts, part{text: pattern[last:]})
+
+	return func(index int, data *TemplateData) (string, error) {
+		renderer := te.newTemplateRenderer(nil, data, nil)
+		renderer.scopes = []templateScope{{item: map[string]interface{}{"@number": index + 1}, index: index, count: -1}}
+
+		var name strings.Builder
+		for _, p := range parts {
+			if p.expr == nil {
+				name.WriteString(p.text)
+				continue
+			}
+			value, err := renderer.eval(p.expr)
+			if err != nil {
+				return "", fmt.Errorf("file name: %w", err)
+			}
+			name.WriteString(templateInvalidFileChars.ReplaceAllString(renderer.format(value), "_"))
+		}
+
+		file := strings.TrimSpace(name.String())
+		if file == "" {
+			return "", fmt.Errorf("file name pattern %q produced an empty name", pattern)
+		}
+		if !strings.EqualFold(filepath.Ext(file), ".docx") {
+			file += ".docx"
+		}
+		return file, nil
+	}, nil
+}
+
+// templateMergeTarget 合并输出文档：以第一条记录的文档为基础，追加后续记录的内容
+type templateMergeTarget struct {
+	doc        *Document
+	sectPr     *SectionProperties // 各记录共用的节属性
+	options    *MailMergeOptions
+	drawingIDs map[string]bool // 已使用的绘图ID
+}
+
+// newTemplateMergeTarget 以第一条记录的文档创建合并输出文档
+func newTemplateMergeTarget(doc *Document, options *MailMergeOptions) *templateMergeTarget {
+	target := &templateMergeTarget{doc: doc, options: options, drawingIDs: make(map[string]bool)}
+	elements := make([]interface{}, 0, len(doc.Body.Elements))
+	for _, element := range doc.Body.Elements {
+		if sectPr, ok := element.(*SectionProperties); ok {
+			target.sectPr = sectPr
+			continue
+		}
+		elements = append(elements, element)
+	}
+	doc.Body.Elements = elements
+	target.walkDrawings(elements, func(drawing *DrawingElement) *DrawingElement {
+		if docPr := drawingDocPr(drawing); docPr != nil {
+			target.drawingIDs[docPr.ID] = true
+		}
+		return drawing
+	})
+	return target
+}
+
+// append 追加一条记录的内容：复制记录中的图片并分配不重复的绘图ID
+func (t *templateMergeTarget) append(source *Document) {
+	t.doc.Body.Elements = append(t.doc.Body.Elements, t.separator())
+
+	resources := &templatePartialResources{
+		source:    source,
+		target:    t.doc,
+		relations: make(map[string]string),
+		numIDs:    make(map[string]string),
+	}
+	elements := make([]interface{}, 0, len(source.Body.Elements))
+	for _, element := range source.Body.Elements {
+		if _, ok := element.(*SectionProperties); !ok {
+			elements = append(elements, element)
+		}
+	}
+	t.walkDrawings(elements, func(drawing *DrawingElement) *DrawingElement {
+		return t.uniqueDrawing(resources.drawing(drawing))
+	})
+	t.doc.Body.Elements = append(t.doc.Body.Elements, elements...)
+}
+
+// separator 创建记录之间的分页符或分节符段落
+func (t *templateMergeTarget) separator() *Paragraph {
+	if t.options.Separator == MailMergeSectionBreak {
+		sectPr := t.sectionProperties()
+		return &Paragraph{Properties: &ParagraphProperties{SectionProperties: &sectPr}}
+	}
+	return &Paragraph{Runs: []Run{{Break: &Break{Type: "page"}}}}
+}
+
+// sectionProperties 返回节属性副本，需要时设置页码从1开始
+func (t *templateMergeTarget) sectionProperties() SectionProperties {
+	sectPr := SectionProperties{}
+	if t.sectPr != nil {
+		sectPr = *t.sectPr
+	}
+	if t.options.Separator == MailMergeSectionBreak && t.options.RestartPageNumbering {
+		pgNumType := PageNumType{Start: "1"}
+		if sectPr.PageNumType != nil {
+			pgNumType.Fmt = sectPr.PageNumType.Fmt
+		}
+		sectPr.PageNumType = &pgNumType
+	}
+	return sectPr
+}
+
+// finish 在文档末尾添加节属性并返回合并文档
+func (t *templateMergeTarget) finish() *Document {
+	if t.sectPr != nil {
+		sectPr := t.sectionProperties()
+		t.doc.Body.Elements = append(t.doc.Body.Elements, &sectPr)
+	}
+	return t.doc
+}
+
+// uniqueDrawing 绘图ID已被使用时复制绘图并分配新ID
+func (t *templateMergeTarget) uniqueDrawing(drawing *DrawingElement) *DrawingElement {
+	docPr := drawingDocPr(drawing)
+	if docPr == nil {
+		return drawing
+	}
+	if !t.drawingIDs[docPr.ID] {
+		t.drawingIDs[docPr.ID] = true
+		return drawing
+	}
+
+	id := fmt.Sprint(t.doc.nextImageID)
+	for t.drawingIDs[id] {
+		t.doc.nextImageID++
+		id = fmt.Sprint(t.doc.nextImageID)
+	}
+	t.doc.nextImageID++
+	t.drawingIDs[id] = true
+
+	newDocPr := *docPr
+	newDocPr.ID = id
+	clone := *drawing
+	if drawing.Inline != nil {
+		inline := *drawing.Inline
+		inline.DocPr = &newDocPr
+		clone.Inline = &inline
+	}
+	if drawing.Anchor != nil {
+		anchor := *drawing.Anchor
+		anchor.DocPr = &newDocPr
+		clone.Anchor = &anchor
+	}
+	return &clone
+}
+
+// walkDrawings 遍历段落、表格和文本框中的绘图并替换为fn的返回值
+func (t *templateMergeTarget) walkDrawings(elements []interface{}, fn func(*DrawingElement) *DrawingElement) {
+	walkDrawingRuns(elements, func(para *Paragraph, run *Run) bool {
+		run.Drawing = fn(run.Drawing)
+		return true
+	})
+}
@@ -0,0 +1,832 @@
+// Package document 模板词法与语法分析
+package document
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TemplateSyntaxError 模板语法错误，包含出错位置
+//
+// 字符串模板中Line为行号；文档模板中Line为段落序号（按文档顺序计数，包含表格单元格中的段落）。
+// Column为从1开始的字符列号。可通过errors.Is(err, ErrTemplateSyntaxError)判断错误类型。
+type TemplateSyntaxError struct {
+	Line    int    // 行号（从1开始）
+	Column  int    // 列号（从1开始）
+	Message string // 错误描述
+}
+
+// Error 实现error接口
+func (e *TemplateSyntaxError) Error() string {
+	return fmt.Sprintf("template syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Unwrap 解包为ErrTemplateSyntaxError，支持errors.Is判断
+func (e *TemplateSyntaxError) Unwrap() error {
+	return ErrTemplateSyntaxError
+}
+
+// templateTokenKind 模板词法单元类型
+type templateTokenKind int
+
+const (
+	tokenText       templateTokenKind = iota // 文本片段
+	tokenRun                                 // 非文本运行（图片、换行、域代码等）
+	tokenTag                                 // 模板标签 {{...}}
+	tokenParaStart                           // 段落开始
+	tokenParaEnd                             // 段落结束
+	tokenElement                             // 其他文档元素，原样输出
+	tokenTableStart                          // 表格开始
+	tokenTableEnd                            // 表格结束
+	tokenRowStart                            // 表格行开始
+	tokenRowEnd                              // 表格行结束
+	tokenCellStart                           // 单元格开始
+	tokenCellEnd                             // 单元格结束
+)
+
+// templateTagKind 模板标签类型
+type templateTagKind int
+
+const (
+	tagOutput  templateTagKind = iota // 变量输出 {{name}}
+	tagOpen                           // 块开始 {{#if x}} {{#unless x}} {{#each x}} {{#block "x"}}
+	tagClose                          // 块结束 {{/if}}
+	tagElse                           // {{else}} 或 {{else if x}}
+	tagImage                          // 图片占位符 {{#image x}}
+	tagExtends                        // 继承 {{extends "base"}}
+	tagComment                        // 注释 {{! ...}}
+	tagPartial                        // 片段引用 {{> name}} 或 {{include "file.docx"}}
+)
+
+// templateTag 模板标签
+type templateTag struct {
+	Raw    string          // 标签原始文本
+	Kind   templateTagKind // 标签类型
+	Helper string          // 块名称：if、unless、each、block；片段引用为>或include
+	Arg    string          // 参数：变量名、条件、块名、片段名等
+	Path   *templatePath   // 变量路径（each、image，以及不含运算和过滤器的变量输出）
+	Expr   templateExpr    // 表达式（变量输出、if、unless、else if）
+	Line   int             // 所在行
+	Column int             // 所在列
+}
+
+// templateToken 模板词法单元，段落、表格等结构也作为词法单元参与分析，使块可以跨越Run和段落
+type templateToken struct {
+	Kind    templateTokenKind
+	Text    string       // 文本内容（tokenText）
+	Tag     *templateTag // 标签（tokenTag）
+	Run     *Run         // 来源Run，用于继承文本样式
+	Para    *Paragraph   // 来源段落，用于继承段落样式
+	Table   *Table       // 来源表格（tokenTableStart）
+	Row     *TableRow    // 来源行（tokenRowStart）
+	Cell    *TableCell   // 来源单元格（tokenCellStart）
+	Element interface{}  // 其他元素（tokenElement）
+}
+
+// templateNode 模板语法树节点：*templateToken、*templateOutputNode、*templateIfNode、
+// *templateEachNode、*templateBlockNode、*templateImageNode、*templatePartialNode
+type templateNode interface{}
+
+// templateOutputNode 变量输出节点
+type templateOutputNode struct {
+	Tag  *templateTag
+	Run  *Run
+	Para *Paragraph
+}
+
+// templateBranch 条件分支
+type templateBranch struct {
+	Condition string
+	Expr      templateExpr
+	Body      []templateNode
+	Tag       *templateTag // 分支开始标签：{{#if}}、{{#unless}}或{{else if}}
+}
+
+// templateIfNode 条件节点，{{#unless x}}解析为条件取反的条件节点
+type templateIfNode struct {
+	Open     *templateTag
+	Branches []templateBranch // 依次为{{#if}}及各{{else if}}分支
+	Else     []templateNode
+	HasElse  bool
+	Close    *templateTag
+}
+
+// templateEachNode 循环节点
+type templateEachNode struct {
+	Open    *templateTag
+	List    string
+	Path    *templatePath
+	Body    []templateNode
+	Else    []templateNode
+	HasElse bool
+	Close   *templateTag
+}
+
+// templateBlockNode 可重写块节点
+type templateBlockNode struct {
+	Open  *templateTag
+	Name  string
+	Body  []templateNode
+	Close *templateTag
+}
+
+// templateImageNode 图片占位符节点
+type templateImageNode struct {
+	Tag  *templateTag
+	Run  *Run
+	Para *Paragraph
+}
+
+// templateExtendsNode 继承声明节点
+type templateExtendsNode struct {
+	Tag *templateTag
+}
+
+// templatePartialNode 片段引用节点，渲染时插入引用模板的内容
+type templatePartialNode struct {
+	Tag *templateTag
+}
+
+// newTemplateSyntaxError 创建带位置的语法错误
+func newTemplateSyntaxError(line, column int, format string, args ...interface{}) *TemplateSyntaxError {
+	return &TemplateSyntaxError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+// templateLexer 模板词法分析器
+type templateLexer struct {
+	tokens []*templateToken
+	line   int
+}
+
+// lexTemplateString 对字符串模板进行词法分析，每一行作为一个段落
+func lexTemplateString(content string, para *Paragraph, run *Run) ([]*templateToken, error) {
+	lexer := &templateLexer{}
+	for _, line := range strings.Split(content, "\n") {
+		lexer.line++
+		lexer.emit(&templateToken{Kind: tokenParaStart, Para: para})
+		if err := lexer.lexText(line, []templateTextPiece{{end: len(line), run: run}}, para); err != nil {
+			return nil, err
+		}
+		lexer.emit(&templateToken{Kind: tokenParaEnd})
+	}
+	return markStandaloneTags(lexer.tokens), nil
+}
+
+// lexTemplateElements 对文档元素进行词法分析
+func lexTemplateElements(elements []interface{}) ([]*templateToken, error) {
+	lexer := &templateLexer{}
+	if err := lexer.lexElements(elements); err != nil {
+		return nil, err
+	}
+	return markStandaloneTags(lexer.tokens), nil
+}
+
+func (l *templateLexer) emit(token *templateToken) {
+	l.tokens = append(l.tokens, token)
+}
+
+func (l *templateLexer) lexElements(elements []interface{}) error {
+	for _, element := range elements {
+		switch elem := element.(type) {
+		case *Paragraph:
+			if err := l.lexParagraph(elem); err != nil {
+				return err
+			}
+		case *Table:
+			if err := l.lexTable(elem); err != nil {
+				return err
+			}
+		default:
+			l.emit(&templateToken{Kind: tokenElement, Element: element})
+		}
+	}
+	return nil
+}
+
+// lexTable 对表格进行词法分析。开始与结束标签位于不同单元格的块会被提升到行级别，
+// 从而以整行为单位重复或隐藏；提升后只剩标签的行在输出中被移除
+func (l *templateLexer) lexTable(table *Table) error {
+	start := len(l.tokens)
+	l.emit(&templateToken{Kind: tokenTableStart, Table: table})
+	for i := range table.Rows {
+		row := &table.Rows[i]
+		l.emit(&templateToken{Kind: tokenRowStart, Row: row})
+		for j := range row.Cells {
+			cell := &row.Cells[j]
+			l.emit(&templateToken{Kind: tokenCellStart, Cell: cell})
+			if err := l.lexElements(cell.Elements()); err != nil {
+				return err
+			}
+			l.emit(&templateToken{Kind: tokenCellEnd})
+		}
+		l.emit(&templateToken{Kind: tokenRowEnd})
+	}
+	l.emit(&templateToken{Kind: tokenTableEnd})
+
+	hoisted := hoistTableTags(l.tokens[start:])
+	l.tokens = append(l.tokens[:start], hoisted...)
+	return nil
+}
+
+// hoistTableTags 将跨单元格的块标签移动到所在行之前或之后
+func hoistTableTags(tokens []*templateToken) []*templateToken {
+	type openTag struct {
+		index, row, cell int
+		elses            []int
+		elseRows         []int
+		elseCells        []int
+	}
+
+	var (
+		stack    []*openTag
+		rowStart = make(map[int]int)   // 行号 -> 行开始位置
+		before   = make(map[int][]int) // 行开始位置 -> 需要提升到此处的标签
+		after    = make(map[int][]int) // 行号 -> 需要移动到行结束之后的标签
+		moved    = make(map[int]bool)  // 被移动的标签
+		touched  = make(map[int]bool)  // 包含被移动标签的行
+		depth    = 0
+		row      = -1
+		cell     = -1
+	)
+
+	for i, token := range tokens {
+		switch token.Kind {
+		case tokenTableStart, tokenCellStart:
+			if token.Kind == tokenCellStart && depth == 2 {
+				cell++
+			}
+			depth++
+			continue
+		case tokenRowStart:
+			if depth == 1 {
+				row++
+				rowStart[row] = i
+			}
+			depth++
+			continue
+		case tokenTableEnd, tokenRowEnd, tokenCellEnd:
+			depth--
+			continue
+		}
+		if token.Kind != tokenTag || depth != 3 {
+			continue
+		}
+
+		tag := token.Tag
+		switch tag.Kind {
+		case tagOpen:
+			stack = append(stack, &openTag{index: i, row: row, cell: cell})
+		case tagElse:
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				top.elses = append(top.elses, i)
+				top.elseRows = append(top.elseRows, row)
+				top.elseCells = append(top.elseCells, cell)
+			}
+		case tagClose:
+			if len(stack) == 0 {
+				continue
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if top.cell == cell || tokens[top.index].Tag.Helper != tag.Helper {
+				continue
+			}
+			before[rowStart[top.row]] = append(before[rowStart[top.row]], top.index)
+			after[row] = append(after[row], i)
+			moved[top.index], moved[i] = true, true
+			touched[top.row], touched[row] = true, true
+			for k, index := range top.elses {
+				if top.elseCells[k] != top.cell {
+					before[rowStart[top.elseRows[k]]] = append(before[rowStart[top.elseRows[k]]], index)
+					moved[index] = true
+					touched[top.elseRows[k]] = true
+				}
+			}
+		}
+	}
+
+	if len(moved) == 0 {
+		return tokens
+	}
+
+	result := make([]*templateToken, 0, len(tokens))
+	var rowTokens []*templateToken
+	depth, row = 0, -1
+	for i, token := range tokens {
+		switch token.Kind {
+		case tokenRowStart:
+			if depth == 1 {
+				row++
+				for _, index := range before[i] {
+					result = append(result, tokens[index])
+				}
+				rowTokens = rowTokens[:0]
+			}
+			depth++
+		case tokenTableStart, tokenCellStart:
+			depth++
+		case tokenTableEnd, tokenRowEnd, tokenCellEnd:
+			depth--
+		}
+
+		if moved[i] {
+			continue
+		}
+		if depth < 2 && !(token.Kind == tokenRowEnd && depth == 1) {
+			result = append(result, token)
+			continue
+		}
+
+		rowTokens = append(rowTokens, token)
+		if token.Kind == tokenRowEnd && depth == 1 {
+			if !touched[row] || !isTagOnlyRow(rowTokens) {
+				result = append(result, rowTokens...)
+			}
+			for _, index := range after[row] {
+				result = append(result, tokens[index])
+			}
+		}
+	}
+	return result
+}
+
+// isTagOnlyRow 检查行中是否只剩空白段落
+func isTagOnlyRow(tokens []*templateToken) bool {
+	for _, token := range tokens {
+		switch token.Kind {
+		case tokenRowStart, tokenRowEnd, tokenCellStart, tokenCellEnd, tokenParaStart, tokenParaEnd:
+		case tokenText:
+			if strings.TrimSpace(token.Text) != "" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// templateTextPiece 段落文本中属于某个Run的片段
+type templateTextPiece struct {
+	start, end int
+	run        *Run
+	object     bool // 非文本运行，位于start处
+}
+
+func (l *templateLexer) lexParagraph(para *Paragraph) error {
+	l.line++
+	l.emit(&templateToken{Kind: tokenParaStart, Para: para})
+
+	var text strings.Builder
+	pieces := make([]templateTextPiece, 0, len(para.Runs))
+	for i := range para.Runs {
+		run := &para.Runs[i]
+		if content := run.Text.Content; content != "" {
+			start := text.Len()
+			text.WriteString(content)
+			pieces = append(pieces, templateTextPiece{start: start, end: text.Len(), run: run})
+		}
+		if runHasObject(run) {
+			pieces = append(pieces, templateTextPiece{start: text.Len(), end: text.Len(), run: run, object: true})
+		}
+	}
+
+	if err := l.lexText(text.String(), pieces, para); err != nil {
+		return err
+	}
+	l.emit(&templateToken{Kind: tokenParaEnd})
+	return nil
+}
+
+// runHasObject 检查运行是否包含文本以外的内容
+func runHasObject(run *Run) bool {
+	return run.Drawing != nil || run.FieldChar != nil || run.InstrText != nil || run.Break != nil || run.Equation != nil
+}
+
+// lexText 在段落文本中查找模板标签，标签可以跨越多个Run但不能跨越段落
+func (l *templateLexer) lexText(text string, pieces []templateTextPiece, para *Paragraph) error {
+	type span struct{ start, end int }
+	var spans []span
+	for pos := 0; ; {
+		open := strings.Index(text[pos:], "{{")
+		if open < 0 {
+			break
+		}
+		open += pos
+		closeIndex := strings.Index(text[open+2:], "}}")
+		if closeIndex < 0 {
+			return newTemplateSyntaxError(l.line, l.column(text, open), "unclosed tag %q", text[open:])
+		}
+		pos = open + 2 + closeIndex + 2
+		spans = append(spans, span{open, pos})
+	}
+
+	next := 0
+	for _, piece := range pieces {
+		if piece.object {
+			l.emit(&templateToken{Kind: tokenRun, Run: piece.run, Para: para})
+			continue
+		}
+		for pos := piece.start; pos < piece.end; {
+			for next < len(spans) && spans[next].end <= pos {
+				next++
+			}
+			if next < len(spans) && spans[next].start <= pos {
+				current := spans[next]
+				if current.start == pos {
+					tag, err := l.parseTag(text[current.start:current.end], l.column(text, current.start))
+					if err != nil {
+						return err
+					}
+					l.emit(&templateToken{Kind: tokenTag, Tag: tag, Run: piece.run, Para: para})
+				}
+				pos = current.end
+				continue
+			}
+			end := piece.end
+			if next < len(spans) && spans[next].start < end {
+				end = spans[next].start
+			}
+			l.emit(&templateToken{Kind: tokenText, Text: text[pos:end], Run: piece.run, Para: para})
+			pos = end
+		}
+	}
+	return nil
+}
+
+func (l *templateLexer) column(text string, offset int) int {
+	return utf8.RuneCountInString(text[:offset]) + 1
+}
+
+// parseTag 解析标签内容
+func (l *templateLexer) parseTag(raw string, column int) (*templateTag, error) {
+	tag := &templateTag{Raw: raw, Line: l.line, Column: column}
+	content := strings.TrimSpace(raw[2 : len(raw)-2])
+	fail := func(format string, args ...interface{}) (*templateTag, error) {
+		return nil, newTemplateSyntaxError(tag.Line, tag.Column, format, args...)
+	}
+
+	switch {
+	case content == "":
+		return fail("empty tag %q", raw)
+
+	case strings.HasPrefix(content, "!"):
+		tag.Kind = tagComment
+
+	case strings.HasPrefix(content, "#"):
+		helper, arg := splitTemplateHelper(content[1:])
+		tag.Helper, tag.Arg = helper, arg
+		switch helper {
+		case "if", "unless":
+			if arg == "" {
+				return fail("missing argument for {{#%s}}", helper)
+			}
+			expr, err := parseTemplateExpression(arg)
+			if err != nil {
+				return fail("%v in {{#%s}}", err, helper)
+			}
+			tag.Kind, tag.Expr = tagOpen, expr
+		case "each", "image":
+			if arg == "" {
+				return fail("missing argument for {{#%s}}", helper)
+			}
+			path, err := parseTemplatePath(arg)
+			if err != nil {
+				return fail("%v in {{#%s}}", err, helper)
+			}
+			tag.Kind, tag.Path = tagOpen, path
+			if helper == "image" {
+				tag.Kind = tagImage
+			}
+		case "block":
+			name, ok := unquoteTemplateString(arg)
+			if !ok {
+				return fail("{{#block}} requires a quoted name")
+			}
+			tag.Kind, tag.Arg = tagOpen, name
+		default:
+			return fail("unknown helper %q", "#"+helper)
+		}
+
+	case strings.HasPrefix(content, "/"):
+		helper := strings.TrimSpace(content[1:])
+		switch helper {
+		case "if", "unless", "each", "block":
+			tag.Kind, tag.Helper = tagClose, helper
+		default:
+			return fail("unknown closing tag %q", raw)
+		}
+
+	case content == "else":
+		tag.Kind = tagElse
+
+	case strings.HasPrefix(content, "else "):
+		helper, arg := splitTemplateHelper(content[len("else"):])
+		if helper != "if" || arg == "" {
+			return fail("invalid else tag %q", raw)
+		}
+		expr, err := parseTemplateExpression(arg)
+		if err != nil {
+			return fail("%v in {{else if}}", err)
+		}
+		tag.Kind, tag.Helper, tag.Arg, tag.Expr = tagElse, "if", arg, expr
+
+	case strings.HasPrefix(content, ">"):
+		arg := strings.TrimSpace(content[1:])
+		name, ok := unquoteTemplateString(arg)
+		if !ok {
+			// 未加引号的片段名不能包含空白和引号
+			if arg == "" || strings.ContainsAny(arg, "\" \t") {
+				return fail("invalid partial name in %q", raw)
+			}
+			name = arg
+		}
+		tag.Kind, tag.Helper, tag.Arg = tagPartial, ">", name
+
+	case strings.HasPrefix(content, "include ") && strings.HasPrefix(strings.TrimSpace(content[len("include"):]), `"`):
+		name, ok := unquoteTemplateString(strings.TrimSpace(content[len("include"):]))
+		if !ok {
+			return fail("{{include}} requires a quoted file name")
+		}
+		tag.Kind, tag.Helper, tag.Arg = tagPartial, "include", name
+
+	case strings.HasPrefix(content, "extends ") || content == "extends":
+		name, ok := unquoteTemplateString(strings.TrimSpace(content[len("extends"):]))
+		if !ok {
+			return fail("{{extends}} requires a quoted template name")
+		}
+		tag.Kind, tag.Arg = tagExtends, name
+
+	default:
+		expr, err := parseTemplateExpression(content)
+		if err != nil && strings.HasPrefix(content, "include ") {
+			return fail("{{include}} requires a quoted file name")
+		}
+		if err != nil {
+			return fail("invalid expression %q: %v", content, err)
+		}
+		tag.Kind, tag.Arg, tag.Expr = tagOutput, content, expr
+		if pathExpr, ok := expr.(*templatePathExpr); ok {
+			tag.Path = pathExpr.Path
+		}
+	}
+	return tag, nil
+}
+
+// splitTemplateHelper 拆分块名称与参数
+func splitTemplateHelper(content string) (string, string) {
+	content = strings.TrimSpace(content)
+	if index := strings.IndexFunc(content, unicode.IsSpace); index >= 0 {
+		return content[:index], strings.TrimSpace(content[index:])
+	}
+	return content, ""
+}
+
+// unquoteTemplateString 去除双引号
+func unquoteTemplateString(value string) (string, bool) {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", false
+	}
+	value = value[1 : len(value)-1]
+	return value, value != "" && !strings.Contains(value, `"`)
+}
+
+// isStandaloneTag 检查标签是否可以独占段落（独占时整个段落在输出中被移除）
+func isStandaloneTag(tag *templateTag) bool {
+	return tag.Kind != tagOutput
+}
+
+// markStandaloneTags 移除只包含块标签和空白的段落的段落边界，
+// 使跨段落的{{#each}}、{{#if}}等标签所在的段落不会在输出中留下空行
+func markStandaloneTags(tokens []*templateToken) []*templateToken {
+	result := make([]*templateToken, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Kind != tokenParaStart {
+			result = append(result, tokens[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(tokens) && tokens[end].Kind != tokenParaEnd {
+			end++
+		}
+		if end >= len(tokens) {
+			result = append(result, tokens[i:]...)
+			break
+		}
+
+		standalone, hasTag := true, false
+		for _, token := range tokens[i+1 : end] {
+			switch {
+			case token.Kind == tokenTag && isStandaloneTag(token.Tag):
+				hasTag = true
+			case token.Kind == tokenText && strings.TrimSpace(token.Text) == "":
+			default:
+				standalone = false
+			}
+		}
+
+		if standalone && hasTag {
+			for _, token := range tokens[i+1 : end] {
+				if token.Kind == tokenTag {
+					result = append(result, token)
+				}
+			}
+		} else {
+			result = append(result, tokens[i:end+1]...)
+		}
+		i = end
+	}
+	return result
+}
+
+// templateParser 模板语法分析器
+type templateParser struct {
+	tokens []*templateToken
+	pos    int
+}
+
+// parseTemplateTokens 将词法单元解析为语法树
+func parseTemplateTokens(tokens []*templateToken) ([]templateNode, error) {
+	parser := &templateParser{tokens: tokens}
+	nodes, stop, err := parser.parseList(nil)
+	if err != nil {
+		return nil, err
+	}
+	if stop != nil {
+		return nil, newTemplateSyntaxError(stop.Line, stop.Column, "unexpected %s", stop.Raw)
+	}
+	return nodes, nil
+}
+
+// parseList 解析节点列表，直到遇到属于open的{{else}}或结束标签
+func (p *templateParser) parseList(open *templateTag) ([]templateNode, *templateTag, error) {
+	nodes := make([]templateNode, 0)
+	depth := 0
+	for p.pos < len(p.tokens) {
+		token := p.tokens[p.pos]
+		p.pos++
+
+		switch token.Kind {
+		case tokenTableStart, tokenRowStart, tokenCellStart:
+			depth++
+		case tokenTableEnd, tokenRowEnd, tokenCellEnd:
+			depth--
+			if depth < 0 && open != nil {
+				return nil, nil, newTemplateSyntaxError(open.Line, open.Column, "%s is not closed within its table cell", open.Raw)
+			}
+		}
+
+		if token.Kind != tokenTag {
+			nodes = append(nodes, token)
+			continue
+		}
+
+		tag := token.Tag
+		switch tag.Kind {
+		case tagOutput:
+			nodes = append(nodes, &templateOutputNode{Tag: tag, Run: token.Run, Para: token.Para})
+		case tagImage:
+			nodes = append(nodes, &templateImageNode{Tag: tag, Run: token.Run, Para: token.Para})
+		case tagExtends:
+			nodes = append(nodes, &templateExtendsNode{Tag: tag})
+		case tagPartial:
+			nodes = append(nodes, &templatePartialNode{Tag: tag})
+		case tagComment:
+		case tagOpen:
+			node, err := p.parseBlock(tag)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, node)
+		case tagElse, tagClose:
+			if open == nil {
+				return nil, tag, nil
+			}
+			if depth != 0 {
+				return nil, nil, newTemplateSyntaxError(tag.Line, tag.Column, "%s crosses a table cell boundary", tag.Raw)
+			}
+			return nodes, tag, nil
+		}
+	}
+
+	if open != nil {
+		return nil, nil, newTemplateSyntaxError(open.Line, open.Column, "unclosed %s", open.Raw)
+	}
+	return nodes, nil, nil
+}
+
+// parseBlock 解析块标签及其内容
+func (p *templateParser) parseBlock(open *templateTag) (templateNode, error) {
+	body, stop, err := p.parseList(open)
+	if err != nil {
+		return nil, err
+	}
+
+	condition := open.Expr
+	if open.Helper == "unless" {
+		condition = &templateNotExpr{X: open.Expr}
+	}
+	branches := []templateBranch{{Condition: open.Arg, Expr: condition, Body: body, Tag: open}}
+
+	var elseBody []templateNode
+	hasElse := false
+	for stop.Kind == tagElse {
+		switch {
+		case open.Helper == "block":
+			return nil, newTemplateSyntaxError(stop.Line, stop.Column, "{{else}} is not allowed in {{#block}}")
+		case hasElse && stop.Expr != nil:
+			return nil, newTemplateSyntaxError(stop.Line, stop.Column, "%s after {{else}} in %s", stop.Raw, open.Raw)
+		case hasElse:
+			return nil, newTemplateSyntaxError(stop.Line, stop.Column, "duplicate {{else}} in %s", open.Raw)
+		case stop.Expr != nil && open.Helper == "each":
+			return nil, newTemplateSyntaxError(stop.Line, stop.Column, "{{else if}} is not allowed in {{#each}}")
+		}
+
+		branch := stop
+		next, nextStop, err := p.parseList(open)
+		if err != nil {
+			return nil, err
+		}
+		if branch.Expr != nil {
+			branches = append(branches, templateBranch{Condition: branch.Arg, Expr: branch.Expr, Body: next, Tag: branch})
+		} else {
+			hasElse, elseBody = true, next
+		}
+		stop = nextStop
+	}
+
+	if stop.Helper != open.Helper {
+		return nil, newTemplateSyntaxError(stop.Line, stop.Column,
+			"%s does not match %s opened at line %d, column %d", stop.Raw, open.Raw, open.Line, open.Column)
+	}
+
+	switch open.Helper {
+	case "if", "unless":
+		return &templateIfNode{
+			Open:     open,
+			Branches: branches,
+			Else:     elseBody,
+			HasElse:  hasElse,
+			Close:    stop,
+		}, nil
+	case "each":
+		return &templateEachNode{Open: open, List: open.Arg, Path: open.Path, Body: body, Else: elseBody, HasElse: hasElse, Close: stop}, nil
+	default:
+		return &templateBlockNode{Open: open, Name: open.Arg, Body: body, Close: stop}, nil
+	}
+}
+
+// templateSource 还原语法树对应的模板源文本
+func templateSource(nodes []templateNode) string {
+	var builder strings.Builder
+	writeTemplateSource(&builder, nodes)
+	return builder.String()
+}
+
+func writeTemplateSource(builder *strings.Builder, nodes []templateNode) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *templateToken:
+			switch n.Kind {
+			case tokenText:
+				builder.WriteString(n.Text)
+			case tokenParaEnd:
+				builder.WriteString("\n")
+			}
+		case *templateOutputNode:
+			builder.WriteString(n.Tag.Raw)
+		case *templateImageNode:
+			builder.WriteString(n.Tag.Raw)
+		case *templateExtendsNode:
+			builder.WriteString(n.Tag.Raw)
+		case *templatePartialNode:
+			builder.WriteString(n.Tag.Raw)
+		case *templateIfNode:
+			for _, branch := range n.Branches {
+				builder.WriteString(branch.Tag.Raw)
+				writeTemplateSource(builder, branch.Body)
+			}
+			if n.HasElse {
+				builder.WriteString("{{else}}")
+				writeTemplateSource(builder, n.Else)
+			}
+			builder.WriteString(n.Close.Raw)
+		case *templateEachNode:
+			builder.WriteString(n.Open.Raw)
+			writeTemplateSource(builder, n.Body)
+			if n.HasElse {
+				builder.WriteString("{{else}}")
+				writeTemplateSource(builder, n.Else)
+			}
+			builder.WriteString(n.Close.Raw)
+		case *templateBlockNode:
+			builder.WriteString(n.Open.Raw)
+			writeTemplateSource(builder, n.Body)
+			builder.WriteString(n.Close.Raw)
+		}
+	}
+}
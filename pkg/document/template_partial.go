@@ -0,0 +1,724 @@
+// Package document 模板片段引用
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// templatePartialResources 片段模板基础文档中的资源在输出文档中的映射。
+// 样式和编号在首次引用片段时导入，图片在输出时按需复制
+type templatePartialResources struct {
+	source    *Document
+	target    *Document
+	relations map[string]string // 片段文档关系ID -> 输出文档关系ID
+	parts     map[string]string // 片段文档部件名称 -> 输出文档部件名称
+	numIDs    map[string]string // 片段文档编号ID -> 输出文档编号ID
+}
+
+// renderPartial 渲染片段引用：{{> name}} 插入已加载的模板，{{include "file.docx"}} 插入基础路径下的文档。
+// 片段使用当前数据与循环作用域渲染，其块重写只在片段内部生效
+func (r *templateRenderer) renderPartial(n *templatePartialNode) error {
+	for _, name := range r.partials {
+		if name == n.Tag.Arg {
+			return newTemplateRenderError(n.Tag, fmt.Errorf("recursive partial %q", n.Tag.Arg))
+		}
+	}
+
+	template, err := r.loadPartial(n.Tag.Arg)
+	if err != nil {
+		return newTemplateRenderError(n.Tag, err)
+	}
+	root, overrides, err := templateInheritance(template)
+	if err != nil {
+		return newTemplateRenderError(n.Tag, err)
+	}
+
+	var resources *templatePartialResources
+	if root.BaseDoc != nil {
+		if r.imported == nil {
+			r.imported = make(map[*Document]*templatePartialResources)
+		}
+		resources = r.imported[root.BaseDoc]
+		if resources == nil {
+			resources = importTemplateResources(r.doc, root.BaseDoc)
+			r.imported[root.BaseDoc] = resources
+		}
+	}
+
+	parentOverrides, parentResources := r.overrides, r.builder.resources
+	r.overrides, r.builder.resources = overrides, resources
+	r.partials = append(r.partials, n.Tag.Arg)
+	err = r.renderNodes(root.nodes)
+	r.partials = r.partials[:len(r.partials)-1]
+	r.overrides, r.builder.resources = parentOverrides, parentResources
+	return err
+}
+
+// loadPartial 获取渲染使用的片段模板，预编译模板使用编译时加载的片段
+func (r *templateRenderer) loadPartial(name string) (*Template, error) {
+	if r.compiled == nil {
+		return r.te.loadPartial(name)
+	}
+	if template, ok := r.compiled.partials[name]; ok {
+		return template, nil
+	}
+	return nil, WrapErrorWithContext("load_partial", ErrTemplateNotFound.Cause, name)
+}
+
+// loadPartial 获取片段模板，缓存中不存在时从基础路径加载同名.docx文件并缓存
+func (te *TemplateEngine) loadPartial(name string) (*Template, error) {
+	if template, err := te.GetTemplate(name); err == nil {
+		return template, nil
+	}
+
+	te.mutex.RLock()
+	basePath := te.basePath
+	te.mutex.RUnlock()
+
+	file := name
+	if filepath.Ext(file) == "" {
+		file += ".docx"
+	}
+	// 引用路径来自模板文本，只允许读取基础路径下的文件
+	if filepath.IsAbs(file) || strings.HasPrefix(file, "/") || strings.HasPrefix(file, "\\") {
+		return nil, WrapErrorWithContext("load_partial", fmt.Errorf("absolute include path %q is not allowed", name), name)
+	}
+	file = filepath.Join(basePath, file)
+	if rel, err := filepath.Rel(filepath.Clean(basePath), file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, WrapErrorWithContext("load_partial", fmt.Errorf("include path %q is outside the base path", name), name)
+	}
+	if _, err := os.Stat(file); err != nil {
+		return nil, WrapErrorWithContext("load_partial", ErrTemplateNotFound.Cause, name)
+	}
+
+	doc, err := Open(file)
+	if err != nil {
+		return nil, WrapErrorWithContext("load_partial", err, name)
+	}
+	return te.LoadTemplateFromDocument(name, doc)
+}
+
+// importTemplateResources 将片段基础文档中用到的样式和编号导入输出文档
+func importTemplateResources(target, source *Document) *templatePartialResources {
+	res := &templatePartialResources{
+		source:    source,
+		target:    target,
+		relations: make(map[string]string),
+		numIDs:    make(map[string]string),
+	}
+
+	styleIDs := make(map[string]bool)
+	numIDs := make(map[string]bool)
+	collectTemplateResourceIDs(source.Body.Elements, styleIDs, numIDs)
+	res.importStyles(styleIDs)
+	res.importNumbering(numIDs)
+	return res
+}
+
+// collectTemplateResourceIDs 收集元素中引用的段落样式、表格样式和编号ID
+func collectTemplateResourceIDs(elements []interface{}, styleIDs, numIDs map[string]bool) {
+	collectParagraph := func(para *Paragraph) {
+		if para.Properties == nil {
+			return
+		}
+		if para.Properties.ParagraphStyle != nil {
+			styleIDs[para.Properties.ParagraphStyle.Val] = true
+		}
+		if numPr := para.Properties.NumberingProperties; numPr != nil && numPr.NumID != nil {
+			numIDs[numPr.NumID.Val] = true
+		}
+	}
+
+	for _, element := range elements {
+		switch elem := element.(type) {
+		case *Paragraph:
+			collectParagraph(elem)
+		case *Table:
+			if elem.Properties != nil && elem.Properties.TableStyle != nil {
+				styleIDs[elem.Properties.TableStyle.Val] = true
+			}
+			for i := range elem.Rows {
+				for j := range elem.Rows[i].Cells {
+					collectTemplateResourceIDs(elem.Rows[i].Cells[j].Elements(), styleIDs, numIDs)
+				}
+			}
+		}
+	}
+}
+
+// importStyles 导入输出文档中不存在的样式及其基础样式、后续样式和链接样式。
+// 同ID样式以输出文档为准，新样式按原文追加到输出文档的styles.xml中
+func (res *templatePartialResources) importStyles(styleIDs map[string]bool) {
+	pending := make([]string, 0, len(styleIDs))
+	for id := range styleIDs {
+		pending = append(pending, id)
+	}
+	sort.Strings(pending)
+
+	sourceStyles := templateDocumentStyles(res.source)
+	targetStyles := templateDocumentStyles(res.target)
+	var added bytes.Buffer
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+		s, ok := sourceStyles[id]
+		if !ok || targetStyles[id] != nil {
+			continue
+		}
+		targetStyles[id] = s
+		added.Write(s.raw)
+		pending = append(pending, s.BasedOn.Val, s.Next.Val, s.Link.Val)
+
+		// 片段样式来自样式管理器时同时加入输出文档的样式管理器
+		if _, parsed := res.source.parts["word/styles.xml"]; !parsed && res.target.styleManager != nil {
+			clone := *res.source.styleManager.GetStyle(id)
+			res.target.styleManager.AddStyle(&clone)
+		}
+	}
+	if added.Len() == 0 {
+		return
+	}
+
+	// 输出文档没有styles.xml时先由样式管理器生成，再追加新样式
+	if _, ok := res.target.parts["word/styles.xml"]; !ok {
+		if err := res.target.serializeStyles(); err != nil {
+			return
+		}
+	}
+	stylesXML := res.target.parts["word/styles.xml"]
+	end := bytes.LastIndex(stylesXML, []byte("</w:styles>"))
+	if end < 0 {
+		return
+	}
+	var buffer bytes.Buffer
+	buffer.Write(stylesXML[:end])
+	buffer.Write(added.Bytes())
+	buffer.Write(stylesXML[end:])
+	res.target.parts["word/styles.xml"] = buffer.Bytes()
+}
+
+// templateRawStyle 用于按原文复制样式定义的styles.xml样式结构
+type templateRawStyle struct {
+	ID      string `xml:"styleId,attr"`
+	BasedOn struct {
+		Val string `xml:"val,attr"`
+	} `xml:"basedOn"`
+	Next struct {
+		Val string `xml:"val,attr"`
+	} `xml:"next"`
+	Link struct {
+		Val string `xml:"val,attr"`
+	} `xml:"link"`
+
+	raw []byte // 样式定义原文
+}
+
+// templateDocumentStyles 返回文档中的样式定义：存在styles.xml时按原文读取，否则由样式管理器生成
+func templateDocumentStyles(doc *Document) map[string]*templateRawStyle {
+	styles := make(map[string]*templateRawStyle)
+	data, ok := doc.parts["word/styles.xml"]
+	if !ok {
+		if doc.styleManager == nil {
+			return styles
+		}
+		for _, s := range doc.styleManager.GetAllStyles() {
+			raw, err := xml.Marshal(s)
+			if err != nil {
+				continue
+			}
+			rawStyle := &templateRawStyle{ID: s.StyleID, raw: raw}
+			if s.BasedOn != nil {
+				rawStyle.BasedOn.Val = s.BasedOn.Val
+			}
+			if s.Next != nil {
+				rawStyle.Next.Val = s.Next.Val
+			}
+			styles[s.StyleID] = rawStyle
+		}
+		return styles
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "style" {
+			continue
+		}
+		rawStyle := &templateRawStyle{}
+		if err := decoder.DecodeElement(rawStyle, &start); err != nil {
+			break
+		}
+		rawStyle.raw = data[offset:decoder.InputOffset()]
+		styles[rawStyle.ID] = rawStyle
+	}
+	return styles
+}
+
+// templateRawNumbering 用于合并编号定义的numbering.xml结构，编号定义按原文保留
+type templateRawNumbering struct {
+	AbstractNums []templateRawAbstractNum `xml:"abstractNum"`
+	Nums         []templateRawNum         `xml:"num"`
+}
+
+type templateRawAbstractNum struct {
+	ID    string `xml:"abstractNumId,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+type templateRawNum struct {
+	ID         string `xml:"numId,attr"`
+	AbstractID struct {
+		Val string `xml:"val,attr"`
+	} `xml:"abstractNumId"`
+	Inner string `xml:",innerxml"`
+}
+
+var (
+	templateNumberingRoot = regexp.MustCompile(`<w:numbering\b[^>]*>`)
+	templateNumStart      = regexp.MustCompile(`<w:num[\s>]`)
+	templateAbstractNumID = regexp.MustCompile(`<w:abstractNumId\b[^>]*/>`)
+)
+
+// importNumbering 导入片段用到的编号定义。与输出文档中同ID且内容相同的定义直接复用，
+// 否则分配新ID，渲染时片段段落的编号ID随之替换
+func (res *templatePartialResources) importNumbering(numIDs map[string]bool) {
+	sourceXML, ok := res.source.parts["word/numbering.xml"]
+	if !ok || len(numIDs) == 0 {
+		return
+	}
+	var source templateRawNumbering
+	if err := xml.Unmarshal(sourceXML, &source); err != nil {
+		return
+	}
+
+	targetXML, exists := res.target.parts["word/numbering.xml"]
+	if !exists {
+		root := templateNumberingRoot.Find(sourceXML)
+		if root == nil {
+			return
+		}
+		targetXML = append([]byte(xml.Header), root...)
+		targetXML = append(targetXML, "</w:numbering>"...)
+	}
+	var target templateRawNumbering
+	if err := xml.Unmarshal(targetXML, &target); err != nil {
+		return
+	}
+
+	targetAbstracts := make(map[string]string, len(target.AbstractNums))
+	nextAbstractID := 0
+	for _, abstract := range target.AbstractNums {
+		targetAbstracts[abstract.ID] = abstract.Inner
+		nextAbstractID = maxTemplateID(nextAbstractID, abstract.ID)
+	}
+	targetNums := make(map[string]string, len(target.Nums))
+	nextNumID := 0
+	for _, num := range target.Nums {
+		targetNums[num.ID] = num.AbstractID.Val
+		nextNumID = maxTemplateID(nextNumID, num.ID)
+	}
+	sourceAbstracts := make(map[string]string, len(source.AbstractNums))
+	for _, abstract := range source.AbstractNums {
+		sourceAbstracts[abstract.ID] = abstract.Inner
+	}
+
+	var abstractsXML, numsXML strings.Builder
+	abstractIDs := make(map[string]string)
+	for _, num := range source.Nums {
+		if !numIDs[num.ID] {
+			continue
+		}
+		inner, ok := sourceAbstracts[num.AbstractID.Val]
+		if !ok {
+			continue
+		}
+
+		abstractID, imported := abstractIDs[num.AbstractID.Val]
+		if !imported {
+			if existing, ok := targetAbstracts[num.AbstractID.Val]; ok && existing == inner {
+				abstractID = num.AbstractID.Val
+			} else {
+				nextAbstractID++
+				abstractID = strconv.Itoa(nextAbstractID)
+				fmt.Fprintf(&abstractsXML, `<w:abstractNum w:abstractNumId="%s">%s</w:abstractNum>`, abstractID, inner)
+			}
+			abstractIDs[num.AbstractID.Val] = abstractID
+		}
+
+		if existing, ok := targetNums[num.ID]; ok && existing == abstractID {
+			res.numIDs[num.ID] = num.ID
+			continue
+		}
+		nextNumID++
+		numID := strconv.Itoa(nextNumID)
+		res.numIDs[num.ID] = numID
+		reference := fmt.Sprintf(`<w:abstractNumId w:val="%s"/>`, abstractID)
+		fmt.Fprintf(&numsXML, `<w:num w:numId="%s">%s</w:num>`, numID, templateAbstractNumID.ReplaceAllLiteralString(num.Inner, reference))
+	}
+
+	if !exists || abstractsXML.Len() > 0 || numsXML.Len() > 0 {
+		res.target.parts["word/numbering.xml"] = insertTemplateNumbering(targetXML, abstractsXML.String(), numsXML.String())
+		res.target.addContentType("word/numbering.xml", "application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml")
+		res.target.ensureNumberingRelationship()
+	}
+}
+
+// insertTemplateNumbering 将抽象编号插入到第一个编号实例之前，编号实例插入到最后一个编号实例之后
+func insertTemplateNumbering(numberingXML []byte, abstracts, nums string) []byte {
+	end := bytes.LastIndex(numberingXML, []byte("</w:numbering>"))
+	if end < 0 {
+		return numberingXML
+	}
+	numsAt := end
+	if index := bytes.LastIndex(numberingXML, []byte("</w:num>")); index >= 0 {
+		numsAt = index + len("</w:num>")
+	}
+	abstractsAt := numsAt
+	if loc := templateNumStart.FindIndex(numberingXML); loc != nil {
+		abstractsAt = loc[0]
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(numberingXML[:abstractsAt])
+	buffer.WriteString(abstracts)
+	buffer.Write(numberingXML[abstractsAt:numsAt])
+	buffer.WriteString(nums)
+	buffer.Write(numberingXML[numsAt:])
+	return buffer.Bytes()
+}
+
+// maxTemplateID 返回current与数字ID中较大者
+func maxTemplateID(current int, id string) int {
+	if n, err := strconv.Atoi(id); err == nil && n > current {
+		return n
+	}
+	return current
+}
+
+// ensureNumberingRelationship 确保文档存在到numbering.xml的关系
+func (d *Document) ensureNumberingRelationship() {
+	const numberingType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering"
+	for _, rels := range []*Relationships{d.relationships, d.documentRelationships} {
+		if rels == nil {
+			continue
+		}
+		for _, rel := range rels.Relationships {
+			if rel.Type == numberingType {
+				return
+			}
+		}
+	}
+	if d.documentRelationships == nil {
+		d.documentRelationships = &Relationships{
+			Xmlns:         "http://schemas.openxmlformats.org/package/2006/relationships",
+			Relationships: []Relationship{},
+		}
+	}
+	d.documentRelationships.Relationships = append(d.documentRelationships.Relationships, Relationship{
+		ID:     d.nextDocumentRelationshipID(),
+		Type:   numberingType,
+		Target: "numbering.xml",
+	})
+}
+
+// paragraphProperties 替换片段段落的编号ID，并移除片段中的分节符
+func (res *templatePartialResources) paragraphProperties(props *ParagraphProperties) *ParagraphProperties {
+	if res == nil || props == nil {
+		return props
+	}
+	if numPr := props.NumberingProperties; numPr != nil && numPr.NumID != nil {
+		if id, ok := res.numIDs[numPr.NumID.Val]; ok {
+			numPr.NumID.Val = id
+		}
+	}
+	props.SectionProperties = nil
+	return props
+}
+
+// drawing 复制片段图片和图表引用的部件到输出文档，并返回使用新关系ID的绘图副本。
+// 文本框中的绘图不在此处理，由mapDrawingTree逐个传入
+func (res *templatePartialResources) drawing(drawing *DrawingElement) *DrawingElement {
+	if res == nil || drawing == nil {
+		return drawing
+	}
+	clone := *drawing
+	if drawing.Inline != nil {
+		inline := *drawing.Inline
+		inline.Graphic = res.graphic(inline.Graphic)
+		clone.Inline = &inline
+	}
+	if drawing.Anchor != nil {
+		anchor := *drawing.Anchor
+		anchor.Graphic = res.graphic(anchor.Graphic)
+		clone.Anchor = &anchor
+	}
+	return &clone
+}
+
+// graphic 返回图片和图表关系ID替换后的图形副本
+func (res *templatePartialResources) graphic(graphic *DrawingGraphic) *DrawingGraphic {
+	if graphic == nil || graphic.GraphicData == nil {
+		return graphic
+	}
+	data := *graphic.GraphicData
+	if pic := data.Pic; pic != nil && pic.BlipFill != nil && pic.BlipFill.Blip != nil {
+		blip := *pic.BlipFill.Blip
+		blip.Embed = res.relation(blip.Embed)
+		blipFill := *pic.BlipFill
+		blipFill.Blip = &blip
+		newPic := *pic
+		newPic.BlipFill = &blipFill
+		data.Pic = &newPic
+	}
+	if data.Chart != nil {
+		chart := *data.Chart
+		chart.ID = res.relation(chart.ID)
+		data.Chart = &chart
+	}
+	clone := *graphic
+	clone.GraphicData = &data
+	return &clone
+}
+
+// mapDrawingTree 对绘图及其文本框中（包括文本框内表格中）的绘图依次调用fn，
+// 返回替换后的副本，原绘图和文本框内容不被修改
+func mapDrawingTree(drawing *DrawingElement, fn func(*DrawingElement) *DrawingElement) *DrawingElement {
+	if drawing == nil {
+		return nil
+	}
+	drawing = fn(drawing)
+	wsp := drawingShape(drawing)
+	if wsp == nil || wsp.Txbx == nil || wsp.Txbx.Content == nil {
+		return drawing
+	}
+
+	mapParagraph := func(para *Paragraph) *Paragraph {
+		clone := *para
+		clone.Runs = append([]Run(nil), para.Runs...)
+		for i := range clone.Runs {
+			clone.Runs[i].Drawing = mapDrawingTree(clone.Runs[i].Drawing, fn)
+		}
+		return &clone
+	}
+	var mapTable func(table *Table) *Table
+	mapTable = func(table *Table) *Table {
+		clone := *table
+		clone.Rows = append([]TableRow(nil), table.Rows...)
+		for i := range clone.Rows {
+			row := &clone.Rows[i]
+			row.Cells = append([]TableCell(nil), row.Cells...)
+			for j := range row.Cells {
+				cell := &row.Cells[j]
+				paragraphs := make([]Paragraph, len(cell.Paragraphs))
+				for k := range cell.Paragraphs {
+					paragraphs[k] = *mapParagraph(&cell.Paragraphs[k])
+				}
+				cell.Paragraphs = paragraphs
+				tables := make([]NestedTable, 0, len(cell.Tables))
+				for _, nested := range cell.Tables {
+					if nested.Table != nil {
+						tables = append(tables, NestedTable{Index: nested.Index, Table: mapTable(nested.Table)})
+					}
+				}
+				cell.Tables = tables
+			}
+		}
+		return &clone
+	}
+
+	source := wsp.Txbx.Content
+	content := &TextBoxContent{
+		Paragraphs: make([]*Paragraph, 0, len(source.Paragraphs)),
+		Tables:     make([]NestedTable, 0, len(source.Tables)),
+	}
+	for _, para := range source.Paragraphs {
+		if para != nil {
+			content.Paragraphs = append(content.Paragraphs, mapParagraph(para))
+		}
+	}
+	for _, nested := range source.Tables {
+		if nested.Table != nil {
+			content.Tables = append(content.Tables, NestedTable{Index: nested.Index, Table: mapTable(nested.Table)})
+		}
+	}
+
+	txbx := *wsp.Txbx
+	txbx.Content = content
+	newWsp := *wsp
+	newWsp.Txbx = &txbx
+	clone := *drawing
+	if drawing.Inline != nil {
+		inline := *drawing.Inline
+		inline.Graphic = graphicWithShape(inline.Graphic, &newWsp)
+		clone.Inline = &inline
+	} else if drawing.Anchor != nil {
+		anchor := *drawing.Anchor
+		anchor.Graphic = graphicWithShape(anchor.Graphic, &newWsp)
+		clone.Anchor = &anchor
+	}
+	return &clone
+}
+
+// graphicWithShape 返回形状替换为wsp的图形副本
+func graphicWithShape(graphic *DrawingGraphic, wsp *WordprocessingShape) *DrawingGraphic {
+	data := *graphic.GraphicData
+	data.Wsp = wsp
+	clone := *graphic
+	clone.GraphicData = &data
+	return &clone
+}
+
+// relation 将片段文档的关系复制到输出文档并返回新关系ID，内部目标的部件一并复制
+func (res *templatePartialResources) relation(id string) string {
+	if newID, ok := res.relations[id]; ok {
+		return newID
+	}
+	var rel *Relationship
+	if res.source.documentRelationships != nil {
+		for i := range res.source.documentRelationships.Relationships {
+			if res.source.documentRelationships.Relationships[i].ID == id {
+				rel = &res.source.documentRelationships.Relationships[i]
+				break
+			}
+		}
+	}
+	if rel == nil {
+		return id
+	}
+
+	target := res.target
+	if target.documentRelationships == nil {
+		target.documentRelationships = &Relationships{
+			Xmlns:         "http://schemas.openxmlformats.org/package/2006/relationships",
+			Relationships: []Relationship{},
+		}
+	}
+	newRel := *rel
+	if rel.TargetMode != "External" {
+		newRel.Target = strings.TrimPrefix(res.part(relationshipPartName(rel.Target)), "word/")
+
+		// 输出文档中已有指向同一部件的关系时直接复用
+		for _, existing := range target.documentRelationships.Relationships {
+			if existing.Type == newRel.Type && existing.Target == newRel.Target && existing.TargetMode == "" {
+				res.relations[id] = existing.ID
+				return existing.ID
+			}
+		}
+	}
+
+	newRel.ID = target.nextDocumentRelationshipID()
+	target.documentRelationships.Relationships = append(target.documentRelationships.Relationships, newRel)
+	res.relations[id] = newRel.ID
+	return newRel.ID
+}
+
+// part 将片段文档的部件复制到输出文档并返回输出文档中的部件名称。
+// 部件自身的关系文件（如图表的嵌入式工作簿）及其引用的部件一并复制；
+// 同名部件内容不同时使用带序号后缀的新名称，如 media/image1_2.png
+func (res *templatePartialResources) part(partName string) string {
+	if res.parts == nil {
+		res.parts = make(map[string]string)
+	}
+	if name, ok := res.parts[partName]; ok {
+		return name
+	}
+	res.parts[partName] = partName // 防止关系循环引用
+
+	data := res.source.parts[partName]
+	relsName := chartRelationshipsPartName(partName)
+	relsData, hasRels := res.source.parts[relsName]
+	if hasRels {
+		relsData = res.partRelationships(partName, relsData)
+	}
+
+	target := res.target
+	name := partName
+	ext := path.Ext(partName)
+	for i := 2; ; i++ {
+		existing, exists := target.parts[name]
+		if !exists || (bytes.Equal(existing, data) && bytes.Equal(target.parts[chartRelationshipsPartName(name)], relsData)) {
+			break
+		}
+		name = strings.TrimSuffix(partName, ext) + "_" + strconv.Itoa(i) + ext
+	}
+	if _, exists := target.parts[name]; !exists {
+		target.parts[name] = data
+		if hasRels {
+			target.parts[chartRelationshipsPartName(name)] = relsData
+		}
+	}
+	res.importContentType(partName, name)
+	res.parts[partName] = name
+	return name
+}
+
+// partRelationships 复制部件关系文件中的内部目标部件，返回目标改写为输出文档部件名称后的关系文件
+func (res *templatePartialResources) partRelationships(partName string, data []byte) []byte {
+	var rels Relationships
+	if err := xml.Unmarshal(data, &rels); err != nil {
+		return data
+	}
+	dir := path.Dir(partName)
+	for i := range rels.Relationships {
+		rel := &rels.Relationships[i]
+		if rel.TargetMode == "External" {
+			continue
+		}
+		source := path.Clean(path.Join(dir, rel.Target))
+		if strings.HasPrefix(rel.Target, "/") {
+			source = strings.TrimPrefix(rel.Target, "/")
+		}
+		if _, exists := res.source.parts[source]; !exists {
+			continue
+		}
+		copied := res.part(source)
+		if relative, err := filepath.Rel(dir, copied); err == nil {
+			rel.Target = filepath.ToSlash(relative)
+		}
+	}
+	output, err := xml.Marshal(&rels)
+	if err != nil {
+		return data
+	}
+	return append([]byte(xml.Header), output...)
+}
+
+// importContentType 复制部件的内容类型（按扩展名的默认类型或按部件名的覆盖类型）
+func (res *templatePartialResources) importContentType(sourceName, targetName string) {
+	if res.source.contentTypes == nil {
+		return
+	}
+	for _, override := range res.source.contentTypes.Overrides {
+		if override.PartName == "/"+sourceName {
+			res.target.addContentType(targetName, override.ContentType)
+			return
+		}
+	}
+
+	ext := strings.TrimPrefix(path.Ext(sourceName), ".")
+	for _, def := range res.target.contentTypes.Defaults {
+		if strings.EqualFold(def.Extension, ext) {
+			return
+		}
+	}
+	for _, def := range res.source.contentTypes.Defaults {
+		if strings.EqualFold(def.Extension, ext) {
+			res.target.contentTypes.Defaults = append(res.target.contentTypes.Defaults, def)
+			return
+		}
+	}
+}
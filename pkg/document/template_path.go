@@ -0,0 +1,265 @@
+// Package document 模板变量路径解析
+package document
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// templateStructTag 结构体字段在模板中使用的名称标签，如 `wordzero:"city"`，"-"表示忽略该字段
+const templateStructTag = "wordzero"
+
+// templatePath 变量路径，如 customer.address.city、order.lines[0].sku
+type templatePath struct {
+	Raw      string
+	Segments []templatePathSegment
+}
+
+// templatePathSegment 路径片段：字段/键名或索引
+type templatePathSegment struct {
+	Name    string // 字段或键名
+	Index   int    // 索引（IsIndex为true时有效）
+	IsIndex bool
+}
+
+// parseTemplatePath 解析变量路径
+//
+// 支持以点分隔的字段名（a.b.c）、方括号索引（a[0]）和数字片段（a.0），
+// 首个片段可以是this或@index、@first、@last等循环变量
+func parseTemplatePath(raw string) (*templatePath, error) {
+	path := &templatePath{Raw: raw}
+	runes := []rune(raw)
+	pos := 0
+
+	readName := func(allowAt bool) (string, error) {
+		start := pos
+		if allowAt && pos < len(runes) && runes[pos] == '@' {
+			pos++
+		}
+		for pos < len(runes) && (runes[pos] == '_' || unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos])) {
+			pos++
+		}
+		name := string(runes[start:pos])
+		if name == "" || name == "@" {
+			return "", fmt.Errorf("invalid path %q", raw)
+		}
+		return name, nil
+	}
+
+	name, err := readName(true)
+	if err != nil {
+		return nil, err
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		return nil, fmt.Errorf("invalid path %q", raw)
+	}
+	path.Segments = append(path.Segments, templatePathSegment{Name: name})
+
+	for pos < len(runes) {
+		switch runes[pos] {
+		case '.':
+			pos++
+			name, err := readName(false)
+			if err != nil {
+				return nil, err
+			}
+			segment := templatePathSegment{Name: name}
+			if index, err := strconv.Atoi(name); err == nil {
+				segment.Index, segment.IsIndex = index, true
+			}
+			path.Segments = append(path.Segments, segment)
+		case '[':
+			end := pos + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unclosed index in path %q", raw)
+			}
+			inner := strings.TrimSpace(string(runes[pos+1 : end]))
+			if index, err := strconv.Atoi(inner); err == nil && index >= 0 {
+				path.Segments = append(path.Segments, templatePathSegment{Name: inner, Index: index, IsIndex: true})
+			} else if key, ok := unquoteTemplateString(inner); ok {
+				path.Segments = append(path.Segments, templatePathSegment{Name: key})
+			} else {
+				return nil, fmt.Errorf("invalid index %q in path %q", inner, raw)
+			}
+			pos = end + 1
+		default:
+			return nil, fmt.Errorf("invalid path %q", raw)
+		}
+	}
+	return path, nil
+}
+
+// Root 返回路径的首个片段名称
+func (p *templatePath) Root() string {
+	return p.Segments[0].Name
+}
+
+// templateMember 获取值的成员：map的键、结构体字段或切片元素，自动解引用指针和接口
+func templateMember(value interface{}, segment templatePathSegment) (interface{}, bool) {
+	if m, ok := value.(map[string]interface{}); ok {
+		member, exists := m[segment.Name]
+		return member, exists
+	}
+
+	v := reflect.ValueOf(value)
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		member := v.MapIndex(reflect.ValueOf(segment.Name).Convert(v.Type().Key()))
+		if !member.IsValid() {
+			return nil, false
+		}
+		return member.Interface(), true
+
+	case reflect.Struct:
+		field, ok := templateStructField(v, segment.Name)
+		if !ok {
+			return nil, false
+		}
+		return field.Interface(), true
+
+	case reflect.Slice, reflect.Array:
+		if !segment.IsIndex || segment.Index >= v.Len() {
+			return nil, false
+		}
+		return v.Index(segment.Index).Interface(), true
+	}
+	return nil, false
+}
+
+// templateStructField 按wordzero标签、字段名、忽略大小写的字段名依次查找导出字段，标签为"-"的字段不可访问
+func templateStructField(v reflect.Value, name string) (reflect.Value, bool) {
+	fields := reflect.VisibleFields(v.Type())
+	match := func(accept func(field reflect.StructField, tagName string) bool) (reflect.Value, bool) {
+		for _, field := range fields {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			tagName, _, _ := strings.Cut(field.Tag.Get(templateStructTag), ",")
+			if tagName == "-" || !accept(field, tagName) {
+				continue
+			}
+			fieldValue, err := v.FieldByIndexErr(field.Index)
+			if err != nil || !fieldValue.CanInterface() {
+				return reflect.Value{}, false
+			}
+			return fieldValue, true
+		}
+		return reflect.Value{}, false
+	}
+
+	if value, ok := match(func(field reflect.StructField, tagName string) bool { return tagName == name }); ok {
+		return value, true
+	}
+	if value, ok := match(func(field reflect.StructField, tagName string) bool { return field.Name == name }); ok {
+		return value, true
+	}
+	return match(func(field reflect.StructField, tagName string) bool {
+		return strings.EqualFold(field.Name, name)
+	})
+}
+
+// templateFieldName 返回结构体字段在模板数据中的名称：wordzero标签或小写字段名
+func templateFieldName(field reflect.StructField) (string, bool) {
+	tagName, _, _ := strings.Cut(field.Tag.Get(templateStructTag), ",")
+	switch tagName {
+	case "-":
+		return "", false
+	case "":
+		return strings.ToLower(field.Name), true
+	}
+	return tagName, true
+}
+
+// maxTemplateValueDepth 结构体转换的最大嵌套深度，防止循环引用导致无限递归
+const maxTemplateValueDepth = 32
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// templateValue 将Go值递归转换为模板数据：结构体转换为map，切片转换为[]interface{}。
+// time.Time以及实现了fmt.Stringer的类型保持原值
+func templateValue(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxTemplateValueDepth {
+		return v.Interface()
+	}
+	if v.CanInterface() && isTemplateContent(v.Interface()) {
+		// 富内容变量值由渲染器展开，保持原类型
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Ptr && v.Type().Implements(reflect.TypeOf((*fmt.Stringer)(nil)).Elem()) {
+			return v.Interface()
+		}
+		return templateValue(v.Elem(), depth+1)
+
+	case reflect.Struct:
+		if v.Type() == timeType || v.Type().Implements(reflect.TypeOf((*fmt.Stringer)(nil)).Elem()) {
+			return v.Interface()
+		}
+		result := make(map[string]interface{})
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			name, ok := templateFieldName(field)
+			if !ok {
+				continue
+			}
+			fieldValue, err := v.FieldByIndexErr(field.Index)
+			if err != nil || !fieldValue.CanInterface() {
+				continue
+			}
+			result[name] = templateValue(fieldValue, depth+1)
+		}
+		return result
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		result := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result[iter.Key().String()] = templateValue(iter.Value(), depth+1)
+		}
+		return result
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		result := make([]interface{}, v.Len())
+		for i := range result {
+			result[i] = templateValue(v.Index(i), depth+1)
+		}
+		return result
+	}
+	return v.Interface()
+}
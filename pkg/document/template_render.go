@@ -0,0 +1,580 @@
+// Package document 模板语法树渲染
+package document
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// templateScope 循环作用域
+type templateScope struct {
+	item  interface{} // 当前循环项
+	index int         // 当前索引
+	count int         // 列表长度
+}
+
+// templateRenderer 遍历语法树并根据模板数据生成文档内容
+type templateRenderer struct {
+	te        *TemplateEngine
+	data      *TemplateData
+	doc       *Document
+	overrides map[string][]templateNode // 子模板重写的块
+	locale    *TemplateLocale           // 区域格式
+	localized bool                      // 是否通过SetLocale设置了区域格式
+	scopes    []templateScope
+	builder   *templateBuilder
+	partials  []string                                // 正在渲染的片段，用于检测循环引用
+	imported  map[*Document]*templatePartialResources // 已导入资源的片段基础文档
+	options   TemplateRenderOptions                   // 渲染选项
+	report    *RenderReport                           // 渲染报告
+	missing   map[templateMissingKey]bool             // 已记录的无法解析的占位符
+	used      map[string]bool                         // 模板使用的数据键
+	compiled  *CompiledTemplate                       // 预编译模板，非nil时过滤器与片段从编译结果中获取
+}
+
+// newTemplateRenderer 创建渲染器，输出元素写入doc
+func (te *TemplateEngine) newTemplateRenderer(doc *Document, data *TemplateData, overrides map[string][]templateNode) *templateRenderer {
+	if data == nil {
+		data = NewTemplateData()
+	}
+	locale := te.currentLocale()
+	return &templateRenderer{
+		te:        te,
+		data:      data,
+		doc:       doc,
+		overrides: overrides,
+		locale:    te.GetLocale(),
+		localized: locale != nil,
+		builder:   newTemplateBuilder(te, doc),
+		options:   te.GetRenderOptions(),
+		report:    &RenderReport{},
+		missing:   make(map[templateMissingKey]bool),
+		used:      make(map[string]bool),
+	}
+}
+
+// render 渲染节点列表并返回生成的文档元素
+func (r *templateRenderer) render(nodes []templateNode) ([]interface{}, error) {
+	if err := r.renderNodes(nodes); err != nil {
+		return nil, err
+	}
+	return r.builder.finish(), nil
+}
+
+func (r *templateRenderer) renderNodes(nodes []templateNode) error {
+	for _, node := range nodes {
+		if err := r.renderNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *templateRenderer) renderNode(node templateNode) error {
+	switch n := node.(type) {
+	case *templateToken:
+		r.builder.emit(n)
+
+	case *templateOutputNode:
+		if r.checkMissing(n.Tag, "variable", n.Tag.Expr) {
+			if r.options.MissingValue != "" {
+				r.builder.addText(r.options.MissingValue, n.Run, n.Para)
+				return nil
+			}
+			if n.Tag.Path != nil {
+				// 变量不存在，保持原始占位符
+				r.builder.addText(n.Tag.Raw, n.Run, n.Para)
+				return nil
+			}
+		}
+		value, err := r.eval(n.Tag.Expr)
+		if err != nil {
+			return newTemplateRenderError(n.Tag, err)
+		}
+		if r.renderContent(value, n.Run, n.Para) {
+			return nil
+		}
+		r.builder.addText(r.format(value), n.Run, n.Para)
+
+	case *templateIfNode:
+		for _, branch := range n.Branches {
+			r.checkMissing(branch.Tag, "condition", branch.Expr)
+			value, err := r.eval(branch.Expr)
+			if err != nil {
+				return newTemplateRenderError(branch.Tag, err)
+			}
+			if isTemplateTruthy(value) {
+				return r.renderNodes(branch.Body)
+			}
+		}
+		return r.renderNodes(n.Else)
+
+	case *templateEachNode:
+		list := r.list(n.Path)
+		if list == nil {
+			if _, ok := r.resolvePath(n.Path); !ok {
+				r.addMissing(n.Open, "list", n.List)
+			}
+		}
+		if len(list) == 0 {
+			return r.renderNodes(n.Else)
+		}
+		for i, item := range list {
+			r.scopes = append(r.scopes, templateScope{item: item, index: i, count: len(list)})
+			err := r.renderNodes(n.Body)
+			r.scopes = r.scopes[:len(r.scopes)-1]
+			if err != nil {
+				return err
+			}
+		}
+
+	case *templateBlockNode:
+		if body, exists := r.overrides[n.Name]; exists {
+			return r.renderNodes(body)
+		}
+		return r.renderNodes(n.Body)
+
+	case *templatePartialNode:
+		return r.renderPartial(n)
+
+	case *templateImageNode:
+		imageData, exists := r.data.Images[n.Tag.Arg]
+		if !exists {
+			r.addMissing(n.Tag, "image", n.Tag.Arg)
+			r.builder.addParagraph(r.te.createTextParagraph("[图片未找到: "+n.Tag.Arg+"]", r.builder.paragraphSource(n.Para)))
+			return nil
+		}
+		r.use(n.Tag.Arg)
+		imagePara, err := r.te.createImageParagraph(imageData, r.doc)
+		if err != nil {
+			return WrapErrorWithContext("render_image", err, n.Tag.Arg)
+		}
+		r.builder.addParagraph(imagePara)
+	}
+	return nil
+}
+
+// format 将输出值转换为文本：时间按区域日期格式输出，设置了区域时浮点数按区域的千分位与小数点输出
+func (r *templateRenderer) format(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return r.locale.formatDate(v, "")
+	case *time.Time:
+		if v != nil {
+			return r.locale.formatDate(*v, "")
+		}
+	case float64:
+		if r.localized {
+			return r.locale.formatNumber(v, -1)
+		}
+	case float32:
+		if r.localized {
+			return r.locale.formatNumber(float64(v), -1)
+		}
+	}
+	return r.te.interfaceToString(value)
+}
+
+// lookupScope 在循环作用域中查找名称（包括this、@index、@first、@last），由内向外查找循环项的字段
+func (r *templateRenderer) lookupScope(name string) (interface{}, bool) {
+	if len(r.scopes) > 0 {
+		scope := r.scopes[len(r.scopes)-1]
+		switch name {
+		case "this":
+			return scope.item, true
+		case "@index":
+			return scope.index, true
+		case "@first":
+			return scope.index == 0, true
+		case "@last":
+			return scope.index == scope.count-1, true
+		}
+	}
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if value, ok := templateMember(r.scopes[i].item, templatePathSegment{Name: name}); ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// resolve 解析路径的其余片段
+func (r *templateRenderer) resolve(root interface{}, path *templatePath) (interface{}, bool) {
+	value := root
+	for _, segment := range path.Segments[1:] {
+		member, ok := templateMember(value, segment)
+		if !ok {
+			return nil, false
+		}
+		value = member
+	}
+	return value, true
+}
+
+// resolvePath 查找变量值：首个片段依次在循环作用域、条件数据（仅单个片段）、变量、列表中查找
+func (r *templateRenderer) resolvePath(path *templatePath) (interface{}, bool) {
+	name := path.Root()
+	if value, ok := r.lookupScope(name); ok {
+		return r.resolve(value, path)
+	}
+	if flag, ok := r.data.Conditions[name]; ok && len(path.Segments) == 1 {
+		r.use(name)
+		return flag, true
+	}
+	if value, ok := r.data.Variables[name]; ok {
+		r.use(name)
+		return r.resolve(value, path)
+	}
+	if list, ok := r.data.Lists[name]; ok {
+		r.use(name)
+		return r.resolve(list, path)
+	}
+	return nil, false
+}
+
+// TemplateRenderError 模板渲染错误，包含出错标签及其位置
+//
+// 位置的含义与TemplateSyntaxError相同。可通过errors.Is(err, ErrTemplateRenderError)判断错误类型，
+// 过滤器返回的原始错误同样可以通过errors.Is/errors.As获取。
+type TemplateRenderError struct {
+	Line   int    // 行号（从1开始）
+	Column int    // 列号（从1开始）
+	Tag    string // 出错的标签原文
+	Err    error  // 原始错误
+}
+
+// Error 实现error接口
+func (e *TemplateRenderError) Error() string {
+	return fmt.Sprintf("template render error at line %d, column %d: %s: %v", e.Line, e.Column, e.Tag, e.Err)
+}
+
+// Unwrap 解包为ErrTemplateRenderError和原始错误
+func (e *TemplateRenderError) Unwrap() []error {
+	return []error{ErrTemplateRenderError, e.Err}
+}
+
+// newTemplateRenderError 创建带标签位置的渲染错误
+func newTemplateRenderError(tag *templateTag, err error) error {
+	return &TemplateRenderError{Line: tag.Line, Column: tag.Column, Tag: tag.Raw, Err: err}
+}
+
+// list 查找循环列表：首个片段依次在循环作用域、列表数据、变量中查找
+func (r *templateRenderer) list(path *templatePath) []interface{} {
+	name := path.Root()
+	var value interface{}
+	if scoped, ok := r.lookupScope(name); ok {
+		value = scoped
+	} else if list, ok := r.data.Lists[name]; ok {
+		r.use(name)
+		value = list
+	} else if variable, ok := r.data.Variables[name]; ok {
+		r.use(name)
+		value = variable
+	} else {
+		return nil
+	}
+
+	resolved, ok := r.resolve(value, path)
+	if !ok {
+		return nil
+	}
+	return templateSlice(resolved)
+}
+
+// templateSlice 将切片或数组转换为[]interface{}
+func templateSlice(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil
+	}
+	list := make([]interface{}, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list
+}
+
+// isTemplateTruthy 判断值在条件中是否为真
+func isTemplateTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != "" && v != "false" && v != "0"
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() != 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() != 0
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	}
+	return true
+}
+
+// templateFrame 构建中的容器（文档主体、表格、行或单元格）
+type templateFrame struct {
+	elements []interface{} // 文档主体或单元格中已完成的元素
+	para     *Paragraph    // 当前打开的段落
+	lastRun  *Run          // 最近追加文本的来源Run，用于合并相邻文本
+	table    *Table
+	row      *TableRow
+	cell     *TableCell
+	source   *TableCell // 来源单元格，单元格内容为空时用于创建空段落
+}
+
+// templateBuilder 根据渲染出的词法单元构建文档元素，保持来源段落与Run的样式。
+// 循环中重复输出的绘图和书签会分配新的ID，避免输出文档中出现重复ID
+type templateBuilder struct {
+	te          *TemplateEngine
+	doc         *Document
+	frames      []*templateFrame
+	drawingIDs  map[string]bool           // 已输出的绘图ID
+	bookmarks   map[string]int            // 书签ID -> 已输出次数
+	bookmarkIDs map[string]string         // 原书签ID -> 最近一次输出使用的ID
+	resources   *templatePartialResources // 正在渲染的片段的资源映射，渲染主模板时为nil
+}
+
+func newTemplateBuilder(te *TemplateEngine, doc *Document) *templateBuilder {
+	return &templateBuilder{
+		te:          te,
+		doc:         doc,
+		frames:      []*templateFrame{{}},
+		drawingIDs:  make(map[string]bool),
+		bookmarks:   make(map[string]int),
+		bookmarkIDs: make(map[string]string),
+	}
+}
+
+func (b *templateBuilder) top() *templateFrame {
+	return b.frames[len(b.frames)-1]
+}
+
+func (b *templateBuilder) push(frame *templateFrame) {
+	b.top().flush()
+	b.frames = append(b.frames, frame)
+}
+
+func (b *templateBuilder) pop() *templateFrame {
+	frame := b.top()
+	frame.flush()
+	b.frames = b.frames[:len(b.frames)-1]
+	return frame
+}
+
+// flush 结束当前段落
+func (f *templateFrame) flush() {
+	if f.para != nil {
+		f.elements = append(f.elements, f.para)
+		f.para = nil
+	}
+	f.lastRun = nil
+}
+
+// emit 输出结构或文本词法单元
+func (b *templateBuilder) emit(token *templateToken) {
+	switch token.Kind {
+	case tokenText:
+		b.addText(token.Text, token.Run, token.Para)
+
+	case tokenRun:
+		frame := b.ensureParagraph(token.Para)
+		run := b.te.cloneRun(token.Run)
+		run.Text = Text{}
+		run.Drawing = mapDrawingTree(run.Drawing, func(drawing *DrawingElement) *DrawingElement {
+			return b.uniqueDrawing(b.resources.drawing(drawing))
+		})
+		frame.para.Runs = append(frame.para.Runs, run)
+		frame.lastRun = nil
+
+	case tokenParaStart:
+		frame := b.top()
+		frame.flush()
+		frame.para = b.newParagraph(token.Para)
+
+	case tokenParaEnd:
+		b.top().flush()
+
+	case tokenElement:
+		if _, ok := token.Element.(*SectionProperties); ok && b.resources != nil {
+			// 片段的节属性不插入输出文档
+			return
+		}
+		frame := b.top()
+		frame.flush()
+		frame.elements = append(frame.elements, b.uniqueElement(token.Element))
+
+	case tokenTableStart:
+		b.push(&templateFrame{table: &Table{
+			Properties: b.te.cloneTableProperties(token.Table.Properties),
+			Grid:       b.te.cloneTableGrid(token.Table.Grid),
+		}})
+
+	case tokenRowStart:
+		b.push(&templateFrame{row: &TableRow{Properties: b.te.cloneTableRowProperties(token.Row.Properties)}})
+
+	case tokenCellStart:
+		b.push(&templateFrame{
+			cell:   &TableCell{Properties: b.te.cloneTableCellProperties(token.Cell.Properties)},
+			source: token.Cell,
+		})
+
+	case tokenCellEnd:
+		frame := b.pop()
+		frame.cell.SetElements(frame.elements)
+		// 单元格至少需要一个段落
+		if len(frame.cell.Paragraphs) == 0 {
+			var source *Paragraph
+			if len(frame.source.Paragraphs) > 0 {
+				source = &frame.source.Paragraphs[0]
+			}
+			frame.cell.Paragraphs = append(frame.cell.Paragraphs, *b.newParagraph(source))
+		}
+		parent := b.top()
+		parent.row.Cells = append(parent.row.Cells, *frame.cell)
+
+	case tokenRowEnd:
+		frame := b.pop()
+		if len(frame.row.Cells) > 0 {
+			parent := b.top()
+			parent.table.Rows = append(parent.table.Rows, *frame.row)
+		}
+
+	case tokenTableEnd:
+		frame := b.pop()
+		if len(frame.table.Rows) > 0 {
+			parent := b.top()
+			parent.elements = append(parent.elements, frame.table)
+		}
+	}
+}
+
+// uniqueDrawing 绘图ID已输出过时复制绘图并分配新ID
+func (b *templateBuilder) uniqueDrawing(drawing *DrawingElement) *DrawingElement {
+	docPr := drawingDocPr(drawing)
+	if docPr == nil {
+		return drawing
+	}
+	if !b.drawingIDs[docPr.ID] {
+		b.drawingIDs[docPr.ID] = true
+		return drawing
+	}
+
+	id := strconv.Itoa(b.doc.nextImageID)
+	for b.drawingIDs[id] {
+		b.doc.nextImageID++
+		id = strconv.Itoa(b.doc.nextImageID)
+	}
+	b.doc.nextImageID++
+	b.drawingIDs[id] = true
+
+	newDocPr := *docPr
+	newDocPr.ID = id
+	clone := *drawing
+	if drawing.Inline != nil {
+		inline := *drawing.Inline
+		inline.DocPr = &newDocPr
+		clone.Inline = &inline
+	}
+	if drawing.Anchor != nil {
+		anchor := *drawing.Anchor
+		anchor.DocPr = &newDocPr
+		clone.Anchor = &anchor
+	}
+	return &clone
+}
+
+// uniqueElement 重复输出的书签使用带序号后缀的ID和名称，如 chapter_2
+func (b *templateBuilder) uniqueElement(element interface{}) interface{} {
+	switch e := element.(type) {
+	case *BookmarkStart:
+		count := b.bookmarks[e.ID]
+		b.bookmarks[e.ID]++
+		if count == 0 {
+			b.bookmarkIDs[e.ID] = e.ID
+			return e
+		}
+		suffix := "_" + strconv.Itoa(count+1)
+		clone := &BookmarkStart{ID: e.ID + suffix, Name: e.Name + suffix}
+		b.bookmarkIDs[e.ID] = clone.ID
+		return clone
+	case *BookmarkEnd:
+		if id, ok := b.bookmarkIDs[e.ID]; ok && id != e.ID {
+			return &BookmarkEnd{ID: id}
+		}
+	}
+	return element
+}
+
+// newParagraph 创建继承来源段落属性的空段落
+func (b *templateBuilder) newParagraph(source *Paragraph) *Paragraph {
+	if source == nil {
+		return &Paragraph{}
+	}
+	return &Paragraph{Properties: b.resources.paragraphProperties(b.te.cloneParagraphProperties(source.Properties))}
+}
+
+// ensureParagraph 确保当前容器中有打开的段落
+func (b *templateBuilder) ensureParagraph(source *Paragraph) *templateFrame {
+	frame := b.top()
+	if frame.para == nil {
+		frame.para = b.newParagraph(source)
+	}
+	return frame
+}
+
+// paragraphSource 返回图片等独立段落应继承样式的段落
+func (b *templateBuilder) paragraphSource(source *Paragraph) *Paragraph {
+	if source == nil {
+		return &Paragraph{}
+	}
+	return source
+}
+
+// addText 追加文本，来自同一Run的相邻文本合并为一个Run
+func (b *templateBuilder) addText(text string, source *Run, para *Paragraph) {
+	if text == "" {
+		return
+	}
+	frame := b.ensureParagraph(para)
+	runs := frame.para.Runs
+	if source != nil && frame.lastRun == source && len(runs) > 0 {
+		runs[len(runs)-1].Text.Content += text
+		return
+	}
+
+	run := Run{Text: Text{Content: text, Space: "preserve"}}
+	if source != nil {
+		run.Properties = b.te.cloneRunProperties(source.Properties)
+	}
+	frame.para.Runs = append(runs, run)
+	frame.lastRun = source
+}
+
+// addParagraph 追加独立段落（如图片段落）
+func (b *templateBuilder) addParagraph(para *Paragraph) {
+	frame := b.top()
+	frame.flush()
+	frame.elements = append(frame.elements, para)
+}
+
+// finish 结束构建并返回文档元素
+func (b *templateBuilder) finish() []interface{} {
+	frame := b.frames[0]
+	frame.flush()
+	return frame.elements
+}
@@ -0,0 +1,188 @@
+// Package document 模板渲染选项与渲染报告
+package document
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TemplateRenderOptions 模板渲染选项
+type TemplateRenderOptions struct {
+	// Strict 严格模式：存在无法解析的占位符时渲染失败，返回列出所有占位符及其位置的*TemplateMissingDataError
+	Strict bool
+	// MissingValue 无法解析的变量输出的文本，如"____"；为空时保留原始占位符
+	MissingValue string
+}
+
+// MissingPlaceholder 渲染时无法解析的占位符
+type MissingPlaceholder struct {
+	Kind   string // 占位符类型：variable、condition、list、image
+	Name   string // 无法解析的变量路径或图片名称
+	Tag    string // 标签原文
+	Line   int    // 行号（从1开始，含义与TemplateSyntaxError相同）
+	Column int    // 列号（从1开始）
+}
+
+// RenderReport 模板渲染报告
+type RenderReport struct {
+	Missing    []MissingPlaceholder // 无法解析的占位符，按渲染时首次出现的顺序排列，循环中的同一标签只记录一次
+	UnusedData []string             // 模板未使用的数据键（变量、列表、条件和图片名称），按名称排序
+	Warnings   []string             // 可读的警告信息
+}
+
+// HasIssues 检查报告中是否存在无法解析的占位符或未使用的数据
+func (r *RenderReport) HasIssues() bool {
+	return len(r.Missing) > 0 || len(r.UnusedData) > 0
+}
+
+// TemplateMissingDataError 严格模式下存在无法解析的占位符时返回的错误
+//
+// 可通过errors.Is(err, ErrTemplateMissingData)判断错误类型。
+type TemplateMissingDataError struct {
+	Missing []MissingPlaceholder
+}
+
+// Error 实现error接口
+func (e *TemplateMissingDataError) Error() string {
+	items := make([]string, len(e.Missing))
+	for i, missing := range e.Missing {
+		items[i] = fmt.Sprintf("line %d, column %d: %s (%s %s)", missing.Line, missing.Column, missing.Tag, missing.Kind, missing.Name)
+	}
+	return fmt.Sprintf("template has %d unresolved placeholder(s): %s", len(e.Missing), strings.Join(items, "; "))
+}
+
+// Unwrap 解包为ErrTemplateMissingData
+func (e *TemplateMissingDataError) Unwrap() error {
+	return ErrTemplateMissingData
+}
+
+// SetRenderOptions 设置模板渲染选项
+func (te *TemplateEngine) SetRenderOptions(options TemplateRenderOptions) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+	te.options = options
+}
+
+// GetRenderOptions 获取模板渲染选项
+func (te *TemplateEngine) GetRenderOptions() TemplateRenderOptions {
+	te.mutex.RLock()
+	defer te.mutex.RUnlock()
+	return te.options
+}
+
+// RenderTemplateWithReport 渲染模板并返回渲染报告
+//
+// 严格模式下存在无法解析的占位符时，返回nil文档、渲染报告和*TemplateMissingDataError。
+func (te *TemplateEngine) RenderTemplateWithReport(templateName string, data *TemplateData) (*Document, *RenderReport, error) {
+	template, err := te.GetTemplate(templateName)
+	if err != nil {
+		return nil, nil, WrapErrorWithContext("render_template_with_report", err, templateName)
+	}
+
+	doc, report, err := te.renderTemplateDocument(template, data)
+	if err != nil {
+		return nil, report, WrapErrorWithContext("render_template_with_report", err, templateName)
+	}
+	return doc, report, nil
+}
+
+// templateMissingKey 占位符去重键
+type templateMissingKey struct {
+	tag  *templateTag
+	name string
+}
+
+// checkMissing 记录表达式中无法解析的变量路径，返回是否存在无法解析的路径。
+// 使用default过滤器的表达式视为已处理缺失值；聚合函数参数只检查首个片段
+func (r *templateRenderer) checkMissing(tag *templateTag, kind string, expr templateExpr) bool {
+	switch e := expr.(type) {
+	case *templatePathExpr:
+		if _, ok := r.resolvePath(e.Path); !ok {
+			r.addMissing(tag, kind, e.Path.Raw)
+			return true
+		}
+	case *templateNotExpr:
+		return r.checkMissing(tag, kind, e.X)
+	case *templateBinaryExpr:
+		left := r.checkMissing(tag, kind, e.Left)
+		right := r.checkMissing(tag, kind, e.Right)
+		return left || right
+	case *templateCallExpr:
+		missing := false
+		for _, arg := range e.Args {
+			pathExpr, ok := arg.(*templatePathExpr)
+			if !ok {
+				missing = r.checkMissing(tag, kind, arg) || missing
+				continue
+			}
+			root := &templatePath{Raw: pathExpr.Path.Root(), Segments: pathExpr.Path.Segments[:1]}
+			if _, ok := r.resolvePath(root); !ok {
+				r.addMissing(tag, kind, pathExpr.Path.Raw)
+				missing = true
+			}
+		}
+		return missing
+	case *templatePipelineExpr:
+		for _, filter := range e.Filters {
+			if filter.Name == "default" {
+				return false
+			}
+		}
+		return r.checkMissing(tag, kind, e.X)
+	}
+	return false
+}
+
+// addMissing 记录无法解析的占位符
+func (r *templateRenderer) addMissing(tag *templateTag, kind, name string) {
+	key := templateMissingKey{tag: tag, name: name}
+	if r.missing[key] {
+		return
+	}
+	r.missing[key] = true
+	r.report.Missing = append(r.report.Missing, MissingPlaceholder{
+		Kind:   kind,
+		Name:   name,
+		Tag:    tag.Raw,
+		Line:   tag.Line,
+		Column: tag.Column,
+	})
+}
+
+// use 记录模板使用的数据键
+func (r *templateRenderer) use(name string) {
+	r.used[name] = true
+}
+
+// finishReport 完成渲染报告：收集未使用的数据键并生成警告信息
+func (r *templateRenderer) finishReport() *RenderReport {
+	unused := make(map[string]bool)
+	for name := range r.data.Variables {
+		unused[name] = !r.used[name]
+	}
+	for name := range r.data.Lists {
+		unused[name] = !r.used[name]
+	}
+	for name := range r.data.Conditions {
+		unused[name] = !r.used[name]
+	}
+	for name := range r.data.Images {
+		unused[name] = !r.used[name]
+	}
+	for name, isUnused := range unused {
+		if isUnused {
+			r.report.UnusedData = append(r.report.UnusedData, name)
+		}
+	}
+	sort.Strings(r.report.UnusedData)
+
+	for _, missing := range r.report.Missing {
+		r.report.Warnings = append(r.report.Warnings, fmt.Sprintf("unresolved %s %q in %s at line %d, column %d",
+			missing.Kind, missing.Name, missing.Tag, missing.Line, missing.Column))
+	}
+	for _, name := range r.report.UnusedData {
+		r.report.Warnings = append(r.report.Warnings, fmt.Sprintf("data key %q is not used by the template", name))
+	}
+	return r.report
+}
@@ -0,0 +1,574 @@
+// Package document 模板数据结构提取与数据校验
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// templateJSONSchemaDraft 导出的JSON Schema版本
+const templateJSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// TemplateSchema 模板所需数据的结构，可导出为JSON Schema
+//
+// 根节点为object，属性包括变量、列表、条件和图片：
+// 变量按用法推断为string、number或object，{{#each}}的来源为array且items描述循环项字段，
+// 只用于条件判断的变量为boolean，图片为format为"image"的string。
+type TemplateSchema struct {
+	Schema     string                     `json:"$schema,omitempty"`    // JSON Schema版本（仅根节点）
+	Title      string                     `json:"title,omitempty"`      // 标题（根节点为模板名称）
+	Type       string                     `json:"type,omitempty"`       // object、array、string、number、boolean，为空表示任意类型
+	Format     string                     `json:"format,omitempty"`     // 格式：image（图片）、date-time（日期）
+	Properties map[string]*TemplateSchema `json:"properties,omitempty"` // 对象属性
+	Items      *TemplateSchema            `json:"items,omitempty"`      // 数组元素
+	Required   []string                   `json:"required,omitempty"`   // 必需属性
+
+	rank int // 类型推断的可信程度，见templateSchemaRank
+}
+
+// 类型推断的可信程度，较高的推断覆盖较低的推断
+const (
+	schemaRankTruthy     = 1 // 仅用于条件判断
+	schemaRankOutput     = 2 // 直接输出
+	schemaRankTyped      = 3 // 与字面量比较或使用数字、日期过滤器
+	schemaRankStructural = 4 // 路径中间片段或循环来源
+)
+
+// ToJSONSchema 导出为JSON Schema文本
+func (s *TemplateSchema) ToJSONSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, WrapError("marshal_json_schema", err)
+	}
+	return data, nil
+}
+
+// setType 设置推断类型，可信程度不低于已有推断时覆盖
+func (s *TemplateSchema) setType(typ, format string, rank int) {
+	if rank < s.rank || (rank == s.rank && s.Type != "") {
+		return
+	}
+	s.Type, s.Format, s.rank = typ, format, rank
+}
+
+// property 获取或创建对象属性，required为true时将属性加入必需列表
+func (s *TemplateSchema) property(name string, required bool) *TemplateSchema {
+	s.setType("object", "", schemaRankStructural)
+	if s.Properties == nil {
+		s.Properties = make(map[string]*TemplateSchema)
+	}
+	child, ok := s.Properties[name]
+	if !ok {
+		child = &TemplateSchema{}
+		s.Properties[name] = child
+	}
+	if required && !containsString(s.Required, name) {
+		s.Required = append(s.Required, name)
+		sort.Strings(s.Required)
+	}
+	return child
+}
+
+// items 获取或创建数组元素
+func (s *TemplateSchema) items() *TemplateSchema {
+	s.setType("array", "", schemaRankStructural)
+	if s.Items == nil {
+		s.Items = &TemplateSchema{}
+	}
+	return s.Items
+}
+
+// memberOrItemField 获取或创建对象属性或列表项字段，用于无法确定是对象还是列表的节点：
+// 节点本身不推断类型，properties和items共用同一个字段结构
+func (s *TemplateSchema) memberOrItemField(name string, required bool) *TemplateSchema {
+	if s.Items == nil {
+		s.Items = &TemplateSchema{}
+	}
+	child := s.Items.property(name, required)
+	if s.Properties == nil {
+		s.Properties = make(map[string]*TemplateSchema)
+	}
+	s.Properties[name] = child
+	if required && !containsString(s.Required, name) {
+		s.Required = append(s.Required, name)
+		sort.Strings(s.Required)
+	}
+	return child
+}
+
+// containsString 检查字符串切片是否包含指定值
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractSchema 根据模板语法树提取模板所需数据的结构
+//
+// 继承的父模板、重写的块和引用的片段一并分析。循环内的变量名如果此前已在循环外使用，
+// 视为全局变量，否则视为循环项的字段。
+func (te *TemplateEngine) ExtractSchema(templateName string) (*TemplateSchema, error) {
+	template, err := te.GetTemplate(templateName)
+	if err != nil {
+		return nil, WrapErrorWithContext("extract_schema", err, templateName)
+	}
+	root, overrides, err := templateInheritance(template)
+	if err != nil {
+		return nil, WrapErrorWithContext("extract_schema", err, templateName)
+	}
+
+	builder := &templateSchemaBuilder{
+		te:        te,
+		root:      &TemplateSchema{Schema: templateJSONSchemaDraft, Title: templateName, Type: "object", rank: schemaRankStructural},
+		overrides: overrides,
+		partials:  []string{templateName},
+	}
+	if err := builder.walk(root.nodes); err != nil {
+		return nil, WrapErrorWithContext("extract_schema", err, templateName)
+	}
+	builder.resolveAggregates()
+	if builder.root.Properties == nil {
+		builder.root.Properties = make(map[string]*TemplateSchema)
+	}
+	return builder.root, nil
+}
+
+// templateSchemaBuilder 遍历语法树推断数据结构
+type templateSchemaBuilder struct {
+	te        *TemplateEngine
+	root      *TemplateSchema
+	scopes    []*TemplateSchema         // 循环项结构，由外向内
+	overrides map[string][]templateNode // 子模板重写的块
+	partials  []string                  // 正在分析的模板与片段，用于跳过循环引用
+
+	aggregates []templateSchemaAggregate // 聚合函数参数，遍历结束后推断
+}
+
+// templateSchemaAggregate 聚合函数参数：首个片段对应的节点与其余片段
+type templateSchemaAggregate struct {
+	name     string
+	node     *TemplateSchema
+	segments []templatePathSegment
+	required bool
+}
+
+func (b *templateSchemaBuilder) walk(nodes []templateNode) error {
+	for _, node := range nodes {
+		if err := b.walkNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *templateSchemaBuilder) walkNode(node templateNode) error {
+	switch n := node.(type) {
+	case *templateOutputNode:
+		b.expr(n.Tag.Expr, schemaRankOutput, true)
+
+	case *templateIfNode:
+		for _, branch := range n.Branches {
+			b.expr(branch.Expr, schemaRankTruthy, false)
+			if err := b.walk(branch.Body); err != nil {
+				return err
+			}
+		}
+		return b.walk(n.Else)
+
+	case *templateEachNode:
+		if list := b.lookup(n.Path, true); list != nil {
+			b.scopes = append(b.scopes, list.items())
+			err := b.walk(n.Body)
+			b.scopes = b.scopes[:len(b.scopes)-1]
+			if err != nil {
+				return err
+			}
+		}
+		return b.walk(n.Else)
+
+	case *templateBlockNode:
+		if body, exists := b.overrides[n.Name]; exists {
+			return b.walk(body)
+		}
+		return b.walk(n.Body)
+
+	case *templateImageNode:
+		b.root.property(n.Tag.Arg, true).setType("string", "image", schemaRankStructural)
+
+	case *templatePartialNode:
+		if containsString(b.partials, n.Tag.Arg) {
+			return nil
+		}
+		template, err := b.te.loadPartial(n.Tag.Arg)
+		if err != nil {
+			return err
+		}
+		root, overrides, err := templateInheritance(template)
+		if err != nil {
+			return err
+		}
+		parentOverrides := b.overrides
+		b.overrides = overrides
+		b.partials = append(b.partials, n.Tag.Arg)
+		err = b.walk(root.nodes)
+		b.partials = b.partials[:len(b.partials)-1]
+		b.overrides = parentOverrides
+		return err
+	}
+	return nil
+}
+
+// expr 根据表达式中变量的用法推断类型，rank为直接使用变量时的推断
+func (b *templateSchemaBuilder) expr(expr templateExpr, rank int, required bool) {
+	switch e := expr.(type) {
+	case *templatePathExpr:
+		if s := b.lookup(e.Path, required); s != nil {
+			if rank == schemaRankTruthy {
+				s.setType("boolean", "", rank)
+			} else {
+				s.setType("string", "", rank)
+			}
+		}
+
+	case *templateNotExpr:
+		b.expr(e.X, rank, required)
+
+	case *templateBinaryExpr:
+		if e.Op == "and" || e.Op == "or" {
+			b.expr(e.Left, rank, required)
+			b.expr(e.Right, rank, required)
+			return
+		}
+		b.compare(e.Left, e.Right, required)
+		b.compare(e.Right, e.Left, required)
+
+	case *templatePipelineExpr:
+		pathExpr, isPath := e.X.(*templatePathExpr)
+		typ, format := "", ""
+		for _, filter := range e.Filters {
+			switch filter.Name {
+			case "default":
+				required = false
+			case "number", "currency", "rmb":
+				typ = "number"
+			case "date":
+				typ, format = "string", "date-time"
+			}
+		}
+		if !isPath || typ == "" {
+			b.expr(e.X, rank, required)
+			return
+		}
+		if s := b.lookup(pathExpr.Path, required); s != nil {
+			s.setType(typ, format, schemaRankTyped)
+		}
+
+	case *templateCallExpr:
+		for _, arg := range e.Args {
+			pathExpr, ok := arg.(*templatePathExpr)
+			if !ok {
+				b.expr(arg, rank, required)
+				continue
+			}
+			b.aggregate(e.Name, pathExpr.Path, required)
+		}
+	}
+}
+
+// compare 根据比较运算另一侧的字面量推断变量类型
+func (b *templateSchemaBuilder) compare(side, other templateExpr, required bool) {
+	pathExpr, ok := side.(*templatePathExpr)
+	if !ok {
+		b.expr(side, schemaRankOutput, required)
+		return
+	}
+	s := b.lookup(pathExpr.Path, required)
+	if s == nil {
+		return
+	}
+	literal, ok := other.(*templateLiteralExpr)
+	if !ok {
+		s.setType("string", "", schemaRankOutput)
+		return
+	}
+	switch literal.Value.(type) {
+	case int, float64:
+		s.setType("number", "", schemaRankTyped)
+	case bool:
+		s.setType("boolean", "", schemaRankTyped)
+	default:
+		s.setType("string", "", schemaRankOutput)
+	}
+}
+
+// aggregate 记录聚合函数的参数，如 sum lines.amount、sum groups.lines.amount
+//
+// 与渲染时的collect一致，路径片段既可能是对象属性也可能是列表项的字段，
+// 需要其他用法确定路径经过的节点类型，因此在遍历结束后由resolveAggregates推断。
+func (b *templateSchemaBuilder) aggregate(name string, path *templatePath, required bool) {
+	head := &templatePath{Raw: path.Root(), Segments: path.Segments[:1]}
+	node := b.lookup(head, required)
+	if node == nil {
+		return
+	}
+	b.aggregates = append(b.aggregates, templateSchemaAggregate{
+		name:     name,
+		node:     node,
+		segments: path.Segments[1:],
+		required: required,
+	})
+}
+
+// resolveAggregates 推断聚合函数参数经过的节点：已知为数组的节点取列表项的字段，
+// 已知为对象的节点取属性，类型未知的节点同时描述两种情况；除count外末端值为数字或数字列表
+func (b *templateSchemaBuilder) resolveAggregates() {
+	for _, aggregate := range b.aggregates {
+		current := aggregate.node
+		if len(aggregate.segments) == 0 {
+			current = current.items()
+		}
+		for _, segment := range aggregate.segments {
+			switch {
+			case segment.IsIndex:
+				current = current.items()
+			case current.Type == "array":
+				current = current.items().property(segment.Name, aggregate.required)
+			case current.Type == "object":
+				current = current.property(segment.Name, aggregate.required)
+			default:
+				current = current.memberOrItemField(segment.Name, aggregate.required)
+			}
+		}
+		if current.Type == "array" {
+			current = current.items()
+		}
+		if aggregate.name != "count" {
+			current.setType("number", "", schemaRankTyped)
+		}
+	}
+}
+
+// lookup 返回路径对应的结构节点，路径经过的节点推断为对象或数组；
+// 循环变量（@index等）返回nil
+func (b *templateSchemaBuilder) lookup(path *templatePath, required bool) *TemplateSchema {
+	name := path.Root()
+	if strings.HasPrefix(name, "@") {
+		return nil
+	}
+
+	var current *TemplateSchema
+	switch {
+	case name == "this" && len(b.scopes) > 0:
+		current = b.scopes[len(b.scopes)-1]
+	case name == "this":
+		return nil
+	case len(b.scopes) > 0 && b.root.Properties[name] == nil:
+		current = b.scopes[len(b.scopes)-1].property(name, required)
+	default:
+		current = b.root.property(name, required)
+	}
+
+	for _, segment := range path.Segments[1:] {
+		if segment.IsIndex {
+			current = current.items()
+		} else {
+			current = current.property(segment.Name, required)
+		}
+	}
+	return current
+}
+
+// SchemaViolation 数据不符合模板数据结构的位置与原因
+type SchemaViolation struct {
+	Path    string // 数据路径，如 items[1].qty
+	Message string // 原因
+}
+
+// TemplateDataValidationError 模板数据校验错误，包含所有不符合结构的位置
+//
+// 可通过errors.Is(err, ErrInvalidTemplateData)判断错误类型。
+type TemplateDataValidationError struct {
+	Violations []SchemaViolation
+}
+
+// Error 实现error接口
+func (e *TemplateDataValidationError) Error() string {
+	items := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		items[i] = violation.Path + ": " + violation.Message
+	}
+	return fmt.Sprintf("template data validation failed: %s", strings.Join(items, "; "))
+}
+
+// Unwrap 解包为ErrInvalidTemplateData
+func (e *TemplateDataValidationError) Unwrap() error {
+	return ErrInvalidTemplateData
+}
+
+// ValidateData 在渲染前按模板数据结构校验数据，返回包含所有问题的*TemplateDataValidationError
+//
+// 根属性依次在条件、变量、列表中查找，format为image的属性在图片数据中查找。
+// 缺少必需属性、类型不符（数字、布尔值、数组、对象）以及图片没有数据或路径均视为错误；
+// string类型接受任意标量值。
+func ValidateData(schema *TemplateSchema, data *TemplateData) error {
+	if schema == nil {
+		return NewValidationError("schema", "", "模板数据结构不能为空")
+	}
+	if data == nil {
+		data = NewTemplateData()
+	}
+
+	var violations []SchemaViolation
+	for _, name := range sortedSchemaProperties(schema) {
+		property := schema.Properties[name]
+		value, ok := templateDataValue(data, name, property)
+		if !ok || value == nil {
+			if containsString(schema.Required, name) {
+				violations = append(violations, SchemaViolation{Path: name, Message: "is required"})
+			}
+			continue
+		}
+		violations = validateSchemaValue(property, value, name, violations)
+	}
+
+	if len(violations) > 0 {
+		return &TemplateDataValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// templateDataValue 查找根属性对应的数据
+func templateDataValue(data *TemplateData, name string, schema *TemplateSchema) (interface{}, bool) {
+	if schema.Format == "image" {
+		image, ok := data.Images[name]
+		return image, ok && image != nil
+	}
+	if flag, ok := data.Conditions[name]; ok {
+		return flag, true
+	}
+	if value, ok := data.Variables[name]; ok {
+		return value, true
+	}
+	if list, ok := data.Lists[name]; ok {
+		return list, true
+	}
+	return nil, false
+}
+
+// validateSchemaValue 校验值是否符合结构，返回追加问题后的列表
+func validateSchemaValue(schema *TemplateSchema, value interface{}, path string, violations []SchemaViolation) []SchemaViolation {
+	fail := func(message string) []SchemaViolation {
+		return append(violations, SchemaViolation{Path: path, Message: message})
+	}
+
+	if schema.Format == "image" {
+		image, ok := value.(*TemplateImageData)
+		if !ok || (len(image.Data) == 0 && image.FilePath == "") {
+			return fail("must be an image with data or file path")
+		}
+		return violations
+	}
+
+	switch schema.Type {
+	case "":
+		// 类型未知的节点（如聚合函数参数经过的节点）按值的实际类型校验属性或列表项
+		switch {
+		case isTemplateObject(value):
+			violations = validateSchemaProperties(schema, value, path, violations)
+		case isTemplateList(value):
+			violations = validateSchemaItems(schema, value, path, violations)
+		}
+
+	case "object":
+		if !isTemplateObject(value) {
+			return fail("must be an object")
+		}
+		violations = validateSchemaProperties(schema, value, path, violations)
+
+	case "array":
+		if !isTemplateList(value) {
+			return fail("must be an array")
+		}
+		violations = validateSchemaItems(schema, value, path, violations)
+
+	case "number":
+		if _, ok := templateNumber(value); !ok {
+			return fail("must be a number")
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+
+	case "string":
+		if !isTemplateContent(value) && (isTemplateObject(value) || isTemplateList(value)) {
+			return fail("must be a scalar value")
+		}
+	}
+	return violations
+}
+
+// validateSchemaProperties 校验对象的属性
+func validateSchemaProperties(schema *TemplateSchema, value interface{}, path string, violations []SchemaViolation) []SchemaViolation {
+	for _, name := range sortedSchemaProperties(schema) {
+		member, ok := templateMember(value, templatePathSegment{Name: name})
+		if !ok || member == nil {
+			if containsString(schema.Required, name) {
+				violations = append(violations, SchemaViolation{Path: path + "." + name, Message: "is required"})
+			}
+			continue
+		}
+		violations = validateSchemaValue(schema.Properties[name], member, path+"."+name, violations)
+	}
+	return violations
+}
+
+// validateSchemaItems 校验列表的每一项
+func validateSchemaItems(schema *TemplateSchema, value interface{}, path string, violations []SchemaViolation) []SchemaViolation {
+	if schema.Items == nil {
+		return violations
+	}
+	for i, item := range templateSlice(value) {
+		if item != nil {
+			violations = validateSchemaValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+	}
+	return violations
+}
+
+// isTemplateObject 检查值是否为map或结构体（时间值除外）
+func isTemplateObject(value interface{}) bool {
+	switch value.(type) {
+	case time.Time, *time.Time:
+		return false
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+}
+
+// isTemplateList 检查值是否为切片或数组
+func isTemplateList(value interface{}) bool {
+	kind := reflect.Indirect(reflect.ValueOf(value)).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// sortedSchemaProperties 返回按名称排序的属性名
+func sortedSchemaProperties(schema *TemplateSchema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
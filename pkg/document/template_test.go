@@ -2,7 +2,20 @@
 package document
 
 import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/ZeroHawkeye/wordZero/pkg/style"
 )
 
 // TestNewTemplateEngine 测试创建模板引擎
@@ -655,18 +668,1922 @@ func TestImagePlaceholder(t *testing.T) {
 	})
 }
 
-// createTestImageData 创建测试用的图片数据
-func createTestImageData() []byte {
-	// 创建一个最小的PNG图片数据用于测试
-	return []byte{
-		0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A,
-		0x00, 0x00, 0x00, 0x0D, 0x49, 0x48, 0x44, 0x52,
-		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
-		0x08, 0x06, 0x00, 0x00, 0x00, 0x1F, 0x15, 0xC4,
-		0x89, 0x00, 0x00, 0x00, 0x0A, 0x49, 0x44, 0x41,
-		0x54, 0x78, 0x9C, 0x63, 0x00, 0x01, 0x00, 0x00,
-		0x05, 0x00, 0x01, 0x0D, 0x0A, 0x2D, 0xB4, 0x00,
-		0x00, 0x00, 0x00, 0x49, 0x45, 0x4E, 0x44, 0xAE,
-		0x42, 0x60, 0x82,
+// templateParagraphTexts 获取文档主体中各段落的文本
+func templateParagraphTexts(doc *Document) []string {
+	texts := make([]string, 0)
+	for _, element := range doc.Body.Elements {
+		if para, ok := element.(*Paragraph); ok {
+			var text strings.Builder
+			for _, run := range para.Runs {
+				text.WriteString(run.Text.Content)
+			}
+			texts = append(texts, text.String())
+		}
+	}
+	return texts
+}
+
+// TestTemplateNestedBlocks 测试嵌套的循环与条件
+func TestTemplateNestedBlocks(t *testing.T) {
+	engine := NewTemplateEngine()
+	_, err := engine.LoadTemplate("orders", `订单列表：
+{{#each orders}}
+订单 {{id}}：
+{{#each items}}
+- {{name}}{{#if gift}}（赠品）{{else}} ×{{qty}}{{/if}}
+{{/each}}
+{{else}}
+暂无订单
+{{/each}}
+{{#if a}}A{{#if b}}B{{else}}b{{/if}}{{else}}-{{/if}}`)
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := NewTemplateData()
+	data.SetList("orders", []interface{}{
+		map[string]interface{}{"id": "A1", "items": []interface{}{
+			map[string]interface{}{"name": "键盘", "qty": 2},
+			map[string]interface{}{"name": "鼠标垫", "gift": true},
+		}},
+		map[string]interface{}{"id": "A2", "items": []map[string]interface{}{
+			{"name": "显示器", "qty": 1},
+		}},
+	})
+	data.SetCondition("a", true)
+
+	doc, err := engine.RenderToDocument("orders", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	expected := []string{"订单列表：", "订单 A1：", "- 键盘 ×2", "- 鼠标垫（赠品）", "订单 A2：", "- 显示器 ×1", "Ab"}
+	if got := templateParagraphTexts(doc); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("渲染结果不正确:\n得到 %q\n期望 %q", got, expected)
+	}
+
+	doc, err = engine.RenderToDocument("orders", NewTemplateData())
+	if err != nil {
+		t.Fatalf("渲染空数据失败: %v", err)
+	}
+	expected = []string{"订单列表：", "暂无订单", "-"}
+	if got := templateParagraphTexts(doc); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("空列表应渲染else分支:\n得到 %q\n期望 %q", got, expected)
+	}
+}
+
+// TestTemplateDocumentBlocks 测试文档模板中跨Run、跨段落的块以及表格行循环
+func TestTemplateDocumentBlocks(t *testing.T) {
+	doc := New()
+	title := doc.AddParagraph("")
+	title.AddFormattedText("标题：{{ti", &TextFormat{Bold: true})
+	title.AddFormattedText("tle}}。", &TextFormat{Bold: true})
+	doc.AddParagraph("{{#if showDetail}}")
+	doc.AddParagraph("详情：{{detail}}")
+	doc.AddParagraph("{{/if}}")
+
+	table := doc.AddTable(&TableConfig{Rows: 2, Cols: 2, Width: 4000})
+	table.SetCellText(0, 0, "任务")
+	table.SetCellText(0, 1, "状态")
+	table.SetCellText(1, 0, "{{#each tasks}}{{name}}")
+	table.SetCellText(1, 1, "{{status}}{{/each}}")
+	doc.AddParagraph("结尾")
+
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("doc", doc); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := NewTemplateData()
+	data.SetVariable("title", "周报")
+	data.SetVariable("detail", "进展顺利")
+	data.SetCondition("showDetail", true)
+	data.SetList("tasks", []interface{}{
+		map[string]interface{}{"name": "解析", "status": "完成"},
+		map[string]interface{}{"name": "渲染", "status": "进行中"},
+	})
+
+	result, err := engine.RenderTemplateToDocument("doc", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	expected := []string{"标题：周报。", "详情：进展顺利", "结尾"}
+	if got := templateParagraphTexts(result); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("段落渲染不正确:\n得到 %q\n期望 %q", got, expected)
+	}
+
+	titlePara := result.Body.Elements[0].(*Paragraph)
+	for _, run := range titlePara.Runs {
+		if run.Properties == nil || run.Properties.Bold == nil {
+			t.Errorf("替换后的文本应保持粗体: %q", run.Text.Content)
+		}
+	}
+
+	resultTable := result.Body.Elements[2].(*Table)
+	if len(resultTable.Rows) != 3 {
+		t.Fatalf("期望3行，得到 %d", len(resultTable.Rows))
+	}
+	if text, _ := resultTable.GetCellText(2, 0); text != "渲染" {
+		t.Errorf("循环行内容不正确: %q", text)
+	}
+	if text, _ := resultTable.GetCellText(2, 1); text != "进行中" {
+		t.Errorf("循环行内容不正确: %q", text)
+	}
+
+	// 条件为假且列表为空时移除对应段落和模板行
+	data.SetCondition("showDetail", false)
+	data.SetList("tasks", nil)
+	result, err = engine.RenderTemplateToDocument("doc", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	if got := templateParagraphTexts(result); len(got) != 2 || got[1] != "结尾" {
+		t.Errorf("条件为假时应移除段落: %q", got)
+	}
+	if rows := len(result.Body.Elements[1].(*Table).Rows); rows != 1 {
+		t.Errorf("空列表应移除模板行，得到 %d 行", rows)
+	}
+}
+
+// TestTemplateSectionLoops 测试跨段落循环重复标题、表格、图片和分页符
+func TestTemplateSectionLoops(t *testing.T) {
+	doc := New()
+	doc.AddParagraph("项目组合报告")
+	doc.AddParagraph("{{#each projects}}")
+	heading := doc.AddHeadingParagraphWithBookmark("{{name}}", 1, "project", nil)
+	heading.Properties.PageBreak = &PageBreak{}
+	doc.AddParagraph("负责人：{{owner}}，报告：{{title}}")
+	table := doc.AddTable(&TableConfig{Rows: 2, Cols: 2, Width: 4000})
+	table.SetCellText(0, 0, "成员")
+	table.SetCellText(0, 1, "角色")
+	table.SetCellText(1, 0, "{{#each members}}{{name}}")
+	table.SetCellText(1, 1, "{{role}}{{/each}}")
+	if _, err := doc.AddImageFromData(createTestImageData(), "logo.png", ImageFormatPNG, 10, 10, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	pageBreak := doc.AddParagraph("")
+	pageBreak.Runs = append(pageBreak.Runs, Run{Break: &Break{Type: "page"}})
+	doc.AddParagraph("{{/each}}")
+	doc.AddParagraph("结束")
+
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("portfolio", doc); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := NewTemplateData()
+	data.SetVariable("title", "2026年报")
+	data.SetList("projects", []interface{}{
+		map[string]interface{}{"name": "Alpha", "owner": "张三", "members": []interface{}{
+			map[string]interface{}{"name": "李四", "role": "开发"},
+			map[string]interface{}{"name": "王五", "role": "测试"},
+		}},
+		map[string]interface{}{"name": "Beta", "owner": "赵六", "members": []interface{}{
+			map[string]interface{}{"name": "钱七", "role": "设计"},
+		}},
+	})
+
+	result, err := engine.RenderTemplateToDocument("portfolio", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+
+	var (
+		headings, tables, pageBreaks []string
+		drawingIDs                   = make(map[string]bool)
+		bookmarkNames                []string
+	)
+	for _, element := range result.Body.Elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			for _, run := range e.Runs {
+				if run.Drawing != nil {
+					drawingIDs[drawingDocPr(run.Drawing).ID] = true
+				}
+				if run.Break != nil {
+					pageBreaks = append(pageBreaks, run.Break.Type)
+				}
+			}
+			if e.Properties != nil && e.Properties.ParagraphStyle != nil && e.Properties.ParagraphStyle.Val == "Heading1" {
+				if e.Properties.PageBreak == nil {
+					t.Error("标题应保留段前分页")
+				}
+				headings = append(headings, e.Runs[0].Text.Content)
+			}
+		case *Table:
+			tables = append(tables, strings.Join(templateTableRows(e), "|"))
+		case *BookmarkStart:
+			bookmarkNames = append(bookmarkNames, e.Name)
+		}
+	}
+
+	if strings.Join(headings, ",") != "Alpha,Beta" {
+		t.Errorf("每个项目应生成一个标题: %q", headings)
+	}
+	if expected := []string{"成员/角色|李四/开发|王五/测试", "成员/角色|钱七/设计"}; strings.Join(tables, ";") != strings.Join(expected, ";") {
+		t.Errorf("每个项目应生成一个表格:\n得到 %q\n期望 %q", tables, expected)
+	}
+	if len(drawingIDs) != 2 {
+		t.Errorf("重复的图片应使用不同的ID: %v", drawingIDs)
+	}
+	if len(pageBreaks) != 2 || pageBreaks[0] != "page" {
+		t.Errorf("每个项目应包含分页符: %q", pageBreaks)
+	}
+	if strings.Join(bookmarkNames, ",") != "project,project_2" {
+		t.Errorf("重复的书签应使用不同的名称: %q", bookmarkNames)
+	}
+	texts := templateParagraphTexts(result)
+	if texts[0] != "项目组合报告" || texts[len(texts)-1] != "结束" || !strings.Contains(strings.Join(texts, "|"), "负责人：赵六，报告：2026年报") {
+		t.Errorf("段落内容不正确: %q", texts)
+	}
+
+	// 输出文档包含模板中的图片资源
+	path := filepath.Join(t.TempDir(), "portfolio.docx")
+	if err := result.Save(path); err != nil {
+		t.Fatalf("保存文档失败: %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	hasMedia := false
+	for name := range reopened.parts {
+		hasMedia = hasMedia || strings.HasPrefix(name, "word/media/")
+	}
+	if !hasMedia {
+		t.Error("输出文档应包含模板中的图片")
+	}
+}
+
+// templateTableRows 返回表格每一行的单元格文本，单元格文本以"/"连接
+func templateTableRows(table *Table) []string {
+	rows := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		cells := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			var text strings.Builder
+			for _, para := range cell.Paragraphs {
+				for _, run := range para.Runs {
+					text.WriteString(run.Text.Content)
+				}
+			}
+			cells = append(cells, text.String())
+		}
+		rows = append(rows, strings.Join(cells, "/"))
+	}
+	return rows
+}
+
+// TestTemplateTableGroups 测试表格中的嵌套循环、多行模板、行条件和聚合
+func TestTemplateTableGroups(t *testing.T) {
+	doc := New()
+	table := doc.AddTable(&TableConfig{Rows: 6, Cols: 3, Width: 6000})
+	cells := [][]string{
+		{"商品", "数量", "金额"},
+		{"{{#each groups}}分组：{{name}}", "", ""},
+		{"{{#each lines}}{{sku}}", "{{qty}}", "{{amount}}{{/each}}"},
+		{"{{#if note}}备注：{{note}}", "", "{{/if}}"},
+		{"小计", "{{sum lines.qty}}", "{{sum lines.amount}}{{/each}}"},
+		{"合计", "{{count groups.lines}}项", "{{sum groups.lines.amount | currency}}"},
+	}
+	for i, row := range cells {
+		for j, text := range row {
+			if err := table.SetCellText(i, j, text); err != nil {
+				t.Fatalf("设置单元格失败: %v", err)
+			}
+		}
+	}
+	if err := table.SetCellFormat(2, 2, &CellFormat{HorizontalAlign: CellAlignRight}); err != nil {
+		t.Fatalf("设置单元格格式失败: %v", err)
+	}
+	if err := table.MergeCellsHorizontal(1, 0, 2); err != nil {
+		t.Fatalf("合并单元格失败: %v", err)
+	}
+
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("quote", doc); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := NewTemplateData()
+	data.SetList("groups", []interface{}{
+		map[string]interface{}{
+			"name": "硬件",
+			"note": "含安装",
+			"lines": []interface{}{
+				map[string]interface{}{"sku": "服务器", "qty": 2, "amount": 20000},
+				map[string]interface{}{"sku": "交换机", "qty": 1, "amount": 3500.5},
+			},
+		},
+		map[string]interface{}{
+			"name":  "软件",
+			"lines": []interface{}{map[string]interface{}{"sku": "授权", "qty": 10, "amount": 800}},
+		},
+	})
+
+	result, err := engine.RenderTemplateToDocument("quote", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	resultTable := result.Body.Elements[0].(*Table)
+	expected := []string{
+		"商品/数量/金额",
+		"分组：硬件",
+		"服务器/2/20000",
+		"交换机/1/3500.5",
+		"备注：含安装//",
+		"小计/3/23500.5",
+		"分组：软件",
+		"授权/10/800",
+		"小计/10/800",
+		"合计/3项/¥24,300.50",
+	}
+	if got := templateTableRows(resultTable); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("分组表格渲染不正确:\n得到 %q\n期望 %q", got, expected)
+	}
+
+	// 模板行的合并单元格与单元格格式保留在每个重复行中
+	for _, index := range []int{1, 6} {
+		props := resultTable.Rows[index].Cells[0].Properties
+		if props == nil || props.GridSpan == nil || props.GridSpan.Val != "3" {
+			t.Errorf("第%d行应保留合并单元格", index)
+		}
+	}
+	for _, index := range []int{2, 3, 7} {
+		cell := resultTable.Rows[index].Cells[2]
+		if len(cell.Paragraphs) == 0 || cell.Paragraphs[0].Properties == nil || cell.Paragraphs[0].Properties.Justification == nil ||
+			cell.Paragraphs[0].Properties.Justification.Val != "right" {
+			t.Errorf("第%d行金额单元格应保持右对齐", index)
+		}
+	}
+
+	// 非数字求和返回渲染错误
+	if _, err := engine.LoadTemplate("bad_sum", "{{sum items.name}}"); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	data = NewTemplateData()
+	data.SetList("items", []interface{}{map[string]interface{}{"name": "x"}})
+	if _, err := engine.RenderToDocument("bad_sum", data); !errors.Is(err, ErrTemplateRenderError) {
+		t.Errorf("非数字求和应返回渲染错误，得到 %v", err)
+	}
+}
+
+// TestTemplateDottedPaths 测试点号与索引路径访问嵌套数据
+func TestTemplateDottedPaths(t *testing.T) {
+	type Address struct {
+		City string `wordzero:"city"`
+	}
+	type Customer struct {
+		Name    string
+		VIP     bool `wordzero:"vip"`
+		Address *Address
+	}
+	type Line struct {
+		SKU    string `wordzero:"sku"`
+		Amount float64
+	}
+	type Invoice struct {
+		Customer *Customer
+		Lines    []Line `wordzero:"lines"`
+		Secret   string `wordzero:"-"`
+	}
+
+	invoice := Invoice{
+		Customer: &Customer{Name: "张三", VIP: true, Address: &Address{City: "深圳"}},
+		Lines:    []Line{{SKU: "A-1", Amount: 10}, {SKU: "B-2", Amount: 2.5}},
+		Secret:   "hidden",
+	}
+
+	data := NewTemplateData()
+	if err := data.FromStruct(&invoice); err != nil {
+		t.Fatalf("绑定结构体失败: %v", err)
+	}
+	if _, exists := data.Variables["secret"]; exists {
+		t.Error("标签为-的字段应被忽略")
+	}
+	if len(data.Lists["lines"]) != 2 {
+		t.Errorf("切片字段应注册为列表: %v", data.Lists)
+	}
+	data.SetVariable("raw", invoice)
+	data.SetVariable("matrix", map[string][][]int{"rows": {{1, 2}, {3, 4}}})
+
+	engine := NewTemplateEngine()
+	_, err := engine.LoadTemplate("invoice", `客户：{{customer.name}}，城市：{{customer.address.city}}
+首行：{{lines[0].sku}} / {{lines.1.sku}} / {{lines[5].sku}}
+{{#if customer.vip}}VIP{{/if}}{{#if customer.address.zip}}有邮编{{/if}}
+{{#each lines}}
+{{sku}}={{amount}}
+{{/each}}
+{{#each raw.Lines}}{{this.SKU}}@{{@index}} {{/each}}
+{{raw.Customer.Address.City}} {{raw.customer.name}} {{matrix.rows[1][0]}} {{secret}}`)
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	doc, err := engine.RenderToDocument("invoice", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	expected := []string{
+		"客户：张三，城市：深圳",
+		"首行：A-1 / B-2 / {{lines[5].sku}}",
+		"VIP",
+		"A-1=10",
+		"B-2=2.5",
+		"A-1@0 B-2@1 ",
+		"深圳 张三 3 {{secret}}",
+	}
+	if got := templateParagraphTexts(doc); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("路径渲染不正确:\n得到 %q\n期望 %q", got, expected)
+	}
+
+	for _, content := range []string{"{{a..b}}", "{{a[x]}}", "{{#each items[}}{{/each}}", "{{1abc}}"} {
+		template, _ := engine.LoadTemplate("invalid_path", content)
+		if err := engine.ValidateTemplate(template); !errors.Is(err, ErrTemplateSyntaxError) {
+			t.Errorf("%q: 期望语法错误，得到 %v", content, err)
+		}
+	}
+}
+
+// TestTemplateSyntaxErrors 测试语法错误的位置信息
+func TestTemplateSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		line    int
+		column  int
+	}{
+		{"Hello {{name}!", 1, 7},
+		{"第一行\n{{#if a}}\n内容", 2, 1},
+		{"{{#each items}}\n  {{/if}}", 2, 3},
+		{"{{else}}", 1, 1},
+		{"文本{{/each}}", 1, 3},
+		{"{{#foo x}}", 1, 1},
+		{"前缀{{#if a}}{{else}}{{else}}{{/if}}", 1, 20},
+		{"{{first name}}", 1, 1},
+		{"片段{{>}}", 1, 3},
+		{"{{include clause.docx}}", 1, 1},
+	}
+
+	engine := NewTemplateEngine()
+	for _, tt := range tests {
+		template, err := engine.LoadTemplate("invalid", tt.content)
+		if err != nil {
+			t.Fatalf("加载模板不应失败: %v", err)
+		}
+
+		err = engine.ValidateTemplate(template)
+		if !errors.Is(err, ErrTemplateSyntaxError) {
+			t.Errorf("%q: 期望语法错误，得到 %v", tt.content, err)
+			continue
+		}
+		var syntaxErr *TemplateSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("%q: 错误应包含TemplateSyntaxError", tt.content)
+		}
+		if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column {
+			t.Errorf("%q: 错误位置为 %d:%d，期望 %d:%d (%v)", tt.content, syntaxErr.Line, syntaxErr.Column, tt.line, tt.column, err)
+		}
+
+		if _, err := engine.RenderToDocument("invalid", NewTemplateData()); !errors.Is(err, ErrTemplateSyntaxError) {
+			t.Errorf("%q: 渲染时应返回语法错误，得到 %v", tt.content, err)
+		}
+	}
+}
+
+// TestTemplateExpressions 测试条件表达式、unless与else if
+func TestTemplateExpressions(t *testing.T) {
+	engine := NewTemplateEngine()
+	_, err := engine.LoadTemplate("expr", `{{#if total > 1000 and status == "paid"}}大额已付{{else if total > 1000}}大额未付{{else if not items}}空订单{{else}}普通{{/if}}
+{{#unless status == "paid"}}待支付{{else}}已支付{{/unless}}
+{{#if (level >= 3 || vip) && name != ""}}优先{{/if}}{{#if missing == null}}无值{{/if}}
+{{#each items}}{{#if price < 10}}{{name}}便宜 {{/if}}{{/each}}
+{{total > 1000}}`)
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	tests := []struct {
+		total  interface{}
+		status string
+		items  []interface{}
+		first  string
+	}{
+		{1200, "paid", nil, "大额已付"},
+		{"1500.5", "pending", nil, "大额未付"},
+		{80, "paid", nil, "空订单"},
+		{80, "paid", []interface{}{map[string]interface{}{"name": "笔", "price": 5}}, "普通"},
+	}
+	for _, tt := range tests {
+		data := NewTemplateData()
+		data.SetVariable("total", tt.total)
+		data.SetVariable("status", tt.status)
+		data.SetVariable("level", 3)
+		data.SetVariable("name", "张三")
+		data.SetList("items", tt.items)
+
+		doc, err := engine.RenderToDocument("expr", data)
+		if err != nil {
+			t.Fatalf("渲染模板失败: %v", err)
+		}
+		texts := templateParagraphTexts(doc)
+		if texts[0] != tt.first {
+			t.Errorf("total=%v status=%s: 得到 %q，期望 %q", tt.total, tt.status, texts[0], tt.first)
+		}
+		if expected := map[bool]string{true: "已支付", false: "待支付"}[tt.status == "paid"]; texts[1] != expected {
+			t.Errorf("unless渲染不正确: 得到 %q，期望 %q", texts[1], expected)
+		}
+		if texts[2] != "优先无值" {
+			t.Errorf("逻辑表达式渲染不正确: %q", texts[2])
+		}
+		if len(tt.items) > 0 && texts[3] != "笔便宜 " {
+			t.Errorf("循环中的比较不正确: %q", texts[3])
+		}
+	}
+
+	for _, content := range []string{
+		"{{#if a >}}x{{/if}}",
+		"{{#if (a}}x{{/if}}",
+		"{{#unless a}}x{{/if}}",
+		"{{#each items}}{{else if a}}{{/each}}",
+		"{{#if a}}{{else}}{{else if b}}{{/if}}",
+		"{{#if a == \"x}}{{/if}}",
+		"{{price | }}",
+	} {
+		template, _ := engine.LoadTemplate("invalid_expr", content)
+		if err := engine.ValidateTemplate(template); !errors.Is(err, ErrTemplateSyntaxError) {
+			t.Errorf("%q: 期望语法错误，得到 %v", content, err)
+		}
+	}
+}
+
+// TestTemplateFilters 测试内置过滤器与自定义过滤器
+func TestTemplateFilters(t *testing.T) {
+	engine := NewTemplateEngine()
+	if err := engine.RegisterFilter("mask", func(value interface{}, args ...interface{}) (interface{}, error) {
+		s := []rune(fmt.Sprint(value))
+		if len(s) <= 2 {
+			return string(s), nil
+		}
+		return string(s[:1]) + strings.Repeat("*", len(s)-2) + string(s[len(s)-1:]), nil
+	}); err != nil {
+		t.Fatalf("注册过滤器失败: %v", err)
+	}
+	if err := engine.RegisterFilter("1bad", nil); err == nil {
+		t.Error("非法过滤器名称应返回错误")
+	}
+
+	_, err := engine.LoadTemplate("filters", `{{price | currency:"CNY"}} {{price | currency:"USD"}} {{refund | currency}} {{count | number:0}}
+{{date | format:"2006-01-02"}} {{day | format:"2006年01月02日"}} {{ratio | format:"%.1f%%"}}
+{{name | upper}} {{name | lower | default:"x"}} {{notes | default:"-"}} {{empty | default:"无"}}
+{{tags | join:"、"}} {{phone | mask}} {{ name | trim | upper }} {{unknown}}`)
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := NewTemplateData()
+	data.SetVariable("price", 1234567.5)
+	data.SetVariable("refund", -12.5)
+	data.SetVariable("count", 98765)
+	data.SetVariable("date", time.Date(2024, 3, 8, 10, 30, 0, 0, time.UTC))
+	data.SetVariable("day", "2024-12-25")
+	data.SetVariable("ratio", 12.34)
+	data.SetVariable("name", " Alice ")
+	data.SetVariable("empty", "")
+	data.SetVariable("tags", []string{"加急", "含税"})
+	data.SetVariable("phone", "13800138000")
+
+	doc, err := engine.RenderToDocument("filters", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	expected := []string{
+		"¥1,234,567.50 $1,234,567.50 -¥12.50 98,765",
+		"2024-03-08 2024年12月25日 12.3%",
+		" ALICE   alice  - 无",
+		"加急、含税 1*********0 ALICE {{unknown}}",
+	}
+	if got := templateParagraphTexts(doc); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("过滤器渲染不正确:\n得到 %q\n期望 %q", got, expected)
+	}
+
+	// 未注册的过滤器和过滤器错误在渲染时返回带位置的错误
+	for _, content := range []string{"第一行\n金额：{{price | nope}}", "第一行\n金额：{{name | currency}}"} {
+		if _, err := engine.LoadTemplate("bad_filter", content); err != nil {
+			t.Fatalf("加载模板失败: %v", err)
+		}
+		_, err = engine.RenderToDocument("bad_filter", data)
+		var renderErr *TemplateRenderError
+		if !errors.Is(err, ErrTemplateRenderError) || !errors.As(err, &renderErr) {
+			t.Fatalf("%q: 期望渲染错误，得到 %v", content, err)
+		}
+		if renderErr.Line != 2 || renderErr.Column != 4 {
+			t.Errorf("%q: 错误位置为 %d:%d，期望 2:4", content, renderErr.Line, renderErr.Column)
+		}
+	}
+}
+
+// TestTemplateLocale 测试区域格式对数字、金额和日期输出的影响
+func TestTemplateLocale(t *testing.T) {
+	content := `{{amount}} {{amount | number}} {{amount | currency}} {{count | number:0}}
+{{signed}} {{signed | date:"long"}} {{created}}
+{{amount | currency:"EUR","de-DE"}} {{signed | date:"long","en-US"}} {{amount | rmb}}`
+
+	data := NewTemplateData()
+	data.SetVariable("amount", 1234567.891)
+	data.SetVariable("count", 1000)
+	data.SetVariable("signed", time.Date(2026, 5, 17, 0, 0, 0, 0, time.UTC))
+	data.SetVariable("created", time.Date(2026, 10, 17, 14, 5, 0, 0, time.UTC))
+
+	tests := []struct {
+		locale   string
+		expected []string
+	}{
+		{"", []string{
+			"1234567.891 1,234,567.89 ¥1,234,567.89 1,000",
+			"2026-05-17 2026年5月17日 2026-10-17 14:05:00",
+			"1.234.567,89 € May 17, 2026 壹佰贰拾叁万肆仟伍佰陆拾柒元捌角玖分",
+		}},
+		{"en-US", []string{
+			"1,234,567.891 1,234,567.89 $1,234,567.89 1,000",
+			"05/17/2026 May 17, 2026 10/17/2026 2:05 PM",
+			"1.234.567,89 € May 17, 2026 壹佰贰拾叁万肆仟伍佰陆拾柒元捌角玖分",
+		}},
+		{"de-DE", []string{
+			"1.234.567,891 1.234.567,89 1.234.567,89 € 1.000",
+			"17.05.2026 17. Mai 2026 17.10.2026 14:05",
+			"1.234.567,89 € May 17, 2026 壹佰贰拾叁万肆仟伍佰陆拾柒元捌角玖分",
+		}},
+	}
+
+	for _, tt := range tests {
+		engine := NewTemplateEngine()
+		if tt.locale != "" {
+			if err := engine.SetLocale(tt.locale); err != nil {
+				t.Fatalf("设置区域失败: %v", err)
+			}
+		}
+		if _, err := engine.LoadTemplate("locale", content); err != nil {
+			t.Fatalf("加载模板失败: %v", err)
+		}
+		doc, err := engine.RenderToDocument("locale", data)
+		if err != nil {
+			t.Fatalf("%s: 渲染模板失败: %v", tt.locale, err)
+		}
+		if got := templateParagraphTexts(doc); strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("%s: 区域格式不正确:\n得到 %q\n期望 %q", tt.locale, got, tt.expected)
+		}
+	}
+
+	engine := NewTemplateEngine()
+	if err := engine.SetLocale("fr-FR"); err == nil {
+		t.Error("不支持的区域应返回错误")
+	}
+	if err := engine.SetLocaleConfig(&TemplateLocale{Name: "x", GroupSeparator: ".", DecimalSeparator: "."}); err == nil {
+		t.Error("小数点与千分位相同应返回错误")
+	}
+	if got, _ := engine.FormatCurrency(-0.001, "USD"); got != "$0.00" {
+		t.Errorf("舍入为0的负数不应带负号: %q", got)
+	}
+	if got, _ := engine.FormatNumber("9876.5", 1); got != "9,876.5" {
+		t.Errorf("数字字符串格式化不正确: %q", got)
+	}
+}
+
+// TestFormatChineseAmount 测试中文大写金额
+func TestFormatChineseAmount(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		expected string
+	}{
+		{0, "零元整"},
+		{0.5, "伍角整"},
+		{0.07, "柒分"},
+		{10, "壹拾元整"},
+		{10.05, "壹拾元零伍分"},
+		{1005, "壹仟零伍元整"},
+		{1234.5, "壹仟贰佰叁拾肆元伍角整"},
+		{10500, "壹万零伍佰元整"},
+		{100000, "壹拾万元整"},
+		{12000.34, "壹万贰仟元叁角肆分"},
+		{100000005, "壹亿零伍元整"},
+		{1000010000, "壹拾亿零壹万元整"},
+		{-88.8, "负捌拾捌元捌角整"},
+		{0.004, "零元整"},
+	}
+	for _, tt := range tests {
+		if got := FormatChineseAmount(tt.amount); got != tt.expected {
+			t.Errorf("FormatChineseAmount(%v) = %q，期望 %q", tt.amount, got, tt.expected)
+		}
+	}
+}
+
+// TestTemplatePartials 测试片段引用使用当前数据作用域渲染
+func TestTemplatePartials(t *testing.T) {
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplate("clause", "{{#if @last}}末条{{else}}条款{{/if}} {{title}}：{{company}}负责"); err != nil {
+		t.Fatalf("加载片段失败: %v", err)
+	}
+	if _, err := engine.LoadTemplate("contract", "合同\n{{#each clauses}}{{> clause}}{{/each}}\n{{> \"clause\"}}"); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := NewTemplateData()
+	data.SetVariable("company", "甲方")
+	data.SetVariable("title", "总则")
+	data.SetList("clauses", []interface{}{
+		map[string]interface{}{"title": "保密"},
+		map[string]interface{}{"title": "违约", "company": "乙方"},
+	})
+	doc, err := engine.RenderTemplateToDocument("contract", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	expected := []string{"合同", "条款 保密：甲方负责", "末条 违约：乙方负责", "条款 总则：甲方负责"}
+	if texts := templateParagraphTexts(doc); strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Errorf("片段渲染结果不正确:\n得到 %q\n期望 %q", texts, expected)
+	}
+
+	template, _ := engine.GetTemplate("contract")
+	partials := 0
+	for _, block := range template.Blocks {
+		if block.Type == "partial" && block.Name == "clause" {
+			partials++
+		}
+	}
+	if partials != 2 {
+		t.Errorf("应识别2个片段引用，实际 %d", partials)
+	}
+
+	// 循环引用和不存在的片段
+	engine.LoadTemplate("a", "A{{> b}}")
+	engine.LoadTemplate("b", "B{{> a}}")
+	engine.LoadTemplate("missing", "{{> nothing}}")
+	var renderErr *TemplateRenderError
+	if _, err := engine.RenderTemplateToDocument("a", data); !errors.As(err, &renderErr) || !strings.Contains(err.Error(), "recursive partial") {
+		t.Errorf("循环引用应返回渲染错误: %v", err)
+	}
+	if _, err := engine.RenderTemplateToDocument("missing", data); !errors.As(err, &renderErr) || renderErr.Tag != "{{> nothing}}" {
+		t.Errorf("不存在的片段应返回渲染错误: %v", err)
+	}
+}
+
+// TestTemplateIncludeDocument 测试从基础路径引用文档片段并导入样式、编号和图片
+func TestTemplateIncludeDocument(t *testing.T) {
+	dir := t.TempDir()
+
+	partial := New()
+	partial.GetStyleManager().AddStyle(&style.Style{
+		Type:    "paragraph",
+		StyleID: "ClauseText",
+		Name:    &style.StyleName{Val: "Clause Text"},
+		BasedOn: &style.BasedOn{Val: "Normal"},
+		RunPr:   &style.RunProperties{Bold: &style.Bold{}},
+	})
+	partial.AddParagraph("{{company}}承诺保密").SetStyle("ClauseText")
+	partial.AddNumberedList("保密期限{{years}}年", 0, ListTypeDecimal)
+	if _, err := partial.AddImageFromData(createTestImageData(), "seal.png", ImageFormatPNG, 10, 10, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	if err := partial.Save(filepath.Join(dir, "confidential.docx")); err != nil {
+		t.Fatalf("保存片段失败: %v", err)
+	}
+
+	main := New()
+	main.AddParagraph("{{#each parties}}")
+	main.AddParagraph("{{include \"confidential.docx\"}}")
+	main.AddParagraph("{{/each}}")
+	mainPath := filepath.Join(dir, "main.docx")
+	if err := main.Save(mainPath); err != nil {
+		t.Fatalf("保存模板失败: %v", err)
+	}
+	base, err := Open(mainPath)
+	if err != nil {
+		t.Fatalf("打开模板失败: %v", err)
+	}
+
+	engine := NewTemplateEngine()
+	engine.SetBasePath(dir)
+	if _, err := engine.LoadTemplateFromDocument("main", base); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	data := NewTemplateData()
+	data.SetVariable("years", 3)
+	data.SetList("parties", []interface{}{
+		map[string]interface{}{"company": "甲方"},
+		map[string]interface{}{"company": "乙方"},
+	})
+	result, err := engine.RenderTemplateToDocument("main", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+
+	var texts, embeds []string
+	numIDs := make(map[string]bool)
+	for _, element := range result.Body.Elements {
+		para, ok := element.(*Paragraph)
+		if !ok {
+			continue
+		}
+		var text strings.Builder
+		for _, run := range para.Runs {
+			text.WriteString(run.Text.Content)
+			if run.Drawing != nil {
+				embeds = append(embeds, run.Drawing.Inline.Graphic.GraphicData.Pic.BlipFill.Blip.Embed)
+			}
+		}
+		if text.Len() > 0 {
+			texts = append(texts, text.String())
+		}
+		if para.Properties != nil && para.Properties.NumberingProperties != nil {
+			numIDs[para.Properties.NumberingProperties.NumID.Val] = true
+		}
+		if strings.HasSuffix(text.String(), "承诺保密") && (para.Properties == nil || para.Properties.ParagraphStyle.Val != "ClauseText") {
+			t.Error("片段段落应保留样式")
+		}
+	}
+	expected := []string{"甲方承诺保密", "保密期限3年", "乙方承诺保密", "保密期限3年"}
+	if strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Errorf("片段渲染结果不正确:\n得到 %q\n期望 %q", texts, expected)
+	}
+	if len(embeds) != 2 || embeds[0] != embeds[1] {
+		t.Fatalf("两次引用应复用同一图片关系: %q", embeds)
+	}
+	if _, ok := engine.GetTemplate("confidential.docx"); ok != nil {
+		t.Error("引用的文档应加入模板缓存")
+	}
+
+	path := filepath.Join(dir, "output.docx")
+	if err := result.Save(path); err != nil {
+		t.Fatalf("保存文档失败: %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	if !strings.Contains(string(reopened.parts["word/styles.xml"]), `w:styleId="ClauseText"`) {
+		t.Error("输出文档应包含片段中的样式")
+	}
+	var target string
+	for _, rel := range reopened.documentRelationships.Relationships {
+		if rel.ID == embeds[0] {
+			target = rel.Target
+		}
+	}
+	if _, ok := reopened.parts["word/"+target]; target == "" || !ok {
+		t.Errorf("输出文档应包含片段中的图片: %q", target)
+	}
+	var numbering templateRawNumbering
+	if err := xml.Unmarshal(reopened.parts["word/numbering.xml"], &numbering); err != nil {
+		t.Fatalf("解析编号定义失败: %v", err)
+	}
+	for id := range numIDs {
+		found := false
+		for _, num := range numbering.Nums {
+			found = found || num.ID == id
+		}
+		if !found {
+			t.Errorf("输出文档缺少编号定义 %s", id)
+		}
+	}
+}
+
+// TestTemplateIncludeChartAndTextBox 测试引用包含图表和文本框图片的文档时复制相关部件并分配新关系ID
+func TestTemplateIncludeChartAndTextBox(t *testing.T) {
+	dir := t.TempDir()
+
+	partial := New()
+	if _, err := partial.AddChart(&ChartConfig{
+		Title:      "片段图表",
+		Categories: []string{"A", "B"},
+		Series:     []ChartSeries{{Name: "数量", Values: []float64{1, 2}}},
+	}); err != nil {
+		t.Fatalf("添加图表失败: %v", err)
+	}
+	box, err := partial.AddTextBox(&ShapeConfig{Width: 60, Height: 40})
+	if err != nil {
+		t.Fatalf("添加文本框失败: %v", err)
+	}
+	if _, err := partial.AddImageFromData(createTestImageData(), "boxed.png", ImageFormatPNG, 10, 10, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	last := len(partial.Body.Elements) - 1
+	box.appendParagraph(partial.Body.Elements[last].(*Paragraph))
+	partial.Body.Elements = partial.Body.Elements[:last]
+	if err := partial.Save(filepath.Join(dir, "partial.docx")); err != nil {
+		t.Fatalf("保存片段失败: %v", err)
+	}
+
+	// 主模板自身的图片和图表占用与片段相同的关系ID和部件名称
+	main := New()
+	if _, err := main.AddImageFromData(createTestImage(20, 20), "main.png", ImageFormatPNG, 20, 20, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	if _, err := main.AddChart(&ChartConfig{
+		Title:      "主图表",
+		Categories: []string{"X"},
+		Series:     []ChartSeries{{Name: "值", Values: []float64{5}}},
+	}); err != nil {
+		t.Fatalf("添加图表失败: %v", err)
+	}
+	main.AddParagraph("{{#each parts}}")
+	main.AddParagraph(`{{include "partial.docx"}}`)
+	main.AddParagraph("{{/each}}")
+
+	engine := NewTemplateEngine()
+	engine.SetBasePath(dir)
+	if _, err := engine.LoadTemplateFromDocument("main", main); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	data := NewTemplateData()
+	data.SetList("parts", []interface{}{map[string]interface{}{}, map[string]interface{}{}})
+	result, err := engine.RenderTemplateToDocument("main", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+
+	opened := saveAndReopen(t, result)
+	titles := make(map[string]string)
+	for _, chart := range opened.ListCharts() {
+		rel := opened.findDocumentRelationship(chart.RelationID)
+		if rel == nil || rel.Type != chartRelationshipType {
+			t.Fatalf("图表关系不正确: %+v", rel)
+		}
+		titles[chart.PartName] = chart.Config.Title
+		rels := opened.parts[chartRelationshipsPartName(chart.PartName)]
+		var chartRels Relationships
+		if err := xml.Unmarshal(rels, &chartRels); err != nil || len(chartRels.Relationships) != 1 {
+			t.Fatalf("图表 %s 缺少关系文件: %s", chart.PartName, rels)
+		}
+		workbook := path.Clean(path.Join(path.Dir(chart.PartName), chartRels.Relationships[0].Target))
+		if len(opened.parts[workbook]) == 0 {
+			t.Errorf("图表 %s 缺少嵌入式工作簿 %s", chart.PartName, workbook)
+		}
+	}
+	if len(titles) != 2 || titles["word/charts/chart1.xml"] != "主图表" || titles["word/charts/chart1_2.xml"] != "片段图表" {
+		t.Errorf("图表部件不正确: %v", titles)
+	}
+
+	images := opened.ListImages()
+	if len(images) != 3 {
+		t.Fatalf("期望主图片和两张文本框图片，得到 %d", len(images))
+	}
+	ids := make(map[string]bool)
+	for _, img := range images {
+		if rel := opened.findDocumentRelationship(img.RelationID); rel == nil || rel.Type != imageRelationshipType || len(img.Data) == 0 {
+			t.Errorf("图片 %s 的关系或数据不正确: %+v", img.ID, rel)
+		}
+		if ids[img.ID] {
+			t.Errorf("绘图ID重复: %s", img.ID)
+		}
+		ids[img.ID] = true
+	}
+	if images[1].RelationID == images[0].RelationID || images[1].RelationID != images[2].RelationID {
+		t.Errorf("文本框图片应使用新的关系ID并在两次引用间复用: %s %s %s", images[0].RelationID, images[1].RelationID, images[2].RelationID)
+	}
+	if shapes := partial.ListShapes(); len(shapes) != 1 || len(shapes[0].Paragraphs()[0].Runs) == 0 {
+		t.Error("渲染不应修改片段文档的文本框")
+	}
+}
+
+// TestTemplateIncludeOutsideBasePath 测试引用路径不能指向基础路径以外的文件
+func TestTemplateIncludeOutsideBasePath(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "templates")
+	secret := New()
+	secret.AddParagraph("机密内容")
+	for _, file := range []string{filepath.Join(dir, "secret.docx"), filepath.Join(base, "sub", "clause.docx")} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := secret.Save(file); err != nil {
+			t.Fatalf("保存文档失败: %v", err)
+		}
+	}
+
+	engine := NewTemplateEngine()
+	engine.SetBasePath(base)
+	for i, include := range []string{"../secret.docx", "sub/../../secret", filepath.Join(dir, "secret.docx")} {
+		name := fmt.Sprintf("escape%d", i)
+		if _, err := engine.LoadTemplate(name, fmt.Sprintf("{{include %q}}", include)); err != nil {
+			t.Fatalf("加载模板失败: %v", err)
+		}
+		_, err := engine.RenderTemplateToDocument(name, NewTemplateData())
+		if err == nil || !errors.Is(err, ErrTemplateRenderError) {
+			t.Errorf("引用 %q 应返回模板渲染错误: %v", include, err)
+		}
+	}
+
+	if _, err := engine.LoadTemplate("inside", `{{include "sub/./clause.docx"}}`); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	if _, err := engine.RenderTemplateToDocument("inside", NewTemplateData()); err != nil {
+		t.Errorf("基础路径下子目录中的文档应可以引用: %v", err)
+	}
+}
+
+// TestTemplatePartialNumbering 测试导入编号定义时为冲突的ID分配新ID
+func TestTemplatePartialNumbering(t *testing.T) {
+	numbering := func(format string) []byte {
+		return []byte(xml.Header + `<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			`<w:abstractNum w:abstractNumId="0"><w:lvl w:ilvl="0"><w:numFmt w:val="` + format + `"/></w:lvl></w:abstractNum>` +
+			`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num></w:numbering>`)
+	}
+
+	target := New()
+	target.parts["word/numbering.xml"] = numbering("decimal")
+	source := New()
+	source.parts["word/numbering.xml"] = numbering("upperRoman")
+	para := source.AddParagraph("条款")
+	para.Properties = &ParagraphProperties{NumberingProperties: &NumberingProperties{
+		ILevel: &ILevel{Val: "0"},
+		NumID:  &NumID{Val: "1"},
+	}}
+
+	res := importTemplateResources(target, source)
+	if res.numIDs["1"] != "2" {
+		t.Fatalf("冲突的编号应分配新ID: %v", res.numIDs)
+	}
+	var merged templateRawNumbering
+	if err := xml.Unmarshal(target.parts["word/numbering.xml"], &merged); err != nil {
+		t.Fatalf("解析编号定义失败: %v", err)
+	}
+	if len(merged.AbstractNums) != 2 || merged.AbstractNums[1].ID != "1" || !strings.Contains(merged.AbstractNums[1].Inner, "upperRoman") {
+		t.Errorf("应追加片段的抽象编号: %+v", merged.AbstractNums)
+	}
+	if len(merged.Nums) != 2 || merged.Nums[1].ID != "2" || merged.Nums[1].AbstractID.Val != "1" {
+		t.Errorf("应追加指向新抽象编号的编号实例: %+v", merged.Nums)
+	}
+	props := res.paragraphProperties(&ParagraphProperties{NumberingProperties: &NumberingProperties{NumID: &NumID{Val: "1"}}})
+	if props.NumberingProperties.NumID.Val != "2" {
+		t.Errorf("片段段落的编号ID应被替换: %s", props.NumberingProperties.NumID.Val)
+	}
+
+	// 相同的编号定义直接复用
+	source.parts["word/numbering.xml"] = numbering("decimal")
+	if res := importTemplateResources(New(), source); res.numIDs["1"] != "1" {
+		t.Errorf("输出文档没有编号定义时应保留原ID: %v", res.numIDs)
+	}
+}
+
+// TestTemplateRenderReport 测试渲染报告、缺失值占位文本与严格模式
+func TestTemplateRenderReport(t *testing.T) {
+	engine := NewTemplateEngine()
+	content := "客户：{{customer.name}}，电话：{{phone}}\n" +
+		"{{#if vipp}}贵宾{{/if}}{{notes | default:\"无\"}}\n" +
+		"{{#each items}}{{name}}×{{qty}}；{{/each}}{{#each orders}}{{id}}{{/each}}"
+	if _, err := engine.LoadTemplate("letter", content); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	data := NewTemplateData()
+	data.SetVariable("customer", map[string]interface{}{"name": "张三"})
+	data.SetVariable("unused", "x")
+	data.SetCondition("vip", true)
+	data.SetList("items", []interface{}{
+		map[string]interface{}{"name": "笔", "qty": 2},
+		map[string]interface{}{"name": "纸"},
+	})
+
+	doc, report, err := engine.RenderTemplateWithReport("letter", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	expected := []string{"客户：张三，电话：{{phone}}", "无", "笔×2；纸×{{qty}}；"}
+	if texts := templateParagraphTexts(doc); strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Errorf("渲染结果不正确:\n得到 %q\n期望 %q", texts, expected)
+	}
+
+	var missing []string
+	for _, m := range report.Missing {
+		missing = append(missing, fmt.Sprintf("%s:%s@%d:%d", m.Kind, m.Name, m.Line, m.Column))
+	}
+	if want := "variable:phone@1:25,condition:vipp@2:1,variable:qty@3:25,list:orders@3:42"; strings.Join(missing, ",") != want {
+		t.Errorf("缺失占位符不正确:\n得到 %s\n期望 %s", strings.Join(missing, ","), want)
+	}
+	if strings.Join(report.UnusedData, ",") != "unused,vip" {
+		t.Errorf("未使用的数据键不正确: %q", report.UnusedData)
+	}
+	if len(report.Warnings) != 6 || !report.HasIssues() {
+		t.Errorf("警告数量不正确: %q", report.Warnings)
+	}
+
+	// 缺失值占位文本
+	engine.SetRenderOptions(TemplateRenderOptions{MissingValue: "____"})
+	doc, err = engine.RenderTemplateToDocument("letter", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	if texts := templateParagraphTexts(doc); texts[0] != "客户：张三，电话：____" || texts[2] != "笔×2；纸×____；" {
+		t.Errorf("缺失变量应输出占位文本: %q", texts)
+	}
+
+	// 严格模式
+	engine.SetRenderOptions(TemplateRenderOptions{Strict: true})
+	doc, report, err = engine.RenderTemplateWithReport("letter", data)
+	var missingErr *TemplateMissingDataError
+	if doc != nil || !errors.Is(err, ErrTemplateMissingData) || !errors.As(err, &missingErr) {
+		t.Fatalf("严格模式应返回缺失数据错误: %v", err)
+	}
+	if len(missingErr.Missing) != 4 || len(report.Missing) != 4 || !strings.Contains(err.Error(), "line 1, column 25: {{phone}}") {
+		t.Errorf("错误应列出所有缺失占位符: %v", err)
+	}
+	if _, err := engine.RenderTemplateToDocument("letter", data); !errors.Is(err, ErrTemplateMissingData) {
+		t.Errorf("严格模式下RenderTemplateToDocument应返回错误: %v", err)
+	}
+
+	data.SetVariable("phone", "123")
+	data.SetCondition("vipp", false)
+	data.SetList("orders", []interface{}{})
+	data.Lists["items"][1].(map[string]interface{})["qty"] = 1
+	if _, report, err := engine.RenderTemplateWithReport("letter", data); err != nil || len(report.Missing) != 0 {
+		t.Errorf("数据完整时严格模式应渲染成功: %v", err)
+	}
+}
+
+// TestTemplateSchema 测试模板数据结构提取与数据校验
+func TestTemplateSchema(t *testing.T) {
+	engine := NewTemplateEngine()
+	content := "客户：{{customer.name}}（{{customer.address.city}}）\n" +
+		"{{#if vip}}贵宾{{/if}}{{#if level > 2}}高级{{/if}}{{notes | default:\"无\"}}\n" +
+		"{{#each items}}{{name}}×{{qty}}：{{price | currency}}{{#each tags}}{{this}}{{/each}}{{/each}}\n" +
+		"合计：{{sum items.price}}，签订日期：{{signed | date:\"2006-01-02\"}}\n" +
+		"{{#image logo}}"
+	if _, err := engine.LoadTemplate("order", content); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	schema, err := engine.ExtractSchema("order")
+	if err != nil {
+		t.Fatalf("提取数据结构失败: %v", err)
+	}
+	if schema.Type != "object" || schema.Title != "order" || schema.Schema == "" {
+		t.Errorf("根节点不正确: %+v", schema)
+	}
+	if got := strings.Join(schema.Required, ","); got != "customer,items,logo,signed" {
+		t.Errorf("必需属性不正确: %s", got)
+	}
+
+	customer := schema.Properties["customer"]
+	if customer.Type != "object" || customer.Properties["address"].Properties["city"].Type != "string" {
+		t.Errorf("嵌套对象结构不正确: %+v", customer)
+	}
+	items := schema.Properties["items"]
+	if items.Type != "array" || items.Items.Type != "object" {
+		t.Fatalf("循环列表结构不正确: %+v", items)
+	}
+	item := items.Items
+	if item.Properties["name"].Type != "string" || item.Properties["price"].Type != "number" {
+		t.Errorf("循环项字段类型不正确: %+v", item.Properties)
+	}
+	if tags := item.Properties["tags"]; tags.Type != "array" || tags.Items.Type != "string" {
+		t.Errorf("嵌套循环结构不正确: %+v", tags)
+	}
+	if got := strings.Join(item.Required, ","); got != "name,price,qty,tags" {
+		t.Errorf("循环项必需字段不正确: %s", got)
+	}
+	checks := map[string]string{"vip": "boolean", "level": "number", "notes": "string", "signed": "string"}
+	for name, typ := range checks {
+		if prop := schema.Properties[name]; prop == nil || prop.Type != typ {
+			t.Errorf("%s类型应为%s: %+v", name, typ, prop)
+		}
+	}
+	if schema.Properties["signed"].Format != "date-time" || schema.Properties["logo"].Format != "image" {
+		t.Errorf("格式不正确: %+v %+v", schema.Properties["signed"], schema.Properties["logo"])
+	}
+	if containsString(schema.Required, "vip") || containsString(schema.Required, "notes") {
+		t.Errorf("条件和带默认值的变量不应为必需: %v", schema.Required)
+	}
+
+	jsonSchema, err := schema.ToJSONSchema()
+	if err != nil {
+		t.Fatalf("导出JSON Schema失败: %v", err)
+	}
+	for _, want := range []string{`"$schema": "https://json-schema.org/draft/2020-12/schema"`, `"format": "image"`, `"items": {`} {
+		if !strings.Contains(string(jsonSchema), want) {
+			t.Errorf("JSON Schema缺少%s:\n%s", want, jsonSchema)
+		}
+	}
+
+	// 数据校验
+	data := NewTemplateData()
+	data.SetVariable("customer", map[string]interface{}{"address": map[string]interface{}{"city": "上海"}})
+	data.SetVariable("level", "高")
+	data.SetVariable("signed", time.Now())
+	data.SetList("items", []interface{}{
+		map[string]interface{}{"name": "笔", "qty": 2, "price": 3.5, "tags": []string{"文具"}},
+		map[string]interface{}{"name": "纸", "price": "贵", "tags": []string{}},
+	})
+	err = ValidateData(schema, data)
+	var validationErr *TemplateDataValidationError
+	if !errors.Is(err, ErrInvalidTemplateData) || !errors.As(err, &validationErr) {
+		t.Fatalf("应返回数据校验错误: %v", err)
+	}
+	var violations []string
+	for _, v := range validationErr.Violations {
+		violations = append(violations, v.Path+" "+v.Message)
+	}
+	expected := []string{
+		"customer.name is required",
+		"items[1].price must be a number",
+		"items[1].qty is required",
+		"level must be a number",
+		"logo is required",
+	}
+	if strings.Join(violations, "|") != strings.Join(expected, "|") {
+		t.Errorf("校验问题不正确:\n得到 %q\n期望 %q", violations, expected)
+	}
+
+	data.SetVariable("customer", map[string]interface{}{"name": "张三", "address": map[string]interface{}{"city": "上海"}})
+	data.SetVariable("level", 3)
+	data.Lists["items"][1] = map[string]interface{}{"name": "纸", "qty": 1, "price": 2, "tags": []string{}}
+	data.SetImageFromData("logo", createTestImageData(), nil)
+	if err := ValidateData(schema, data); err != nil {
+		t.Errorf("完整数据应通过校验: %v", err)
+	}
+	if _, err := engine.RenderTemplateToDocument("order", data); err != nil {
+		t.Errorf("通过校验的数据应能渲染: %v", err)
+	}
+
+	// 模板分析结果包含数据结构
+	renderer := NewTemplateRenderer()
+	renderer.SetLogging(false)
+	renderer.engine = engine
+	analysis, err := renderer.AnalyzeTemplate("order")
+	if err != nil || analysis.Schema == nil || analysis.Schema.Properties["items"].Items == nil {
+		t.Errorf("分析结果应包含数据结构: %v", err)
+	}
+}
+
+// TestTemplateSchemaAggregatePaths 测试聚合函数多段路径的数据结构推断
+func TestTemplateSchemaAggregatePaths(t *testing.T) {
+	engine := NewTemplateEngine()
+	content := "订单{{order.no}}数量：{{sum order.lines.qty}}\n" +
+		"总额：{{sum groups.lines.amount}}，明细{{count groups.lines}}条\n" +
+		"{{#each groups}}{{name}}：{{sum lines.amount}}{{/each}}"
+	if _, err := engine.LoadTemplate("report", content); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	schema, err := engine.ExtractSchema("report")
+	if err != nil {
+		t.Fatalf("提取数据结构失败: %v", err)
+	}
+
+	order := schema.Properties["order"]
+	if order.Type != "object" || order.Items != nil {
+		t.Fatalf("order应为对象: %+v", order)
+	}
+	lines := order.Properties["lines"]
+	if lines.Type != "" || lines.Items == nil || lines.Items.Properties["qty"].Type != "number" {
+		t.Errorf("order.lines应为对象或列表且qty为数字: %+v", lines)
+	}
+	groups := schema.Properties["groups"]
+	if groups.Type != "array" || groups.Items.Type != "object" {
+		t.Fatalf("groups应为数组: %+v", groups)
+	}
+	if amount := groups.Items.Properties["lines"].Items.Properties["amount"]; amount.Type != "number" {
+		t.Errorf("groups.lines.amount应为数字: %+v", amount)
+	}
+
+	data := NewTemplateData()
+	data.SetVariable("order", map[string]interface{}{
+		"no":    "A01",
+		"lines": []interface{}{map[string]interface{}{"qty": 2}, map[string]interface{}{"qty": 3}},
+	})
+	data.SetList("groups", []interface{}{
+		map[string]interface{}{"name": "办公", "lines": []interface{}{map[string]interface{}{"amount": 10.5}}},
+		map[string]interface{}{"name": "耗材", "lines": []interface{}{map[string]interface{}{"amount": 4}, map[string]interface{}{"amount": 6}}},
+	})
+	if err := ValidateData(schema, data); err != nil {
+		t.Fatalf("正确数据应通过校验: %v", err)
+	}
+	if _, err := engine.RenderTemplateToDocument("report", data); err != nil {
+		t.Errorf("通过校验的数据应能渲染: %v", err)
+	}
+
+	// 路径中间节点为对象时同样接受
+	data.SetVariable("order", map[string]interface{}{"no": "A02", "lines": map[string]interface{}{"qty": 1}})
+	if err := ValidateData(schema, data); err != nil {
+		t.Errorf("order.lines为对象时应通过校验: %v", err)
+	}
+
+	data.SetVariable("order", map[string]interface{}{"no": "A03", "lines": []interface{}{map[string]interface{}{"qty": "多"}}})
+	data.Lists["groups"][1] = map[string]interface{}{"name": "耗材", "lines": []interface{}{map[string]interface{}{}}}
+	err = ValidateData(schema, data)
+	var validationErr *TemplateDataValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("应返回数据校验错误: %v", err)
+	}
+	var violations []string
+	for _, v := range validationErr.Violations {
+		violations = append(violations, v.Path+" "+v.Message)
+	}
+	expected := []string{"groups[1].lines[0].amount is required", "order.lines[0].qty must be a number"}
+	if strings.Join(violations, "|") != strings.Join(expected, "|") {
+		t.Errorf("校验问题不正确:\n得到 %q\n期望 %q", violations, expected)
+	}
+}
+
+// TestTemplateDataFromJSON 测试从JSON和map生成模板数据
+func TestTemplateDataFromJSON(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(createTestImageData())
+	payload := `{
+		"customer": {"name": "张三", "address": {"city": "上海"}},
+		"orderNo": 12345678901,
+		"vip": true,
+		"items": [{"name": "笔", "qty": 2, "price": 3.5}, {"name": "纸", "qty": 1, "price": 10}],
+		"logo": "data:image/png;base64,` + encoded + `",
+		"seal": {"type": "image", "data": "` + encoded + `", "width": 30, "alt": "印章", "align": "center"},
+		"chart": {"type": "image", "path": "charts/sales.png"},
+		"meta": {"type": "report"}
+	}`
+
+	data, err := TemplateDataFromJSON([]byte(payload))
+	if err != nil {
+		t.Fatalf("解析JSON失败: %v", err)
+	}
+	if vip, ok := data.GetCondition("vip"); !ok || !vip {
+		t.Errorf("布尔值应为条件: %v", data.Conditions)
+	}
+	if items, ok := data.GetList("items"); !ok || len(items) != 2 || items[0].(map[string]interface{})["qty"] != 2 {
+		t.Errorf("数组应为列表且整数保持为int: %v", data.Lists["items"])
+	}
+	if data.Variables["orderNo"] != 12345678901 {
+		t.Errorf("大整数不应丢失精度: %#v", data.Variables["orderNo"])
+	}
+	if meta, ok := data.Variables["meta"].(map[string]interface{}); !ok || meta["type"] != "report" {
+		t.Errorf("非图片对象应为变量: %v", data.Variables["meta"])
+	}
+	if logo := data.Images["logo"]; logo == nil || len(logo.Data) != len(createTestImageData()) {
+		t.Errorf("data URI应解码为图片: %+v", logo)
+	}
+	seal := data.Images["seal"]
+	if seal == nil || seal.Config == nil || seal.Config.Size.Width != 30 || !seal.Config.Size.KeepAspectRatio ||
+		seal.Config.Alignment != AlignCenter || seal.AltText != "印章" {
+		t.Errorf("图片对象解析不正确: %+v", seal)
+	}
+	if chart := data.Images["chart"]; chart == nil || chart.FilePath != "charts/sales.png" || chart.Data != nil {
+		t.Errorf("路径图片解析不正确: %+v", chart)
+	}
+
+	engine := NewTemplateEngine()
+	content := "{{customer.name}}（{{customer.address.city}}）订单{{orderNo}}{{#if vip}}，贵宾{{/if}}\n" +
+		"{{#each items}}{{name}}×{{qty}}；{{/each}}\n{{#image logo}}"
+	if _, err := engine.LoadTemplate("order", content); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	doc, err := engine.RenderTemplateToDocument("order", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	texts := templateParagraphTexts(doc)
+	if len(texts) < 2 || texts[0] != "张三（上海）订单12345678901，贵宾" || texts[1] != "笔×2；纸×1；" {
+		t.Errorf("渲染结果不正确: %q", texts)
+	}
+
+	// map中的嵌套map、切片和结构体统一转换
+	type line struct {
+		SKU string
+	}
+	fromMap, err := TemplateDataFromMap(map[string]interface{}{
+		"lines":  []line{{SKU: "A1"}},
+		"active": false,
+		"tags":   map[string]string{"a": "b"},
+	})
+	if err != nil {
+		t.Fatalf("从map生成数据失败: %v", err)
+	}
+	if lines := fromMap.Lists["lines"]; len(lines) != 1 || lines[0].(map[string]interface{})["sku"] != "A1" {
+		t.Errorf("结构体切片应转换为列表: %v", fromMap.Lists)
+	}
+	if active, ok := fromMap.Conditions["active"]; !ok || active {
+		t.Errorf("布尔值应为条件: %v", fromMap.Conditions)
+	}
+	if _, ok := fromMap.Variables["tags"].(map[string]interface{}); !ok {
+		t.Errorf("map应转换为map[string]interface{}: %T", fromMap.Variables["tags"])
+	}
+
+	invalid := []string{
+		`[1, 2]`,
+		`null`,
+		`{"logo": {"type": "image", "data": "!!!"}}`,
+		`{"logo": {"type": "image"}}`,
+		`{"logo": {"type": "image", "path": "a.png", "align": "top"}}`,
+	}
+	for _, payload := range invalid {
+		if _, err := TemplateDataFromJSON([]byte(payload)); err == nil {
+			t.Errorf("无效数据应返回错误: %s", payload)
+		}
+	}
+}
+
+// TestTemplateMailMergeTextBoxAndChart 测试合并文档中文本框图片和图表的关系与绘图ID
+func TestTemplateMailMergeTextBoxAndChart(t *testing.T) {
+	base := New()
+	base.AddParagraph("{{name}}")
+	if _, err := base.AddChart(&ChartConfig{
+		Categories: []string{"A"},
+		Series:     []ChartSeries{{Name: "值", Values: []float64{1}}},
+	}); err != nil {
+		t.Fatalf("添加图表失败: %v", err)
+	}
+	box, err := base.AddTextBox(&ShapeConfig{Width: 60, Height: 40})
+	if err != nil {
+		t.Fatalf("添加文本框失败: %v", err)
+	}
+	if _, err := base.AddImageFromData(createTestImageData(), "boxed.png", ImageFormatPNG, 10, 10, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	last := len(base.Body.Elements) - 1
+	box.appendParagraph(base.Body.Elements[last].(*Paragraph))
+	base.Body.Elements = base.Body.Elements[:last]
+
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("card", base); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	index := 0
+	source := MailMergeFunc(func() (*TemplateData, error) {
+		index++
+		if index > 3 {
+			return nil, io.EOF
+		}
+		data := NewTemplateData()
+		data.SetVariable("name", fmt.Sprint(index))
+		return data, nil
+	})
+	merged, result, err := engine.MergeToDocument("card", source, &MailMergeOptions{Workers: 2})
+	if err != nil || result.Succeeded != 3 {
+		t.Fatalf("合并到单个文档失败: %v %+v", err, result)
+	}
+
+	opened := saveAndReopen(t, merged)
+	ids := make(map[string]bool)
+	opened.forEachDrawingRun(func(para *Paragraph, run *Run) bool {
+		id := drawingDocPr(run.Drawing).ID
+		if ids[id] {
+			t.Errorf("绘图ID重复: %s", id)
+		}
+		ids[id] = true
+		return true
+	})
+	if len(ids) != 9 {
+		t.Errorf("期望3个图表、3个文本框和3张文本框图片，得到 %d 个绘图", len(ids))
+	}
+	images := opened.ListImages()
+	if len(images) != 3 {
+		t.Fatalf("期望3张文本框图片，得到 %d", len(images))
+	}
+	for _, img := range images {
+		if rel := opened.findDocumentRelationship(img.RelationID); rel == nil || rel.Type != imageRelationshipType || len(img.Data) == 0 {
+			t.Errorf("图片 %s 的关系或数据不正确: %+v", img.ID, rel)
+		}
+	}
+	charts := opened.ListCharts()
+	if len(charts) != 3 {
+		t.Fatalf("期望3个图表，得到 %d", len(charts))
+	}
+	for _, chart := range charts {
+		rel := opened.findDocumentRelationship(chart.RelationID)
+		if rel == nil || rel.Type != chartRelationshipType || len(opened.parts[chart.PartName]) == 0 {
+			t.Errorf("图表关系不正确: %+v", rel)
+		}
+	}
+}
+
+// TestTemplateMailMerge 测试邮件合并
+func TestTemplateMailMerge(t *testing.T) {
+	engine := NewTemplateEngine()
+	content := "尊敬的{{name}}（{{address.city}}）：{{#if vip}}贵宾{{/if}}\n您的职位为{{position}}。"
+	if _, err := engine.LoadTemplate("offer", content); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	engine.SetRenderOptions(TemplateRenderOptions{Strict: true})
+
+	// CSV逐条输出文件，第3条记录缺少职位
+	csvData := "\ufeffname,address.city,vip,position\n张三,上海,true,工程师\n李四,北京,false,设计师\n王五,广州,FALSE\n赵六,深圳,false,产品经理\n"
+	dir := t.TempDir()
+	var progress []MailMergeProgress
+	result, err := engine.MergeToFiles("offer", NewCSVMergeSource(strings.NewReader(csvData)), &MailMergeOptions{
+		Workers:         3,
+		OutputDir:       dir,
+		FileNamePattern: "offer_{{@number}}_{{name}}",
+		Progress:        func(p MailMergeProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("邮件合并失败: %v", err)
+	}
+	if result.Total != 4 || result.Succeeded != 3 || len(result.Errors) != 1 || result.Errors[0].Index != 2 {
+		t.Fatalf("合并结果不正确: %+v", result)
+	}
+	if !errors.Is(result.Errors[0], ErrTemplateMissingData) {
+		t.Errorf("记录错误应保留原始错误: %v", result.Errors[0])
+	}
+	wantFiles := []string{"offer_1_张三.docx", "offer_2_李四.docx", "", "offer_4_赵六.docx"}
+	for i, want := range wantFiles {
+		if want == "" {
+			if result.Files[i] != "" {
+				t.Errorf("失败记录不应有输出文件: %q", result.Files[i])
+			}
+			continue
+		}
+		if result.Files[i] != filepath.Join(dir, want) {
+			t.Errorf("第%d条记录文件名不正确: %q", i, result.Files[i])
+		}
+	}
+	if len(progress) != 4 || progress[3].Completed != 4 || progress[3].Failed != 1 {
+		t.Errorf("进度回调不正确: %+v", progress)
+	}
+	doc, err := Open(result.Files[0])
+	if err != nil {
+		t.Fatalf("打开输出文件失败: %v", err)
+	}
+	if texts := templateParagraphTexts(doc); len(texts) < 1 || texts[0] != "尊敬的张三（上海）：贵宾" {
+		t.Errorf("输出文件内容不正确: %q", texts)
+	}
+
+	// 重复的文件名作为记录错误
+	result, err = engine.MergeToFiles("offer", NewCSVMergeSource(strings.NewReader(csvData)), &MailMergeOptions{
+		Workers:         1,
+		OutputDir:       t.TempDir(),
+		FileNamePattern: "固定",
+	})
+	if err != nil || len(result.Errors) != 3 {
+		t.Errorf("重复文件名应为记录错误: %v %+v", err, result)
+	}
+
+	// JSON Lines合并为单个文档，记录中的图片复制到合并文档
+	encoded := base64.StdEncoding.EncodeToString(createTestImageData())
+	engine.SetRenderOptions(TemplateRenderOptions{})
+	if _, err := engine.LoadTemplate("card", "{{name}}\n{{#image photo}}"); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	var lines strings.Builder
+	for _, name := range []string{"张三", "李四", "王五"} {
+		fmt.Fprintf(&lines, "{\"name\": %q, \"photo\": \"data:image/png;base64,%s\"}\n", name, encoded)
+	}
+	merged, result, err := engine.MergeToDocument("card", NewJSONLinesMergeSource(strings.NewReader(lines.String())), &MailMergeOptions{
+		Workers:              2,
+		Separator:            MailMergeSectionBreak,
+		RestartPageNumbering: true,
+	})
+	if err != nil || result.Succeeded != 3 {
+		t.Fatalf("合并到单个文档失败: %v %+v", err, result)
+	}
+	var names []string
+	sections, drawings := 0, make(map[string]bool)
+	for _, element := range merged.Body.Elements {
+		para, ok := element.(*Paragraph)
+		if !ok {
+			continue
+		}
+		if para.Properties != nil && para.Properties.SectionProperties != nil {
+			sections++
+			if para.Properties.SectionProperties.PageNumType == nil || para.Properties.SectionProperties.PageNumType.Start != "1" {
+				t.Errorf("分节符应重新开始页码")
+			}
+		}
+		for _, run := range para.Runs {
+			if run.Drawing != nil {
+				drawings[drawingDocPr(run.Drawing).ID] = true
+			}
+			if run.Text.Content != "" {
+				names = append(names, run.Text.Content)
+			}
+		}
+	}
+	if strings.Join(names, ",") != "张三,李四,王五" || sections != 2 || len(drawings) != 3 {
+		t.Errorf("合并文档内容不正确: %v 分节符=%d 图片=%d", names, sections, len(drawings))
+	}
+	if _, err := merged.ToBytes(); err != nil {
+		t.Errorf("合并文档应能保存: %v", err)
+	}
+
+	// 分页符分隔、迭代函数来源与出错后停止
+	index := 0
+	source := MailMergeFunc(func() (*TemplateData, error) {
+		index++
+		if index > 100 {
+			return nil, io.EOF
+		}
+		data := NewTemplateData()
+		if index != 2 {
+			data.SetVariable("name", fmt.Sprint(index))
+		}
+		return data, nil
+	})
+	if _, err := engine.LoadTemplate("name", "{{name}}"); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	engine.SetRenderOptions(TemplateRenderOptions{Strict: true})
+	_, result, err = engine.MergeToDocument("name", source, &MailMergeOptions{Workers: 1, StopOnError: true})
+	var mergeErr *MailMergeError
+	if !errors.As(err, &mergeErr) || mergeErr.Index != 1 || result.Total >= 100 {
+		t.Errorf("出错后应停止读取记录: %v %+v", err, result)
+	}
+
+	// 读取记录失败
+	if _, err := engine.MergeToFiles("offer", NewJSONLinesMergeSource(strings.NewReader("{\"name\": 1}\n[")), &MailMergeOptions{OutputDir: t.TempDir()}); err == nil {
+		t.Error("读取记录失败应返回错误")
+	}
+}
+
+// TestCompiledTemplate 测试预编译模板
+func TestCompiledTemplate(t *testing.T) {
+	engine := NewTemplateEngine()
+	if err := engine.RegisterFilter("shout", func(value interface{}, args ...interface{}) (interface{}, error) {
+		return fmt.Sprint(value) + "!", nil
+	}); err != nil {
+		t.Fatalf("注册过滤器失败: %v", err)
+	}
+	if err := engine.SetLocale("en-US"); err != nil {
+		t.Fatalf("设置区域格式失败: %v", err)
+	}
+	if _, err := engine.LoadTemplate("sign", "签名：{{signer | shout}}"); err != nil {
+		t.Fatalf("加载片段失败: %v", err)
+	}
+	if _, err := engine.LoadTemplate("base", "{{#block \"body\"}}默认{{/block}}\n{{> sign}}"); err != nil {
+		t.Fatalf("加载父模板失败: %v", err)
+	}
+	template, err := engine.LoadTemplate("letter", "{{extends \"base\"}}{{#block \"body\"}}{{name}}：{{amount | number:2}}{{#each items}}[{{this}}]{{/each}}{{/block}}")
+	if err != nil {
+		t.Fatalf("加载子模板失败: %v", err)
+	}
+
+	compiled, err := engine.Compile(template)
+	if err != nil {
+		t.Fatalf("编译模板失败: %v", err)
+	}
+	if compiled.Name() != "letter" {
+		t.Errorf("模板名称不正确: %s", compiled.Name())
+	}
+
+	newData := func(i int) *TemplateData {
+		data := NewTemplateData()
+		data.SetVariable("name", fmt.Sprintf("客户%d", i))
+		data.SetVariable("amount", 1234.5*float64(i))
+		data.SetVariable("signer", "李四")
+		data.SetList("items", []interface{}{i, i + 1})
+		return data
+	}
+	expected, err := engine.RenderTemplateToDocument("letter", newData(2))
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+	want := []string{"客户2：2,469.00[2][3]", "签名：李四!"}
+	if texts := templateParagraphTexts(expected); strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Fatalf("渲染结果不正确: %q", texts)
+	}
+
+	// 编译后修改引擎不影响已编译的模板
+	engine.SetLocale("de-DE")
+	engine.RegisterFilter("shout", func(value interface{}, args ...interface{}) (interface{}, error) {
+		return "?", nil
+	})
+	engine.RemoveTemplate("sign")
+	engine.SetRenderOptions(TemplateRenderOptions{Strict: true})
+
+	var wg sync.WaitGroup
+	results := make([][]string, 16)
+	errs := make([]error, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			doc, err := compiled.Render(newData(i))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = templateParagraphTexts(doc)
+		}(i)
+	}
+	wg.Wait()
+	for i, texts := range results {
+		if errs[i] != nil {
+			t.Fatalf("并发渲染失败: %v", errs[i])
+		}
+		if !strings.HasPrefix(texts[0], fmt.Sprintf("客户%d：", i)) || texts[1] != "签名：李四!" {
+			t.Errorf("第%d次渲染结果不正确: %q", i, texts)
+		}
+	}
+	if texts := results[2]; strings.Join(texts, "|") != strings.Join(want, "|") {
+		t.Errorf("预编译模板的渲染结果应与直接渲染一致: %q", texts)
+	}
+
+	// 编译时固定的渲染选项
+	doc, report, err := compiled.RenderWithReport(NewTemplateData())
+	if err != nil || doc == nil || len(report.Missing) != 4 {
+		t.Errorf("编译时的非严格模式应保留: %v %+v", err, report)
+	}
+
+	// 片段无法加载时编译失败
+	strict, err := engine.CompileTemplate("letter")
+	if err == nil || strict != nil {
+		t.Errorf("片段不存在时编译应失败")
+	}
+	if _, err := engine.CompileTemplate("missing"); err == nil {
+		t.Error("模板不存在时编译应失败")
+	}
+}
+
+// TestTemplateRichDocumentTableImages 测试文档值中表格内的图片复制到输出文档并分配新的关系ID和绘图ID
+func TestTemplateRichDocumentTableImages(t *testing.T) {
+	value := New()
+	table := value.AddTable(&TableConfig{Rows: 1, Cols: 1, Width: 2000})
+	if _, err := value.AddImageFromData(createTestImageData(), "cell.png", ImageFormatPNG, 10, 10, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	last := len(value.Body.Elements) - 1
+	table.Rows[0].Cells[0].Paragraphs = []Paragraph{*value.Body.Elements[last].(*Paragraph)}
+	value.Body.Elements = value.Body.Elements[:last]
+
+	source := New()
+	if _, err := source.AddImageFromData(createTestImage(20, 20), "main.png", ImageFormatPNG, 20, 20, nil); err != nil {
+		t.Fatalf("添加图片失败: %v", err)
+	}
+	source.AddParagraph("{{doc}}")
+	source.AddParagraph("{{doc}}")
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("rich", source); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	data := NewTemplateData()
+	data.SetVariable("doc", value)
+	doc, err := engine.RenderTemplateToDocument("rich", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+
+	images := saveAndReopen(t, doc).ListImages()
+	if len(images) != 3 {
+		t.Fatalf("期望3张图片，得到 %d", len(images))
+	}
+	if images[0].ID == images[1].ID || images[1].ID == images[2].ID || images[1].RelationID == images[0].RelationID || images[1].RelationID != images[2].RelationID {
+		t.Errorf("表格中的图片应使用新的绘图ID和关系ID: %+v", images)
+	}
+	if len(images[1].Data) == 0 {
+		t.Error("表格中的图片数据丢失")
+	}
+}
+
+// TestTemplateRichContent 测试富文本、段落、表格、超链接与HTML变量值
+func TestTemplateRichContent(t *testing.T) {
+	source := New()
+	source.AddFormattedParagraph("摘要：{{summary}}。", &TextFormat{FontFamily: "宋体", FontSize: 12})
+	source.AddParagraph("{{details}}").SetAlignment(AlignCenter)
+	source.AddParagraph("{{html}}")
+	source.AddParagraph("{{table}}")
+	source.AddParagraph("访问{{site}}")
+
+	engine := NewTemplateEngine()
+	if _, err := engine.LoadTemplateFromDocument("rich", source); err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+
+	table := New().CreateTable(&TableConfig{Rows: 2, Cols: 2, Width: 4000, Data: [][]string{{"名称", "数量"}, {"笔", "2"}}})
+	data := NewTemplateData()
+	data.SetVariable("summary", NewRichText().AddText("营收", nil).AddText("增长20%", &TextFormat{Bold: true, FontColor: "#FF0000"}))
+	data.SetVariable("details", []*Paragraph{
+		{Runs: []Run{{Text: Text{Content: "第一段"}}}},
+		{Properties: &ParagraphProperties{ParagraphStyle: &ParagraphStyle{Val: "Heading2"}}, Runs: []Run{{Text: Text{Content: "第二段"}}}},
+	})
+	data.SetVariable("html", HTMLContent(`<h1>标题</h1><p>正文<b>加粗</b><span style="color:#00f">蓝色</span></p><ul><li>甲</li><li>乙</li></ul>`))
+	data.SetVariable("table", table)
+	data.SetVariable("site", NewTemplateLink("官网", "https://example.com"))
+
+	doc, err := engine.RenderTemplateToDocument("rich", data)
+	if err != nil {
+		t.Fatalf("渲染模板失败: %v", err)
+	}
+
+	var paragraphs []*Paragraph
+	var tables []*Table
+	for _, element := range doc.Body.Elements {
+		switch e := element.(type) {
+		case *Paragraph:
+			paragraphs = append(paragraphs, e)
+		case *Table:
+			tables = append(tables, e)
+		}
+	}
+	texts := make([]string, len(paragraphs))
+	for i, p := range paragraphs {
+		for _, run := range p.Runs {
+			texts[i] += run.Text.Content
+		}
+	}
+	expected := []string{"摘要：营收增长20%。", "第一段", "第二段", "标题", "正文加粗蓝色", "• 甲", "• 乙", "访问官网"}
+	if strings.Join(texts, "|") != strings.Join(expected, "|") {
+		t.Fatalf("段落内容不正确:\n%q\n期望:\n%q", texts, expected)
+	}
+
+	// 富文本沿用占位符字体并应用自身格式
+	summary := paragraphs[0].Runs
+	if len(summary) != 4 || summary[1].Properties.FontFamily.ASCII != "宋体" || summary[1].Properties.Bold != nil {
+		t.Errorf("普通片段应沿用占位符格式: %+v", summary)
+	}
+	if p := summary[2].Properties; p.Bold == nil || p.Color == nil || p.Color.Val != "FF0000" || p.FontFamily.ASCII != "宋体" {
+		t.Errorf("格式片段应为红色加粗: %+v", p)
+	}
+
+	// 段落沿用占位符段落的对齐，指定样式的段落保持自身样式
+	if p := paragraphs[1].Properties; p == nil || p.Justification == nil || p.Justification.Val != "center" {
+		t.Errorf("段落应沿用占位符段落的格式: %+v", p)
+	}
+	if p := paragraphs[2].Properties; p.ParagraphStyle == nil || p.ParagraphStyle.Val != "Heading2" {
+		t.Errorf("段落样式应保留: %+v", p)
+	}
+
+	// HTML
+	if p := paragraphs[3].Properties; p == nil || p.ParagraphStyle == nil || p.ParagraphStyle.Val != "Heading1" {
+		t.Errorf("h1应使用标题样式: %+v", p)
+	}
+	body := paragraphs[4].Runs
+	if len(body) != 3 || body[1].Properties.Bold == nil || body[2].Properties.Color == nil || body[2].Properties.Color.Val != "0000FF" {
+		t.Errorf("HTML行内格式不正确: %+v", body)
+	}
+
+	// 表格在占位符位置插入，原表格不受影响
+	if len(tables) != 1 || tables[0] == table || tables[0].Rows[1].Cells[0].Paragraphs[0].Runs[0].Text.Content != "笔" {
+		t.Errorf("表格应复制到占位符位置: %v", tables)
+	}
+	if doc.Body.Elements[7] != tables[0] {
+		t.Errorf("表格位置不正确: %T", doc.Body.Elements[7])
+	}
+
+	// 超链接以HYPERLINK域输出
+	link := paragraphs[7].Runs
+	if len(link) != 6 || link[2].InstrText == nil || !strings.Contains(link[2].InstrText.Content, `HYPERLINK "https://example.com"`) ||
+		link[4].Properties.Underline == nil || link[5].FieldChar.FieldCharType != "end" {
+		t.Errorf("超链接结构不正确: %+v", link)
+	}
+
+	// 只含行内标签的HTML插入当前段落
+	elements, inline := parseTemplateHTML(`A<i>B</i><br/>C &amp; D`, engine, nil)
+	if !inline || len(elements) != 1 || len(elements[0].(*Paragraph).Runs) != 4 {
+		t.Errorf("行内HTML解析不正确: %v %+v", inline, elements)
+	}
+}
+
+// createTestImageData 创建测试用的图片数据
+func createTestImageData() []byte {
+	// 创建一个最小的PNG图片数据用于测试
+	return []byte{
+		0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A,
+		0x00, 0x00, 0x00, 0x0D, 0x49, 0x48, 0x44, 0x52,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+		0x08, 0x06, 0x00, 0x00, 0x00, 0x1F, 0x15, 0xC4,
+		0x89, 0x00, 0x00, 0x00, 0x0A, 0x49, 0x44, 0x41,
+		0x54, 0x78, 0x9C, 0x63, 0x00, 0x01, 0x00, 0x00,
+		0x05, 0x00, 0x01, 0x0D, 0x0A, 0x2D, 0xB4, 0x00,
+		0x00, 0x00, 0x00, 0x49, 0x45, 0x4E, 0x44, 0xAE,
+		0x42, 0x60, 0x82,
+	}
+}
+
+// TestTemplateMalformedHTML 测试无法解析的HTML按纯文本输出且不残留未闭合的超链接域
+func TestTemplateMalformedHTML(t *testing.T) {
+	engine := NewTemplateEngine()
+	paragraphText := func(para *Paragraph) string {
+		var builder strings.Builder
+		for _, run := range para.Runs {
+			builder.WriteString(run.Text.Content)
+		}
+		return builder.String()
+	}
+	checkFields := func(source string, paragraphs []interface{}) {
+		for _, element := range paragraphs {
+			depth := 0
+			for _, run := range element.(*Paragraph).Runs {
+				if run.FieldChar == nil {
+					continue
+				}
+				switch run.FieldChar.FieldCharType {
+				case "begin":
+					depth++
+				case "end":
+					depth--
+				}
+			}
+			if depth != 0 {
+				t.Errorf("%q 的段落中有未闭合的域: %+v", source, element)
+			}
+		}
+	}
+
+	for _, source := range []string{`x < y & z`, `<a href="https://example.com">链接 a < b`, `<p>甲</p><p>乙 < 丙</p>`} {
+		elements, inline := parseTemplateHTML(source, engine, nil)
+		checkFields(source, elements)
+		if !inline || len(elements) != 1 || paragraphText(elements[0].(*Paragraph)) != source {
+			t.Errorf("无法解析的HTML应整体按纯文本输出: %q -> %v %+v", source, inline, elements)
+		}
+	}
+
+	// 未闭合的超链接在段落结束时关闭
+	for _, source := range []string{`<a href="https://example.com">链接`, `<p><a href="https://example.com">链接</p><p>后文</p>`} {
+		elements, _ := parseTemplateHTML(source, engine, nil)
+		checkFields(source, elements)
+		if len(elements) == 0 || !strings.Contains(paragraphText(elements[0].(*Paragraph)), "链接") {
+			t.Errorf("超链接文本丢失: %q %+v", source, elements)
+		}
 	}
 }
@@ -47,6 +47,9 @@ func NewConverter(opts *ConvertOptions) *Converter {
 	if opts.EnableFootnotes {
 		extensions = append(extensions, extension.Footnote)
 	}
+	if opts.EnableMath {
+		extensions = append(extensions, &mathExtension{})
+	}
 
 	md := goldmark.New(
 		goldmark.WithExtensions(extensions...),
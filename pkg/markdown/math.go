@@ -0,0 +1,219 @@
+package markdown
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/ZeroHawkeye/wordZero/pkg/document"
+)
+
+// KindMathInline 行内公式节点类型
+var KindMathInline = ast.NewNodeKind("MathInline")
+
+// KindMathBlock 公式块节点类型
+var KindMathBlock = ast.NewNodeKind("MathBlock")
+
+// MathInline 行内公式节点（$...$）
+type MathInline struct {
+	ast.BaseInline
+	Value []byte // LaTeX内容
+}
+
+// Kind 实现ast.Node接口
+func (n *MathInline) Kind() ast.NodeKind {
+	return KindMathInline
+}
+
+// Dump 实现ast.Node接口
+func (n *MathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Value": string(n.Value)}, nil)
+}
+
+// MathBlock 公式块节点（$$...$$）
+type MathBlock struct {
+	ast.BaseBlock
+	closed bool // 单行公式块在开始行即已结束
+}
+
+// Kind 实现ast.Node接口
+func (n *MathBlock) Kind() ast.NodeKind {
+	return KindMathBlock
+}
+
+// IsRaw 实现ast.Node接口，公式块内容不进行内联解析
+func (n *MathBlock) IsRaw() bool {
+	return true
+}
+
+// Dump 实现ast.Node接口
+func (n *MathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// LaTeX 返回公式块的LaTeX内容
+func (n *MathBlock) LaTeX(source []byte) string {
+	var buf bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		buf.Write(segment.Value(source))
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// mathInlineParser 解析$...$与行内$$...$$
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	delimiter := 1
+	if len(line) > 1 && line[1] == '$' {
+		delimiter = 2
+	}
+
+	// 开始定界符后不能是空白，避免将金额等误识别为公式
+	if len(line) <= delimiter || util.IsSpace(line[delimiter]) {
+		return nil
+	}
+
+	for i := delimiter; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '$':
+			if delimiter == 2 && (i+1 >= len(line) || line[i+1] != '$') {
+				continue
+			}
+			// 结束定界符前不能是空白，之后不能紧跟数字
+			end := i + delimiter
+			if util.IsSpace(line[i-1]) || (end < len(line) && line[end] >= '0' && line[end] <= '9') {
+				continue
+			}
+			value := line[delimiter:i]
+			if len(value) == 0 {
+				return nil
+			}
+			block.Advance(end)
+			return &MathInline{Value: append([]byte(nil), value...)}
+		case '\n':
+			return nil
+		}
+	}
+	return nil
+}
+
+// mathBlockParser 解析以$$开始和结束的公式块
+type mathBlockParser struct{}
+
+func (p *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+
+	node := &MathBlock{}
+	start := pos + 2
+	rest := line[start:]
+	if end := bytes.Index(rest, []byte("$$")); end >= 0 {
+		// 单行公式块：$$...$$后不能有其他内容
+		if !util.IsBlank(rest[end+2:]) {
+			return nil, parser.NoChildren
+		}
+		node.Lines().Append(text.NewSegment(segment.Start+start, segment.Start+start+end))
+		node.closed = true
+	} else if !util.IsBlank(rest) {
+		node.Lines().Append(text.NewSegment(segment.Start+start, segment.Stop))
+	}
+	return node, parser.NoChildren
+}
+
+func (p *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	if node.(*MathBlock).closed {
+		return parser.Close
+	}
+
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	newline := 0
+	if line[len(line)-1] == '\n' {
+		newline = 1
+	}
+	if end := bytes.Index(line, []byte("$$")); end >= 0 {
+		node.Lines().Append(text.NewSegment(segment.Start, segment.Start+end))
+		reader.Advance(segment.Len() - newline)
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - newline)
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// mathExtension 为goldmark添加$...$和$$...$$公式语法
+type mathExtension struct{}
+
+// Extend 实现goldmark.Extender接口
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 750)),
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 150)),
+	)
+}
+
+// renderMathBlock 渲染公式块为独立显示公式
+func (r *WordRenderer) renderMathBlock(node *MathBlock) (ast.WalkStatus, error) {
+	latex := node.LaTeX(r.source)
+	if latex == "" {
+		return ast.WalkSkipChildren, nil
+	}
+
+	if _, err := r.doc.AddLaTeXEquation(latex); err != nil {
+		convErr := NewConversionError("InvalidMath", "invalid latex formula", 0, 0, err)
+		if r.opts.ErrorCallback != nil {
+			r.opts.ErrorCallback(convErr)
+		}
+		if r.opts.StrictMode {
+			return ast.WalkStop, convErr
+		}
+		// 解析失败时保留原始LaTeX文本
+		r.doc.AddParagraph("$$" + latex + "$$")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// renderMathInline 渲染行内公式，解析失败时保留原始文本
+func (r *WordRenderer) renderMathInline(node *MathInline, para *document.Paragraph) {
+	latex := string(node.Value)
+	if _, err := para.AddLaTeXEquation(latex); err != nil {
+		if r.opts.ErrorCallback != nil {
+			r.opts.ErrorCallback(NewConversionError("InvalidMath", "invalid latex formula", 0, 0, err))
+		}
+		para.AddFormattedText("$"+latex+"$", nil)
+	}
+}
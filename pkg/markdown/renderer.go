@@ -86,6 +86,10 @@ func (r *WordRenderer) Render(doc ast.Node) error {
 			// TableCell节点由Table处理
 			return ast.WalkSkipChildren, nil
 
+		// 数学公式支持
+		case *MathBlock:
+			return r.renderMathBlock(n)
+
 		// 任务列表支持
 		case *extast.TaskCheckBox:
 			if r.opts.EnableTaskList {
@@ -182,6 +186,9 @@ func (r *WordRenderer) renderInlineContent(node ast.Node, para *document.Paragra
 		case *ast.Image:
 			r.renderImageInline(n, para)
 
+		case *MathInline:
+			r.renderMathInline(n, para)
+
 		default:
 			// 对于其他类型，尝试提取文本内容
 			text := r.extractTextContent(n)
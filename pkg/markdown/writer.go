@@ -124,6 +124,8 @@ func (w *MarkdownWriter) writeParagraph(para *document.Paragraph) error {
 	style := w.getParagraphStyle(para)
 
 	switch {
+	case w.isMathParagraph(para):
+		return w.writeMathBlock(para)
 	case strings.HasPrefix(style, "Heading"):
 		return w.writeHeading(para, style)
 	case style == "Quote":
@@ -232,6 +234,14 @@ func (w *MarkdownWriter) writeNormalParagraph(para *document.Paragraph) error {
 	return nil
 }
 
+// writeMathBlock 写入独立显示公式
+func (w *MarkdownWriter) writeMathBlock(para *document.Paragraph) error {
+	for _, equation := range para.Equations() {
+		w.output.WriteString("$$\n" + equation.LaTeX() + "\n$$\n\n")
+	}
+	return nil
+}
+
 // writeTable 写入表格
 func (w *MarkdownWriter) writeTable(table *document.Table) error {
 	if table == nil || len(table.Rows) == 0 {
@@ -330,6 +340,14 @@ func (w *MarkdownWriter) formatRunText(run *document.Run) string {
 		return ""
 	}
 
+	// 公式导出为LaTeX
+	if run.Equation != nil {
+		if run.Equation.Display {
+			return "$$" + run.Equation.LaTeX() + "$$"
+		}
+		return "$" + run.Equation.LaTeX() + "$"
+	}
+
 	text := run.Text.Content
 	if text == "" {
 		return ""
@@ -370,9 +388,13 @@ func (w *MarkdownWriter) extractCellText(cell *document.TableCell) string {
 
 	var result strings.Builder
 
-	for _, para := range cell.Paragraphs {
-		text := w.extractParagraphText(&para)
-		result.WriteString(text)
+	for _, element := range cell.Elements() {
+		switch e := element.(type) {
+		case *document.Paragraph:
+			result.WriteString(w.extractParagraphText(e))
+		case *document.Table:
+			result.WriteString(w.nestedTableText(e))
+		}
 	}
 
 	// 清理表格单元格中的换行符
@@ -383,6 +405,37 @@ func (w *MarkdownWriter) extractCellText(cell *document.TableCell) string {
 	return text
 }
 
+// nestedTableText 将单元格中的嵌套表格转换为单行文本。
+// Markdown表格不支持嵌套，GFM表格中输出为内联HTML表格，简单表格中以逗号分隔单元格、分号分隔行
+func (w *MarkdownWriter) nestedTableText(table *document.Table) string {
+	var result strings.Builder
+	if w.opts.UseGFMTables {
+		result.WriteString("<table>")
+		for _, row := range table.Rows {
+			result.WriteString("<tr>")
+			for _, cell := range row.Cells {
+				result.WriteString("<td>" + w.extractCellText(&cell) + "</td>")
+			}
+			result.WriteString("</tr>")
+		}
+		result.WriteString("</table>")
+		return result.String()
+	}
+
+	for i, row := range table.Rows {
+		if i > 0 {
+			result.WriteString("; ")
+		}
+		for j, cell := range row.Cells {
+			if j > 0 {
+				result.WriteString(", ")
+			}
+			result.WriteString(w.extractCellText(&cell))
+		}
+	}
+	return " " + result.String() + " "
+}
+
 // getParagraphStyle 获取段落样式
 func (w *MarkdownWriter) getParagraphStyle(para *document.Paragraph) string {
 	if para.Properties != nil && para.Properties.ParagraphStyle != nil {
@@ -412,6 +465,20 @@ func (w *MarkdownWriter) isListParagraph(para *document.Paragraph) bool {
 	return para.Properties.NumberingProperties != nil
 }
 
+// isMathParagraph 检查段落是否只包含独立显示公式
+func (w *MarkdownWriter) isMathParagraph(para *document.Paragraph) bool {
+	hasEquation := false
+	for _, run := range para.Runs {
+		switch {
+		case run.Equation != nil && run.Equation.Display:
+			hasEquation = true
+		case run.Equation != nil, strings.TrimSpace(run.Text.Content) != "", run.Drawing != nil:
+			return false
+		}
+	}
+	return hasEquation
+}
+
 // isNumberedList 判断是否为编号列表
 func (w *MarkdownWriter) isNumberedList(para *document.Paragraph) bool {
 	// 简单实现，实际应该检查编号格式
@@ -35,6 +35,8 @@ type Style struct {
 	TablePr     *TableProperties     `xml:"w:tblPr,omitempty"`
 	TableRowPr  *TableRowProperties  `xml:"w:trPr,omitempty"`
 	TableCellPr *TableCellProperties `xml:"w:tcPr,omitempty"`
+	// 表格条件格式，仅用于表格样式
+	TableStylePr []*TableStylePr `xml:"w:tblStylePr,omitempty"`
 }
 
 // StyleName 样式名称
@@ -111,10 +113,17 @@ type RunProperties struct {
 
 // TableProperties 表格样式属性
 type TableProperties struct {
-	XMLName    xml.Name       `xml:"w:tblPr"`
-	TblInd     *TblIndent     `xml:"w:tblInd,omitempty"`     // 表格缩进
-	TblBorders *TblBorders    `xml:"w:tblBorders,omitempty"` // 表格边框
-	TblCellMar *TblCellMargin `xml:"w:tblCellMar,omitempty"` // 表格单元格边距
+	XMLName             xml.Name          `xml:"w:tblPr"`
+	TblStyleRowBandSize *TblStyleBandSize `xml:"w:tblStyleRowBandSize,omitempty"` // 行条带包含的行数
+	TblStyleColBandSize *TblStyleBandSize `xml:"w:tblStyleColBandSize,omitempty"` // 列条带包含的列数
+	TblInd              *TblIndent        `xml:"w:tblInd,omitempty"`              // 表格缩进
+	TblBorders          *TblBorders       `xml:"w:tblBorders,omitempty"`          // 表格边框
+	TblCellMar          *TblCellMargin    `xml:"w:tblCellMar,omitempty"`          // 表格单元格边距
+}
+
+// TblStyleBandSize 表格样式的条带大小
+type TblStyleBandSize struct {
+	Val string `xml:"w:val,attr"`
 }
 
 // TblIndent 表格缩进
@@ -165,9 +174,29 @@ type TableRowProperties struct {
 }
 
 // TableCellProperties 表格单元格样式属性
+// 注意：字段顺序必须符合OpenXML标准
 type TableCellProperties struct {
-	XMLName xml.Name `xml:"w:tcPr"`
-	// 表格单元格样式属性将在后续实现
+	XMLName   xml.Name   `xml:"w:tcPr"`
+	TcBorders *TcBorders `xml:"w:tcBorders,omitempty"` // 单元格边框
+	Shading   *Shading   `xml:"w:shd,omitempty"`       // 单元格底纹
+	VAlign    *VAlign    `xml:"w:vAlign,omitempty"`    // 垂直对齐
+}
+
+// TcBorders 单元格边框
+type TcBorders struct {
+	XMLName xml.Name   `xml:"w:tcBorders"`
+	Top     *TblBorder `xml:"w:top,omitempty"`
+	Left    *TblBorder `xml:"w:left,omitempty"`
+	Bottom  *TblBorder `xml:"w:bottom,omitempty"`
+	Right   *TblBorder `xml:"w:right,omitempty"`
+	InsideH *TblBorder `xml:"w:insideH,omitempty"`
+	InsideV *TblBorder `xml:"w:insideV,omitempty"`
+}
+
+// VAlign 单元格垂直对齐
+type VAlign struct {
+	XMLName xml.Name `xml:"w:vAlign"`
+	Val     string   `xml:"w:val,attr"`
 }
 
 // 基础样式元素定义
@@ -1366,6 +1395,21 @@ func (sm *StyleManager) cloneStyle(source *Style) *Style {
 		cloned.TableCellPr = sm.cloneTableCellProperties(source.TableCellPr)
 	}
 
+	// 克隆表格条件格式
+	for _, pr := range source.TableStylePr {
+		if pr == nil {
+			continue
+		}
+		cloned.TableStylePr = append(cloned.TableStylePr, &TableStylePr{
+			Type:        pr.Type,
+			ParagraphPr: sm.cloneParagraphProperties(pr.ParagraphPr),
+			RunPr:       sm.cloneRunProperties(pr.RunPr),
+			TablePr:     sm.cloneTableProperties(pr.TablePr),
+			TableRowPr:  sm.cloneTableRowProperties(pr.TableRowPr),
+			TableCellPr: sm.cloneTableCellProperties(pr.TableCellPr),
+		})
+	}
+
 	return cloned
 }
 
@@ -1531,6 +1575,14 @@ func (sm *StyleManager) cloneTableProperties(source *TableProperties) *TableProp
 
 	cloned := &TableProperties{}
 
+	// 克隆条带大小
+	if source.TblStyleRowBandSize != nil {
+		cloned.TblStyleRowBandSize = &TblStyleBandSize{Val: source.TblStyleRowBandSize.Val}
+	}
+	if source.TblStyleColBandSize != nil {
+		cloned.TblStyleColBandSize = &TblStyleBandSize{Val: source.TblStyleColBandSize.Val}
+	}
+
 	// 克隆表格缩进
 	if source.TblInd != nil {
 		cloned.TblInd = &TblIndent{
@@ -1652,12 +1704,42 @@ func (sm *StyleManager) cloneTableCellProperties(source *TableCellProperties) *T
 		return nil
 	}
 
-	// 目前表格单元格属性是空结构体，简单返回新实例
 	cloned := &TableCellProperties{}
 
+	// 克隆单元格边框
+	if source.TcBorders != nil {
+		cloned.TcBorders = &TcBorders{
+			Top:     cloneTblBorder(source.TcBorders.Top),
+			Left:    cloneTblBorder(source.TcBorders.Left),
+			Bottom:  cloneTblBorder(source.TcBorders.Bottom),
+			Right:   cloneTblBorder(source.TcBorders.Right),
+			InsideH: cloneTblBorder(source.TcBorders.InsideH),
+			InsideV: cloneTblBorder(source.TcBorders.InsideV),
+		}
+	}
+
+	// 克隆单元格底纹
+	if source.Shading != nil {
+		cloned.Shading = &Shading{Fill: source.Shading.Fill, Val: source.Shading.Val}
+	}
+
+	// 克隆垂直对齐
+	if source.VAlign != nil {
+		cloned.VAlign = &VAlign{Val: source.VAlign.Val}
+	}
+
 	return cloned
 }
 
+// cloneTblBorder 深拷贝边框定义
+func cloneTblBorder(source *TblBorder) *TblBorder {
+	if source == nil {
+		return nil
+	}
+	cloned := *source
+	return &cloned
+}
+
 // GetStylesByType 按类型获取样式
 func (sm *StyleManager) GetStylesByType(styleType StyleType) []*Style {
 	var styles []*Style
@@ -0,0 +1,293 @@
+// Package style 表格样式构建器
+package style
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+// TableCondition 表格条件格式的作用区域
+type TableCondition string
+
+const (
+	// TableConditionFirstRow 标题行
+	TableConditionFirstRow TableCondition = "firstRow"
+	// TableConditionLastRow 汇总行
+	TableConditionLastRow TableCondition = "lastRow"
+	// TableConditionFirstColumn 第一列
+	TableConditionFirstColumn TableCondition = "firstCol"
+	// TableConditionLastColumn 最后一列
+	TableConditionLastColumn TableCondition = "lastCol"
+	// TableConditionBand1Vertical 奇数列条带
+	TableConditionBand1Vertical TableCondition = "band1Vert"
+	// TableConditionBand2Vertical 偶数列条带
+	TableConditionBand2Vertical TableCondition = "band2Vert"
+	// TableConditionBand1Horizontal 奇数行条带
+	TableConditionBand1Horizontal TableCondition = "band1Horz"
+	// TableConditionBand2Horizontal 偶数行条带
+	TableConditionBand2Horizontal TableCondition = "band2Horz"
+	// TableConditionTopRightCell 右上角单元格
+	TableConditionTopRightCell TableCondition = "neCell"
+	// TableConditionTopLeftCell 左上角单元格
+	TableConditionTopLeftCell TableCondition = "nwCell"
+	// TableConditionBottomRightCell 右下角单元格
+	TableConditionBottomRightCell TableCondition = "seCell"
+	// TableConditionBottomLeftCell 左下角单元格
+	TableConditionBottomLeftCell TableCondition = "swCell"
+)
+
+// tableConditionOrder Word输出条件格式的顺序
+var tableConditionOrder = map[TableCondition]int{
+	TableConditionBand1Vertical:   1,
+	TableConditionBand2Vertical:   2,
+	TableConditionBand1Horizontal: 3,
+	TableConditionBand2Horizontal: 4,
+	TableConditionFirstRow:        5,
+	TableConditionLastRow:         6,
+	TableConditionFirstColumn:     7,
+	TableConditionLastColumn:      8,
+	TableConditionTopRightCell:    9,
+	TableConditionTopLeftCell:     10,
+	TableConditionBottomRightCell: 11,
+	TableConditionBottomLeftCell:  12,
+}
+
+// TableStylePr 表格样式的条件格式
+type TableStylePr struct {
+	XMLName     xml.Name             `xml:"w:tblStylePr"`
+	Type        string               `xml:"w:type,attr"`
+	ParagraphPr *ParagraphProperties `xml:"w:pPr,omitempty"`
+	RunPr       *RunProperties       `xml:"w:rPr,omitempty"`
+	TablePr     *TableProperties     `xml:"w:tblPr,omitempty"`
+	TableRowPr  *TableRowProperties  `xml:"w:trPr,omitempty"`
+	TableCellPr *TableCellProperties `xml:"w:tcPr,omitempty"`
+}
+
+// TableStyleBorder 表格样式边框线
+type TableStyleBorder struct {
+	Style string `json:"style"`           // 线型，如single、double、dashed、none
+	Size  int    `json:"size,omitempty"`  // 线宽（1/8磅），0表示4
+	Color string `json:"color,omitempty"` // 颜色（十六进制），为空表示auto
+}
+
+// TableStyleBorders 表格样式边框
+type TableStyleBorders struct {
+	Top     *TableStyleBorder `json:"top,omitempty"`
+	Left    *TableStyleBorder `json:"left,omitempty"`
+	Bottom  *TableStyleBorder `json:"bottom,omitempty"`
+	Right   *TableStyleBorder `json:"right,omitempty"`
+	InsideH *TableStyleBorder `json:"insideH,omitempty"` // 内部横线
+	InsideV *TableStyleBorder `json:"insideV,omitempty"` // 内部竖线
+}
+
+// TableConditionFormat 表格区域格式
+type TableConditionFormat struct {
+	Run           *QuickRunConfig       `json:"run,omitempty"`           // 字符格式
+	Paragraph     *QuickParagraphConfig `json:"paragraph,omitempty"`     // 段落格式
+	Borders       *TableStyleBorders    `json:"borders,omitempty"`       // 边框
+	Shading       string                `json:"shading,omitempty"`       // 单元格底纹颜色（十六进制）
+	VerticalAlign string                `json:"verticalAlign,omitempty"` // 垂直对齐：top、center、bottom
+}
+
+// TableStyleBuilder 表格样式构建器
+//
+// 除整体格式外，可以为标题行、汇总行、首末列、行列条带和四个角单元格定义条件格式（w:tblStylePr）。
+// 条件格式是否生效由表格的tblLook决定，如 document.Table.ApplyTableStyle 中的 FirstRowHeader、BandedRows 等选项。
+//
+//	tableStyle := style.NewTableStyleBuilder("ReportTable", "报表").
+//		Table(&style.TableConditionFormat{Borders: &style.TableStyleBorders{InsideH: &style.TableStyleBorder{Style: "single", Color: "BFBFBF"}}}).
+//		FirstRow(&style.TableConditionFormat{Run: &style.QuickRunConfig{Bold: true, FontColor: "FFFFFF"}, Shading: "1F4E79"}).
+//		BandedRows(nil, &style.TableConditionFormat{Shading: "F2F2F2"}).
+//		Build()
+type TableStyleBuilder struct {
+	style      *Style
+	conditions map[TableCondition]*TableStylePr
+}
+
+// NewTableStyleBuilder 创建表格样式构建器，样式默认基于Normal Table
+func NewTableStyleBuilder(styleID, name string) *TableStyleBuilder {
+	return &TableStyleBuilder{
+		style: &Style{
+			Type:        string(StyleTypeTable),
+			StyleID:     styleID,
+			CustomStyle: true,
+			Name:        &StyleName{Val: name},
+			BasedOn:     &BasedOn{Val: "a1"},
+		},
+		conditions: make(map[TableCondition]*TableStylePr),
+	}
+}
+
+// BasedOn 设置基础样式，为空时不继承
+func (b *TableStyleBuilder) BasedOn(styleID string) *TableStyleBuilder {
+	if styleID == "" {
+		b.style.BasedOn = nil
+	} else {
+		b.style.BasedOn = &BasedOn{Val: styleID}
+	}
+	return b
+}
+
+// Table 设置整个表格的格式，边框作用于表格的外框和内部线
+func (b *TableStyleBuilder) Table(format *TableConditionFormat) *TableStyleBuilder {
+	if format == nil {
+		return b
+	}
+	if format.Run != nil {
+		b.style.RunPr = createRunProperties(format.Run)
+	}
+	if format.Paragraph != nil {
+		b.style.ParagraphPr = createParagraphProperties(format.Paragraph)
+	}
+	if format.Borders != nil {
+		if b.style.TablePr == nil {
+			b.style.TablePr = &TableProperties{}
+		}
+		borders := format.Borders
+		b.style.TablePr.TblBorders = &TblBorders{
+			Top:     createTableStyleBorder(borders.Top),
+			Left:    createTableStyleBorder(borders.Left),
+			Bottom:  createTableStyleBorder(borders.Bottom),
+			Right:   createTableStyleBorder(borders.Right),
+			InsideH: createTableStyleBorder(borders.InsideH),
+			InsideV: createTableStyleBorder(borders.InsideV),
+		}
+	}
+	if cellPr := createTableStyleCellProperties(format, false); cellPr != nil {
+		b.style.TableCellPr = cellPr
+	}
+	return b
+}
+
+// BandSize 设置每个行条带和列条带包含的行数、列数，小于等于0时保持默认值1
+func (b *TableStyleBuilder) BandSize(rows, cols int) *TableStyleBuilder {
+	if rows <= 0 && cols <= 0 {
+		return b
+	}
+	if b.style.TablePr == nil {
+		b.style.TablePr = &TableProperties{}
+	}
+	if rows > 0 {
+		b.style.TablePr.TblStyleRowBandSize = &TblStyleBandSize{Val: fmt.Sprintf("%d", rows)}
+	}
+	if cols > 0 {
+		b.style.TablePr.TblStyleColBandSize = &TblStyleBandSize{Val: fmt.Sprintf("%d", cols)}
+	}
+	return b
+}
+
+// Condition 设置指定区域的条件格式，重复设置时覆盖之前的格式，format为nil时删除该条件格式
+func (b *TableStyleBuilder) Condition(condition TableCondition, format *TableConditionFormat) *TableStyleBuilder {
+	if format == nil {
+		delete(b.conditions, condition)
+		return b
+	}
+	pr := &TableStylePr{Type: string(condition)}
+	if format.Run != nil {
+		pr.RunPr = createRunProperties(format.Run)
+	}
+	if format.Paragraph != nil {
+		pr.ParagraphPr = createParagraphProperties(format.Paragraph)
+	}
+	pr.TableCellPr = createTableStyleCellProperties(format, true)
+	b.conditions[condition] = pr
+	return b
+}
+
+// FirstRow 设置标题行格式
+func (b *TableStyleBuilder) FirstRow(format *TableConditionFormat) *TableStyleBuilder {
+	return b.Condition(TableConditionFirstRow, format)
+}
+
+// LastRow 设置汇总行格式
+func (b *TableStyleBuilder) LastRow(format *TableConditionFormat) *TableStyleBuilder {
+	return b.Condition(TableConditionLastRow, format)
+}
+
+// FirstColumn 设置第一列格式
+func (b *TableStyleBuilder) FirstColumn(format *TableConditionFormat) *TableStyleBuilder {
+	return b.Condition(TableConditionFirstColumn, format)
+}
+
+// LastColumn 设置最后一列格式
+func (b *TableStyleBuilder) LastColumn(format *TableConditionFormat) *TableStyleBuilder {
+	return b.Condition(TableConditionLastColumn, format)
+}
+
+// BandedRows 设置奇数行和偶数行条带格式（不计标题行），nil表示不设置
+func (b *TableStyleBuilder) BandedRows(odd, even *TableConditionFormat) *TableStyleBuilder {
+	return b.Condition(TableConditionBand1Horizontal, odd).Condition(TableConditionBand2Horizontal, even)
+}
+
+// BandedColumns 设置奇数列和偶数列条带格式（不计第一列），nil表示不设置
+func (b *TableStyleBuilder) BandedColumns(odd, even *TableConditionFormat) *TableStyleBuilder {
+	return b.Condition(TableConditionBand1Vertical, odd).Condition(TableConditionBand2Vertical, even)
+}
+
+// Build 生成表格样式，条件格式按Word的顺序输出。返回的样式可通过 StyleManager.AddStyle 添加到文档
+func (b *TableStyleBuilder) Build() *Style {
+	conditions := make([]TableCondition, 0, len(b.conditions))
+	for condition := range b.conditions {
+		conditions = append(conditions, condition)
+	}
+	sort.Slice(conditions, func(i, j int) bool {
+		return tableConditionOrder[conditions[i]] < tableConditionOrder[conditions[j]]
+	})
+
+	b.style.TableStylePr = make([]*TableStylePr, 0, len(conditions))
+	for _, condition := range conditions {
+		b.style.TableStylePr = append(b.style.TableStylePr, b.conditions[condition])
+	}
+	// 返回副本，之后继续修改构建器不影响已生成的样式
+	return (&StyleManager{}).cloneStyle(b.style)
+}
+
+// createTableStyleBorder 创建边框定义
+func createTableStyleBorder(border *TableStyleBorder) *TblBorder {
+	if border == nil {
+		return nil
+	}
+	size := border.Size
+	if size <= 0 {
+		size = 4
+	}
+	color := border.Color
+	if color == "" {
+		color = "auto"
+	}
+	if border.Style == "" || border.Style == "none" || border.Style == "nil" {
+		return &TblBorder{Val: "nil", Sz: "0", Space: "0", Color: "auto"}
+	}
+	return &TblBorder{Val: border.Style, Sz: fmt.Sprintf("%d", size), Space: "0", Color: color}
+}
+
+// createTableStyleCellProperties 创建单元格属性，withBorders为true时边框写入单元格边框
+func createTableStyleCellProperties(format *TableConditionFormat, withBorders bool) *TableCellProperties {
+	props := &TableCellProperties{}
+	empty := true
+	if withBorders && format.Borders != nil {
+		borders := format.Borders
+		props.TcBorders = &TcBorders{
+			Top:     createTableStyleBorder(borders.Top),
+			Left:    createTableStyleBorder(borders.Left),
+			Bottom:  createTableStyleBorder(borders.Bottom),
+			Right:   createTableStyleBorder(borders.Right),
+			InsideH: createTableStyleBorder(borders.InsideH),
+			InsideV: createTableStyleBorder(borders.InsideV),
+		}
+		empty = false
+	}
+	if format.Shading != "" {
+		props.Shading = &Shading{Fill: format.Shading, Val: "clear"}
+		empty = false
+	}
+	if format.VerticalAlign != "" {
+		props.VAlign = &VAlign{Val: format.VerticalAlign}
+		empty = false
+	}
+	if empty {
+		return nil
+	}
+	return props
+}
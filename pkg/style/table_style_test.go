@@ -0,0 +1,73 @@
+package style
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestTableStyleBuilder(t *testing.T) {
+	builder := NewTableStyleBuilder("ReportTable", "报表").
+		Table(&TableConditionFormat{
+			Run:     &QuickRunConfig{FontSize: 10},
+			Borders: &TableStyleBorders{Top: &TableStyleBorder{Style: "single", Size: 8, Color: "1F4E79"}, InsideV: &TableStyleBorder{Style: "none"}},
+		}).
+		BandedRows(nil, &TableConditionFormat{Shading: "F2F2F2"}).
+		LastRow(&TableConditionFormat{Run: &QuickRunConfig{Bold: true}, Borders: &TableStyleBorders{Top: &TableStyleBorder{Style: "double"}}}).
+		FirstRow(&TableConditionFormat{
+			Run:           &QuickRunConfig{Bold: true, FontColor: "FFFFFF"},
+			Paragraph:     &QuickParagraphConfig{Alignment: "center"},
+			Shading:       "1F4E79",
+			VerticalAlign: "center",
+		}).
+		Condition(TableConditionTopLeftCell, &TableConditionFormat{Shading: "000000"}).
+		FirstColumn(&TableConditionFormat{Run: &QuickRunConfig{Italic: true}}).
+		FirstColumn(nil).
+		BandSize(2, 0)
+	tableStyle := builder.Build()
+
+	if tableStyle.Type != string(StyleTypeTable) || !tableStyle.CustomStyle || tableStyle.BasedOn.Val != "a1" {
+		t.Errorf("样式基本属性不正确: %+v", tableStyle)
+	}
+	var types []string
+	for _, pr := range tableStyle.TableStylePr {
+		types = append(types, pr.Type)
+	}
+	if strings.Join(types, ",") != "band2Horz,firstRow,lastRow,nwCell" {
+		t.Errorf("条件格式顺序不正确: %v", types)
+	}
+
+	data, err := xml.Marshal(tableStyle)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	output := string(data)
+	for _, expected := range []string{
+		`<w:tblStylePr w:type="firstRow"><w:pPr><w:jc w:val="center"></w:jc></w:pPr><w:rPr><w:b></w:b><w:color w:val="FFFFFF"></w:color></w:rPr><w:tcPr><w:shd w:fill="1F4E79" w:val="clear"></w:shd><w:vAlign w:val="center"></w:vAlign></w:tcPr></w:tblStylePr>`,
+		`<w:tblStylePr w:type="lastRow"><w:rPr><w:b></w:b></w:rPr><w:tcPr><w:tcBorders><w:top w:val="double" w:sz="4" w:space="0" w:color="auto"></w:top></w:tcBorders></w:tcPr></w:tblStylePr>`,
+		`<w:tblPr><w:tblStyleRowBandSize w:val="2"></w:tblStyleRowBandSize><w:tblBorders><w:top w:val="single" w:sz="8" w:space="0" w:color="1F4E79"></w:top><w:insideV w:val="nil" w:sz="0" w:space="0" w:color="auto"></w:insideV></w:tblBorders></w:tblPr>`,
+		`<w:rPr><w:sz w:val="20"></w:sz></w:rPr>`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("样式XML缺少 %s\n%s", expected, output)
+		}
+	}
+
+	// 生成的样式与构建器相互独立
+	builder.FirstRow(&TableConditionFormat{Shading: "FF0000"})
+	if tableStyle.TableStylePr[1].TableCellPr.Shading.Fill != "1F4E79" {
+		t.Error("继续修改构建器不应影响已生成的样式")
+	}
+
+	// 克隆
+	sm := NewStyleManager()
+	sm.AddStyle(tableStyle)
+	cloned := sm.Clone().GetStyle("ReportTable")
+	if cloned == tableStyle || len(cloned.TableStylePr) != 4 || cloned.TableStylePr[1].TableCellPr.VAlign.Val != "center" {
+		t.Fatalf("克隆样式不正确: %+v", cloned)
+	}
+	clonedData, _ := xml.Marshal(cloned)
+	if string(clonedData) != output {
+		t.Errorf("克隆后的样式XML不一致:\n%s\n%s", clonedData, output)
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ZeroHawkeye/wordZero/pkg/markdown"
+)
+
+// TestMarkdownMathConversion 测试Markdown数学公式转换
+func TestMarkdownMathConversion(t *testing.T) {
+	source := `勾股定理 $a^2 + b^2 = c^2$ 成立，价格为 $5 到 $10。
+
+$$
+x = \frac{-b \pm \sqrt{b^2 - 4ac}}{2a}
+$$
+
+$$\sum_{i=1}^{n} i = \frac{n(n+1)}{2}$$
+`
+
+	converter := markdown.NewConverter(markdown.DefaultOptions())
+	doc, err := converter.ConvertString(source, nil)
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+
+	paragraphs := doc.Body.GetParagraphs()
+	if len(paragraphs) != 3 {
+		t.Fatalf("期望3个段落，得到 %d", len(paragraphs))
+	}
+
+	inline := paragraphs[0].Equations()
+	if len(inline) != 1 || inline[0].Display || inline[0].LaTeX() != "a^2+b^2=c^2" {
+		t.Fatalf("行内公式不正确: %+v", inline)
+	}
+	var text strings.Builder
+	for _, run := range paragraphs[0].Runs {
+		text.WriteString(run.Text.Content)
+	}
+	if !strings.Contains(text.String(), "价格为 $5 到 $10") {
+		t.Errorf("金额不应被识别为公式: %q", text.String())
+	}
+
+	for i, expected := range []string{`x=\frac{-b\pm\sqrt{b^2-4ac}}{2a}`, `\sum_{i=1}^n i=\frac{n(n+1)}{2}`} {
+		equations := paragraphs[i+1].Equations()
+		if len(equations) != 1 || !equations[0].Display {
+			t.Fatalf("第%d个公式块不正确: %+v", i+1, equations)
+		}
+		if equations[0].LaTeX() != expected {
+			t.Errorf("公式块内容不正确: %s", equations[0].LaTeX())
+		}
+	}
+
+	// 导出回Markdown
+	exporter := markdown.NewExporter(markdown.DefaultExportOptions())
+	output, err := exporter.ExportToString(doc, nil)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	for _, expected := range []string{"$a^2+b^2=c^2$", "$$\nx=\\frac{-b\\pm\\sqrt{b^2-4ac}}{2a}\n$$"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("导出的Markdown缺少 %q:\n%s", expected, output)
+		}
+	}
+}
+
+// TestMarkdownMathInvalid 测试无效公式的处理
+func TestMarkdownMathInvalid(t *testing.T) {
+	var errs []error
+	opts := markdown.DefaultOptions()
+	opts.ErrorCallback = func(err error) { errs = append(errs, err) }
+
+	converter := markdown.NewConverter(opts)
+	doc, err := converter.ConvertString("$$\n\\frac{a}\n$$\n", opts)
+	if err != nil {
+		t.Fatalf("非严格模式不应返回错误: %v", err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("期望1个错误回调，得到 %d", len(errs))
+	}
+	paragraphs := doc.Body.GetParagraphs()
+	if len(paragraphs) != 1 || paragraphs[0].Runs[0].Text.Content != `$$\frac{a}$$` {
+		t.Errorf("无效公式应保留原始文本")
+	}
+
+	opts = markdown.DefaultOptions()
+	opts.StrictMode = true
+	if _, err := markdown.NewConverter(opts).ConvertString("$$\n\\frac{a}\n$$\n", opts); err == nil {
+		t.Error("严格模式下无效公式应返回错误")
+	}
+
+	opts = markdown.DefaultOptions()
+	opts.EnableMath = false
+	doc, _ = markdown.NewConverter(opts).ConvertString("公式 $x^2$", opts)
+	if len(doc.Body.GetParagraphs()[0].Equations()) != 0 {
+		t.Error("禁用公式时不应解析公式")
+	}
+}